@@ -91,6 +91,7 @@ export class CertificateController {
         isMarksheet = false, // Whether this is a marksheet certificate
         marksheetData, // Optional: semester and academic year for marksheet
         issueDate = new Date().toISOString().split('T')[0],
+        graduationDate = '', // RFC3339; required by the chaincode for DEGREE and DIPLOMA
       } = req.body;
 
       console.log('\n' + '═'.repeat(70));
@@ -197,6 +198,9 @@ export class CertificateController {
           certificateId,
           studentID,
           certificateType,
+          '0', // validityDays: expiry is tracked off-chain for now
+          'false', // overrideEligibility
+          graduationDate,
         ]);
 
         blockchainResult = JSON.parse(result.toString());
@@ -303,7 +307,7 @@ export class CertificateController {
         logger.info(`Verifying certificate ${certificate.id} on blockchain...`);
         console.log('\n🔍 Verifying on Blockchain...');
         
-        const result = await this.fabricService.evaluateTransaction('VerifyCertificate', [
+        const result = await this.fabricService.evaluateTransaction('CheckCertificate', [
           certificate.id,
           certificate.blockchainHash || certificate.id,
         ]);
@@ -16,7 +16,7 @@ export class StudentController {
    */
   async createStudent(req: Request, res: Response): Promise<void> {
     try {
-      const { studentId, name, email, department, gpa, enrollmentDate, notes } = req.body;
+      const { studentId, name, email, department, program, gpa, enrollmentDate, notes } = req.body;
 
       console.log('\n' + '═'.repeat(70));
       console.log('📝 STUDENT CREATION');
@@ -41,10 +41,12 @@ export class StudentController {
 
       logger.info(`Creating student: ${studentId}`);
 
-      // Submit transaction to chaincode
+      // Submit transaction to chaincode; name and email go via transient data
+      // so they are stored in the private PII collection, not on the ledger
       const result = await this.fabricService.submitTransaction(
         'CreateStudent',
-        [studentId, name, email, department]
+        [studentId, department, program || ''],
+        { student_pii: JSON.stringify({ name, email }) }
       );
 
       const student = JSON.parse(result.toString());
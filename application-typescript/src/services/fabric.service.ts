@@ -196,10 +196,12 @@ export class FabricService {
 
   /**
    * Submit transaction to chaincode
+   * Transient data is passed to the chaincode without being recorded on the ledger
    */
   async submitTransaction(
     functionName: string,
-    args: string[]
+    args: string[],
+    transientData?: Record<string, string>
   ): Promise<Buffer> {
     try {
       const contract = await this.getContract();
@@ -229,7 +231,19 @@ export class FabricService {
         `Submitting transaction: ${functionName} with args: ${args.join(', ')}`
       );
 
-      const result = await contract.submitTransaction(functionName, ...args);
+      let result: Buffer;
+      if (transientData) {
+        const transientMap: Record<string, Buffer> = {};
+        for (const [key, value] of Object.entries(transientData)) {
+          transientMap[key] = Buffer.from(value);
+        }
+        result = await contract
+          .createTransaction(functionName)
+          .setTransient(transientMap)
+          .submit(...args);
+      } else {
+        result = await contract.submitTransaction(functionName, ...args);
+      }
       logger.info(`Transaction ${functionName} completed successfully`);
       return result;
     } catch (error) {
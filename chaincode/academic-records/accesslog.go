@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== ACCESS LOGGING ==========
+//
+// Evaluated reads leave no trace on the ledger. The *Logged variants are submitted instead and
+// write an ACCESS audit entry naming who read what and why. With RequireLoggedReads set in the
+// org config, verifier orgs can only read records and certificates through them. Reads by
+// regulator orgs are tagged with regulatorAccessTag.
+
+// GetStudentRecordsLogged returns all records of a student and logs the access; submit it
+func (s *SmartContract) GetStudentRecordsLogged(ctx contractapi.TransactionContextInterface, studentID string, purpose string) ([]*AcademicRecord, error) {
+	purpose, err := requireAccessPurpose(purpose)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~student", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*AcademicRecord{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		record, err := readAcademicRecord(ctx, compositeKeyParts[1])
+		if err == nil {
+			records = append(records, record)
+		}
+	}
+
+	tag, err := accessLogTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := logAudit(ctx, "ACCESS", "STUDENT", studentID, fmt.Sprintf("%sGetStudentRecords (%d records): %s", tag, len(records), purpose)); err != nil {
+		return nil, err
+	}
+	if err := filterResponse(ctx, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetCertificateLogged returns a certificate and logs the access; submit it
+func (s *SmartContract) GetCertificateLogged(ctx contractapi.TransactionContextInterface, certificateID string, purpose string) (*Certificate, error) {
+	purpose, err := requireAccessPurpose(purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := readCertificateView(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := accessLogTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := logAudit(ctx, "ACCESS", "CERTIFICATE", certificateID, fmt.Sprintf("%sGetCertificate: %s", tag, purpose)); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// assertUnloggedReadAllowed refuses a plain getter to verifier orgs when the org config
+// requires logged reads, pointing them at the logged variant
+func assertUnloggedReadAllowed(ctx contractapi.TransactionContextInterface, loggedVariant string) error {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if !config.RequireLoggedReads {
+		return nil
+	}
+
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	if config.hasRole(creatorOrg, orgRoleVerifier) && !config.hasRole(creatorOrg, orgRoleUniversity) {
+		return fmt.Errorf("verifier reads must be logged: submit %s with a purpose instead", loggedVariant)
+	}
+	return nil
+}
+
+// requireAccessPurpose trims the caller's stated purpose and rejects an empty one
+func requireAccessPurpose(purpose string) (string, error) {
+	purpose = strings.TrimSpace(purpose)
+	if purpose == "" {
+		return "", fmt.Errorf("a purpose is required for logged reads")
+	}
+	return purpose, nil
+}
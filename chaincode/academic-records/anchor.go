@@ -0,0 +1,254 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== CERTIFICATE ANCHORS ==========
+//
+// An anchor commits to every certificate live on a date with a Merkle root NITWarangal can publish
+// outside the network. Leaves are ordered by certificate ID and hashed as in RFC 6962:
+//
+//	leaf = SHA-256(0x00 || certificate hash bytes)
+//	node = SHA-256(0x01 || left || right)
+//
+// where the certificate hash bytes are the hex-decoded CertificateHash. A level with an odd
+// number of nodes promotes its last node unchanged, so an inclusion proof has no step for that
+// level. To verify a proof, start from the leaf and, for each step, hash the step's hash on the
+// side it names; the result must equal the published root.
+
+// anchorKeyPrefix prefixes the world state keys of certificate anchors
+const anchorKeyPrefix = "ANCHOR_"
+
+// anchorDateLayout is the layout of an anchor's as-of date
+const anchorDateLayout = "2006-01-02"
+
+// Domain separation prefixes of leaf and interior node hashes
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// CertificateAnchor is a Merkle root over the certificates live on AsOfDate
+type CertificateAnchor struct {
+	DocType   string        `json:"docType"`
+	AnchorID  string        `json:"anchorId"`
+	AsOfDate  string        `json:"asOfDate"` // YYYY-MM-DD; certificates issued by the end of this UTC day
+	Root      string        `json:"root"`     // hex SHA-256; empty when there are no leaves
+	LeafCount int           `json:"leafCount"`
+	Leaves    []*AnchorLeaf `json:"leaves"` // sorted by certificate ID
+	CreatedBy string        `json:"createdBy"`
+	CreatedAt string        `json:"createdAt"`
+}
+
+// AnchorLeaf is one certificate committed to by an anchor
+type AnchorLeaf struct {
+	CertificateID   string `json:"certificateId"`
+	CertificateHash string `json:"certificateHash"`
+}
+
+// InclusionProofStep is a sibling hash on the path from a leaf to the root
+type InclusionProofStep struct {
+	Hash string `json:"hash"`
+	Side string `json:"side"` // left or right: where the sibling goes when hashing the pair
+}
+
+// CertificateInclusionProof shows that a certificate is a leaf of an anchor's Merkle tree
+type CertificateInclusionProof struct {
+	AnchorID        string                `json:"anchorId"`
+	AsOfDate        string                `json:"asOfDate"`
+	Root            string                `json:"root"`
+	CertificateID   string                `json:"certificateId"`
+	CertificateHash string                `json:"certificateHash"`
+	LeafIndex       int                   `json:"leafIndex"`
+	LeafCount       int                   `json:"leafCount"`
+	LeafHash        string                `json:"leafHash"`
+	Path            []*InclusionProofStep `json:"path"` // from the leaf level up
+}
+
+// BuildCertificateMerkleRoot builds the Merkle root over the certificates issued on or before
+// asOfDate (YYYY-MM-DD) that are still ISSUED, and stores it as an anchor (NITWarangal only).
+// Revoked and superseded certificates are left out, so inclusion means the certificate was live
+// when the anchor was built.
+func (s *SmartContract) BuildCertificateMerkleRoot(ctx contractapi.TransactionContextInterface, asOfDate string) (*CertificateAnchor, error) {
+	creatorOrg, err := requireOrgRole(ctx, "build certificate anchors", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf, err := time.Parse(anchorDateLayout, asOfDate)
+	if err != nil {
+		return nil, fmt.Errorf("asOfDate must be YYYY-MM-DD: %v", err)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	if asOf.After(txTime) {
+		return nil, fmt.Errorf("asOfDate %s is in the future", asOfDate)
+	}
+	cutoff := asOf.AddDate(0, 0, 1)
+
+	resultsIterator, err := assetRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	leaves := []*AnchorLeaf{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var cert Certificate
+		if err := json.Unmarshal(response.Value, &cert); err != nil || cert.DocType != docTypeCertificate {
+			continue
+		}
+		if cert.Status != certStatusIssued {
+			continue
+		}
+		issuedAt, err := time.Parse(time.RFC3339, cert.IssuedDate)
+		if err != nil || !issuedAt.Before(cutoff) {
+			continue
+		}
+		if _, err := hex.DecodeString(cert.CertificateHash); err != nil {
+			return nil, fmt.Errorf("certificate %s has a malformed hash", cert.CertificateID)
+		}
+		leaves = append(leaves, &AnchorLeaf{CertificateID: cert.CertificateID, CertificateHash: cert.CertificateHash})
+	}
+
+	sort.Slice(leaves, func(i, j int) bool {
+		return leaves[i].CertificateID < leaves[j].CertificateID
+	})
+
+	levels := merkleLevels(leaves)
+	root := ""
+	if len(leaves) > 0 {
+		root = hex.EncodeToString(levels[len(levels)-1][0])
+	}
+
+	anchor := &CertificateAnchor{
+		DocType:   docTypeCertificateAnchor,
+		AnchorID:  anchorKeyPrefix + ctx.GetStub().GetTxID(),
+		AsOfDate:  asOfDate,
+		Root:      root,
+		LeafCount: len(leaves),
+		Leaves:    leaves,
+		CreatedBy: creatorOrg,
+		CreatedAt: txTime.UTC().Format(time.RFC3339),
+	}
+
+	anchorJSON, err := json.Marshal(anchor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anchor: %v", err)
+	}
+	if err := ctx.GetStub().PutState(anchor.AnchorID, anchorJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "BuildCertificateMerkleRoot", "CERTIFICATE", anchor.AnchorID, fmt.Sprintf("Anchored %d certificates issued by %s with root %s", anchor.LeafCount, asOfDate, root))
+
+	return anchor, nil
+}
+
+// GetCertificateAnchor returns a certificate anchor
+func (s *SmartContract) GetCertificateAnchor(ctx contractapi.TransactionContextInterface, anchorID string) (*CertificateAnchor, error) {
+	return readCertificateAnchor(ctx, anchorID)
+}
+
+// GetCertificateInclusionProof returns the sibling path proving a certificate is a leaf of an anchor
+func (s *SmartContract) GetCertificateInclusionProof(ctx contractapi.TransactionContextInterface, certificateID string, anchorID string) (*CertificateInclusionProof, error) {
+	anchor, err := readCertificateAnchor(ctx, anchorID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := sort.Search(len(anchor.Leaves), func(i int) bool {
+		return anchor.Leaves[i].CertificateID >= certificateID
+	})
+	if index == len(anchor.Leaves) || anchor.Leaves[index].CertificateID != certificateID {
+		return nil, fmt.Errorf("certificate %s is not included in anchor %s", certificateID, anchorID)
+	}
+
+	levels := merkleLevels(anchor.Leaves)
+	proof := &CertificateInclusionProof{
+		AnchorID:        anchor.AnchorID,
+		AsOfDate:        anchor.AsOfDate,
+		Root:            anchor.Root,
+		CertificateID:   certificateID,
+		CertificateHash: anchor.Leaves[index].CertificateHash,
+		LeafIndex:       index,
+		LeafCount:       anchor.LeafCount,
+		LeafHash:        hex.EncodeToString(levels[0][index]),
+		Path:            []*InclusionProofStep{},
+	}
+
+	position := index
+	for _, level := range levels[:len(levels)-1] {
+		if position%2 == 1 {
+			proof.Path = append(proof.Path, &InclusionProofStep{Hash: hex.EncodeToString(level[position-1]), Side: "left"})
+		} else if position+1 < len(level) {
+			proof.Path = append(proof.Path, &InclusionProofStep{Hash: hex.EncodeToString(level[position+1]), Side: "right"})
+		}
+		position /= 2
+	}
+
+	return proof, nil
+}
+
+// merkleLevels hashes the leaves and every level above them; the last level holds the root.
+// Leaf hashes must be valid hex, which BuildCertificateMerkleRoot checks before anchoring.
+func merkleLevels(leaves []*AnchorLeaf) [][][]byte {
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashBytes, _ := hex.DecodeString(leaf.CertificateHash)
+		digest := sha256.Sum256(append([]byte{merkleLeafPrefix}, hashBytes...))
+		level[i] = digest[:]
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			pair := make([]byte, 0, 1+2*sha256.Size)
+			pair = append(pair, merkleNodePrefix)
+			pair = append(pair, level[i]...)
+			pair = append(pair, level[i+1]...)
+			digest := sha256.Sum256(pair)
+			next = append(next, digest[:])
+		}
+		level = next
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// readCertificateAnchor reads a certificate anchor by ID
+func readCertificateAnchor(ctx contractapi.TransactionContextInterface, anchorID string) (*CertificateAnchor, error) {
+	anchorJSON, err := ctx.GetStub().GetState(anchorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if anchorJSON == nil {
+		return nil, fmt.Errorf("anchor %s does not exist", anchorID)
+	}
+
+	var anchor CertificateAnchor
+	if err := json.Unmarshal(anchorJSON, &anchor); err != nil || anchor.DocType != docTypeCertificateAnchor {
+		return nil, fmt.Errorf("%s is not a certificate anchor", anchorID)
+	}
+	return &anchor, nil
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== GRADE APPEALS ==========
+//
+// A student appeals one course grade on a published record. The department decides the
+// appeal and NITWarangal countersigns the decision; an upheld appeal supersedes the record
+// with the corrected grade, so SGPA and CGPA are recomputed and the replacement is verified again.
+
+// Grade appeal statuses; OPEN and DECIDED appeals are unresolved
+const (
+	appealStatusOpen      = "OPEN"
+	appealStatusDecided   = "DECIDED" // decided by the department, awaiting countersign
+	appealStatusUpheld    = "UPHELD"
+	appealStatusDismissed = "DISMISSED"
+)
+
+// GradeAppeal is a student's appeal against one course grade
+type GradeAppeal struct {
+	DocType       string `json:"docType"`
+	AppealID      string `json:"appealId"`
+	RecordID      string `json:"recordId"`
+	StudentID     string `json:"studentId"`
+	CourseCode    string `json:"courseCode"`
+	OriginalGrade string `json:"originalGrade"`
+	Reason        string `json:"reason"`
+	Status        string `json:"status"`
+	FiledBy       string `json:"filedBy"`
+	FiledAt       string `json:"filedAt"`
+
+	// Department decision: UPHELD with the corrected grade, or DISMISSED
+	Decision  string `json:"decision,omitempty"`
+	NewGrade  string `json:"newGrade,omitempty"`
+	DecidedBy string `json:"decidedBy,omitempty"`
+	DecidedAt string `json:"decidedAt,omitempty"`
+
+	CountersignedBy     string `json:"countersignedBy,omitempty"`
+	CountersignedAt     string `json:"countersignedAt,omitempty"`
+	ReplacementRecordID string `json:"replacementRecordId,omitempty"`
+}
+
+// FileGradeAppeal opens an appeal against a course grade on an approved or verified record
+// (the student's own identity, or NITWarangal on their behalf)
+func (s *SmartContract) FileGradeAppeal(ctx contractapi.TransactionContextInterface, recordID string, courseCode string, reason string) (*GradeAppeal, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to appeal a grade")
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	filedBy, err := assertActsForStudent(ctx, record.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != recordStatusApproved && record.Status != recordStatusVerified {
+		return nil, fmt.Errorf("record %s is %s; only APPROVED or VERIFIED grades can be appealed", recordID, record.Status)
+	}
+
+	var appealed *CourseGrade
+	for i := range record.Courses {
+		if record.Courses[i].CourseCode == courseCode {
+			appealed = &record.Courses[i]
+			break
+		}
+	}
+	if appealed == nil {
+		return nil, fmt.Errorf("course %s is not part of record %s", courseCode, recordID)
+	}
+
+	existing, err := getAppealsByIndex(ctx, "appeal~record~course", []string{recordID, courseCode})
+	if err != nil {
+		return nil, err
+	}
+	for _, appeal := range existing {
+		if appealUnresolved(appeal) {
+			return nil, fmt.Errorf("appeal %s for course %s on record %s is still %s", appeal.AppealID, courseCode, recordID, appeal.Status)
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	appeal := GradeAppeal{
+		DocType:       docTypeGradeAppeal,
+		AppealID:      "APPEAL_" + ctx.GetStub().GetTxID(),
+		RecordID:      recordID,
+		StudentID:     record.StudentID,
+		CourseCode:    courseCode,
+		OriginalGrade: appealed.Grade,
+		Reason:        reason,
+		Status:        appealStatusOpen,
+		FiledBy:       filedBy,
+		FiledAt:       txTime.UTC().Format(time.RFC3339),
+	}
+	if err := putGradeAppeal(ctx, &appeal); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "appeal~student", []string{appeal.StudentID, appeal.AppealID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "appeal~record~course", []string{recordID, courseCode, appeal.AppealID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "appeal~status", []string{appeal.Status, appeal.AppealID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "FileGradeAppeal", "APPEAL", appeal.AppealID, fmt.Sprintf("Appeal of grade %s in %s on record %s: %s", appeal.OriginalGrade, courseCode, recordID, reason))
+
+	return &appeal, nil
+}
+
+// ResolveGradeAppeal records the department's decision on an OPEN appeal (Departments only):
+// UPHELD with the corrected grade, or DISMISSED. It takes effect once NITWarangal countersigns.
+func (s *SmartContract) ResolveGradeAppeal(ctx contractapi.TransactionContextInterface, appealID string, decision string, newGrade string) (*GradeAppeal, error) {
+	creatorOrg, err := requireOrgRole(ctx, "resolve grade appeals", orgRoleDepartment)
+	if err != nil {
+		return nil, err
+	}
+
+	appeal, err := readGradeAppeal(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+	if appeal.Status != appealStatusOpen {
+		return nil, fmt.Errorf("appeal %s is %s, not %s", appealID, appeal.Status, appealStatusOpen)
+	}
+
+	newGrade = normalizeGrade(newGrade)
+	switch decision {
+	case appealStatusUpheld:
+		if newGrade == "" {
+			return nil, fmt.Errorf("an upheld appeal needs the corrected grade")
+		}
+		if newGrade == appeal.OriginalGrade {
+			return nil, fmt.Errorf("corrected grade %s is the same as the original", newGrade)
+		}
+		record, err := readAcademicRecord(ctx, appeal.RecordID)
+		if err != nil {
+			return nil, err
+		}
+		scale, err := gradeScaleForRecord(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := scale.Grades[newGrade]; !ok {
+			return nil, fmt.Errorf("grade %q is not in the grade scale", newGrade)
+		}
+	case appealStatusDismissed:
+		if newGrade != "" {
+			return nil, fmt.Errorf("a dismissed appeal cannot carry a new grade")
+		}
+	default:
+		return nil, fmt.Errorf("invalid decision %q: must be %s or %s", decision, appealStatusUpheld, appealStatusDismissed)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	appeal.Decision = decision
+	appeal.NewGrade = newGrade
+	appeal.DecidedBy = creatorOrg
+	appeal.DecidedAt = txTime.UTC().Format(time.RFC3339)
+	if err := setAppealStatus(ctx, appeal, appealStatusDecided); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("Department decision: %s", decision)
+	if newGrade != "" {
+		details = fmt.Sprintf("Department decision: %s, grade %s -> %s", decision, appeal.OriginalGrade, newGrade)
+	}
+	logAudit(ctx, "ResolveGradeAppeal", "APPEAL", appealID, details)
+
+	return appeal, nil
+}
+
+// CountersignGradeAppeal confirms the department's decision (NITWarangal only). An upheld
+// appeal supersedes the record with the corrected grade under the ID <recordID>_<appealID>.
+func (s *SmartContract) CountersignGradeAppeal(ctx contractapi.TransactionContextInterface, appealID string) (*GradeAppeal, error) {
+	creatorOrg, err := requireOrgRole(ctx, "countersign grade appeals", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	appeal, err := readGradeAppeal(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+	if appeal.Status != appealStatusDecided {
+		return nil, fmt.Errorf("appeal %s is %s, not awaiting countersign", appealID, appeal.Status)
+	}
+
+	var events []*LifecycleEvent
+	if appeal.Decision == appealStatusUpheld {
+		record, err := readAcademicRecord(ctx, appeal.RecordID)
+		if err != nil {
+			return nil, err
+		}
+
+		courses := make([]CourseGrade, len(record.Courses))
+		copy(courses, record.Courses)
+		for i := range courses {
+			if courses[i].CourseCode == appeal.CourseCode {
+				courses[i].Grade = appeal.NewGrade
+				courses[i].GradePoint = 0
+			}
+		}
+		scale, err := gradeScaleForRecord(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyGradeScale(scale, courses, nil); err != nil {
+			return nil, err
+		}
+
+		reason := fmt.Sprintf("Grade appeal %s upheld: %s %s -> %s", appealID, appeal.CourseCode, appeal.OriginalGrade, appeal.NewGrade)
+		replacement, supersedeEvents, err := supersedeRecord(ctx, creatorOrg, record, appeal.RecordID+"_"+appealID, courses, reason)
+		if err != nil {
+			return nil, err
+		}
+		appeal.ReplacementRecordID = replacement.RecordID
+		events = supersedeEvents
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	appeal.CountersignedBy = creatorOrg
+	appeal.CountersignedAt = txTime.UTC().Format(time.RFC3339)
+	if err := setAppealStatus(ctx, appeal, appeal.Decision); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "CountersignGradeAppeal", "APPEAL", appealID, fmt.Sprintf("Countersigned decision %s", appeal.Decision))
+
+	if len(events) > 0 {
+		if err := emitEvents(ctx, eventRecordSuperseded, events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return appeal, nil
+}
+
+// GetAppealsByStudent lists a student's grade appeals (NITWarangal, Departments or the student)
+func (s *SmartContract) GetAppealsByStudent(ctx contractapi.TransactionContextInterface, studentID string) ([]*GradeAppeal, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	trusted, err := orgHasRole(ctx, creatorOrg, orgRoleUniversity, orgRoleDepartment)
+	if err != nil {
+		return nil, err
+	}
+	if !trusted {
+		if _, err := assertActsForStudent(ctx, studentID); err != nil {
+			return nil, err
+		}
+	}
+	return getAppealsByIndex(ctx, "appeal~student", []string{studentID})
+}
+
+// GetOpenAppeals lists the appeals awaiting a department decision or countersign
+// (NITWarangal and Departments)
+func (s *SmartContract) GetOpenAppeals(ctx contractapi.TransactionContextInterface) ([]*GradeAppeal, error) {
+	if _, err := requireOrgRole(ctx, "list open appeals", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+
+	appeals := []*GradeAppeal{}
+	for _, status := range []string{appealStatusOpen, appealStatusDecided} {
+		matching, err := getAppealsByIndex(ctx, "appeal~status", []string{status})
+		if err != nil {
+			return nil, err
+		}
+		appeals = append(appeals, matching...)
+	}
+	return appeals, nil
+}
+
+// appealUnresolved reports whether an appeal still blocks a new appeal of the same grade
+func appealUnresolved(appeal *GradeAppeal) bool {
+	return appeal.Status == appealStatusOpen || appeal.Status == appealStatusDecided
+}
+
+// setAppealStatus moves an appeal to a new status, keeping the status index in step
+func setAppealStatus(ctx contractapi.TransactionContextInterface, appeal *GradeAppeal, status string) error {
+	if err := deleteIndex(ctx, "appeal~status", []string{appeal.Status, appeal.AppealID}); err != nil {
+		return err
+	}
+	appeal.Status = status
+	if err := putGradeAppeal(ctx, appeal); err != nil {
+		return err
+	}
+	return putIndex(ctx, "appeal~status", []string{appeal.Status, appeal.AppealID})
+}
+
+// getAppealsByIndex loads the appeals under a partial key of one of the appeal indexes; the
+// appeal ID is the last key attribute
+func getAppealsByIndex(ctx contractapi.TransactionContextInterface, index string, attributes []string) ([]*GradeAppeal, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appeals: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	appeals := []*GradeAppeal{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) == 0 {
+			continue
+		}
+
+		appeal, err := readGradeAppeal(ctx, compositeKeyParts[len(compositeKeyParts)-1])
+		if err == nil {
+			appeals = append(appeals, appeal)
+		}
+	}
+	return appeals, nil
+}
+
+// readGradeAppeal loads a grade appeal from world state
+func readGradeAppeal(ctx contractapi.TransactionContextInterface, appealID string) (*GradeAppeal, error) {
+	appealJSON, err := ctx.GetStub().GetState(appealID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if appealJSON == nil {
+		return nil, fmt.Errorf("appeal %s not found", appealID)
+	}
+
+	var appeal GradeAppeal
+	if err := json.Unmarshal(appealJSON, &appeal); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal appeal: %v", err)
+	}
+	if appeal.DocType != docTypeGradeAppeal {
+		return nil, fmt.Errorf("appeal %s not found", appealID)
+	}
+	return &appeal, nil
+}
+
+// putGradeAppeal saves a grade appeal under its appeal ID
+func putGradeAppeal(ctx contractapi.TransactionContextInterface, appeal *GradeAppeal) error {
+	appealJSON, err := json.Marshal(appeal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal appeal: %v", err)
+	}
+	if err := ctx.GetStub().PutState(appeal.AppealID, appealJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
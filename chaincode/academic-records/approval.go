@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== APPROVAL CHECKLIST ==========
+
+// ChecklistItem is one thing the exam section confirms before approving a record
+type ChecklistItem struct {
+	Key       string `json:"key"`
+	Label     string `json:"label"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+// ChecklistSchema lists the items an approval checklist must answer; it is stored on the
+// ledger so new items can be added without a chaincode upgrade
+type ChecklistSchema struct {
+	DocType   string           `json:"docType"`
+	Items     []*ChecklistItem `json:"items"`
+	UpdatedBy string           `json:"updatedBy"`
+	UpdatedAt string           `json:"updatedAt"`
+}
+
+// ApprovalChecklist is what the approver submits with ApproveAcademicRecord
+type ApprovalChecklist struct {
+	Items map[string]bool `json:"items"`
+	Notes string          `json:"notes"`
+}
+
+// Approval records who approved a record and what they checked
+type Approval struct {
+	ApprovedBy string          `json:"approvedBy"`
+	ApproverID string          `json:"approverId"`
+	ApprovedAt string          `json:"approvedAt"`
+	Checklist  map[string]bool `json:"checklist"`
+	Notes      string          `json:"notes"`
+	Round      int             `json:"round"` // number of rejections before this approval
+}
+
+// PendingApproval is a SUBMITTED record that some, but not enough, officers have approved
+type PendingApproval struct {
+	RecordID  string      `json:"recordId"`
+	StudentID string      `json:"studentId"`
+	Required  int         `json:"required"`
+	Approvals []*Approval `json:"approvals"`
+}
+
+// checklistSchemaKey is the world state key of the approval checklist config asset
+const checklistSchemaKey = "CONFIG_APPROVAL_CHECKLIST"
+
+// defaultChecklistItems is used until NITWarangal stores its own schema
+var defaultChecklistItems = []*ChecklistItem{
+	{Key: "gradeSheetReceived", Label: "Grade sheet received", Mandatory: true},
+	{Key: "hodSignatureSighted", Label: "HOD signature sighted", Mandatory: true},
+	{Key: "moderationDone", Label: "Moderation done", Mandatory: true},
+}
+
+// GetApprovalChecklistSchema returns the checklist schema in force
+func (s *SmartContract) GetApprovalChecklistSchema(ctx contractapi.TransactionContextInterface) (*ChecklistSchema, error) {
+	return getChecklistSchema(ctx)
+}
+
+// UpdateApprovalChecklistSchema replaces the approval checklist schema (NITWarangal only)
+func (s *SmartContract) UpdateApprovalChecklistSchema(ctx contractapi.TransactionContextInterface, itemsJSON string) (*ChecklistSchema, error) {
+	creatorOrg, err := requireOrgRole(ctx, "update the approval checklist", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*ChecklistItem
+	if err := json.Unmarshal([]byte(itemsJSON), &items); err != nil {
+		return nil, fmt.Errorf("invalid checklist items JSON: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("checklist must define at least one item")
+	}
+
+	seen := map[string]bool{}
+	for i, item := range items {
+		if item == nil || strings.TrimSpace(item.Key) == "" {
+			return nil, fmt.Errorf("checklist item at position %d: key is required", i)
+		}
+		if seen[item.Key] {
+			return nil, fmt.Errorf("checklist item %s: listed more than once", item.Key)
+		}
+		seen[item.Key] = true
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	schema := ChecklistSchema{
+		DocType:   docTypeChecklistSchema,
+		Items:     items,
+		UpdatedBy: creatorOrg,
+		UpdatedAt: txTime.UTC().Format(time.RFC3339),
+	}
+
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal checklist schema: %v", err)
+	}
+	if err := ctx.GetStub().PutState(checklistSchemaKey, schemaJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "UpdateApprovalChecklistSchema", "CONFIG", checklistSchemaKey, fmt.Sprintf("Checklist schema updated: %s", string(schemaJSON)))
+
+	return &schema, nil
+}
+
+// getChecklistSchema reads the stored checklist schema, falling back to the default
+func getChecklistSchema(ctx contractapi.TransactionContextInterface) (*ChecklistSchema, error) {
+	schemaJSON, err := ctx.GetStub().GetState(checklistSchemaKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checklist schema: %v", err)
+	}
+	if schemaJSON == nil {
+		return &ChecklistSchema{DocType: docTypeChecklistSchema, Items: defaultChecklistItems}, nil
+	}
+
+	var schema ChecklistSchema
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checklist schema: %v", err)
+	}
+	return &schema, nil
+}
+
+// parseApprovalChecklist validates a submitted checklist against the schema: unknown items
+// are rejected and every mandatory item must be present and true
+func parseApprovalChecklist(schema *ChecklistSchema, checklistJSON string) (*ApprovalChecklist, error) {
+	var checklist ApprovalChecklist
+	if err := json.Unmarshal([]byte(checklistJSON), &checklist); err != nil {
+		return nil, fmt.Errorf("invalid checklist JSON: %v", err)
+	}
+	if checklist.Items == nil {
+		checklist.Items = map[string]bool{}
+	}
+
+	known := map[string]bool{}
+	for _, item := range schema.Items {
+		known[item.Key] = true
+		if item.Mandatory && !checklist.Items[item.Key] {
+			return nil, fmt.Errorf("checklist item %s (%s) is mandatory and must be true", item.Key, item.Label)
+		}
+	}
+	for key := range checklist.Items {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown checklist item %s", key)
+		}
+	}
+
+	return &checklist, nil
+}
+
+// GetPendingApprovals lists SUBMITTED records with partial approvals and who has signed (NITWarangal only)
+func (s *SmartContract) GetPendingApprovals(ctx contractapi.TransactionContextInterface) ([]*PendingApproval, error) {
+	if _, err := requireOrgRole(ctx, "view pending approvals", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~status", []string{recordStatusSubmitted})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	pending := []*PendingApproval{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		record, err := readAcademicRecord(ctx, compositeKeyParts[1])
+		if err != nil {
+			continue
+		}
+		approvals := currentApprovals(record)
+		if len(approvals) == 0 {
+			continue
+		}
+		pending = append(pending, &PendingApproval{
+			RecordID:  record.RecordID,
+			StudentID: record.StudentID,
+			Required:  config.approvalsRequired(),
+			Approvals: approvals,
+		})
+	}
+
+	return pending, nil
+}
+
+// currentApprovals returns the approvals given since the record was last rejected
+func currentApprovals(record *AcademicRecord) []*Approval {
+	approvals := []*Approval{}
+	for _, approval := range record.Approvals {
+		if approval.Round == len(record.Rejections) {
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals
+}
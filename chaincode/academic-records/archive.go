@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT ARCHIVE ==========
+//
+// Students entered by mistake cannot be deleted, since DelState would cut them out of history.
+// ArchiveStudent tombstones them instead: the student key stays, the student~status and
+// student~department entries move to student~archived and the email is released. Student
+// reads skip archived students unless an admin passes includeArchived.
+
+// ArchiveStudent tombstones a student that has no academic records or certificates (NITWarangal only)
+func (s *SmartContract) ArchiveStudent(ctx contractapi.TransactionContextInterface, studentID string, reason string) (*Student, error) {
+	if _, err := requireOrgRole(ctx, "archive students", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to archive a student")
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Archived {
+		return nil, fmt.Errorf("student %s is already archived", studentID)
+	}
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+
+	for _, index := range []struct{ objectType, asset string }{
+		{"record~student", "academic records"},
+		{"cert~student~type", "certificates"},
+	} {
+		used, err := indexHasEntries(ctx, index.objectType, []string{studentID})
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, fmt.Errorf("student %s has %s and cannot be archived", studentID, index.asset)
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	student.Archived = true
+	student.ArchivedBy = getClientCommonName(ctx)
+	student.ArchivedAt = txTime.UTC().Format(time.RFC3339)
+	student.ArchiveReason = reason
+	if err := putStudent(ctx, student); err != nil {
+		return nil, err
+	}
+	if err := deleteIndex(ctx, "student~status", []string{student.Status, studentID}); err != nil {
+		return nil, err
+	}
+	if err := deleteIndex(ctx, "student~department", []string{student.Department, studentID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "student~archived", []string{studentID}); err != nil {
+		return nil, err
+	}
+
+	// Release the address so a corrected enrollment can use it
+	pii, err := readStudentPII(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if pii != nil && pii.Email != "" {
+		owner, err := emailOwner(ctx, pii.Email)
+		if err != nil {
+			return nil, err
+		}
+		if owner == studentID {
+			if err := deleteEmailIndex(ctx, pii.Email); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	logAudit(ctx, "ArchiveStudent", "STUDENT", studentID, fmt.Sprintf("Archived by %s: %s", student.ArchivedBy, reason))
+
+	return student, nil
+}
+
+// UnarchiveStudent restores an archived student to the listings (NITWarangal only)
+func (s *SmartContract) UnarchiveStudent(ctx contractapi.TransactionContextInterface, studentID string, reason string) (*Student, error) {
+	if _, err := requireOrgRole(ctx, "unarchive students", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to unarchive a student")
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !student.Archived {
+		return nil, fmt.Errorf("student %s is not archived", studentID)
+	}
+
+	pii, err := readStudentPII(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if pii != nil && pii.Email != "" {
+		if err := assertEmailAvailable(ctx, pii.Email, studentID); err != nil {
+			return nil, err
+		}
+		if err := putEmailIndex(ctx, pii.Email, studentID); err != nil {
+			return nil, err
+		}
+	}
+
+	student.Archived = false
+	student.ArchivedBy, student.ArchivedAt, student.ArchiveReason = "", "", ""
+	if err := putStudent(ctx, student); err != nil {
+		return nil, err
+	}
+	if err := deleteIndex(ctx, "student~archived", []string{studentID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "student~status", []string{student.Status, studentID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "student~department", []string{student.Department, studentID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "UnarchiveStudent", "STUDENT", studentID, fmt.Sprintf("Unarchived: %s", reason))
+
+	return student, nil
+}
+
+// assertIncludeArchivedAllowed restricts reads of archived students to admin identities
+func assertIncludeArchivedAllowed(ctx contractapi.TransactionContextInterface, includeArchived bool) error {
+	if !includeArchived {
+		return nil
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return fmt.Errorf("including archived students requires the %s=true attribute: %v", adminAttribute, err)
+	}
+	return nil
+}
+
+// closedStudentError refuses changes to archived, merged or transferred-out students; nil for any other student
+func closedStudentError(student *Student) error {
+	switch {
+	case student.Archived:
+		return fmt.Errorf("student %s is archived; call UnarchiveStudent first", student.StudentID)
+	case student.Status == studentStatusMerged:
+		return fmt.Errorf("student %s was merged into %s", student.StudentID, student.MergedInto)
+	case student.Status == studentStatusTransferredOut:
+		return fmt.Errorf("student %s was transferred out to %s", student.StudentID, student.TransferredTo)
+	}
+	return nil
+}
+
+// getArchivedStudents walks the student~archived index
+func getArchivedStudents(ctx contractapi.TransactionContextInterface) ([]*Student, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("student~archived", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived students: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	students := []*Student{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 1 {
+			continue
+		}
+
+		student, err := readStudent(ctx, compositeKeyParts[0])
+		if err == nil {
+			students = append(students, student)
+		}
+	}
+	return students, nil
+}
+
+// indexHasEntries reports whether any index entry starts with the given attributes
+func indexHasEntries(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) (bool, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, attributes)
+	if err != nil {
+		return false, fmt.Errorf("failed to query %s index: %v", objectType, err)
+	}
+	defer resultsIterator.Close()
+	return resultsIterator.HasNext(), nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// archiveCall and unarchiveCall return ArchiveStudent and UnarchiveStudent calls
+func archiveCall(l *testLedger, studentID string, reason string) func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+	return func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.ArchiveStudent(ctx, studentID, reason)
+	}
+}
+
+func unarchiveCall(l *testLedger, studentID string, reason string) func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+	return func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.UnarchiveStudent(ctx, studentID, reason)
+	}
+}
+
+// listedStudents returns the IDs GetStudentsByStatus lists for ACTIVE students
+func listedStudents(t *testing.T, l *testLedger, as *ledgertest.Identity, includeArchived bool) []string {
+	t.Helper()
+	students := ledgertest.MustInvoke(t, l, as, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) ([]*Student, error) {
+		return l.contract.GetStudentsByStatus(ctx, studentStatusActive, includeArchived)
+	})
+	ids := make([]string, len(students))
+	for i, student := range students {
+		ids[i] = student.StudentID
+	}
+	return ids
+}
+
+func TestArchiveAndUnarchiveStudent(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestStudent(t, l, "CS21002", "CSE")
+	email := l.CompositeKey(t, "email", "cs21001@student.nitw.ac.in")
+	getStudent := func(includeArchived bool) func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+			return l.contract.GetStudent(ctx, "CS21001", includeArchived, "")
+		}
+	}
+
+	archived, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, archiveCall(l, "CS21001", " duplicate enrollment "))
+	if ws.Err != nil {
+		t.Fatalf("ArchiveStudent: %v", ws.Err)
+	}
+	if !archived.Archived || archived.ArchivedBy != testRegistrar.Name || archived.ArchivedAt != l.Now.Format(time.RFC3339) || archived.ArchiveReason != "duplicate enrollment" {
+		t.Errorf("archived student = %+v", archived)
+	}
+	if ws.Deleted("CS21001") || !l.storedStudent(t, "CS21001").Archived {
+		t.Errorf("the student key was deleted instead of tombstoned")
+	}
+	if l.HasIndex(t, "student~status", studentStatusActive, "CS21001") || l.HasIndex(t, "student~department", "CSE", "CS21001") || !l.HasIndex(t, "student~archived", "CS21001") {
+		t.Errorf("archiving did not move the student from the listing indexes to student~archived")
+	}
+	if l.Stub.Private[collectionStudentPII][email] != nil {
+		t.Errorf("archiving did not release the email address")
+	}
+	if got := writtenAuditActions(t, ws); len(got) != 1 || got[0] != "ArchiveStudent" {
+		t.Errorf("audit actions = %v, want [ArchiveStudent]", got)
+	}
+
+	if got := listedStudents(t, l, testRegistrar, false); len(got) != 1 || got[0] != "CS21002" {
+		t.Errorf("active listing = %v, want only CS21002", got)
+	}
+	if got := listedStudents(t, l, testAdmin, true); len(got) != 2 {
+		t.Errorf("listing with archived students = %v, want both", got)
+	}
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "including archived students requires the admin=true attribute", getStudent(true))
+	ledgertest.InvokeError(t, l, testAdmin, ledgertest.TxOptions{}, "student CS21001 not found", getStudent(false))
+	if got := ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{}, getStudent(true)); !got.Archived {
+		t.Errorf("admin read of the archived student = %+v", got)
+	}
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "student CS21001 is archived; call UnarchiveStudent first", func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.UpdateStudentStatus(ctx, "CS21001", studentStatusSuspended, "fee default")
+	})
+
+	restored, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, unarchiveCall(l, "CS21001", "enrollment confirmed"))
+	if ws.Err != nil {
+		t.Fatalf("UnarchiveStudent: %v", ws.Err)
+	}
+	if restored.Archived || restored.ArchivedBy != "" || restored.ArchivedAt != "" || restored.ArchiveReason != "" || restored.Status != studentStatusActive {
+		t.Errorf("unarchived student = %+v", restored)
+	}
+	if !l.HasIndex(t, "student~status", studentStatusActive, "CS21001") || !l.HasIndex(t, "student~department", "CSE", "CS21001") || l.HasIndex(t, "student~archived", "CS21001") {
+		t.Errorf("unarchiving did not restore the listing indexes")
+	}
+	if owner := string(l.Stub.Private[collectionStudentPII][email]); owner != "CS21001" {
+		t.Errorf("email is indexed to %q after unarchiving, want CS21001", owner)
+	}
+	if got := writtenAuditActions(t, ws); len(got) != 1 || got[0] != "UnarchiveStudent" {
+		t.Errorf("audit actions = %v, want [UnarchiveStudent]", got)
+	}
+	if got := listedStudents(t, l, testRegistrar, false); len(got) != 2 {
+		t.Errorf("active listing after unarchiving = %v, want both students", got)
+	}
+	// Create, archive and unarchive all stay in the key's history
+	if n := len(l.Stub.History["CS21001"]); n != 3 {
+		t.Errorf("student key has %d history entries, want 3", n)
+	}
+}
+
+func TestArchiveStudentRefusals(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestStudent(t, l, "CS21002", "CSE")
+	NewTestRecord(t, l, "REC1", "CS21002", 1, 2)
+	NewTestStudent(t, l, "CS21003", "CSE")
+	NewTestCertificate(t, l, "CERT1", "CS21003", "BONAFIDE")
+	NewTestStudent(t, l, "CS21004", "CSE")
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, archiveCall(l, "CS21004", "test entry"))
+
+	tests := []struct {
+		name    string
+		as      *ledgertest.Identity
+		call    func(ctx contractapi.TransactionContextInterface) (*Student, error)
+		wantErr string
+	}{
+		{"department caller", testExamCell, archiveCall(l, "CS21001", "test entry"), "only the university can archive students"},
+		{"regulator caller", testRegulator, unarchiveCall(l, "CS21004", "restored"), "only the university can unarchive students"},
+		{"blank reason", testRegistrar, archiveCall(l, "CS21001", "  "), "a reason is required to archive a student"},
+		{"blank unarchive reason", testRegistrar, unarchiveCall(l, "CS21004", ""), "a reason is required to unarchive a student"},
+		{"student with records", testRegistrar, archiveCall(l, "CS21002", "test entry"), "student CS21002 has academic records and cannot be archived"},
+		{"student with certificates", testRegistrar, archiveCall(l, "CS21003", "test entry"), "student CS21003 has certificates and cannot be archived"},
+		{"already archived", testRegistrar, archiveCall(l, "CS21004", "test entry"), "student CS21004 is already archived"},
+		{"not archived", testRegistrar, unarchiveCall(l, "CS21001", "restored"), "student CS21001 is not archived"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := ledgertest.InvokeError(t, l, tt.as, ledgertest.TxOptions{}, tt.wantErr, tt.call)
+			if len(ws.LedgerWrites()) != 0 {
+				t.Errorf("refused call wrote %d entries", len(ws.LedgerWrites()))
+			}
+		})
+	}
+}
+
+func TestUnarchiveRefusesAReusedEmail(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, archiveCall(l, "CS21001", "duplicate enrollment"))
+
+	// The corrected enrollment takes over the released address
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21001")}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.CreateStudent(ctx, "CS21101", "CSE", "")
+	})
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "email cs21001@student.nitw.ac.in already belongs to student CS21101", unarchiveCall(l, "CS21001", "restored"))
+	if !l.storedStudent(t, "CS21001").Archived {
+		t.Errorf("refused unarchive changed the student")
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== AUDIT QUERIES ==========
+
+// auditTimeRange bounds the audit timestamp; both ends are inclusive RFC 3339 UTC strings
+type auditTimeRange struct {
+	From string `json:"$gte"`
+	To   string `json:"$lte,omitempty"`
+}
+
+// auditSelector is the Mango selector used by QueryAuditLogs; empty filters are omitted.
+// Audit entries carry no docType, so logId tells them apart from other assets.
+type auditSelector struct {
+	Organization string          `json:"organization,omitempty"`
+	Action       string          `json:"action,omitempty"`
+	Timestamp    auditTimeRange  `json:"timestamp"`
+	LogID        map[string]bool `json:"logId"`
+}
+
+// QueryAuditLogs returns audit entries by organization, action and time range, oldest first
+// (NITWarangal and regulators). Empty filters mean any; fromTime and toTime are RFC 3339 and inclusive.
+func (s *SmartContract) QueryAuditLogs(ctx contractapi.TransactionContextInterface, org string, action string, fromTime string, toTime string, pageSize int32, bookmark string) (*PaginatedAuditLogs, error) {
+	if _, err := requireOrgRole(ctx, "query audit logs", orgRoleUniversity, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+
+	from, err := normalizeAuditTime(fromTime, "fromTime")
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeAuditTime(toTime, "toTime")
+	if err != nil {
+		return nil, err
+	}
+	if to != "" && from > to {
+		return nil, fmt.Errorf("fromTime %s is after toTime %s", from, to)
+	}
+
+	// Marshal the selector from a struct so caller input can never alter the query shape.
+	// Sorting on every index field keeps CouchDB on the index; org and action are equality
+	// matches, so the order is by timestamp.
+	query := struct {
+		Selector auditSelector       `json:"selector"`
+		Sort     []map[string]string `json:"sort"`
+		UseIndex []string            `json:"use_index"`
+	}{
+		Selector: auditSelector{
+			Organization: org,
+			Action:       action,
+			Timestamp:    auditTimeRange{From: from, To: to},
+			LogID:        map[string]bool{"$exists": true},
+		},
+	}
+	switch {
+	case org != "" && action != "":
+		query.Sort = []map[string]string{{"organization": "asc"}, {"action": "asc"}, {"timestamp": "asc"}}
+		query.UseIndex = []string{"_design/indexAuditOrgActionTimestampDoc", "indexAuditOrgActionTimestamp"}
+	case org != "":
+		query.Sort = []map[string]string{{"organization": "asc"}, {"timestamp": "asc"}}
+		query.UseIndex = []string{"_design/indexAuditOrgTimestampDoc", "indexAuditOrgTimestamp"}
+	case action != "":
+		query.Sort = []map[string]string{{"action": "asc"}, {"timestamp": "asc"}}
+		query.UseIndex = []string{"_design/indexAuditActionTimestampDoc", "indexAuditActionTimestamp"}
+	default:
+		query.Sort = []map[string]string{{"timestamp": "asc"}}
+		query.UseIndex = []string{"_design/indexAuditTimestampDoc", "indexAuditTimestamp"}
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	logs := []*AuditLog{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var log AuditLog
+		if err := json.Unmarshal(response.Value, &log); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit log: %v", err)
+		}
+		logs = append(logs, &log)
+	}
+
+	return &PaginatedAuditLogs{
+		Logs:         logs,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}, nil
+}
+
+// normalizeAuditTime parses an optional RFC 3339 bound into the UTC form audit timestamps use
+func normalizeAuditTime(value string, field string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: must be RFC 3339", field, value)
+	}
+	return parsed.UTC().Format(time.RFC3339), nil
+}
+
+// ========== AUDIT RETENTION ==========
+
+// auditPurgeRecordID is the record ID purge summaries are logged under
+const auditPurgeRecordID = "AUDIT_RETENTION"
+
+// AuditPurgeResult reports one PurgeAuditLogs batch
+type AuditPurgeResult struct {
+	Purged int    `json:"purged"`
+	Cutoff string `json:"cutoff"`
+	More   bool   `json:"more"` // true when the batch limit was hit; call again to continue
+}
+
+// PurgeAuditLogs deletes audit entries written before the RFC 3339 cutoff together with their
+// index entries (university admin identities only). The cutoff must lie outside the retention
+// period in the org config. At most pageSize entries are purged per call.
+func (s *SmartContract) PurgeAuditLogs(ctx contractapi.TransactionContextInterface, beforeTimestamp string, pageSize int32) (*AuditPurgeResult, error) {
+	if _, err := requireOrgRole(ctx, "purge audit logs", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("purging audit logs requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	cutoff, err := time.Parse(time.RFC3339, beforeTimestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cutoff %q: must be RFC3339", beforeTimestamp)
+	}
+
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	retainedFrom := txTime.AddDate(0, 0, -config.auditRetention())
+	if cutoff.After(retainedFrom) {
+		return nil, fmt.Errorf("cutoff %s is inside the %d-day audit retention period; the latest allowed cutoff is %s",
+			beforeTimestamp, config.auditRetention(), retainedFrom.UTC().Format(time.RFC3339))
+	}
+
+	// Audit IDs start with the zero-padded tx timestamp, ahead of the per-transaction sequence
+	// number, so a plain range covers everything before the cutoff. Pagination is not available
+	// in submit transactions, so stop at the batch limit.
+	batchSize := normalizePageSize(pageSize)
+	resultsIterator, err := ctx.GetStub().GetStateByRange("audit_", fmt.Sprintf("audit_%019d", cutoff.UnixNano()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	result := &AuditPurgeResult{Cutoff: cutoff.UTC().Format(time.RFC3339)}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if int32(result.Purged) == batchSize {
+			result.More = true
+			break
+		}
+
+		var entry AuditLog
+		if err := json.Unmarshal(response.Value, &entry); err == nil && entry.RecordID != "" {
+			// Entries about config assets name their composite key, which cannot be a composite
+			// key attribute, so logAudit never indexed them
+			if _, err := ctx.GetStub().CreateCompositeKey("audit", []string{entry.RecordID, response.Key}); err == nil {
+				if err := deleteIndex(ctx, "audit", []string{entry.RecordID, response.Key}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := ctx.GetStub().DelState(response.Key); err != nil {
+			return nil, fmt.Errorf("failed to delete audit log %s: %v", response.Key, err)
+		}
+		result.Purged++
+	}
+
+	logAudit(ctx, "PurgeAuditLogs", "AUDIT", auditPurgeRecordID, fmt.Sprintf("Purged %d audit entries before %s (more: %t)", result.Purged, result.Cutoff, result.More))
+
+	return result, nil
+}
+
+// ========== STUDENT AUDIT TRAIL ==========
+
+// StudentAuditEntry is an audit entry tagged with the student asset it concerns
+type StudentAuditEntry struct {
+	AssetType string    `json:"assetType"` // STUDENT, RECORD, CERTIFICATE
+	AssetID   string    `json:"assetId"`
+	Entry     *AuditLog `json:"entry"`
+}
+
+// PaginatedStudentAuditTrail holds one page of a student's audit trail; pass Bookmark back
+// to get the next page, an empty Bookmark means the trail is complete
+type PaginatedStudentAuditTrail struct {
+	Entries      []*StudentAuditEntry `json:"entries"`
+	FetchedCount int32                `json:"fetchedCount"`
+	Bookmark     string               `json:"bookmark"`
+}
+
+// GetStudentAuditTrail merges the audit entries of a student, their records and their
+// certificates into one list, oldest first (NITWarangal and regulators). The bookmark is the log ID of
+// the last entry already returned.
+func (s *SmartContract) GetStudentAuditTrail(ctx contractapi.TransactionContextInterface, studentID string, pageSize int32, bookmark string) (*PaginatedStudentAuditTrail, error) {
+	if _, err := requireOrgRole(ctx, "view student audit trails", orgRoleUniversity, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	assets := map[string]string{studentID: "STUDENT"}
+	recordIDs, err := indexedIDs(ctx, "record~student", []string{studentID}, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, recordID := range recordIDs {
+		assets[recordID] = "RECORD"
+	}
+	certificateIDs, err := indexedIDs(ctx, "cert~student~type", []string{studentID}, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, certificateID := range certificateIDs {
+		assets[certificateID] = "CERTIFICATE"
+	}
+
+	entries := []*StudentAuditEntry{}
+	for assetID, assetType := range assets {
+		logs, err := auditEntries(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			if log.LogID > bookmark {
+				entries = append(entries, &StudentAuditEntry{AssetType: assetType, AssetID: assetID, Entry: log})
+			}
+		}
+	}
+
+	// Log IDs start with the zero-padded tx timestamp, so sorting by ID is chronological
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Entry.LogID < entries[j].Entry.LogID
+	})
+
+	page := &PaginatedStudentAuditTrail{Entries: entries}
+	if limit := int(normalizePageSize(pageSize)); len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.Bookmark = entries[limit-1].Entry.LogID
+	}
+	page.FetchedCount = int32(len(page.Entries))
+	return page, nil
+}
+
+// indexedIDs returns the attribute at position from every key of a composite index under prefix
+func indexedIDs(ctx contractapi.TransactionContextInterface, objectType string, prefix []string, position int) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s index: %v", objectType, err)
+	}
+	defer resultsIterator.Close()
+
+	ids := []string{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) <= position {
+			continue
+		}
+		ids = append(ids, compositeKeyParts[position])
+	}
+	return ids, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+func TestAuditUserIsTheClientCommonName(t *testing.T) {
+	hidden := testRegistrar.With(nil)
+	hidden.Name = "registrar2"
+	hidden.HideCertificate = true
+
+	tests := []struct {
+		name     string
+		as       *ledgertest.Identity
+		wantUser string
+	}{
+		{"from the certificate", testRegistrar, "registrar1"},
+		{"another user of the same org", testAdmin, "admin1"},
+		{"from the client ID when the certificate is unavailable", hidden, "registrar2"},
+	}
+	l := newTestLedger(t)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			studentID := fmt.Sprintf("CS2100%d", i+1)
+			_, ws := ledgertest.Invoke(l, tt.as, ledgertest.TxOptions{Transient: piiTransient(studentID)}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+				return l.contract.CreateStudent(ctx, studentID, "CSE", "")
+			})
+			if ws.Err != nil {
+				t.Fatalf("CreateStudent: %v", ws.Err)
+			}
+
+			entries := writtenAuditEntries(t, ws)
+			if len(entries) == 0 {
+				t.Fatalf("CreateStudent wrote no audit entry")
+			}
+			for _, entry := range entries {
+				if entry.User != tt.wantUser || entry.UserID != tt.as.ClientID() || entry.Organization != tt.as.MSPID {
+					t.Errorf("%s entry by %q (%s) of %s, want %q (%s) of %s", entry.Action, entry.User, entry.UserID, entry.Organization, tt.wantUser, tt.as.ClientID(), tt.as.MSPID)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryAuditLogsTimeWindow(t *testing.T) {
+	l := newTestLedger(t)
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2024, time.June, 2, hour, minute, 0, 0, time.UTC)
+	}
+	for i, created := range []time.Time{at(10, 0), at(11, 0), at(11, 30), at(12, 0)} {
+		studentID := fmt.Sprintf("CS2100%d", i+1)
+		ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{Transient: piiTransient(studentID), At: created}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+			return l.contract.CreateStudent(ctx, studentID, "CSE", "")
+		})
+	}
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{At: at(11, 15)}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.UpdateStudentStatus(ctx, "CS21001", studentStatusSuspended, "fee default")
+	})
+
+	tests := []struct {
+		name     string
+		as       *ledgertest.Identity
+		action   string
+		from     string
+		to       string
+		wantErr  string
+		wantRecs []string
+	}{
+		{"closed window", testRegistrar, "CreateStudent", "2024-06-02T10:30:00Z", "2024-06-02T11:45:00Z", "", []string{"CS21002", "CS21003"}},
+		{"bounds are inclusive", testRegistrar, "CreateStudent", "2024-06-02T11:00:00Z", "2024-06-02T11:30:00Z", "", []string{"CS21002", "CS21003"}},
+		{"open end", testRegistrar, "CreateStudent", "2024-06-02T11:30:00Z", "", "", []string{"CS21003", "CS21004"}},
+		{"open start", testRegistrar, "CreateStudent", "", "2024-06-02T10:00:00Z", "", []string{"CS21001"}},
+		{"offset bounds", testRegistrar, "CreateStudent", "2024-06-02T16:30:00+05:30", "2024-06-02T16:59:59+05:30", "", []string{"CS21002"}},
+		{"any action", testRegulator, "", "2024-06-02T11:00:00Z", "2024-06-02T11:15:00Z", "", []string{"CS21002", "CS21001"}},
+		{"empty window", testRegistrar, "", "2024-06-02T12:30:00Z", "2024-06-02T13:00:00Z", "", []string{}},
+		{"reversed window", testRegistrar, "", "2024-06-02T12:00:00Z", "2024-06-02T11:00:00Z", "fromTime 2024-06-02T12:00:00Z is after toTime 2024-06-02T11:00:00Z", nil},
+		{"not RFC 3339", testRegistrar, "", "2024-06-02", "", `invalid fromTime "2024-06-02": must be RFC 3339`, nil},
+		{"department caller", testExamCell, "", "", "", "only the university or regulators can query audit logs", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := func(ctx contractapi.TransactionContextInterface) (*PaginatedAuditLogs, error) {
+				return l.contract.QueryAuditLogs(ctx, "", tt.action, tt.from, tt.to, 0, "")
+			}
+			if tt.wantErr != "" {
+				ledgertest.InvokeError(t, l, tt.as, ledgertest.TxOptions{}, tt.wantErr, query)
+				return
+			}
+
+			page := ledgertest.MustInvoke(t, l, tt.as, ledgertest.TxOptions{}, query)
+			got := []string{}
+			for _, entry := range page.Logs {
+				got = append(got, entry.RecordID)
+			}
+			if !reflect.DeepEqual(got, tt.wantRecs) {
+				t.Errorf("entries for %v, want %v", got, tt.wantRecs)
+			}
+		})
+	}
+}
+
+func TestPurgeAuditLogsCoversUnindexedEntries(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+
+	purgeAt := ledgertest.Epoch.AddDate(8, 0, 0)
+	result := ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{At: purgeAt}, func(ctx contractapi.TransactionContextInterface) (*AuditPurgeResult, error) {
+		return l.contract.PurgeAuditLogs(ctx, ledgertest.Epoch.AddDate(0, 1, 0).Format(time.RFC3339), 1000)
+	})
+	if result.Purged == 0 || result.More {
+		t.Fatalf("purge result = %+v, want every entry purged in one batch", result)
+	}
+	// The ledger setup audited the founding institution's registration under its composite key
+	studentIndex := l.CompositeKey(t, "audit", "CS21001")
+	for key, value := range l.Stub.State {
+		var entry AuditLog
+		if strings.HasPrefix(key, "audit_") && json.Unmarshal(value, &entry) == nil && entry.Action != "PurgeAuditLogs" {
+			t.Errorf("audit entry %s about %q survived the purge", key, entry.RecordID)
+		}
+		if strings.HasPrefix(key, studentIndex) {
+			t.Errorf("audit index entry %q survived the purge", key)
+		}
+	}
+}
+
+func TestAuditEntriesOfOneTransactionStayApart(t *testing.T) {
+	l := newTestLedger(t)
+	ws := l.Submit(testAdmin, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) error {
+		for _, details := range []string{"first note", "second note"} {
+			if err := logAudit(ctx, "AddNote", "RECORD", "REC9", details); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if ws.Err != nil {
+		t.Fatalf("audit transaction failed: %v", ws.Err)
+	}
+
+	entries := writtenAuditEntries(t, ws)
+	if len(entries) != 2 || entries[0].LogID == entries[1].LogID {
+		t.Fatalf("audit entries written = %+v, want two under distinct keys", entries)
+	}
+	for _, entry := range entries {
+		if !l.HasIndex(t, "audit", "REC9", entry.LogID) {
+			t.Errorf("audit entry %s is not indexed", entry.LogID)
+		}
+	}
+	logs := ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) ([]*AuditLog, error) {
+		return l.contract.GetAuditLog(ctx, "REC9")
+	})
+	details := []string{}
+	for _, entry := range logs {
+		details = append(details, entry.Details)
+	}
+	if want := []string{"first note", "second note"}; !reflect.DeepEqual(details, want) {
+		t.Errorf("audit trail of REC9 = %v, want %v", details, want)
+	}
+
+	// Both entries fall inside the purge range along with their index entries
+	purgeAt := ledgertest.Epoch.AddDate(8, 0, 0)
+	ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{At: purgeAt}, func(ctx contractapi.TransactionContextInterface) (*AuditPurgeResult, error) {
+		return l.contract.PurgeAuditLogs(ctx, ledgertest.Epoch.AddDate(0, 1, 0).Format(time.RFC3339), 1000)
+	})
+	for _, entry := range entries {
+		if l.Stub.State[entry.LogID] != nil || l.HasIndex(t, "audit", "REC9", entry.LogID) {
+			t.Errorf("audit entry %s survived the purge", entry.LogID)
+		}
+	}
+}
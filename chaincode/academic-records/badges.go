@@ -0,0 +1,358 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== OPEN BADGES ==========
+//
+// ExportOpenBadge renders an issued certificate as an Open Badges 2.0 assertion. The badge class
+// comes from a configurable asset keyed by certification type and program, so new badge types
+// need no code change; a class with program "*" covers every program of its type. The assertion
+// is hosted at the certificate's verification URL.
+
+// badgeClassObjectType prefixes the composite keys of badge classes
+const badgeClassObjectType = "BADGE_CLASS"
+
+// anyProgram is the badge class program that matches students of every program
+const anyProgram = "*"
+
+// openBadgesContext is the JSON-LD context of Open Badges 2.0 documents
+const openBadgesContext = "https://w3id.org/openbadges/v2"
+
+// BadgeClass describes the badge a certification type earns
+type BadgeClass struct {
+	DocType           string   `json:"docType"`
+	CertificationType string   `json:"certificationType"`
+	Program           string   `json:"program"` // e.g. BTECH, or * for every program
+	ID                string   `json:"id"`      // URL of the published badge class
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Image             string   `json:"image"` // URL
+	CriteriaURL       string   `json:"criteriaUrl,omitempty"`
+	CriteriaNarrative string   `json:"criteriaNarrative,omitempty"`
+	IssuerID          string   `json:"issuerId"` // URL of the issuer profile
+	IssuerName        string   `json:"issuerName"`
+	IssuerURL         string   `json:"issuerUrl"`
+	IssuerEmail       string   `json:"issuerEmail,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	UpdatedBy         string   `json:"updatedBy"`
+	UpdatedAt         string   `json:"updatedAt"`
+}
+
+// OpenBadgeAssertion is an Open Badges 2.0 assertion with its badge class embedded
+type OpenBadgeAssertion struct {
+	Context      string                 `json:"@context"`
+	Type         string                 `json:"type"`
+	ID           string                 `json:"id"`
+	Recipient    *OpenBadgeRecipient    `json:"recipient"`
+	Badge        *OpenBadgeClass        `json:"badge"`
+	Verification *OpenBadgeVerification `json:"verification"`
+	IssuedOn     string                 `json:"issuedOn"`
+	Expires      string                 `json:"expires,omitempty"`
+}
+
+// OpenBadgeRecipient identifies the earner by salted email hash
+type OpenBadgeRecipient struct {
+	Type     string `json:"type"`
+	Hashed   bool   `json:"hashed"`
+	Salt     string `json:"salt"`
+	Identity string `json:"identity"` // sha256$ followed by the hex SHA-256 of email + salt
+}
+
+// OpenBadgeClass is the badge class as embedded in an assertion
+type OpenBadgeClass struct {
+	Type        string            `json:"type"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Image       string            `json:"image"`
+	Criteria    *OpenBadgeCrit    `json:"criteria"`
+	Issuer      *OpenBadgeProfile `json:"issuer"`
+	Tags        []string          `json:"tags,omitempty"`
+}
+
+// OpenBadgeCrit is the criteria of a badge class
+type OpenBadgeCrit struct {
+	ID        string `json:"id,omitempty"`
+	Narrative string `json:"narrative,omitempty"`
+}
+
+// OpenBadgeProfile is the issuer of a badge class
+type OpenBadgeProfile struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Email string `json:"email,omitempty"`
+}
+
+// OpenBadgeVerification tells consumers how to check the assertion
+type OpenBadgeVerification struct {
+	Type string `json:"type"`
+}
+
+// CreateBadgeClass stores a new badge class (NITWarangal only)
+func (s *SmartContract) CreateBadgeClass(ctx contractapi.TransactionContextInterface, badgeClassJSON string) (*BadgeClass, error) {
+	return saveBadgeClass(ctx, badgeClassJSON, false)
+}
+
+// UpdateBadgeClass replaces an existing badge class (NITWarangal only)
+func (s *SmartContract) UpdateBadgeClass(ctx contractapi.TransactionContextInterface, badgeClassJSON string) (*BadgeClass, error) {
+	return saveBadgeClass(ctx, badgeClassJSON, true)
+}
+
+// GetBadgeClass returns the badge class of a certification type and program
+func (s *SmartContract) GetBadgeClass(ctx contractapi.TransactionContextInterface, certificationType string, program string) (*BadgeClass, error) {
+	key, err := badgeClassKey(ctx, certificationType, program)
+	if err != nil {
+		return nil, err
+	}
+	badgeClass, err := readBadgeClass(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if badgeClass == nil {
+		return nil, fmt.Errorf("no badge class for %s %s", certificationType, program)
+	}
+	return badgeClass, nil
+}
+
+// ListBadgeClasses returns every badge class, optionally narrowed to one certification type
+func (s *SmartContract) ListBadgeClasses(ctx contractapi.TransactionContextInterface, certificationType string) ([]*BadgeClass, error) {
+	attributes := []string{}
+	if certificationType != "" {
+		attributes = append(attributes, certificationType)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(badgeClassObjectType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query badge classes: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	badgeClasses := []*BadgeClass{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var badgeClass BadgeClass
+		if err := json.Unmarshal(response.Value, &badgeClass); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal badge class: %v", err)
+		}
+		badgeClasses = append(badgeClasses, &badgeClass)
+	}
+	return badgeClasses, nil
+}
+
+// ExportOpenBadge returns an issued certificate as an Open Badges 2.0 assertion (NITWarangal only).
+// The recipient is the student's email, hashed with a salt derived from the transaction.
+func (s *SmartContract) ExportOpenBadge(ctx contractapi.TransactionContextInterface, certificateID string) (*OpenBadgeAssertion, error) {
+	if _, err := requireOrgRole(ctx, "export open badges", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Status != certStatusIssued {
+		return nil, fmt.Errorf("certificate %s is %s and cannot be exported as a badge", certificateID, cert.Status)
+	}
+
+	student, err := readStudent(ctx, cert.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	pii, err := readStudentPII(ctx, cert.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if pii == nil || pii.Email == "" {
+		return nil, fmt.Errorf("student %s has no email to address the badge to", cert.StudentID)
+	}
+
+	badgeClass, err := applicableBadgeClass(ctx, cert.CertificationType, student.Program)
+	if err != nil {
+		return nil, err
+	}
+
+	// Endorsers share the tx ID, so they derive the same salt
+	saltHash := sha256.Sum256([]byte(ctx.GetStub().GetTxID() + "|" + certificateID))
+	salt := hex.EncodeToString(saltHash[:8])
+	identity := sha256.Sum256([]byte(normalizeEmail(pii.Email) + salt))
+
+	assertion := &OpenBadgeAssertion{
+		Context: openBadgesContext,
+		Type:    "Assertion",
+		ID:      certificateVerificationURL(cert),
+		Recipient: &OpenBadgeRecipient{
+			Type:     "email",
+			Hashed:   true,
+			Salt:     salt,
+			Identity: "sha256$" + hex.EncodeToString(identity[:]),
+		},
+		Badge: &OpenBadgeClass{
+			Type:        "BadgeClass",
+			ID:          badgeClass.ID,
+			Name:        badgeClass.Name,
+			Description: badgeClass.Description,
+			Image:       badgeClass.Image,
+			Criteria:    &OpenBadgeCrit{ID: badgeClass.CriteriaURL, Narrative: badgeClass.CriteriaNarrative},
+			Issuer: &OpenBadgeProfile{
+				Type:  "Profile",
+				ID:    badgeClass.IssuerID,
+				Name:  badgeClass.IssuerName,
+				URL:   badgeClass.IssuerURL,
+				Email: badgeClass.IssuerEmail,
+			},
+			Tags: badgeClass.Tags,
+		},
+		Verification: &OpenBadgeVerification{Type: "hosted"},
+		IssuedOn:     cert.IssuedDate,
+		Expires:      cert.ValidUntil,
+	}
+	return assertion, nil
+}
+
+// saveBadgeClass validates and stores a badge class, auditing the old and new values
+func saveBadgeClass(ctx contractapi.TransactionContextInterface, badgeClassJSON string, replace bool) (*BadgeClass, error) {
+	creatorOrg, err := requireOrgRole(ctx, "manage badge classes", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var badgeClass BadgeClass
+	if err := json.Unmarshal([]byte(badgeClassJSON), &badgeClass); err != nil {
+		return nil, fmt.Errorf("invalid badge class JSON: %v", err)
+	}
+	badgeClass.Program = normalizeBadgeProgram(badgeClass.Program)
+	if badgeClass.Name == "" || badgeClass.Description == "" {
+		return nil, fmt.Errorf("badge class needs a name and a description")
+	}
+	if badgeClass.CriteriaURL == "" && badgeClass.CriteriaNarrative == "" {
+		return nil, fmt.Errorf("badge class needs a criteriaUrl or a criteriaNarrative")
+	}
+	if badgeClass.IssuerName == "" {
+		return nil, fmt.Errorf("badge class needs an issuerName")
+	}
+	urls := []struct{ field, value string }{
+		{"id", badgeClass.ID},
+		{"image", badgeClass.Image},
+		{"issuerId", badgeClass.IssuerID},
+		{"issuerUrl", badgeClass.IssuerURL},
+		{"criteriaUrl", badgeClass.CriteriaURL},
+	}
+	for _, u := range urls {
+		if u.value == "" && u.field == "criteriaUrl" {
+			continue
+		}
+		if parsed, err := url.Parse(u.value); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return nil, fmt.Errorf("%s must be an https URL", u.field)
+		}
+	}
+
+	key, err := badgeClassKey(ctx, badgeClass.CertificationType, badgeClass.Program)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := readBadgeClass(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if replace && existing == nil {
+		return nil, fmt.Errorf("no badge class for %s %s", badgeClass.CertificationType, badgeClass.Program)
+	}
+	if !replace && existing != nil {
+		return nil, fmt.Errorf("badge class for %s %s already exists", badgeClass.CertificationType, badgeClass.Program)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	badgeClass.DocType = docTypeBadgeClass
+	badgeClass.UpdatedBy = creatorOrg
+	badgeClass.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	storedJSON, err := json.Marshal(badgeClass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal badge class: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	if existing == nil {
+		logAudit(ctx, "CreateBadgeClass", "CONFIG", key, fmt.Sprintf("Badge class created: %s", string(storedJSON)))
+	} else {
+		oldJSON, err := json.Marshal(existing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal badge class: %v", err)
+		}
+		logAudit(ctx, "UpdateBadgeClass", "CONFIG", key, fmt.Sprintf("Badge class changed from %s to %s", string(oldJSON), string(storedJSON)))
+	}
+
+	return &badgeClass, nil
+}
+
+// applicableBadgeClass returns the badge class for the student's program, or the one for every program
+func applicableBadgeClass(ctx contractapi.TransactionContextInterface, certificationType string, program string) (*BadgeClass, error) {
+	for _, candidate := range []string{normalizeProgram(program), anyProgram} {
+		key, err := badgeClassKey(ctx, certificationType, candidate)
+		if err != nil {
+			return nil, err
+		}
+		badgeClass, err := readBadgeClass(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if badgeClass != nil {
+			return badgeClass, nil
+		}
+	}
+	return nil, fmt.Errorf("no badge class for %s certificates of program %s; create one with CreateBadgeClass", certificationType, normalizeProgram(program))
+}
+
+// badgeClassKey builds the key of a badge class after validating its parts
+func badgeClassKey(ctx contractapi.TransactionContextInterface, certificationType string, program string) (string, error) {
+	if strings.TrimSpace(certificationType) == "" {
+		return "", fmt.Errorf("certification type is required")
+	}
+	return ctx.GetStub().CreateCompositeKey(badgeClassObjectType, []string{certificationType, normalizeBadgeProgram(program)})
+}
+
+// normalizeBadgeProgram upper-cases a program code; * and an empty program mean every program
+func normalizeBadgeProgram(program string) string {
+	program = strings.TrimSpace(program)
+	if program == "" || program == anyProgram {
+		return anyProgram
+	}
+	return normalizeProgram(program)
+}
+
+// readBadgeClass reads a badge class by key, returning nil if there is none
+func readBadgeClass(ctx contractapi.TransactionContextInterface, key string) (*BadgeClass, error) {
+	badgeClassJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if badgeClassJSON == nil {
+		return nil, nil
+	}
+
+	var badgeClass BadgeClass
+	if err := json.Unmarshal(badgeClassJSON, &badgeClass); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal badge class: %v", err)
+	}
+	return &badgeClass, nil
+}
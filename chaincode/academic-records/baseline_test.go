@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+func TestCreateStudentWriteSet(t *testing.T) {
+	l := newTestLedger(t)
+
+	_, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21001")}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.CreateStudent(ctx, "CS21001", "CSE", "")
+	})
+	if ws.Err != nil {
+		t.Fatalf("CreateStudent: %v", ws.Err)
+	}
+
+	var student Student
+	ledgertest.Decode(t, ws.Put("CS21001"), &student, "student")
+	if student.Status != studentStatusActive || student.Department != "CSE" || student.Program != "BTECH" {
+		t.Errorf("student = %+v, want an ACTIVE CSE BTECH student", student)
+	}
+	if student.Name != "" || student.Email != "" {
+		t.Errorf("public student document carries PII: %q, %q", student.Name, student.Email)
+	}
+	if ws.PutPrivate(collectionStudentPII, "CS21001") == nil {
+		t.Errorf("PII was not written to %s", collectionStudentPII)
+	}
+	for _, index := range [][]string{{"student~department", "CSE", "CS21001"}, {"student~status", studentStatusActive, "CS21001"}} {
+		if ws.Put(l.CompositeKey(t, index[0], index[1:]...)) == nil {
+			t.Errorf("index %v was not written", index)
+		}
+	}
+	if actions := writtenAuditActions(t, ws); !ledgertest.ContainsString(actions, "CreateStudent") {
+		t.Errorf("audit actions = %v, want CreateStudent", actions)
+	}
+	if name, _, _ := ws.Event(); name != eventStudentCreated {
+		t.Errorf("event = %q, want %q", name, eventStudentCreated)
+	}
+}
+
+func TestFailedTransactionCommitsNothing(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	before := len(l.Stub.State)
+
+	ws := ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21001")}, "already exists", func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.CreateStudent(ctx, "CS21001", "CSE", "")
+	})
+	if len(ws.LedgerWrites()) != 0 {
+		t.Errorf("rejected CreateStudent wrote %v", ws.PutKeys())
+	}
+
+	// The record is refused after validation, so nothing it staged may survive
+	ledgertest.InvokeError(t, l, testExamCell, ledgertest.TxOptions{}, "must be supplied in the transient map", func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+	})
+	if len(l.Stub.State) != before {
+		t.Errorf("world state grew from %d to %d keys after failed transactions", before, len(l.Stub.State))
+	}
+}
+
+func TestRecordLifecycle(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+
+	ledgertest.RunScenario(t, l, l.recordLifecycleSteps("REC1", "CS21001", 1, testCourses(4))...)
+
+	record := l.storedRecord(t, "REC1")
+	if len(record.Courses) != 4 {
+		t.Fatalf("verified record publishes %d courses, want 4", len(record.Courses))
+	}
+	// (3*10 + 4*8 + 3*6 + 4*4) / 14 = 6.857
+	if record.SGPA != 6.86 || record.CGPA != 6.86 {
+		t.Errorf("SGPA, CGPA = %v, %v, want 6.86, 6.86", record.SGPA, record.CGPA)
+	}
+	if len(record.Approvals) != 2 || record.ApprovedBy != testRegistrar.MSPID || record.VerifiedBy != testVerifier.MSPID {
+		t.Errorf("approvals = %d, approved by %q, verified by %q", len(record.Approvals), record.ApprovedBy, record.VerifiedBy)
+	}
+	if l.HasIndex(t, "record~awaitingverification", record.ApprovedAt, "REC1") {
+		t.Errorf("verified record is still queued for verification")
+	}
+	if !l.HasIndex(t, "record~status", recordStatusVerified, "REC1") || l.HasIndex(t, "record~status", recordStatusSubmitted, "REC1") {
+		t.Errorf("record~status index does not follow the record to VERIFIED")
+	}
+}
+
+func TestRecordCoursesStayPrivateUntilApproval(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	record := NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+
+	if len(record.Courses) != 0 || record.CoursesHash == "" {
+		t.Errorf("submitted record has %d public courses and hash %q", len(record.Courses), record.CoursesHash)
+	}
+	if l.Stub.Private[collectionDraftGrades]["REC1"] == nil {
+		t.Fatalf("courses were not staged in %s", collectionDraftGrades)
+	}
+
+	approved := approveTestRecord(t, l, "REC1")
+	if approved.Status != recordStatusApproved || len(approved.Courses) != 3 {
+		t.Errorf("approved record has status %s and %d courses", approved.Status, len(approved.Courses))
+	}
+	if !l.HasIndex(t, "record~awaitingverification", approved.ApprovedAt, "REC1") {
+		t.Errorf("approved record is not queued for verification")
+	}
+}
+
+func TestGetStudentsByStatusFollowsStatusChanges(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestStudent(t, l, "CS21002", "CSE")
+
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.UpdateStudentStatus(ctx, "CS21002", studentStatusSuspended, "disciplinary committee")
+	})
+
+	for status, want := range map[string][]string{
+		studentStatusActive:    {"CS21001"},
+		studentStatusSuspended: {"CS21002"},
+	} {
+		students := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) ([]*Student, error) {
+			return l.contract.GetStudentsByStatus(ctx, status, false)
+		})
+		got := []string{}
+		for _, student := range students {
+			got = append(got, student.StudentID)
+		}
+		if len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("GetStudentsByStatus(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestIssueAndRevokeCertificate(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	cert := NewTestCertificate(t, l, "CERT1", "CS21001", "BONAFIDE")
+
+	if cert.Status != certStatusIssued || cert.Template == nil || cert.CertificateHash == "" {
+		t.Fatalf("issued certificate = %+v", cert)
+	}
+	isValid := func(ctx contractapi.TransactionContextInterface) (*CertificateVerdict, error) {
+		return l.contract.IsCertificateValid(ctx, "CERT1")
+	}
+	if verdict := ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, isValid); !verdict.Valid || !verdict.HashValid {
+		t.Errorf("verdict for a new certificate = %+v", verdict)
+	}
+
+	certs := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) ([]*Certificate, error) {
+		return l.contract.GetStudentCertificates(ctx, "CS21001")
+	})
+	if len(certs) != 1 || certs[0].CertificateID != "CERT1" {
+		t.Errorf("GetStudentCertificates returned %d certificates", len(certs))
+	}
+
+	_, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Certificate, error) {
+		return l.contract.RevokeCertificate(ctx, "CERT1", "ADMIN_ERROR", "wrong semester on certificate")
+	})
+	if ws.Err != nil {
+		t.Fatalf("RevokeCertificate: %v", ws.Err)
+	}
+	name, payload, _ := ws.Event()
+	var event EventPayload
+	if err := json.Unmarshal(payload, &event); err != nil || name != eventCertificateRevoked || len(event.Events) != 1 {
+		t.Errorf("revocation event = %q %s", name, payload)
+	}
+	if verdict := ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, isValid); verdict.Valid || verdict.Outcome != certStatusRevoked {
+		t.Errorf("verdict for a revoked certificate = %+v", verdict)
+	}
+}
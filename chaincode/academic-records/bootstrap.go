@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== LEDGER BOOTSTRAP ==========
+
+// ledgerInitKey is the sentinel written by the first InitLedger; later calls are no-ops
+const ledgerInitKey = "CONFIG_LEDGER_INIT"
+
+// LedgerInit is the sentinel asset recording when the ledger was bootstrapped
+type LedgerInit struct {
+	DocType        string   `json:"docType"`
+	Demo           bool     `json:"demo"`
+	ConfigsWritten []string `json:"configsWritten"`
+	StudentsSeeded []string `json:"studentsSeeded"`
+	RecordsSeeded  []string `json:"recordsSeeded"`
+	CoursesSeeded  []string `json:"coursesSeeded"`
+	InitializedBy  string   `json:"initializedBy"`
+	InitializedAt  string   `json:"initializedAt"`
+}
+
+// demoStudent is one sample student seeded by InitLedger with demo set
+type demoStudent struct {
+	StudentID  string
+	Department string
+	Name       string
+	Email      string
+	RecordID   string
+	Courses    []CourseGrade
+}
+
+// demoStudents are the sample students and their first-semester records
+var demoStudents = []demoStudent{
+	{
+		StudentID:  "DEMO_CS001",
+		Department: "CSE",
+		Name:       "Demo Student One",
+		Email:      "demo.cs001@example.edu",
+		RecordID:   "DEMO_CS001_S1",
+		Courses: []CourseGrade{
+			{CourseCode: "CS101", CourseName: "Programming Fundamentals", Credits: 4, Grade: "A"},
+			{CourseCode: "MA101", CourseName: "Engineering Mathematics I", Credits: 4, Grade: "B"},
+			{CourseCode: "PH101", CourseName: "Engineering Physics", Credits: 3, Grade: "B"},
+		},
+	},
+	{
+		StudentID:  "DEMO_EC001",
+		Department: "ECE",
+		Name:       "Demo Student Two",
+		Email:      "demo.ec001@example.edu",
+		RecordID:   "DEMO_EC001_S1",
+		Courses: []CourseGrade{
+			{CourseCode: "EC101", CourseName: "Basic Electronics", Credits: 4, Grade: "B"},
+			{CourseCode: "MA101", CourseName: "Engineering Mathematics I", Credits: 4, Grade: "A"},
+			{CourseCode: "CY101", CourseName: "Engineering Chemistry", Credits: 3, Grade: "C"},
+		},
+	},
+	{
+		StudentID:  "DEMO_ME001",
+		Department: "MECH",
+		Name:       "Demo Student Three",
+		Email:      "demo.me001@example.edu",
+	},
+}
+
+// demoCourses are the catalog entries behind the demo records
+var demoCourses = []Course{
+	{CourseCode: "CS101", Title: "Programming Fundamentals", Credits: 4, Department: "CSE"},
+	{CourseCode: "EC101", Title: "Basic Electronics", Credits: 4, Department: "ECE"},
+	{CourseCode: "MA101", Title: "Engineering Mathematics I", Credits: 4, Department: "MATH"},
+	{CourseCode: "PH101", Title: "Engineering Physics", Credits: 3, Department: "PHY"},
+	{CourseCode: "CY101", Title: "Engineering Chemistry", Credits: 3, Department: "CHEM"},
+}
+
+// InitLedger bootstraps a new network (university only): it stores the default org config,
+// grade scale, certificate policy, role policy, department registry and GPA conversion tables
+// wherever none exists, and with demo set
+// seeds a few sample catalog courses, students and submitted records. It never overwrites
+// existing keys and does nothing once the ledger has been initialized.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface, demo bool) (*LedgerInit, error) {
+	creatorOrg, err := requireOrgRole(ctx, "initialize the ledger", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	existingJSON, err := ctx.GetStub().GetState(ledgerInitKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if existingJSON != nil {
+		var existing LedgerInit
+		if err := json.Unmarshal(existingJSON, &existing); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ledger ledgerInit: %v", err)
+		}
+		return &existing, nil
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	ledgerInit := LedgerInit{
+		DocType:        docTypeLedgerInit,
+		Demo:           demo,
+		ConfigsWritten: []string{},
+		StudentsSeeded: []string{},
+		RecordsSeeded:  []string{},
+		CoursesSeeded:  []string{},
+		InitializedBy:  creatorOrg,
+		InitializedAt:  now,
+	}
+
+	if err := writeDefaultConfigs(ctx, &ledgerInit); err != nil {
+		return nil, err
+	}
+	if demo {
+		if err := seedDemoData(ctx, &ledgerInit, txTime.UTC().Year()); err != nil {
+			return nil, err
+		}
+	}
+
+	initJSON, err := json.Marshal(ledgerInit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger ledgerInit: %v", err)
+	}
+	if err := ctx.GetStub().PutState(ledgerInitKey, initJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "InitLedger", "CONFIG", ledgerInitKey, fmt.Sprintf("Ledger initialized (demo=%t): configs %v, courses %v, students %v, records %v", demo, ledgerInit.ConfigsWritten, ledgerInit.CoursesSeeded, ledgerInit.StudentsSeeded, ledgerInit.RecordsSeeded))
+
+	return &ledgerInit, nil
+}
+
+// writeDefaultConfigs stores each config asset's default value unless the key already holds one
+func writeDefaultConfigs(ctx contractapi.TransactionContextInterface, ledgerInit *LedgerInit) error {
+	defaults := []struct {
+		key   string
+		asset interface{}
+	}{
+		{orgConfigKey, OrgConfig{
+			DocType:        docTypeOrgConfig,
+			UniversityOrgs: defaultOrgConfig.UniversityOrgs,
+			DepartmentOrgs: defaultOrgConfig.DepartmentOrgs,
+			VerifierOrgs:   defaultOrgConfig.VerifierOrgs,
+			RegulatorOrgs:  defaultOrgConfig.RegulatorOrgs,
+
+			RequiredApprovals:  defaultOrgConfig.RequiredApprovals,
+			AuditRetentionDays: defaultOrgConfig.AuditRetentionDays,
+			RepeatPolicy:       defaultOrgConfig.RepeatPolicy,
+			StudentIDPattern:   defaultOrgConfig.StudentIDPattern,
+			UpdatedBy:          ledgerInit.InitializedBy,
+			UpdatedAt:          ledgerInit.InitializedAt,
+
+			CertificateHashAlgorithm: defaultOrgConfig.CertificateHashAlgorithm,
+			ClassificationThresholds: defaultOrgConfig.ClassificationThresholds,
+			HonorsMinCGPA:            defaultOrgConfig.HonorsMinCGPA,
+			DeansListMinSGPA:         defaultOrgConfig.DeansListMinSGPA,
+			VisibilityProfiles:       defaultOrgConfig.VisibilityProfiles,
+			StudentListRoles:         defaultOrgConfig.StudentListRoles,
+			StudentReadRoles:         defaultOrgConfig.StudentReadRoles,
+		}},
+		{gradeScaleKey, GradeScale{
+			DocType:   docTypeGradeScale,
+			Grades:    defaultGradeScale,
+			UpdatedBy: ledgerInit.InitializedBy,
+			UpdatedAt: ledgerInit.InitializedAt,
+		}},
+		{certPolicyKey, CertificatePolicy{
+			DocType:                    docTypeCertPolicy,
+			UniqueTypes:                defaultUniqueCertificationTypes,
+			VerificationRequestTTLDays: defaultVerificationRequestTTLDays,
+			UpdatedBy:                  ledgerInit.InitializedBy,
+			UpdatedAt:                  ledgerInit.InitializedAt,
+		}},
+		{departmentRegistryKey, DepartmentRegistry{
+			DocType:     docTypeDepartmentRegistry,
+			Departments: defaultDepartments,
+			UpdatedBy:   ledgerInit.InitializedBy,
+			UpdatedAt:   ledgerInit.InitializedAt,
+		}},
+		{rolePolicyKey, RolePolicy{
+			DocType:   docTypeRolePolicy,
+			Attribute: defaultRoleAttribute,
+			Roles:     defaultOperationRoles,
+			UpdatedBy: ledgerInit.InitializedBy,
+			UpdatedAt: ledgerInit.InitializedAt,
+		}},
+	}
+	for _, table := range defaultGPAConversionTables {
+		key, err := gpaConversionKey(ctx, table.TargetScale, 1)
+		if err != nil {
+			return err
+		}
+		table.DocType = docTypeGPAConversion
+		table.Version = 1
+		table.UpdatedBy = ledgerInit.InitializedBy
+		table.UpdatedAt = ledgerInit.InitializedAt
+		defaults = append(defaults, struct {
+			key   string
+			asset interface{}
+		}{key, table})
+	}
+
+	for _, config := range defaults {
+		existing, err := ctx.GetStub().GetState(config.key)
+		if err != nil {
+			return fmt.Errorf("failed to read state: %v", err)
+		}
+		if existing != nil {
+			continue
+		}
+
+		configJSON, err := json.Marshal(config.asset)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %v", config.key, err)
+		}
+		if err := ctx.GetStub().PutState(config.key, configJSON); err != nil {
+			return fmt.Errorf("failed to put state: %v", err)
+		}
+		ledgerInit.ConfigsWritten = append(ledgerInit.ConfigsWritten, config.key)
+	}
+	return nil
+}
+
+// seedDemoData stores the demo catalog courses, students and their records, skipping any key
+// already in use so real data is never touched
+func seedDemoData(ctx contractapi.TransactionContextInterface, ledgerInit *LedgerInit, year int) error {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	departmentOrg := config.DepartmentOrgs[0]
+	scale, err := getGradeScale(ctx)
+	if err != nil {
+		return err
+	}
+	institutionID, err := callerInstitution(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, demo := range demoCourses {
+		existing, err := readCourse(ctx, demo.CourseCode)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		course := demo
+		course.DocType = docTypeCourse
+		course.CreatedBy = departmentOrg
+		course.CreatedAt = ledgerInit.InitializedAt
+		course.UpdatedAt = ledgerInit.InitializedAt
+		if err := putCourse(ctx, &course); err != nil {
+			return err
+		}
+		ledgerInit.CoursesSeeded = append(ledgerInit.CoursesSeeded, course.CourseCode)
+	}
+
+	for _, demo := range demoStudents {
+		existing, err := ctx.GetStub().GetState(demo.StudentID)
+		if err != nil {
+			return fmt.Errorf("failed to read state: %v", err)
+		}
+		if existing != nil {
+			continue
+		}
+
+		student := Student{
+			DocType:        docTypeStudent,
+			StudentID:      demo.StudentID,
+			Department:     demo.Department,
+			Program:        defaultProgram,
+			EnrollmentDate: ledgerInit.InitializedAt,
+			Status:         studentStatusActive,
+			CreatedBy:      ledgerInit.InitializedBy,
+			CreatedAt:      ledgerInit.InitializedAt,
+			InstitutionID:  institutionID,
+		}
+		if err := putStudent(ctx, &student); err != nil {
+			return err
+		}
+		if err := putStudentPII(ctx, &StudentPII{DocType: docTypeStudentPII, StudentID: demo.StudentID, Name: demo.Name, Email: demo.Email}); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, "student~department", []string{student.Department, student.StudentID}); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, "student~status", []string{student.Status, student.StudentID}); err != nil {
+			return err
+		}
+		ledgerInit.StudentsSeeded = append(ledgerInit.StudentsSeeded, demo.StudentID)
+
+		if demo.RecordID == "" {
+			continue
+		}
+		existing, err = ctx.GetStub().GetState(demo.RecordID)
+		if err != nil {
+			return fmt.Errorf("failed to read state: %v", err)
+		}
+		if existing != nil {
+			continue
+		}
+
+		// The demo catalog entries were written in this transaction and cannot be read back
+		// yet, so the demo courses (which match them) only go through the grade scale
+		courses := append([]CourseGrade{}, demo.Courses...)
+		if err := validateCourses(courses); err != nil {
+			return err
+		}
+		if err := applyGradeScale(scale, courses, nil); err != nil {
+			return err
+		}
+
+		record := AcademicRecord{
+			DocType:    docTypeRecord,
+			RecordID:   demo.RecordID,
+			StudentID:  demo.StudentID,
+			Department: demo.Department,
+			Semester:   1,
+			Year:       year,
+			Status:     recordStatusSubmitted,
+			CreatedBy:  departmentOrg,
+			CreatedAt:  ledgerInit.InitializedAt,
+			Version:    1,
+
+			InstitutionID: institutionID,
+		}
+		if err := stageDraftCourses(ctx, &record, courses); err != nil {
+			return err
+		}
+		if err := putAcademicRecord(ctx, &record); err != nil {
+			return err
+		}
+		if err := putRecordIndexes(ctx, &record); err != nil {
+			return err
+		}
+		if err := setRecordEndorsementPolicy(ctx, &record); err != nil {
+			return err
+		}
+		ledgerInit.RecordsSeeded = append(ledgerInit.RecordsSeeded, demo.RecordID)
+	}
+	return nil
+}
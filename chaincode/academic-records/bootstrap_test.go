@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// initLedgerCall returns an InitLedger call
+func initLedgerCall(l *testLedger, demo bool) func(ctx contractapi.TransactionContextInterface) (*LedgerInit, error) {
+	return func(ctx contractapi.TransactionContextInterface) (*LedgerInit, error) {
+		return l.contract.InitLedger(ctx, demo)
+	}
+}
+
+func TestInitLedgerIsIdempotent(t *testing.T) {
+	for _, demo := range []bool{false, true} {
+		l := newEmptyTestLedger()
+		first, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, initLedgerCall(l, demo))
+		if ws.Err != nil {
+			t.Fatalf("InitLedger(%t): %v", demo, ws.Err)
+		}
+		for _, key := range []string{orgConfigKey, gradeScaleKey, certPolicyKey, departmentRegistryKey, rolePolicyKey, ledgerInitKey} {
+			if ws.Put(key) == nil {
+				t.Errorf("InitLedger(%t) did not write %s", demo, key)
+			}
+		}
+		if got := writtenAuditActions(t, ws); len(got) != 1 || got[0] != "InitLedger" {
+			t.Errorf("InitLedger(%t) audit actions = %v", demo, got)
+		}
+		state := make(map[string]string, len(l.Stub.State))
+		for key, value := range l.Stub.State {
+			state[key] = string(value)
+		}
+
+		for _, as := range []*ledgertest.Identity{testRegistrar, testDean} {
+			for _, again := range []bool{demo, !demo} {
+				second, ws := ledgertest.Invoke(l, as, ledgertest.TxOptions{}, initLedgerCall(l, again))
+				if ws.Err != nil {
+					t.Fatalf("repeated InitLedger(%t): %v", again, ws.Err)
+				}
+				if !reflect.DeepEqual(second, first) {
+					t.Errorf("repeated InitLedger(%t) = %+v, want %+v", again, second, first)
+				}
+				if writes := ws.LedgerWrites(); len(writes) != 0 {
+					t.Errorf("repeated InitLedger(%t) wrote %d entries", again, len(writes))
+				}
+			}
+		}
+		if len(l.Stub.State) != len(state) {
+			t.Errorf("world state grew from %d to %d keys", len(state), len(l.Stub.State))
+		}
+		for key, value := range state {
+			if string(l.Stub.State[key]) != value {
+				t.Errorf("repeated InitLedger changed %s", key)
+			}
+		}
+	}
+}
+
+func TestInitLedgerIsUniversityOnly(t *testing.T) {
+	for _, as := range []*ledgertest.Identity{testExamCell, testVerifier, testRegulator} {
+		l := newEmptyTestLedger()
+		ws := ledgertest.InvokeError(t, l, as, ledgertest.TxOptions{}, "only the university can initialize the ledger", initLedgerCall(l, true))
+		if len(ws.LedgerWrites()) != 0 || len(l.Stub.State) != 0 {
+			t.Errorf("%s: refused InitLedger wrote to the ledger", as.MSPID)
+		}
+	}
+}
+
+func TestInitLedgerKeepsExistingConfig(t *testing.T) {
+	l := newEmptyTestLedger()
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+		return l.contract.InitConfig(ctx, `{"universityOrgs":["NITWarangalMSP"],"departmentOrgs":["DepartmentsMSP"],"verifierOrgs":["VerifiersMSP"],"requiredApprovals":3}`)
+	})
+	// A scale stored by an earlier deployment, before InitLedger existed
+	l.Stub.State[gradeScaleKey], _ = json.Marshal(GradeScale{DocType: docTypeGradeScale, Grades: map[string]float64{"P": 5, "F": 0}})
+	config := string(l.Stub.State[orgConfigKey])
+	scale := string(l.Stub.State[gradeScaleKey])
+
+	init, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, initLedgerCall(l, false))
+	if ws.Err != nil {
+		t.Fatalf("InitLedger: %v", ws.Err)
+	}
+	for _, key := range []string{orgConfigKey, gradeScaleKey} {
+		if ledgertest.ContainsString(init.ConfigsWritten, key) || ws.Put(key) != nil {
+			t.Errorf("InitLedger overwrote %s", key)
+		}
+	}
+	if !ledgertest.ContainsString(init.ConfigsWritten, certPolicyKey) {
+		t.Errorf("configs written = %v, want the missing %s", init.ConfigsWritten, certPolicyKey)
+	}
+	if string(l.Stub.State[orgConfigKey]) != config || string(l.Stub.State[gradeScaleKey]) != scale {
+		t.Errorf("existing org config or grade scale changed")
+	}
+
+	// Edits made after the first run survive a second one
+	l.updateConfig(t, func(config *OrgConfig) { config.RequiredApprovals = 1 })
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, initLedgerCall(l, true))
+	if got := l.storedConfig(t).RequiredApprovals; got != 1 {
+		t.Errorf("required approvals = %d after a second InitLedger, want 1", got)
+	}
+	if _, seeded := l.Stub.State[demoStudents[0].StudentID]; seeded {
+		t.Errorf("a second InitLedger seeded demo data")
+	}
+}
+
+func TestInitLedgerSeedsDemoData(t *testing.T) {
+	l := newEmptyTestLedger()
+	// A catalog entry and a student already on the ledger must be left alone
+	ledgertest.MustInvoke(t, l, testExamCell, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Course, error) {
+		return l.contract.CreateCourse(ctx, "CS101", "Computer Programming", 3, "CSE")
+	})
+	existing := NewTestStudent(t, l, demoStudents[1].StudentID, "CSE")
+	before := string(l.Stub.State[existing.StudentID])
+
+	init := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, initLedgerCall(l, true))
+
+	wantCourses := []string{"EC101", "MA101", "PH101", "CY101"}
+	if !reflect.DeepEqual(init.CoursesSeeded, wantCourses) {
+		t.Errorf("courses seeded = %v, want %v", init.CoursesSeeded, wantCourses)
+	}
+	wantStudents := []string{demoStudents[0].StudentID, demoStudents[2].StudentID}
+	if !reflect.DeepEqual(init.StudentsSeeded, wantStudents) {
+		t.Errorf("students seeded = %v, want %v", init.StudentsSeeded, wantStudents)
+	}
+	if want := []string{demoStudents[0].RecordID}; !reflect.DeepEqual(init.RecordsSeeded, want) {
+		t.Errorf("records seeded = %v, want %v", init.RecordsSeeded, want)
+	}
+	if string(l.Stub.State[existing.StudentID]) != before {
+		t.Errorf("demo seeding overwrote student %s", existing.StudentID)
+	}
+
+	record := l.storedRecord(t, demoStudents[0].RecordID)
+	if record.Status != recordStatusSubmitted || len(record.Courses) != 0 || record.CoursesHash == "" {
+		t.Errorf("demo record is %s with %d public courses and hash %q", record.Status, len(record.Courses), record.CoursesHash)
+	}
+	if l.Stub.Private[collectionDraftGrades][record.RecordID] == nil {
+		t.Errorf("demo record has no staged draft grades")
+	}
+	if !l.HasIndex(t, "student~status", studentStatusActive, demoStudents[2].StudentID) {
+		t.Errorf("demo student %s is not indexed by status", demoStudents[2].StudentID)
+	}
+
+	// The seeded record goes through the normal workflow
+	l.registerFoundingInstitution(t)
+	verifyTestRecord(t, l, record.RecordID)
+	if got := l.storedRecord(t, record.RecordID); len(got.Courses) != len(demoStudents[0].Courses) {
+		t.Errorf("verified demo record has %d courses, want %d", len(got.Courses), len(demoStudents[0].Courses))
+	}
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== BULK OPERATIONS ==========
+//
+// Bulk functions apply the single-item rules to every item and report per-item failures
+// instead of aborting, so one bad row never blocks the rest of the batch. Everything that
+// succeeds is written in the one transaction, with its own audit entry, and the batch sets
+// a single composite event.
+
+// maxBulkStudents keeps a BulkCreateStudents transaction well under the block size limit
+const maxBulkStudents = 500
+
+// BulkItemError reports why one item of a batch was not processed
+type BulkItemError struct {
+	Index int    `json:"index"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// BulkStudentInput is the public part of one student in a BulkCreateStudents batch
+type BulkStudentInput struct {
+	StudentID  string `json:"studentId"`
+	Department string `json:"department"`
+	Program    string `json:"program,omitempty"`
+}
+
+// BulkStudentResult reports the outcome of BulkCreateStudents
+type BulkStudentResult struct {
+	Created  []string         `json:"created"`
+	Skipped  []string         `json:"skipped"` // already existing students, when duplicates are skipped
+	Rejected []*BulkItemError `json:"rejected"`
+}
+
+// BulkCreateStudents enrolls a batch of students (NITWarangal only). studentsJSON lists
+// {studentId, department, program}; their names and emails are read from the "students_pii" transient
+// key as an object keyed by student ID. Existing students are skipped, or fail the whole batch
+// when failOnDuplicate is set.
+func (s *SmartContract) BulkCreateStudents(ctx contractapi.TransactionContextInterface, studentsJSON string, failOnDuplicate bool) (*BulkStudentResult, error) {
+	creatorOrg, err := requireOrgRole(ctx, "create students", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []*BulkStudentInput
+	if err := json.Unmarshal([]byte(studentsJSON), &inputs); err != nil {
+		return nil, fmt.Errorf("invalid students JSON: %v", err)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("batch must contain at least one student")
+	}
+	if len(inputs) > maxBulkStudents {
+		return nil, fmt.Errorf("batch of %d students exceeds the limit of %d", len(inputs), maxBulkStudents)
+	}
+
+	piiByStudent, err := readTransientBulkPII(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := getDepartmentRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkStudentResult{Created: []string{}, Skipped: []string{}, Rejected: []*BulkItemError{}}
+	events := []*LifecycleEvent{}
+	seen := map[string]bool{}
+	seenEmails := map[string]string{}
+	for i, input := range inputs {
+		if input == nil || strings.TrimSpace(input.StudentID) == "" {
+			result.Rejected = append(result.Rejected, &BulkItemError{Index: i, Error: "student ID is required"})
+			continue
+		}
+		studentID := input.StudentID
+		reject := func(reason string) {
+			result.Rejected = append(result.Rejected, &BulkItemError{Index: i, ID: studentID, Error: reason})
+		}
+
+		if seen[studentID] {
+			reject("listed more than once in the batch")
+			continue
+		}
+		seen[studentID] = true
+
+		existing, err := readStudent(ctx, studentID)
+		if err == nil && existing.DocType == docTypeStudent {
+			if failOnDuplicate {
+				return nil, fmt.Errorf("student %s at index %d already exists", studentID, i)
+			}
+			result.Skipped = append(result.Skipped, studentID)
+			continue
+		}
+		if err := assertKeyUnused(ctx, studentID, docTypeStudent); err != nil {
+			reject(err.Error())
+			continue
+		}
+
+		pii := piiByStudent[studentID]
+		if pii == nil || pii.Name == "" || pii.Email == "" {
+			reject(fmt.Sprintf("%s must include name and email for this student", transientBulkStudentPII))
+			continue
+		}
+		pii.DocType = docTypeStudentPII
+		pii.StudentID = studentID
+		if err := validateNewStudent(config, registry, studentID, input.Department, pii); err != nil {
+			reject(err.Error())
+			continue
+		}
+
+		// Earlier rows of this batch are not visible to GetPrivateData yet
+		if owner := seenEmails[normalizeEmail(pii.Email)]; owner != "" {
+			reject(fmt.Sprintf("email already belongs to student %s in this batch", owner))
+			continue
+		}
+		if err := assertEmailAvailable(ctx, pii.Email, studentID); err != nil {
+			reject(err.Error())
+			continue
+		}
+		seenEmails[normalizeEmail(pii.Email)] = studentID
+
+		student, err := createStudent(ctx, creatorOrg, studentID, input.Department, input.Program, pii)
+		if err != nil {
+			return nil, err
+		}
+		event, err := newLifecycleEvent(ctx, eventStudentCreated, "STUDENT", studentID, student.Status)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		result.Created = append(result.Created, studentID)
+	}
+
+	if len(events) > 0 {
+		if err := emitEvents(ctx, eventStudentCreated, events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// maxBatchRecords caps the records BatchApproveRecords handles per transaction
+const maxBatchRecords = 500
+
+// Outcomes of one record in a BatchApproveRecords call
+const (
+	batchOutcomeApproved = "APPROVED" // enough officers have signed, record is APPROVED
+	batchOutcomeSigned   = "SIGNED"   // approval recorded, more signatures needed
+	batchOutcomeSkipped  = "SKIPPED"  // nothing written, see Reason
+)
+
+// RecordBatchOutcome reports what BatchApproveRecords did with one record
+type RecordBatchOutcome struct {
+	RecordID string `json:"recordId"`
+	Outcome  string `json:"outcome"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// BatchApproveRecords applies the caller's approval, with one checklist and remarks, to up to
+// maxBatchRecords records (NITWarangal only). Each record is checked exactly as by
+// ApproveAcademicRecord; a record that fails is skipped with the reason and the rest are still
+// approved. Only one record per student is taken per batch, because a student's CGPA is computed
+// from committed state and would miss another semester approved in the same transaction.
+func (s *SmartContract) BatchApproveRecords(ctx contractapi.TransactionContextInterface, recordIDsJSON string, checklistJSON string, remarks string) ([]*RecordBatchOutcome, error) {
+	creatorOrg, err := requireOrgRole(ctx, "approve records", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationApproveRecord); err != nil {
+		return nil, err
+	}
+
+	var recordIDs []string
+	if err := json.Unmarshal([]byte(recordIDsJSON), &recordIDs); err != nil {
+		return nil, fmt.Errorf("invalid record IDs JSON: %v", err)
+	}
+	if len(recordIDs) == 0 {
+		return nil, fmt.Errorf("batch must contain at least one record")
+	}
+	if len(recordIDs) > maxBatchRecords {
+		return nil, fmt.Errorf("batch of %d records exceeds the limit of %d", len(recordIDs), maxBatchRecords)
+	}
+
+	schema, err := getChecklistSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checklist, err := parseApprovalChecklist(schema, checklistJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	approverID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	outcomes := []*RecordBatchOutcome{}
+	events := []*LifecycleEvent{}
+	seenRecords := map[string]bool{}
+	seenStudents := map[string]string{}
+	for _, recordID := range recordIDs {
+		outcome := &RecordBatchOutcome{RecordID: recordID, Outcome: batchOutcomeSkipped}
+		outcomes = append(outcomes, outcome)
+
+		if seenRecords[recordID] {
+			outcome.Reason = "listed more than once in the batch"
+			continue
+		}
+		seenRecords[recordID] = true
+
+		record, err := readAcademicRecord(ctx, recordID)
+		if err != nil {
+			outcome.Reason = err.Error()
+			continue
+		}
+		if other, ok := seenStudents[record.StudentID]; ok {
+			outcome.Reason = fmt.Sprintf("record %s of the same student is already in this batch", other)
+			continue
+		}
+		if err := checkApproval(ctx, record, approverID); err != nil {
+			outcome.Reason = err.Error()
+			continue
+		}
+		seenStudents[record.StudentID] = recordID
+
+		approved, err := applyApproval(ctx, record, creatorOrg, approverID, checklist, remarks)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			outcome.Outcome = batchOutcomeSigned
+			continue
+		}
+
+		outcome.Outcome = batchOutcomeApproved
+		event, err := newLifecycleEvent(ctx, eventRecordApproved, "RECORD", recordID, record.Status)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		if err := emitEvents(ctx, eventRecordApproved, events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return outcomes, nil
+}
+
+// maxBulkCertificates caps the certificates BulkIssueCertificates issues per transaction
+const maxBulkCertificates = 500
+
+// BulkIssueRequest is one certificate in a BulkIssueCertificates batch. Without a
+// certificateId the ID is derived from the transaction ID and the request's position.
+type BulkIssueRequest struct {
+	CertificateID     string            `json:"certificateId"`
+	StudentID         string            `json:"studentId"`
+	CertificationType string            `json:"certificationType"`
+	ValidityDays      int               `json:"validityDays"`
+	GraduationDate    string            `json:"graduationDate,omitempty"` // RFC3339; required for DEGREE and DIPLOMA
+	Metadata          map[string]string `json:"metadata"`
+}
+
+// BulkIssueResult reports the outcome of BulkIssueCertificates
+type BulkIssueResult struct {
+	Issued   []string         `json:"issued"`
+	Failures []*BulkItemError `json:"failures"`
+}
+
+// BulkIssueCertificates issues a batch of certificates (NITWarangal issues), running the same
+// uniqueness and eligibility checks as IssueCertificate for each request. Failed requests are
+// reported and the rest are issued. IDs and hashes depend only on the transaction, so every
+// endorser computes the same batch.
+func (s *SmartContract) BulkIssueCertificates(ctx contractapi.TransactionContextInterface, requestsJSON string) (*BulkIssueResult, error) {
+	if _, err := requireOrgRole(ctx, "issue certificates", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationIssueCertificate); err != nil {
+		return nil, err
+	}
+
+	var requests []*BulkIssueRequest
+	if err := json.Unmarshal([]byte(requestsJSON), &requests); err != nil {
+		return nil, fmt.Errorf("invalid requests JSON: %v", err)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("batch must contain at least one request")
+	}
+	if len(requests) > maxBulkCertificates {
+		return nil, fmt.Errorf("batch of %d requests exceeds the limit of %d", len(requests), maxBulkCertificates)
+	}
+
+	policy, err := getCertificatePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	result := &BulkIssueResult{Issued: []string{}, Failures: []*BulkItemError{}}
+	events := []*LifecycleEvent{}
+	seenIDs := map[string]bool{}
+	seenUnique := map[string]bool{}
+	for i, request := range requests {
+		if request == nil {
+			result.Failures = append(result.Failures, &BulkItemError{Index: i, Error: "request is empty"})
+			continue
+		}
+		certificateID := request.CertificateID
+		if certificateID == "" {
+			certificateID = fmt.Sprintf("CERT_%s_%d", txID, i)
+		}
+		fail := func(reason string) {
+			result.Failures = append(result.Failures, &BulkItemError{Index: i, ID: certificateID, Error: reason})
+		}
+
+		if request.StudentID == "" || request.CertificationType == "" {
+			fail("studentId and certificationType are required")
+			continue
+		}
+		if seenIDs[certificateID] {
+			fail("certificate ID listed more than once in the batch")
+			continue
+		}
+
+		// The uniqueness index does not see certificates written earlier in this transaction
+		uniqueKey := request.StudentID + "|" + request.CertificationType
+		if policy.isUniqueType(request.CertificationType) && seenUnique[uniqueKey] {
+			fail(fmt.Sprintf("student %s already receives a %s certificate in this batch", request.StudentID, request.CertificationType))
+			continue
+		}
+
+		cert, err := s.issueCertificate(ctx, certificateID, request.StudentID, request.CertificationType, request.ValidityDays, request.GraduationDate, "", false)
+		if err != nil {
+			fail(err.Error())
+			continue
+		}
+		seenIDs[certificateID] = true
+		seenUnique[uniqueKey] = true
+
+		if len(request.Metadata) > 0 {
+			cert.Metadata = request.Metadata
+			if err := putCertificate(ctx, cert); err != nil {
+				return nil, err
+			}
+		}
+
+		event, err := newLifecycleEvent(ctx, eventCertificateIssued, "CERTIFICATE", certificateID, cert.Status)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+		result.Issued = append(result.Issued, certificateID)
+	}
+
+	if len(events) > 0 {
+		if err := emitEvents(ctx, eventCertificateIssued, events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// GraduationOutcome reports what GraduateBatch did for one student
+type GraduationOutcome struct {
+	StudentID     string `json:"studentId"`
+	Graduated     bool   `json:"graduated"`
+	CertificateID string `json:"certificateId,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// GraduateBatch closes out a cohort at convocation (university admin identities only). Each
+// student who meets the DEGREE requirements is marked GRADUATED, which freezes their profile and
+// records, and with issueDegrees set receives a DEGREE certificate carrying the convocation date
+// (YYYY-MM-DD) as its graduation date. Students who fail are reported and do not hold up the rest.
+func (s *SmartContract) GraduateBatch(ctx contractapi.TransactionContextInterface, studentIDsJSON string, convocationDate string, issueDegrees bool) ([]*GraduationOutcome, error) {
+	if _, err := requireOrgRole(ctx, "graduate students", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("graduating a cohort requires the %s=true attribute: %v", adminAttribute, err)
+	}
+	if _, err := time.Parse("2006-01-02", convocationDate); err != nil {
+		return nil, fmt.Errorf("invalid convocation date %q: must be YYYY-MM-DD", convocationDate)
+	}
+
+	var studentIDs []string
+	if err := json.Unmarshal([]byte(studentIDsJSON), &studentIDs); err != nil {
+		return nil, fmt.Errorf("invalid student IDs JSON: %v", err)
+	}
+	if len(studentIDs) == 0 {
+		return nil, fmt.Errorf("batch must contain at least one student")
+	}
+	if len(studentIDs) > maxBulkStudents {
+		return nil, fmt.Errorf("batch of %d students exceeds the limit of %d", len(studentIDs), maxBulkStudents)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	outcomes := []*GraduationOutcome{}
+	events := []*LifecycleEvent{}
+	seen := map[string]bool{}
+	for i, studentID := range studentIDs {
+		outcome := &GraduationOutcome{StudentID: studentID}
+		outcomes = append(outcomes, outcome)
+
+		if seen[studentID] {
+			outcome.Error = "listed more than once in the batch"
+			continue
+		}
+		seen[studentID] = true
+
+		student, err := readStudent(ctx, studentID)
+		if err != nil {
+			outcome.Error = err.Error()
+			continue
+		}
+		if studentFrozen(student) {
+			outcome.Error = "student has already graduated"
+			continue
+		}
+		report, err := evaluateEligibility(ctx, studentID, "DEGREE")
+		if err != nil {
+			return nil, err
+		}
+		if !report.Eligible {
+			outcome.Error = (&EligibilityError{Report: report}).Error()
+			continue
+		}
+
+		if issueDegrees {
+			certificateID := fmt.Sprintf("DEGREE_%s_%d", txID, i)
+			cert, err := s.issueCertificate(ctx, certificateID, studentID, "DEGREE", 0, convocationDate+"T00:00:00Z", "", false)
+			if err != nil {
+				outcome.Error = err.Error()
+				continue
+			}
+			cert.Metadata = map[string]string{"convocationDate": convocationDate}
+			if err := putCertificate(ctx, cert); err != nil {
+				return nil, err
+			}
+			outcome.CertificateID = certificateID
+
+			event, err := newLifecycleEvent(ctx, eventCertificateIssued, "CERTIFICATE", certificateID, cert.Status)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+
+		if err := setStudentStatus(ctx, student, studentStatusGraduated, "Graduated at convocation "+convocationDate); err != nil {
+			return nil, err
+		}
+		logAudit(ctx, "GraduateBatch", "STUDENT", studentID, fmt.Sprintf("Graduated at convocation %s", convocationDate))
+		logAudit(ctx, "FreezeStudent", "STUDENT", studentID, "Student graduated; profile and records frozen")
+		outcome.Graduated = true
+
+		event, err := newLifecycleEvent(ctx, eventStudentStatusChanged, "STUDENT", studentID, student.Status)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		if err := emitEvents(ctx, eventStudentStatusChanged, events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return outcomes, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ========== CANONICAL JSON ==========
+//
+// Every digest the chaincode commits to (certificate hashes, transcript records hashes, draft
+// course hashes and dossier hashes) is a digest over the canonical JSON of the hashed value, so
+// it does not depend on Go struct field order and can be recomputed in any language:
+//
+//   - objects have their members sorted by key, comparing the UTF-8 bytes of the keys;
+//   - there is no whitespace outside strings;
+//   - strings are escaped as in RFC 8785: ", \ and control characters only, using \b \f \n \r \t
+//     where they exist and lowercase \u00xx otherwise; invalid UTF-8 is replaced by U+FFFD;
+//   - numbers are written as ECMAScript Number.prototype.toString would (RFC 8785): integers
+//     without a fraction or exponent, other values as the shortest round-tripping decimal, with
+//     an exponent only below 1e-6 or from 1e21;
+//   - true, false and null are written literally, and arrays keep their order.
+//
+// Keys are ASCII throughout, so UTF-8 order equals the UTF-16 order RFC 8785 prescribes. The
+// output is the hashed byte string; the digest is written as lowercase hex. Certificate hashes
+// use the algorithm named in their HashAlgorithm, every other digest is SHA-256. Test vectors for
+// other implementations are in testdata/canonical-json-vectors.json.
+
+// hashFormatCanonical marks digests computed over canonical JSON
+const hashFormatCanonical = "canonical-json/v1"
+
+// canonicalJSON serializes value in the canonical form described above
+func canonicalJSON(value interface{}) ([]byte, error) {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %v", err)
+	}
+
+	// UseNumber keeps each number as encoding/json formatted it, which follows the ECMAScript rules
+	decoder := json.NewDecoder(bytes.NewReader(valueJSON))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to decode value: %v", err)
+	}
+
+	var canonical bytes.Buffer
+	writeCanonical(&canonical, generic)
+	return canonical.Bytes(), nil
+}
+
+// writeCanonical writes a decoded JSON value. The escaping is spelled out here rather than left
+// to encoding/json, whose choices have changed between Go releases.
+func writeCanonical(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			writeCanonical(buf, v[key])
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, element := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonical(buf, element)
+		}
+		buf.WriteByte(']')
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// writeCanonicalString writes a quoted, RFC 8785 escaped string
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalHash returns the hex SHA-256 of value's canonical JSON
+func canonicalHash(value interface{}) (string, error) {
+	return canonicalDigest(value, hashAlgorithmSHA256)
+}
+
+// canonicalDigest returns the hex digest of value's canonical JSON under algorithm
+func canonicalDigest(value interface{}, algorithm string) (string, error) {
+	canonical, err := canonicalJSON(value)
+	if err != nil {
+		return "", err
+	}
+	return hexDigest(algorithm, canonical)
+}
+
+// ========== HASH ALGORITHMS ==========
+
+// Hash algorithms a certificate hash can be computed with
+const (
+	hashAlgorithmSHA256  = "sha256"
+	hashAlgorithmSHA3256 = "sha3-256"
+)
+
+// knownHashAlgorithm reports whether algorithm is supported; empty means sha256
+func knownHashAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case "", hashAlgorithmSHA256, hashAlgorithmSHA3256:
+		return true
+	}
+	return false
+}
+
+// hexDigest hashes data under algorithm and returns the lowercase hex digest; empty means sha256
+func hexDigest(algorithm string, data []byte) (string, error) {
+	switch algorithm {
+	case "", hashAlgorithmSHA256:
+		hash := sha256.Sum256(data)
+		return hex.EncodeToString(hash[:]), nil
+	case hashAlgorithmSHA3256:
+		hash := sha3.Sum256(data)
+		return hex.EncodeToString(hash[:]), nil
+	}
+	return "", fmt.Errorf("unsupported hash algorithm %q: must be %s or %s", algorithm, hashAlgorithmSHA256, hashAlgorithmSHA3256)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// canonicalVector is one entry of testdata/canonical-json-vectors.json
+type canonicalVector struct {
+	Description string `json:"description"`
+	Input       string `json:"input"`
+	Canonical   string `json:"canonical"`
+	SHA256      string `json:"sha256"`
+}
+
+func TestCanonicalJSONVectors(t *testing.T) {
+	vectorsJSON, err := os.ReadFile("testdata/canonical-json-vectors.json")
+	if err != nil {
+		t.Fatalf("failed to read vectors: %v", err)
+	}
+	var vectors []canonicalVector
+	if err := json.Unmarshal(vectorsJSON, &vectors); err != nil {
+		t.Fatalf("failed to decode vectors: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatalf("no vectors in testdata/canonical-json-vectors.json")
+	}
+
+	for _, vector := range vectors {
+		t.Run(vector.Description, func(t *testing.T) {
+			// Go values as the chaincode hashes them: numbers are float64, as in every asset
+			var value interface{}
+			if err := json.Unmarshal([]byte(vector.Input), &value); err != nil {
+				t.Fatalf("invalid input: %v", err)
+			}
+
+			canonical, err := canonicalJSON(value)
+			if err != nil {
+				t.Fatalf("canonicalJSON: %v", err)
+			}
+			if string(canonical) != vector.Canonical {
+				t.Errorf("canonical bytes\n%s\nwant\n%s", canonical, vector.Canonical)
+			}
+			if digest, err := hexDigest(hashAlgorithmSHA256, []byte(vector.Canonical)); err != nil || digest != vector.SHA256 {
+				t.Errorf("sha256 of the expected canonical bytes = %s, %v; the vector says %s", digest, err, vector.SHA256)
+			}
+			if digest, err := canonicalHash(value); err != nil || digest != vector.SHA256 {
+				t.Errorf("canonicalHash = %s, %v; want %s", digest, err, vector.SHA256)
+			}
+
+			// Canonical output is a fixed point
+			var again interface{}
+			if err := json.Unmarshal(canonical, &again); err != nil {
+				t.Fatalf("canonical output is not valid JSON: %v", err)
+			}
+			if twice, _ := canonicalJSON(again); string(twice) != string(canonical) {
+				t.Errorf("canonicalizing the output again gives\n%s", twice)
+			}
+		})
+	}
+}
+
+func TestCertificateHashMatchesTheVector(t *testing.T) {
+	// The first vector is the payload of certificate CERT001
+	const want = "3dcca5645efe7bbf77aefb35e6488effd89a34ffdcd87f770065837e48903449"
+	got, err := generateCertificateHash(hashAlgorithmSHA256, "CERT001", "21CS001", "DEGREE", "2024-06-01T10:00:00Z")
+	if err != nil || got != want {
+		t.Errorf("generateCertificateHash = %s, %v; want %s", got, err, want)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== COURSE CATALOG ==========
+
+// Course is a catalog entry; submitted course grades must match its code and credits and
+// take their stored course name from its title
+type Course struct {
+	DocType    string  `json:"docType"`
+	CourseCode string  `json:"courseCode"`
+	Title      string  `json:"title"`
+	Credits    float64 `json:"credits"`
+	Department string  `json:"department"` // offering department
+	CreatedBy  string  `json:"createdBy"`
+	CreatedAt  string  `json:"createdAt"`
+	UpdatedAt  string  `json:"updatedAt"`
+}
+
+// courseObjectType prefixes the composite keys of catalog entries
+const courseObjectType = "course"
+
+// departmentAttribute is the client certificate attribute naming the department a
+// department org identity acts for
+const departmentAttribute = "department"
+
+// CreateCourse adds a course to the catalog (department identities, for their own department)
+func (s *SmartContract) CreateCourse(ctx contractapi.TransactionContextInterface, courseCode string, title string, credits float64, department string) (*Course, error) {
+	creatorOrg, err := requireOrgRole(ctx, "manage the course catalog", orgRoleDepartment)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireOwnDepartment(ctx, department); err != nil {
+		return nil, err
+	}
+
+	courseCode = strings.TrimSpace(courseCode)
+	if courseCode == "" {
+		return nil, fmt.Errorf("course code is required")
+	}
+	if err := validateCourseDetails(courseCode, title, credits); err != nil {
+		return nil, err
+	}
+	existing, err := readCourse(ctx, courseCode)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("course %s already exists", courseCode)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	course := Course{
+		DocType:    docTypeCourse,
+		CourseCode: courseCode,
+		Title:      strings.TrimSpace(title),
+		Credits:    credits,
+		Department: department,
+		CreatedBy:  creatorOrg,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := putCourse(ctx, &course); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "CreateCourse", "COURSE", courseCode, fmt.Sprintf("Created course %s %q (%.2f credits) for %s", courseCode, course.Title, credits, department))
+
+	return &course, nil
+}
+
+// UpdateCourse changes a course's title and credits (department identities of the offering
+// department). Records already stored keep the values they were created with.
+func (s *SmartContract) UpdateCourse(ctx contractapi.TransactionContextInterface, courseCode string, title string, credits float64) (*Course, error) {
+	if _, err := requireOrgRole(ctx, "manage the course catalog", orgRoleDepartment); err != nil {
+		return nil, err
+	}
+
+	course, err := readCourse(ctx, courseCode)
+	if err != nil {
+		return nil, err
+	}
+	if course == nil {
+		return nil, fmt.Errorf("course %s does not exist", courseCode)
+	}
+	if err := requireOwnDepartment(ctx, course.Department); err != nil {
+		return nil, err
+	}
+	if err := validateCourseDetails(courseCode, title, credits); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	oldTitle, oldCredits := course.Title, course.Credits
+	course.Title = strings.TrimSpace(title)
+	course.Credits = credits
+	course.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+	if err := putCourse(ctx, course); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "UpdateCourse", "COURSE", courseCode, fmt.Sprintf("Course changed from %q (%.2f credits) to %q (%.2f credits)", oldTitle, oldCredits, course.Title, course.Credits))
+
+	return course, nil
+}
+
+// GetCourse returns a catalog entry
+func (s *SmartContract) GetCourse(ctx contractapi.TransactionContextInterface, courseCode string) (*Course, error) {
+	course, err := readCourse(ctx, courseCode)
+	if err != nil {
+		return nil, err
+	}
+	if course == nil {
+		return nil, fmt.Errorf("course %s does not exist", courseCode)
+	}
+	return course, nil
+}
+
+// requireOwnDepartment fails unless the caller's department attribute names department
+func requireOwnDepartment(ctx contractapi.TransactionContextInterface, department string) error {
+	if strings.TrimSpace(department) == "" {
+		return fmt.Errorf("department is required")
+	}
+	callerDepartment, found, err := ctx.GetClientIdentity().GetAttributeValue(departmentAttribute)
+	if err != nil {
+		return fmt.Errorf("failed to read client attribute %s: %v", departmentAttribute, err)
+	}
+	if !found || callerDepartment != department {
+		return fmt.Errorf("only identities of department %s may do this", department)
+	}
+	return nil
+}
+
+// validateCourseDetails applies the same limits to catalog entries as to submitted grades
+func validateCourseDetails(courseCode string, title string, credits float64) error {
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("course %s: title is required", courseCode)
+	}
+	if credits <= 0 || credits > maxCourseCredits {
+		return fmt.Errorf("course %s: credits %v must be greater than 0 and at most %d", courseCode, credits, maxCourseCredits)
+	}
+	return nil
+}
+
+// readCourse reads a catalog entry, returning nil if the code is not in the catalog
+func readCourse(ctx contractapi.TransactionContextInterface, courseCode string) (*Course, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(courseObjectType, []string{strings.TrimSpace(courseCode)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create course key: %v", err)
+	}
+	courseJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if courseJSON == nil {
+		return nil, nil
+	}
+
+	var course Course
+	if err := json.Unmarshal(courseJSON, &course); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal course: %v", err)
+	}
+	return &course, nil
+}
+
+// putCourse stores a catalog entry under its composite key
+func putCourse(ctx contractapi.TransactionContextInterface, course *Course) error {
+	key, err := ctx.GetStub().CreateCompositeKey(courseObjectType, []string{course.CourseCode})
+	if err != nil {
+		return fmt.Errorf("failed to create course key: %v", err)
+	}
+	courseJSON, err := json.Marshal(course)
+	if err != nil {
+		return fmt.Errorf("failed to marshal course: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, courseJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
+
+// applyCourseCatalog checks submitted courses against the catalog, rejecting unknown codes
+// and credit mismatches, and replaces each course name with the catalog title. It is skipped
+// while the org config allows uncatalogued courses for legacy imports.
+func applyCourseCatalog(ctx contractapi.TransactionContextInterface, courses []CourseGrade) error {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if config.AllowUncataloguedCourses {
+		return nil
+	}
+
+	for i := range courses {
+		course, err := readCourse(ctx, courses[i].CourseCode)
+		if err != nil {
+			return err
+		}
+		if course == nil {
+			return fmt.Errorf("course %s is not in the course catalog", courses[i].CourseCode)
+		}
+		if toHundredths(courses[i].Credits) != toHundredths(course.Credits) {
+			return fmt.Errorf("course %s: credits %v do not match the catalog's %v", course.CourseCode, courses[i].Credits, course.Credits)
+		}
+		courses[i].CourseCode = course.CourseCode
+		courses[i].CourseName = course.Title
+	}
+	return nil
+}
@@ -0,0 +1,786 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== CERTIFICATE LIFECYCLE ==========
+
+// Certificate statuses
+const (
+	certStatusIssued     = "ISSUED"
+	certStatusRevoked    = "REVOKED"
+	certStatusSuperseded = "SUPERSEDED"
+)
+
+// certReviewRecordSuperseded flags a certificate covering a record that has since been superseded
+const certReviewRecordSuperseded = "RECORD_SUPERSEDED"
+
+// certTransitions lists the statuses each certificate status may move to
+var certTransitions = map[string][]string{
+	certStatusIssued: {certStatusRevoked, certStatusSuperseded},
+}
+
+// CertificateCheckResult is the outcome of checking a presented certificate hash
+type CertificateCheckResult struct {
+	CertificateID string `json:"certificateId"`
+	Verified      bool   `json:"verified"`
+	Outcome       string `json:"outcome"` // see CertificateVerdict.Outcome
+	Status        string `json:"status,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	CheckedAt     string `json:"checkedAt"`
+
+	// Issuing institution, set whenever the certificate exists
+	InstitutionID   string `json:"institutionId,omitempty"`
+	InstitutionName string `json:"institutionName,omitempty"`
+}
+
+// Certificate verdict outcomes
+const (
+	certOutcomeValid        = "VALID"
+	certOutcomeNotFound     = "NOT_FOUND"
+	certOutcomeExpired      = "EXPIRED"
+	certOutcomeNotYetValid  = "NOT_YET_VALID"
+	certOutcomeHashMismatch = "HASH_MISMATCH"
+
+	// The certificate names a hash algorithm this chaincode cannot compute
+	certOutcomeUnknownHashAlgorithm = "UNKNOWN_HASH_ALGORITHM"
+)
+
+// expiringCertificationTypes may be issued with a validity period; all other types are permanent
+var expiringCertificationTypes = map[string]bool{
+	"PROVISIONAL": true,
+	"BONAFIDE":    true,
+	"TRANSCRIPT":  true,
+}
+
+// CertificateVerdict answers whether a certificate can be trusted right now
+type CertificateVerdict struct {
+	CertificateID        string `json:"certificateId"`
+	Exists               bool   `json:"exists"`
+	Status               string `json:"status,omitempty"`
+	WithinValidityPeriod bool   `json:"withinValidityPeriod"`
+	HashValid            bool   `json:"hashValid"`
+	Valid                bool   `json:"valid"`
+	Outcome              string `json:"outcome"` // VALID, NOT_FOUND, REVOKED, SUPERSEDED, EXPIRED, NOT_YET_VALID, HASH_MISMATCH, UNKNOWN_HASH_ALGORITHM
+	Reason               string `json:"reason,omitempty"`
+	ReviewStatus         string `json:"reviewStatus,omitempty"` // RECORD_SUPERSEDED while the certificate awaits revocation or reissue
+	CheckedAt            string `json:"checkedAt"`
+	InstitutionID        string `json:"institutionId,omitempty"` // issuing institution
+	InstitutionName      string `json:"institutionName,omitempty"`
+}
+
+// validRevocationReasons are the reason codes accepted by RevokeCertificate
+var validRevocationReasons = map[string]bool{
+	"FRAUD":       true,
+	"ADMIN_ERROR": true,
+	"SUPERSEDED":  true,
+	"COURT_ORDER": true,
+}
+
+// RevokeCertificate revokes an issued certificate (NITWarangal only)
+func (s *SmartContract) RevokeCertificate(ctx contractapi.TransactionContextInterface, certificateID string, reasonCode string, details string) (*Certificate, error) {
+	creatorOrg, err := requireOrgRole(ctx, "revoke certificates", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationRevokeCertificate); err != nil {
+		return nil, err
+	}
+
+	if !validRevocationReasons[reasonCode] {
+		return nil, fmt.Errorf("invalid revocation reason %q: must be one of FRAUD, ADMIN_ERROR, SUPERSEDED, COURT_ORDER", reasonCode)
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := transitionCertificate(cert, certStatusRevoked); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	cert.RevokedAt = txTime.UTC().Format(time.RFC3339)
+	cert.RevokedBy = creatorOrg
+	cert.RevocationReason = reasonCode
+	cert.RevocationDetails = details
+
+	if err := putCertificate(ctx, cert); err != nil {
+		return nil, err
+	}
+	if err := countMonthly(ctx, monthlyRevoked); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RevokeCertificate", "CERTIFICATE", certificateID, fmt.Sprintf("Certificate revoked (%s): %s", reasonCode, details))
+
+	if err := emitLifecycleEvent(ctx, eventCertificateRevoked, "CERTIFICATE", certificateID, cert.Status); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// IsCertificateValid reports whether a certificate exists, is ISSUED, is within its validity period and has an intact hash
+func (s *SmartContract) IsCertificateValid(ctx contractapi.TransactionContextInterface, certificateID string) (*CertificateVerdict, error) {
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	verdict := &CertificateVerdict{
+		CertificateID: certificateID,
+		CheckedAt:     txTime.UTC().Format(time.RFC3339),
+	}
+
+	certJSON, err := ctx.GetStub().GetState(certificateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if certJSON == nil {
+		verdict.Outcome = certOutcomeNotFound
+		verdict.Reason = "certificate not found"
+		return verdict, nil
+	}
+
+	var cert Certificate
+	if err := json.Unmarshal(certJSON, &cert); err != nil || cert.DocType != docTypeCertificate {
+		verdict.Outcome = certOutcomeNotFound
+		verdict.Reason = "certificate not found"
+		return verdict, nil
+	}
+
+	verdict.Exists = true
+	verdict.Status = cert.Status
+	verdict.ReviewStatus = cert.ReviewStatus
+	verdict.InstitutionID = assetInstitution(cert.InstitutionID)
+	verdict.InstitutionName, err = institutionName(ctx, cert.InstitutionID)
+	if err != nil {
+		return nil, err
+	}
+	verdict.WithinValidityPeriod = certificateWithinValidity(&cert, txTime)
+	knownAlgorithm := knownHashAlgorithm(cert.HashAlgorithm)
+	if knownAlgorithm {
+		verdict.HashValid, err = certificateHashMatches(&cert)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case !knownAlgorithm:
+		verdict.Outcome = certOutcomeUnknownHashAlgorithm
+		verdict.Reason = fmt.Sprintf("certificate hash uses unsupported algorithm %q", cert.HashAlgorithm)
+	case cert.Status != certStatusIssued:
+		verdict.Outcome = cert.Status
+		verdict.Reason = fmt.Sprintf("certificate is %s", cert.Status)
+	case !verdict.WithinValidityPeriod && certificateExpired(&cert, txTime):
+		verdict.Outcome = certOutcomeExpired
+		verdict.Reason = fmt.Sprintf("certificate expired at %s", cert.ValidUntil)
+	case !verdict.WithinValidityPeriod:
+		verdict.Outcome = certOutcomeNotYetValid
+		verdict.Reason = "certificate is not yet valid"
+	case !verdict.HashValid:
+		verdict.Outcome = certOutcomeHashMismatch
+		verdict.Reason = "stored hash does not match certificate contents"
+	default:
+		verdict.Valid = true
+		verdict.Outcome = certOutcomeValid
+	}
+
+	return verdict, nil
+}
+
+// CheckCertificate checks a presented certificate hash without writing to the ledger, so it is
+// safe to call with evaluateTransaction
+func (s *SmartContract) CheckCertificate(ctx contractapi.TransactionContextInterface, certificateID string, certHash string) (*CertificateCheckResult, error) {
+	verdict, err := s.IsCertificateValid(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CertificateCheckResult{
+		CertificateID:   certificateID,
+		Outcome:         verdict.Outcome,
+		Status:          verdict.Status,
+		Reason:          verdict.Reason,
+		CheckedAt:       verdict.CheckedAt,
+		InstitutionID:   verdict.InstitutionID,
+		InstitutionName: verdict.InstitutionName,
+	}
+	if !verdict.Exists || verdict.Outcome == certOutcomeUnknownHashAlgorithm {
+		return result, nil
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.CertificateHash != certHash {
+		result.Outcome = certOutcomeHashMismatch
+		result.Reason = "presented hash does not match the issued certificate"
+		return result, nil
+	}
+
+	result.Verified = verdict.Valid
+	return result, nil
+}
+
+// maxCertificateChecks caps the certificates one CheckCertificates call accepts
+const maxCertificateChecks = 100
+
+// CertificateCheckRequest is one presented certificate in a CheckCertificates batch
+type CertificateCheckRequest struct {
+	CertificateID string `json:"certificateId"`
+	CertHash      string `json:"certHash"`
+}
+
+// CheckCertificates runs CheckCertificate for each presented {certificateId, certHash} pair and
+// returns the results keyed by certificate ID; read-only like CheckCertificate
+func (s *SmartContract) CheckCertificates(ctx contractapi.TransactionContextInterface, requestsJSON string) (map[string]*CertificateCheckResult, error) {
+	var requests []*CertificateCheckRequest
+	if err := json.Unmarshal([]byte(requestsJSON), &requests); err != nil {
+		return nil, fmt.Errorf("invalid requests JSON: %v", err)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("at least one certificate must be presented")
+	}
+	if len(requests) > maxCertificateChecks {
+		return nil, fmt.Errorf("batch of %d certificates exceeds the limit of %d", len(requests), maxCertificateChecks)
+	}
+
+	results := map[string]*CertificateCheckResult{}
+	for i, request := range requests {
+		if request == nil || request.CertificateID == "" {
+			return nil, fmt.Errorf("request at position %d: certificateId is required", i)
+		}
+		if _, seen := results[request.CertificateID]; seen {
+			return nil, fmt.Errorf("certificate %s: presented more than once", request.CertificateID)
+		}
+
+		result, err := s.CheckCertificate(ctx, request.CertificateID, request.CertHash)
+		if err != nil {
+			return nil, err
+		}
+		results[request.CertificateID] = result
+	}
+	return results, nil
+}
+
+// RecordVerification checks a presented certificate hash and registers the verification on the
+// ledger on behalf of requestedBy. Use CheckCertificate when no ledger record is wanted.
+func (s *SmartContract) RecordVerification(ctx contractapi.TransactionContextInterface, certificateID string, certHash string, requestedBy string) (*CertificateCheckResult, error) {
+	result, err := s.CheckCertificate(ctx, certificateID, certHash)
+	if err != nil {
+		return nil, err
+	}
+	if result.Outcome == certOutcomeNotFound {
+		return result, nil
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordVerificationEvent(ctx, cert, requestedBy, result); err != nil {
+		return nil, err
+	}
+	if err := countMonthly(ctx, monthlyVerified); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RecordVerification", "CERTIFICATE", certificateID, fmt.Sprintf("Verification requested by %s: %s", requestedBy, result.Outcome))
+
+	return result, nil
+}
+
+// ExtendCertificateValidity renews a time-limited certificate without reissuing it (NITWarangal only)
+func (s *SmartContract) ExtendCertificateValidity(ctx contractapi.TransactionContextInterface, certificateID string, newValidUntil string) (*Certificate, error) {
+	if _, err := requireOrgRole(ctx, "extend certificate validity", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	until, err := time.Parse(time.RFC3339, newValidUntil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validUntil %q: must be RFC3339", newValidUntil)
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Status != certStatusIssued {
+		return nil, fmt.Errorf("cannot extend validity of certificate in %s status", cert.Status)
+	}
+	if cert.ValidUntil == "" {
+		return nil, fmt.Errorf("certificate %s is permanent and has no validity period", certificateID)
+	}
+
+	current, err := time.Parse(time.RFC3339, cert.ValidUntil)
+	if err != nil {
+		return nil, fmt.Errorf("stored validUntil is malformed: %v", err)
+	}
+	if !until.After(current) {
+		return nil, fmt.Errorf("new validUntil must be later than the current %s", cert.ValidUntil)
+	}
+
+	previous := cert.ValidUntil
+	cert.ValidUntil = until.UTC().Format(time.RFC3339)
+
+	if err := putCertificate(ctx, cert); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "ExtendCertificateValidity", "CERTIFICATE", certificateID, fmt.Sprintf("Validity extended %s -> %s", previous, cert.ValidUntil))
+
+	return cert, nil
+}
+
+// validateValidityDays checks that only expiring certification types carry a validity period
+func validateValidityDays(certificationType string, validityDays int) error {
+	if validityDays < 0 {
+		return fmt.Errorf("validityDays cannot be negative")
+	}
+	if validityDays > 0 && !expiringCertificationTypes[certificationType] {
+		return fmt.Errorf("%s certificates are permanent and cannot have a validity period", certificationType)
+	}
+	return nil
+}
+
+// transitionCertificate moves a certificate to a new status if the state machine allows it
+func transitionCertificate(cert *Certificate, to string) error {
+	for _, allowed := range certTransitions[cert.Status] {
+		if allowed == to {
+			cert.Status = to
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot move certificate %s from %s to %s", cert.CertificateID, cert.Status, to)
+}
+
+// certificateWithinValidity reports whether the certificate has taken effect and not expired at the given time
+func certificateWithinValidity(cert *Certificate, at time.Time) bool {
+	from := cert.ValidFrom
+	if from == "" {
+		from = cert.IssuedDate
+	}
+	start, err := time.Parse(time.RFC3339, from)
+	if err != nil || at.Before(start) {
+		return false
+	}
+	return !certificateExpired(cert, at)
+}
+
+// certificateExpired reports whether the certificate's ValidUntil has passed; permanent certificates never expire
+func certificateExpired(cert *Certificate, at time.Time) bool {
+	if cert.ValidUntil == "" {
+		return false
+	}
+	until, err := time.Parse(time.RFC3339, cert.ValidUntil)
+	if err != nil {
+		return true
+	}
+	return at.After(until)
+}
+
+// GetCertificateByHash finds a certificate from the hash printed on a document. Revoked and
+// superseded certificates are returned too so their status is visible.
+func (s *SmartContract) GetCertificateByHash(ctx contractapi.TransactionContextInterface, certHash string) (*Certificate, error) {
+	if certHash == "" {
+		return nil, fmt.Errorf("certificate hash is required")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("certhash~id", []string{certHash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificates: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		// Skip index entries whose certificate is missing or no longer carries this hash
+		cert, err := readCertificate(ctx, compositeKeyParts[1])
+		if err != nil || cert.CertificateHash != certHash {
+			continue
+		}
+		return cert, nil
+	}
+
+	return nil, fmt.Errorf("no certificate found with hash %s", certHash)
+}
+
+// ReissueCertificate supersedes an issued certificate with a new one of the same type (NITWarangal only)
+func (s *SmartContract) ReissueCertificate(ctx contractapi.TransactionContextInterface, oldCertificateID string, newCertificateID string, validityDays int, reason string) (*Certificate, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to reissue a certificate")
+	}
+
+	original, err := readCertificate(ctx, oldCertificateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := transitionCertificate(original, certStatusSuperseded); err != nil {
+		return nil, err
+	}
+	original.SupersededBy = newCertificateID
+
+	replacement, err := s.issueCertificate(ctx, newCertificateID, original.StudentID, original.CertificationType, validityDays, original.GraduationDate, oldCertificateID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putCertificate(ctx, original); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "ReissueCertificate", "CERTIFICATE", oldCertificateID, fmt.Sprintf("Superseded by %s: %s", newCertificateID, reason))
+
+	// One transaction, two asset changes: a single composite event
+	supersededEvent, err := newLifecycleEvent(ctx, eventCertificateReissued, "CERTIFICATE", oldCertificateID, original.Status)
+	if err != nil {
+		return nil, err
+	}
+	issuedEvent, err := newLifecycleEvent(ctx, eventCertificateIssued, "CERTIFICATE", newCertificateID, replacement.Status)
+	if err != nil {
+		return nil, err
+	}
+	if err := emitEvents(ctx, eventCertificateReissued, supersededEvent, issuedEvent); err != nil {
+		return nil, err
+	}
+
+	return replacement, nil
+}
+
+// checkCertificateUnique rejects a second live certificate of a unique certification type
+// for the same student; revoked and superseded certificates do not count
+func checkCertificateUnique(ctx contractapi.TransactionContextInterface, studentID string, certificationType string, excludeCertificateID string) error {
+	policy, err := getCertificatePolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if !policy.isUniqueType(certificationType) {
+		return nil
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("cert~student~type", []string{studentID, certificationType})
+	if err != nil {
+		return fmt.Errorf("failed to query certificates: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 3 || compositeKeyParts[2] == excludeCertificateID {
+			continue
+		}
+
+		existing, err := readCertificate(ctx, compositeKeyParts[2])
+		if err != nil {
+			continue
+		}
+		if existing.Status == certStatusIssued {
+			return fmt.Errorf("student %s already holds %s certificate %s; revoke or reissue it instead", studentID, certificationType, existing.CertificateID)
+		}
+	}
+	return nil
+}
+
+// readCertificate loads a certificate from world state
+func readCertificate(ctx contractapi.TransactionContextInterface, certificateID string) (*Certificate, error) {
+	certJSON, err := ctx.GetStub().GetState(certificateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if certJSON == nil {
+		return nil, fmt.Errorf("certificate not found")
+	}
+
+	var cert Certificate
+	if err := json.Unmarshal(certJSON, &cert); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal certificate: %v", err)
+	}
+	return &cert, nil
+}
+
+// putCertificate saves a certificate under its certificate ID
+func putCertificate(ctx contractapi.TransactionContextInterface, cert *Certificate) error {
+	if err := assertInstitutionWrite(ctx, cert.InstitutionID); err != nil {
+		return err
+	}
+	if err := countCertificate(ctx, cert); err != nil {
+		return err
+	}
+	certJSON, err := json.Marshal(cert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal certificate: %v", err)
+	}
+	if err := ctx.GetStub().PutState(cert.CertificateID, certJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
+
+// flagCoveringCertificates flags the issued certificates attesting record, which has just been
+// superseded by replacementID, and returns a lifecycle event for each
+func flagCoveringCertificates(ctx contractapi.TransactionContextInterface, record *AcademicRecord, replacementID string, reason string) ([]*LifecycleEvent, error) {
+	certificateIDs, err := indexedCertificateIDs(ctx, record.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	events := []*LifecycleEvent{}
+	for _, certificateID := range certificateIDs {
+		cert, err := readCertificate(ctx, certificateID)
+		if err != nil || cert.Status != certStatusIssued {
+			continue
+		}
+		covered := false
+		for _, recordID := range cert.CoveredRecordIDs {
+			covered = covered || recordID == record.RecordID
+		}
+		if !covered {
+			continue
+		}
+
+		cert.ReviewStatus = certReviewRecordSuperseded
+		cert.ReviewReason = fmt.Sprintf("Record %s superseded by %s: %s", record.RecordID, replacementID, reason)
+		cert.FlaggedAt = txTime.UTC().Format(time.RFC3339)
+		if err := putCertificate(ctx, cert); err != nil {
+			return nil, err
+		}
+
+		logAudit(ctx, "FlagCertificate", "CERTIFICATE", certificateID, cert.ReviewReason)
+
+		event, err := newLifecycleEvent(ctx, eventCertificateFlagged, "CERTIFICATE", certificateID, cert.Status)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// indexedCertificateIDs lists the certificate IDs under a student in the cert~student~type index
+func indexedCertificateIDs(ctx contractapi.TransactionContextInterface, studentID string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("cert~student~type", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificates: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	certificateIDs := []string{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 3 {
+			continue
+		}
+		certificateIDs = append(certificateIDs, compositeKeyParts[2])
+	}
+	return certificateIDs, nil
+}
+
+// CertIndexBackfillResult reports the certificates indexed by one BackfillCertificateIndex call
+type CertIndexBackfillResult struct {
+	Indexed []string `json:"indexed"`
+	More    bool     `json:"more"` // true when the batch limit was hit; call again to continue
+}
+
+// BackfillCertificateIndex writes the cert~student~type entry of certificates issued before
+// issuance maintained it (NITWarangal admin identities only). At most maxPageSize certificates
+// are indexed per call; certificates already indexed are skipped.
+func (s *SmartContract) BackfillCertificateIndex(ctx contractapi.TransactionContextInterface) (*CertIndexBackfillResult, error) {
+	if _, err := requireOrgRole(ctx, "backfill the certificate index", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("backfilling the certificate index requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	resultsIterator, err := assetRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	result := &CertIndexBackfillResult{Indexed: []string{}}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var cert Certificate
+		if err := json.Unmarshal(response.Value, &cert); err != nil || cert.DocType != docTypeCertificate {
+			continue
+		}
+		indexKey, err := ctx.GetStub().CreateCompositeKey("cert~student~type", []string{cert.StudentID, cert.CertificationType, cert.CertificateID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cert~student~type index: %v", err)
+		}
+		existing, err := ctx.GetStub().GetState(indexKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state: %v", err)
+		}
+		if existing != nil {
+			continue
+		}
+
+		if int32(len(result.Indexed)) == maxPageSize {
+			result.More = true
+			break
+		}
+		if err := putIndex(ctx, "cert~student~type", []string{cert.StudentID, cert.CertificationType, cert.CertificateID}); err != nil {
+			return nil, err
+		}
+		result.Indexed = append(result.Indexed, cert.CertificateID)
+	}
+
+	logAudit(ctx, "BackfillCertificateIndex", "CERTIFICATE", "CERT_INDEX", fmt.Sprintf("Indexed %d certificates: %v", len(result.Indexed), result.Indexed))
+
+	return result, nil
+}
+
+// ========== CERTIFICATE POLICY ==========
+
+// CertificatePolicy holds the issuance rules NITWarangal can change without a chaincode upgrade
+type CertificatePolicy struct {
+	DocType     string   `json:"docType"`
+	UniqueTypes []string `json:"uniqueTypes"` // certification types a student may hold only one live copy of
+
+	// Days a verification request stays answerable
+	VerificationRequestTTLDays int `json:"verificationRequestTtlDays"`
+
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// certPolicyKey is the world state key of the certificate policy config asset
+const certPolicyKey = "CONFIG_CERT_POLICY"
+
+// defaultUniqueCertificationTypes is used until NITWarangal stores its own policy;
+// TRANSCRIPT may legitimately be issued many times
+var defaultUniqueCertificationTypes = []string{"DEGREE", "DIPLOMA"}
+
+// defaultVerificationRequestTTLDays is used when the policy does not set a request lifetime
+const defaultVerificationRequestTTLDays = 30
+
+// GetCertificatePolicy returns the certificate policy in force
+func (s *SmartContract) GetCertificatePolicy(ctx contractapi.TransactionContextInterface) (*CertificatePolicy, error) {
+	return getCertificatePolicy(ctx)
+}
+
+// UpdateCertificatePolicy replaces the certificate policy (NITWarangal only)
+func (s *SmartContract) UpdateCertificatePolicy(ctx contractapi.TransactionContextInterface, policyJSON string) (*CertificatePolicy, error) {
+	creatorOrg, err := requireOrgRole(ctx, "update the certificate policy", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy CertificatePolicy
+	if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy JSON: %v", err)
+	}
+	if policy.UniqueTypes == nil {
+		policy.UniqueTypes = []string{}
+	}
+	for _, certificationType := range policy.UniqueTypes {
+		if certificationType == "" {
+			return nil, fmt.Errorf("policy contains an empty certification type")
+		}
+	}
+	if policy.VerificationRequestTTLDays < 0 {
+		return nil, fmt.Errorf("verification request TTL cannot be negative")
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	policy.DocType = docTypeCertPolicy
+	policy.UpdatedBy = creatorOrg
+	policy.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	storedJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certificate policy: %v", err)
+	}
+	if err := ctx.GetStub().PutState(certPolicyKey, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "UpdateCertificatePolicy", "CONFIG", certPolicyKey, fmt.Sprintf("Certificate policy updated: %s", string(storedJSON)))
+
+	return &policy, nil
+}
+
+// getCertificatePolicy reads the stored certificate policy, falling back to the default
+func getCertificatePolicy(ctx contractapi.TransactionContextInterface) (*CertificatePolicy, error) {
+	policyJSON, err := ctx.GetStub().GetState(certPolicyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate policy: %v", err)
+	}
+	if policyJSON == nil {
+		return &CertificatePolicy{
+			DocType:                    docTypeCertPolicy,
+			UniqueTypes:                defaultUniqueCertificationTypes,
+			VerificationRequestTTLDays: defaultVerificationRequestTTLDays,
+		}, nil
+	}
+
+	var policy CertificatePolicy
+	if err := json.Unmarshal(policyJSON, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal certificate policy: %v", err)
+	}
+	return &policy, nil
+}
+
+// isUniqueType reports whether a student may hold only one live certificate of this type
+func (p *CertificatePolicy) isUniqueType(certificationType string) bool {
+	for _, unique := range p.UniqueTypes {
+		if unique == certificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// verificationRequestTTL returns how long a verification request stays answerable
+func (p *CertificatePolicy) verificationRequestTTL() int {
+	if p.VerificationRequestTTLDays <= 0 {
+		return defaultVerificationRequestTTLDays
+	}
+	return p.VerificationRequestTTLDays
+}
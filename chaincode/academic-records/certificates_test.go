@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// studentCertificateIDs returns the IDs GetStudentCertificates lists for a student
+func studentCertificateIDs(t *testing.T, l *testLedger, studentID string) []string {
+	t.Helper()
+	certificates := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) ([]*Certificate, error) {
+		return l.contract.GetStudentCertificates(ctx, studentID)
+	})
+	if certificates == nil {
+		t.Fatalf("GetStudentCertificates(%s) returned nil, want a list", studentID)
+	}
+	ids := []string{}
+	for _, cert := range certificates {
+		ids = append(ids, cert.CertificateID)
+	}
+	return ids
+}
+
+// unindexCertificate removes a certificate's index entry, as for certificates issued before
+// issuance maintained the index
+func unindexCertificate(t *testing.T, l *testLedger, certificateID string) {
+	t.Helper()
+	cert := l.storedCertificate(t, certificateID)
+	delete(l.Stub.State, l.CompositeKey(t, "cert~student~type", cert.StudentID, cert.CertificationType, cert.CertificateID))
+}
+
+// backfillCall returns a BackfillCertificateIndex call
+func backfillCall(l *testLedger) func(ctx contractapi.TransactionContextInterface) (*CertIndexBackfillResult, error) {
+	return func(ctx contractapi.TransactionContextInterface) (*CertIndexBackfillResult, error) {
+		return l.contract.BackfillCertificateIndex(ctx)
+	}
+}
+
+func TestStudentCertificatesComeFromTheIndex(t *testing.T) {
+	l := newTestLedger(t)
+	for _, studentID := range []string{"CS21001", "CS21002", "CS21003"} {
+		NewTestStudent(t, l, studentID, "CSE")
+	}
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+	verifyTestRecord(t, l, "REC1")
+	NewTestCertificate(t, l, "TR1", "CS21001", "TRANSCRIPT")
+	NewTestCertificate(t, l, "BON1", "CS21001", "BONAFIDE")
+	NewTestCertificate(t, l, "BON2", "CS21002", "BONAFIDE")
+
+	if !l.HasIndex(t, "cert~student~type", "CS21001", "TRANSCRIPT", "TR1") {
+		t.Errorf("issuance did not index TR1")
+	}
+	if got, want := studentCertificateIDs(t, l, "CS21001"), []string{"BON1", "TR1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("certificates of CS21001 = %v, want %v", got, want)
+	}
+	for _, studentID := range []string{"CS21003", "CS29999"} {
+		if got := studentCertificateIDs(t, l, studentID); len(got) != 0 {
+			t.Errorf("certificates of %s = %v, want an empty list", studentID, got)
+		}
+	}
+
+	// Only the index is consulted, so an unindexed certificate does not show up
+	unindexCertificate(t, l, "TR1")
+	if got, want := studentCertificateIDs(t, l, "CS21001"), []string{"BON1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("certificates of CS21001 without TR1 indexed = %v, want %v", got, want)
+	}
+}
+
+func TestBackfillCertificateIndex(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+	verifyTestRecord(t, l, "REC1")
+	NewTestCertificate(t, l, "BON1", "CS21001", "BONAFIDE")
+	NewTestCertificate(t, l, "TR1", "CS21001", "TRANSCRIPT")
+	NewTestCertificate(t, l, "TR2", "CS21001", "TRANSCRIPT")
+	unindexCertificate(t, l, "TR1")
+	unindexCertificate(t, l, "TR2")
+
+	ledgertest.InvokeError(t, l, testExamCell, ledgertest.TxOptions{}, "only the university can backfill the certificate index", backfillCall(l))
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "backfilling the certificate index requires the admin=true attribute", backfillCall(l))
+
+	result, ws := ledgertest.Invoke(l, testAdmin, ledgertest.TxOptions{}, backfillCall(l))
+	if ws.Err != nil {
+		t.Fatalf("BackfillCertificateIndex: %v", ws.Err)
+	}
+	if want := []string{"TR1", "TR2"}; !reflect.DeepEqual(result.Indexed, want) || result.More {
+		t.Errorf("backfill = %+v, want %v indexed and no more", result, want)
+	}
+	if got := writtenAuditActions(t, ws); len(got) != 1 || got[0] != "BackfillCertificateIndex" {
+		t.Errorf("audit actions = %v, want [BackfillCertificateIndex]", got)
+	}
+	if got, want := studentCertificateIDs(t, l, "CS21001"), []string{"BON1", "TR1", "TR2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("certificates after backfill = %v, want %v", got, want)
+	}
+
+	if again := ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{}, backfillCall(l)); len(again.Indexed) != 0 || again.More {
+		t.Errorf("second backfill = %+v, want nothing left to index", again)
+	}
+}
+
+func TestBackfillCertificateIndexInBatches(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	// Certificates written by an earlier chaincode version, with no index entries
+	for i := 0; i <= int(maxPageSize); i++ {
+		cert := Certificate{DocType: docTypeCertificate, CertificateID: fmt.Sprintf("LEGACY%05d", i), StudentID: "CS21001", CertificationType: "BONAFIDE", Status: certStatusIssued}
+		l.Stub.State[cert.CertificateID], _ = json.Marshal(cert)
+	}
+
+	first := ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{}, backfillCall(l))
+	if len(first.Indexed) != int(maxPageSize) || !first.More {
+		t.Errorf("first batch indexed %d with more = %t, want %d and more", len(first.Indexed), first.More, maxPageSize)
+	}
+	second := ledgertest.MustInvoke(t, l, testAdmin, ledgertest.TxOptions{}, backfillCall(l))
+	if want := []string{fmt.Sprintf("LEGACY%05d", maxPageSize)}; !reflect.DeepEqual(second.Indexed, want) || second.More {
+		t.Errorf("second batch = %+v, want %v and no more", second, want)
+	}
+	if got := studentCertificateIDs(t, l, "CS21001"); len(got) != int(maxPageSize)+1 {
+		t.Errorf("GetStudentCertificates lists %d certificates, want %d", len(got), maxPageSize+1)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== DEGREE CLASSIFICATION ==========
+//
+// Graduation certificates carry the graduation date printed on the parchment and the class of
+// the degree. The class is the first band, in descending order of minimum CGPA, that the
+// student's final CGPA over verified records reaches. Bands are configured per program in the
+// org config, with "*" covering programs that have none of their own. The bands used are copied
+// onto the certificate, so a later config change cannot alter what an issued certificate claims.
+
+// ClassificationBand is one degree class and the minimum CGPA it needs
+type ClassificationBand struct {
+	Label   string  `json:"label"` // e.g. First Class with Distinction
+	MinCGPA float64 `json:"minCgpa"`
+}
+
+// graduationCertificationTypes need a graduation date and are issued with a classification
+var graduationCertificationTypes = map[string]bool{
+	"DEGREE":  true,
+	"DIPLOMA": true,
+}
+
+// defaultClassificationThresholds apply until the org config sets others
+var defaultClassificationThresholds = map[string][]ClassificationBand{
+	anyProgram: {
+		{Label: "First Class with Distinction", MinCGPA: 8.0},
+		{Label: "First Class", MinCGPA: 6.5},
+		{Label: "Second Class", MinCGPA: 5.0},
+		{Label: "Pass Class", MinCGPA: 0},
+	},
+}
+
+// validateClassificationThresholds checks every program's bands are labelled, within 0-10 and
+// listed in strictly descending order of minimum CGPA
+func validateClassificationThresholds(thresholds map[string][]ClassificationBand) error {
+	programs := make([]string, 0, len(thresholds))
+	for program := range thresholds {
+		programs = append(programs, program)
+	}
+	sort.Strings(programs)
+
+	for _, program := range programs {
+		bands := thresholds[program]
+		if strings.TrimSpace(program) == "" {
+			return fmt.Errorf("classificationThresholds: program cannot be empty; use %s for every program", anyProgram)
+		}
+		if len(bands) == 0 {
+			return fmt.Errorf("classificationThresholds for %s must list at least one band", program)
+		}
+		for i, band := range bands {
+			if strings.TrimSpace(band.Label) == "" {
+				return fmt.Errorf("classificationThresholds for %s: band %d needs a label", program, i)
+			}
+			if band.MinCGPA < 0 || band.MinCGPA > 10 {
+				return fmt.Errorf("classificationThresholds for %s: minimum CGPA of %s must be between 0 and 10", program, band.Label)
+			}
+			if i > 0 && toHundredths(band.MinCGPA) >= toHundredths(bands[i-1].MinCGPA) {
+				return fmt.Errorf("classificationThresholds for %s must be in descending order of minimum CGPA", program)
+			}
+		}
+	}
+	return nil
+}
+
+// parseGraduationDate validates a graduation date: RFC3339 and not after the transaction time
+func parseGraduationDate(ctx contractapi.TransactionContextInterface, graduationDate string) (string, error) {
+	graduatedAt, err := time.Parse(time.RFC3339, graduationDate)
+	if err != nil {
+		return "", fmt.Errorf("invalid graduation date %q: must be RFC3339: %v", graduationDate, err)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	if graduatedAt.After(txTime) {
+		return "", fmt.Errorf("graduation date %s is in the future", graduationDate)
+	}
+	return graduatedAt.UTC().Format(time.RFC3339), nil
+}
+
+// classifyStudent returns the student's final CGPA over verified records, the class it falls in
+// and the bands it was classified against
+func classifyStudent(ctx contractapi.TransactionContextInterface, student *Student) (float64, string, []ClassificationBand, error) {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	thresholds := config.ClassificationThresholds
+	if len(thresholds) == 0 {
+		thresholds = defaultClassificationThresholds
+	}
+	bands, ok := thresholds[normalizeProgram(student.Program)]
+	if !ok {
+		bands, ok = thresholds[anyProgram]
+	}
+	if !ok {
+		return 0, "", nil, fmt.Errorf("no classification thresholds for program %s", normalizeProgram(student.Program))
+	}
+
+	records, err := getStudentRecordList(ctx, student.StudentID)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	verified := []*AcademicRecord{}
+	for _, record := range records {
+		if record.Status == recordStatusVerified {
+			verified = append(verified, record)
+		}
+	}
+	if len(verified) == 0 {
+		return 0, "", nil, fmt.Errorf("student %s has no verified records to classify the degree on", student.StudentID)
+	}
+	sort.SliceStable(verified, func(i, j int) bool {
+		return semesterAfter(verified[j], verified[i])
+	})
+	cgpa, _ := calculateCGPA(verified, config.RepeatPolicy)
+
+	for _, band := range bands {
+		if toHundredths(cgpa) >= toHundredths(band.MinCGPA) {
+			return cgpa, band.Label, append([]ClassificationBand{}, bands...), nil
+		}
+	}
+	return 0, "", nil, fmt.Errorf("CGPA %.2f of student %s is below every classification band of program %s", cgpa, student.StudentID, normalizeProgram(student.Program))
+}
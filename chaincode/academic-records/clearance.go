@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== NO-DUES CLEARANCE ==========
+
+// Clearance items; each is set by identities whose department attribute names the item
+var clearanceItems = []string{"LIBRARY", "ACCOUNTS", "HOSTEL"}
+
+// Clearance item statuses; an item never set is PENDING
+const (
+	clearanceStatusPending = "PENDING"
+	clearanceStatusCleared = "CLEARED"
+)
+
+// ClearanceItem is the state of one no-dues item
+type ClearanceItem struct {
+	Status    string `json:"status"`
+	Remarks   string `json:"remarks,omitempty"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// Clearance is a student's no-dues record
+type Clearance struct {
+	DocType     string                    `json:"docType"`
+	StudentID   string                    `json:"studentId"`
+	Items       map[string]*ClearanceItem `json:"items"`
+	Outstanding []string                  `json:"outstanding"`
+}
+
+// SetClearance marks one item CLEARED for a student (NITWarangal identities of that office)
+func (s *SmartContract) SetClearance(ctx contractapi.TransactionContextInterface, studentID string, item string, remarks string) (*Clearance, error) {
+	return updateClearance(ctx, studentID, item, clearanceStatusCleared, remarks)
+}
+
+// RevertClearance returns an item set in error to PENDING; a reason is required
+func (s *SmartContract) RevertClearance(ctx contractapi.TransactionContextInterface, studentID string, item string, reason string) (*Clearance, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("a reason is required to revert a clearance")
+	}
+	return updateClearance(ctx, studentID, item, clearanceStatusPending, reason)
+}
+
+// GetClearanceStatus returns every clearance item of a student and lists the outstanding ones
+// (NITWarangal and Departments)
+func (s *SmartContract) GetClearanceStatus(ctx contractapi.TransactionContextInterface, studentID string) (*Clearance, error) {
+	if _, err := requireOrgRole(ctx, "view clearances", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	return readClearance(ctx, studentID)
+}
+
+// updateClearance sets one item's status, checking the caller acts for that office
+func updateClearance(ctx contractapi.TransactionContextInterface, studentID string, item string, status string, remarks string) (*Clearance, error) {
+	if _, err := requireOrgRole(ctx, "update clearances", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	item = strings.ToUpper(strings.TrimSpace(item))
+	known := false
+	for _, candidate := range clearanceItems {
+		known = known || candidate == item
+	}
+	if !known {
+		return nil, fmt.Errorf("invalid clearance item %q: must be one of %s", item, strings.Join(clearanceItems, ", "))
+	}
+	if err := requireOwnDepartment(ctx, item); err != nil {
+		return nil, err
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	clearance, err := readClearance(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	previous := clearance.Items[item].Status
+	if previous == status {
+		return nil, fmt.Errorf("%s clearance of student %s is already %s", item, studentID, status)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	clearance.Items[item] = &ClearanceItem{
+		Status:    status,
+		Remarks:   strings.TrimSpace(remarks),
+		UpdatedBy: getClientCommonName(ctx),
+		UpdatedAt: txTime.UTC().Format(time.RFC3339),
+	}
+	clearance.Outstanding = outstandingClearances(clearance)
+
+	clearanceJSON, err := json.Marshal(clearance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal clearance: %v", err)
+	}
+	if err := ctx.GetStub().PutState(clearanceKey(studentID), clearanceJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	action := "SetClearance"
+	if status == clearanceStatusPending {
+		action = "RevertClearance"
+	}
+	logAudit(ctx, action, "STUDENT", studentID, fmt.Sprintf("%s clearance %s -> %s: %s", item, previous, status, clearance.Items[item].Remarks))
+
+	return clearance, nil
+}
+
+// clearanceKey is the world state key of a student's clearance record
+func clearanceKey(studentID string) string {
+	return "CLEARANCE_" + studentID
+}
+
+// readClearance loads a student's clearance record with every item present, PENDING if never set
+func readClearance(ctx contractapi.TransactionContextInterface, studentID string) (*Clearance, error) {
+	clearanceJSON, err := ctx.GetStub().GetState(clearanceKey(studentID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+
+	clearance := Clearance{DocType: docTypeClearance, StudentID: studentID}
+	if clearanceJSON != nil {
+		if err := json.Unmarshal(clearanceJSON, &clearance); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal clearance: %v", err)
+		}
+	}
+	if clearance.Items == nil {
+		clearance.Items = map[string]*ClearanceItem{}
+	}
+	for _, item := range clearanceItems {
+		if clearance.Items[item] == nil {
+			clearance.Items[item] = &ClearanceItem{Status: clearanceStatusPending}
+		}
+	}
+	clearance.Outstanding = outstandingClearances(&clearance)
+	return &clearance, nil
+}
+
+// outstandingClearances lists the items not yet CLEARED, in clearanceItems order
+func outstandingClearances(clearance *Clearance) []string {
+	outstanding := []string{}
+	for _, item := range clearanceItems {
+		if clearance.Items[item] == nil || clearance.Items[item].Status != clearanceStatusCleared {
+			outstanding = append(outstanding, item)
+		}
+	}
+	return outstanding
+}
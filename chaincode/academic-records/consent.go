@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT CONSENT ==========
+
+// Access grant scopes; a RECORDS grant also covers certificates
+const (
+	accessScopeRecords      = "RECORDS"
+	accessScopeCertificates = "CERTIFICATES"
+)
+
+// Access grant statuses
+const (
+	accessGrantActive  = "ACTIVE"
+	accessGrantRevoked = "REVOKED"
+)
+
+// studentIDAttribute binds a client identity to the student it belongs to
+const studentIDAttribute = "studentId"
+
+// AccessGrant is a student's consent for a verifier organization or identity to read their data
+type AccessGrant struct {
+	DocType   string `json:"docType"`
+	GrantID   string `json:"grantId"`
+	StudentID string `json:"studentId"`
+	Grantee   string `json:"grantee"` // MSP ID or client identity ID
+	Scope     string `json:"scope"`   // RECORDS, CERTIFICATES
+	ExpiresAt string `json:"expiresAt"`
+	Status    string `json:"status"` // ACTIVE, REVOKED
+	GrantedBy string `json:"grantedBy"`
+	GrantedAt string `json:"grantedAt"`
+	RevokedAt string `json:"revokedAt,omitempty"`
+}
+
+// GrantRecordAccess records a student's consent for a verifier to read their data until expiresAt
+// (NITWarangal on the student's behalf, or the student's own identity)
+func (s *SmartContract) GrantRecordAccess(ctx contractapi.TransactionContextInterface, studentID string, grantee string, scope string, expiresAt string) (*AccessGrant, error) {
+	grantedBy, err := assertActsForStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if grantee == "" {
+		return nil, fmt.Errorf("grantee is required")
+	}
+	if scope != accessScopeRecords && scope != accessScopeCertificates {
+		return nil, fmt.Errorf("invalid scope %q: must be RECORDS or CERTIFICATES", scope)
+	}
+
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiresAt %q: must be RFC3339", expiresAt)
+	}
+	if !expiry.After(txTime) {
+		return nil, fmt.Errorf("expiresAt must be in the future")
+	}
+
+	grant := AccessGrant{
+		DocType:   docTypeAccessGrant,
+		GrantID:   "GRANT_" + ctx.GetStub().GetTxID(),
+		StudentID: studentID,
+		Grantee:   grantee,
+		Scope:     scope,
+		ExpiresAt: expiry.UTC().Format(time.RFC3339),
+		Status:    accessGrantActive,
+		GrantedBy: grantedBy,
+		GrantedAt: txTime.UTC().Format(time.RFC3339),
+	}
+
+	if err := putAccessGrant(ctx, &grant); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "grant~student", []string{studentID, grant.GrantID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "GrantRecordAccess", "ACCESS_GRANT", grant.GrantID, fmt.Sprintf("Student %s granted %s access to %s until %s", studentID, scope, grantee, grant.ExpiresAt))
+
+	return &grant, nil
+}
+
+// RevokeRecordAccess withdraws a consent grant before it expires
+func (s *SmartContract) RevokeRecordAccess(ctx contractapi.TransactionContextInterface, grantID string) (*AccessGrant, error) {
+	grant, err := readAccessGrant(ctx, grantID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := assertActsForStudent(ctx, grant.StudentID); err != nil {
+		return nil, err
+	}
+	if grant.Status != accessGrantActive {
+		return nil, fmt.Errorf("access grant %s is already %s", grantID, grant.Status)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	grant.Status = accessGrantRevoked
+	grant.RevokedAt = txTime.UTC().Format(time.RFC3339)
+
+	if err := putAccessGrant(ctx, grant); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RevokeRecordAccess", "ACCESS_GRANT", grantID, fmt.Sprintf("Access for %s revoked", grant.Grantee))
+
+	return grant, nil
+}
+
+// GetAccessGrants lists all consent grants a student has given
+func (s *SmartContract) GetAccessGrants(ctx contractapi.TransactionContextInterface, studentID string) ([]*AccessGrant, error) {
+	if _, err := assertActsForStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	return getStudentAccessGrants(ctx, studentID)
+}
+
+// assertRecordAccess lets NITWarangal, Departments and regulators through and requires every other caller
+// to hold an active, unexpired grant covering the scope
+func assertRecordAccess(ctx contractapi.TransactionContextInterface, studentID string, scope string) error {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	trusted, err := orgHasRole(ctx, creatorOrg, orgRoleUniversity, orgRoleDepartment, orgRoleRegulator)
+	if err != nil {
+		return err
+	}
+	if trusted {
+		return nil
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	grants, err := getStudentAccessGrants(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		if grant.Status != accessGrantActive || (grant.Grantee != creatorOrg && grant.Grantee != clientID) {
+			continue
+		}
+		if grant.Scope != scope && grant.Scope != accessScopeRecords {
+			continue
+		}
+		expiry, err := time.Parse(time.RFC3339, grant.ExpiresAt)
+		if err == nil && txTime.Before(expiry) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("FORBIDDEN: no active %s access grant from student %s for %s", scope, studentID, creatorOrg)
+}
+
+// assertActsForStudent allows NITWarangal or the identity bound to the student, by attribute or
+// identity registry, returning who acted
+func assertActsForStudent(ctx contractapi.TransactionContextInterface, studentID string) (string, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	university, err := orgHasRole(ctx, creatorOrg, orgRoleUniversity)
+	if err != nil {
+		return "", err
+	}
+	if university {
+		return creatorOrg, nil
+	}
+
+	boundStudent, err := boundStudentID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if boundStudent == "" || boundStudent != studentID {
+		return "", fmt.Errorf("only NITWarangal or student %s can manage their access grants", studentID)
+	}
+	return "student:" + studentID, nil
+}
+
+// getStudentAccessGrants walks the grant~student index
+func getStudentAccessGrants(ctx contractapi.TransactionContextInterface, studentID string) ([]*AccessGrant, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("grant~student", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access grants: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	grants := []*AccessGrant{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		grant, err := readAccessGrant(ctx, compositeKeyParts[1])
+		if err == nil {
+			grants = append(grants, grant)
+		}
+	}
+	return grants, nil
+}
+
+// readAccessGrant loads an access grant from world state
+func readAccessGrant(ctx contractapi.TransactionContextInterface, grantID string) (*AccessGrant, error) {
+	grantJSON, err := ctx.GetStub().GetState(grantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if grantJSON == nil {
+		return nil, fmt.Errorf("access grant %s not found", grantID)
+	}
+
+	var grant AccessGrant
+	if err := json.Unmarshal(grantJSON, &grant); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal access grant: %v", err)
+	}
+	if grant.DocType != docTypeAccessGrant {
+		return nil, fmt.Errorf("access grant %s not found", grantID)
+	}
+	return &grant, nil
+}
+
+// putAccessGrant saves an access grant under its grant ID
+func putAccessGrant(ctx contractapi.TransactionContextInterface, grant *AccessGrant) error {
+	grantJSON, err := json.Marshal(grant)
+	if err != nil {
+		return fmt.Errorf("failed to marshal access grant: %v", err)
+	}
+	if err := ctx.GetStub().PutState(grant.GrantID, grantJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
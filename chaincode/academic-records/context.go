@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== TRANSACTION CONTEXT ==========
+//
+// Fabric runs transactions concurrently in one chaincode process, so anything kept for the
+// duration of a transaction lives on its context rather than in package variables. main installs
+// TransactionContext as the contract's TransactionContextHandler, and contractapi creates a
+// fresh one for every transaction.
+
+// TransactionContext is the context of one transaction with the state the chaincode keeps for it
+type TransactionContext struct {
+	contractapi.TransactionContext
+
+	stats         *txStats     // counter deltas written so far, see transactionStats
+	orgConfigJSON []byte       // org config in force, see getOrgConfig
+	scope         *callerScope // caller's institution scope, see institutionScope
+	auditSeq      int          // audit entries written so far, see logAudit
+}
+
+// txContext returns the chaincode's own context of the transaction
+func txContext(ctx contractapi.TransactionContextInterface) (*TransactionContext, error) {
+	txCtx, ok := ctx.(*TransactionContext)
+	if !ok {
+		return nil, fmt.Errorf("unsupported transaction context %T", ctx)
+	}
+	return txCtx, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== DEGREE RULES ==========
+//
+// Degree requirements differ per department and program and change with each regulation.
+// A rule set applies to students who enrolled in its regulation year or later, until a
+// newer regulation for the same department and program takes over.
+
+// DegreeRules are the DEGREE requirements for one department, program and regulation year
+type DegreeRules struct {
+	DocType        string  `json:"docType"`
+	Department     string  `json:"department"`
+	Program        string  `json:"program"` // e.g. BTECH, MTECH, PHD
+	RegulationYear int     `json:"regulationYear"`
+	MinCredits     float64 `json:"minCredits"`
+	MinCGPA        float64 `json:"minCgpa"`
+	MaxBacklogs    int     `json:"maxBacklogs"` // courses whose counted attempt is a fail
+	UpdatedBy      string  `json:"updatedBy"`
+	UpdatedAt      string  `json:"updatedAt"`
+}
+
+// degreeRulesObjectType prefixes the composite keys of degree rule sets
+const degreeRulesObjectType = "DEGREE_RULES"
+
+// defaultProgram is assumed for students enrolled without a program
+const defaultProgram = "BTECH"
+
+// CreateDegreeRules stores a new rule set (NITWarangal only)
+func (s *SmartContract) CreateDegreeRules(ctx contractapi.TransactionContextInterface, rulesJSON string) (*DegreeRules, error) {
+	return saveDegreeRules(ctx, rulesJSON, false)
+}
+
+// UpdateDegreeRules replaces an existing rule set (NITWarangal only)
+func (s *SmartContract) UpdateDegreeRules(ctx contractapi.TransactionContextInterface, rulesJSON string) (*DegreeRules, error) {
+	return saveDegreeRules(ctx, rulesJSON, true)
+}
+
+// DeleteDegreeRules removes a rule set (NITWarangal only). Students it covered fall back to
+// the previous regulation, or become ineligible if there is none.
+func (s *SmartContract) DeleteDegreeRules(ctx contractapi.TransactionContextInterface, department string, program string, regulationYear int) error {
+	if _, err := requireOrgRole(ctx, "delete degree rules", orgRoleUniversity); err != nil {
+		return err
+	}
+
+	key, err := degreeRulesKey(ctx, department, program, regulationYear)
+	if err != nil {
+		return err
+	}
+	existing, err := readDegreeRules(ctx, key)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("no degree rules for %s %s regulation %d", department, normalizeProgram(program), regulationYear)
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to delete state: %v", err)
+	}
+
+	existingJSON, err := json.Marshal(existing)
+	if err != nil {
+		return fmt.Errorf("failed to marshal degree rules: %v", err)
+	}
+	logAudit(ctx, "DeleteDegreeRules", "CONFIG", key, fmt.Sprintf("Degree rules deleted: %s", string(existingJSON)))
+	return nil
+}
+
+// GetDegreeRules returns one rule set
+func (s *SmartContract) GetDegreeRules(ctx contractapi.TransactionContextInterface, department string, program string, regulationYear int) (*DegreeRules, error) {
+	key, err := degreeRulesKey(ctx, department, program, regulationYear)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := readDegreeRules(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		return nil, fmt.Errorf("no degree rules for %s %s regulation %d", department, normalizeProgram(program), regulationYear)
+	}
+	return rules, nil
+}
+
+// ListDegreeRules returns every rule set for a department, optionally narrowed to one program
+func (s *SmartContract) ListDegreeRules(ctx contractapi.TransactionContextInterface, department string, program string) ([]*DegreeRules, error) {
+	attributes := []string{department}
+	if program != "" {
+		attributes = append(attributes, normalizeProgram(program))
+	}
+	return queryDegreeRules(ctx, attributes)
+}
+
+// saveDegreeRules validates and stores a rule set, auditing the old and new values
+func saveDegreeRules(ctx contractapi.TransactionContextInterface, rulesJSON string, replace bool) (*DegreeRules, error) {
+	creatorOrg, err := requireOrgRole(ctx, "manage degree rules", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules DegreeRules
+	if err := json.Unmarshal([]byte(rulesJSON), &rules); err != nil {
+		return nil, fmt.Errorf("invalid degree rules JSON: %v", err)
+	}
+	rules.Program = normalizeProgram(rules.Program)
+	if rules.MinCredits < 0 {
+		return nil, fmt.Errorf("minimum credits cannot be negative")
+	}
+	if rules.MinCGPA < 0 || rules.MinCGPA > 10 {
+		return nil, fmt.Errorf("minimum CGPA must be between 0 and 10")
+	}
+	if rules.MaxBacklogs < 0 {
+		return nil, fmt.Errorf("maximum backlogs cannot be negative")
+	}
+
+	key, err := degreeRulesKey(ctx, rules.Department, rules.Program, rules.RegulationYear)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := readDegreeRules(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if replace && existing == nil {
+		return nil, fmt.Errorf("no degree rules for %s %s regulation %d", rules.Department, rules.Program, rules.RegulationYear)
+	}
+	if !replace && existing != nil {
+		return nil, fmt.Errorf("degree rules for %s %s regulation %d already exist", rules.Department, rules.Program, rules.RegulationYear)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	rules.DocType = docTypeDegreeRules
+	rules.UpdatedBy = creatorOrg
+	rules.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	storedJSON, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal degree rules: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	if existing == nil {
+		logAudit(ctx, "CreateDegreeRules", "CONFIG", key, fmt.Sprintf("Degree rules created: %s", string(storedJSON)))
+	} else {
+		oldJSON, err := json.Marshal(existing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal degree rules: %v", err)
+		}
+		logAudit(ctx, "UpdateDegreeRules", "CONFIG", key, fmt.Sprintf("Degree rules changed from %s to %s", string(oldJSON), string(storedJSON)))
+	}
+
+	return &rules, nil
+}
+
+// degreeRulesKey builds the key of a rule set after validating its parts
+func degreeRulesKey(ctx contractapi.TransactionContextInterface, department string, program string, regulationYear int) (string, error) {
+	if strings.TrimSpace(department) == "" {
+		return "", fmt.Errorf("department is required")
+	}
+	if regulationYear < 1900 || regulationYear > 9999 {
+		return "", fmt.Errorf("invalid regulation year %d", regulationYear)
+	}
+	return ctx.GetStub().CreateCompositeKey(degreeRulesObjectType, []string{department, normalizeProgram(program), strconv.Itoa(regulationYear)})
+}
+
+// normalizeProgram upper-cases a program code, defaulting to the B.Tech program
+func normalizeProgram(program string) string {
+	program = strings.ToUpper(strings.TrimSpace(program))
+	if program == "" {
+		return defaultProgram
+	}
+	return program
+}
+
+// readDegreeRules reads a rule set by key, returning nil if there is none
+func readDegreeRules(ctx contractapi.TransactionContextInterface, key string) (*DegreeRules, error) {
+	rulesJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if rulesJSON == nil {
+		return nil, nil
+	}
+
+	var rules DegreeRules
+	if err := json.Unmarshal(rulesJSON, &rules); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal degree rules: %v", err)
+	}
+	return &rules, nil
+}
+
+// queryDegreeRules returns the rule sets under a partial key of department and program
+func queryDegreeRules(ctx contractapi.TransactionContextInterface, attributes []string) ([]*DegreeRules, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(degreeRulesObjectType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query degree rules: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	ruleSets := []*DegreeRules{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var rules DegreeRules
+		if err := json.Unmarshal(response.Value, &rules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal degree rules: %v", err)
+		}
+		ruleSets = append(ruleSets, &rules)
+	}
+	return ruleSets, nil
+}
+
+// applicableDegreeRules returns the latest regulation for the student's department and program
+// that is not newer than their enrollment year, or nil if none has been defined
+func applicableDegreeRules(ctx contractapi.TransactionContextInterface, student *Student) (*DegreeRules, error) {
+	enrolledAt, err := time.Parse(time.RFC3339, student.EnrollmentDate)
+	if err != nil {
+		return nil, fmt.Errorf("student %s has an invalid enrollment date: %v", student.StudentID, err)
+	}
+
+	ruleSets, err := queryDegreeRules(ctx, []string{student.Department, normalizeProgram(student.Program)})
+	if err != nil {
+		return nil, err
+	}
+
+	var applicable *DegreeRules
+	for _, rules := range ruleSets {
+		if rules.RegulationYear > enrolledAt.Year() {
+			continue
+		}
+		if applicable == nil || rules.RegulationYear > applicable.RegulationYear {
+			applicable = rules
+		}
+	}
+	return applicable, nil
+}
+
+// countBacklogs counts the courses whose counted attempt carries no grade points
+func countBacklogs(records []*AcademicRecord, repeatPolicy string) int {
+	backlogs := 0
+	for _, course := range countedAttempts(records, repeatPolicy) {
+		if course.GradePoint == 0 {
+			backlogs++
+		}
+	}
+	return backlogs
+}
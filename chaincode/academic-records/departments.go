@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== DEPARTMENT REGISTRY ==========
+
+// DepartmentRegistry lists the department codes students can belong to
+type DepartmentRegistry struct {
+	DocType     string   `json:"docType"`
+	Departments []string `json:"departments"`
+	UpdatedBy   string   `json:"updatedBy"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
+
+// departmentRegistryKey is the world state key of the department registry config asset
+const departmentRegistryKey = "CONFIG_DEPARTMENTS"
+
+// GetDepartmentRegistry returns the registered departments
+func (s *SmartContract) GetDepartmentRegistry(ctx contractapi.TransactionContextInterface) (*DepartmentRegistry, error) {
+	return getDepartmentRegistry(ctx)
+}
+
+// UpdateDepartmentRegistry replaces the list of registered departments (NITWarangal only)
+func (s *SmartContract) UpdateDepartmentRegistry(ctx contractapi.TransactionContextInterface, departmentsJSON string) (*DepartmentRegistry, error) {
+	creatorOrg, err := requireOrgRole(ctx, "update the department registry", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var departments []string
+	if err := json.Unmarshal([]byte(departmentsJSON), &departments); err != nil {
+		return nil, fmt.Errorf("invalid departments JSON: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, department := range departments {
+		if strings.TrimSpace(department) == "" {
+			return nil, fmt.Errorf("department registry contains an empty department")
+		}
+		if seen[department] {
+			return nil, fmt.Errorf("department %s is listed more than once", department)
+		}
+		seen[department] = true
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	registry := DepartmentRegistry{
+		DocType:     docTypeDepartmentRegistry,
+		Departments: departments,
+		UpdatedBy:   creatorOrg,
+		UpdatedAt:   txTime.UTC().Format(time.RFC3339),
+	}
+	if registry.Departments == nil {
+		registry.Departments = []string{}
+	}
+
+	storedJSON, err := json.Marshal(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal department registry: %v", err)
+	}
+	if err := ctx.GetStub().PutState(departmentRegistryKey, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "UpdateDepartmentRegistry", "CONFIG", departmentRegistryKey, fmt.Sprintf("Department registry updated: %s", string(storedJSON)))
+
+	return &registry, nil
+}
+
+// defaultDepartments are NITW's department codes, used until a registry is stored
+var defaultDepartments = []string{"CSE", "ECE", "EEE", "MECH", "CIVIL", "CHE", "MME", "BT", "MATH", "PHY", "CHEM"}
+
+// getDepartmentRegistry reads the stored registry, falling back to the default departments
+func getDepartmentRegistry(ctx contractapi.TransactionContextInterface) (*DepartmentRegistry, error) {
+	registryJSON, err := ctx.GetStub().GetState(departmentRegistryKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read department registry: %v", err)
+	}
+	if registryJSON == nil {
+		return &DepartmentRegistry{DocType: docTypeDepartmentRegistry, Departments: defaultDepartments}, nil
+	}
+
+	var registry DepartmentRegistry
+	if err := json.Unmarshal(registryJSON, &registry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal department registry: %v", err)
+	}
+	return &registry, nil
+}
+
+// has reports whether a department is registered
+func (r *DepartmentRegistry) has(department string) bool {
+	for _, registered := range r.Departments {
+		if registered == department {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== DEPARTMENT TRANSFERS ==========
+//
+// A transfer writes a transfer~studentID~txTime~txID entry, like the status history. Records
+// carry the department they were created under; older records without one are placed by
+// replaying the transfers against their semester.
+
+// DepartmentTransfer is one move of a student to another department
+type DepartmentTransfer struct {
+	DocType           string `json:"docType"`
+	StudentID         string `json:"studentId"`
+	FromDepartment    string `json:"fromDepartment"`
+	ToDepartment      string `json:"toDepartment"`
+	EffectiveSemester int    `json:"effectiveSemester"` // first semester taken in the new department
+	Reason            string `json:"reason"`
+	TransferredBy     string `json:"transferredBy"`
+	TransferredAt     string `json:"transferredAt"`
+	TxID              string `json:"txId"`
+}
+
+// TransferStudentDepartment moves a student to a registered department from effectiveSemester
+// on (NITWarangal only). The student~department index follows the move.
+func (s *SmartContract) TransferStudentDepartment(ctx contractapi.TransactionContextInterface, studentID string, newDepartment string, effectiveSemester int, reason string) (*Student, error) {
+	creatorOrg, err := requireOrgRole(ctx, "transfer students", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to transfer a student")
+	}
+	if effectiveSemester < 1 {
+		return nil, fmt.Errorf("effective semester must be at least 1")
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+	if studentFrozen(student) {
+		return nil, frozenStudentError(studentID)
+	}
+	if student.Department == newDepartment {
+		return nil, fmt.Errorf("student %s is already in department %s", studentID, newDepartment)
+	}
+
+	registry, err := getDepartmentRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !registry.has(newDepartment) {
+		return nil, fmt.Errorf("department %s is not in the department registry", newDepartment)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	txID := ctx.GetStub().GetTxID()
+
+	oldDepartment := student.Department
+	student.Department = newDepartment
+	if err := putStudent(ctx, student); err != nil {
+		return nil, err
+	}
+	if err := deleteIndex(ctx, "student~department", []string{oldDepartment, studentID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "student~department", []string{newDepartment, studentID}); err != nil {
+		return nil, err
+	}
+
+	transfer := DepartmentTransfer{
+		DocType:           docTypeDepartmentTransfer,
+		StudentID:         studentID,
+		FromDepartment:    oldDepartment,
+		ToDepartment:      newDepartment,
+		EffectiveSemester: effectiveSemester,
+		Reason:            reason,
+		TransferredBy:     creatorOrg,
+		TransferredAt:     txTime.UTC().Format(time.RFC3339),
+		TxID:              txID,
+	}
+	transferKey, err := ctx.GetStub().CreateCompositeKey("transfer", []string{studentID, fmt.Sprintf("%019d", txTime.UnixNano()), txID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transfer key: %v", err)
+	}
+	transferJSON, err := json.Marshal(transfer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transfer: %v", err)
+	}
+	if err := ctx.GetStub().PutState(transferKey, transferJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "TransferStudentDepartment", "STUDENT", studentID, fmt.Sprintf("Transferred from %s to %s effective semester %d: %s", oldDepartment, newDepartment, effectiveSemester, reason))
+
+	return student, nil
+}
+
+// GetDepartmentTransfers returns a student's department transfers, oldest first
+func (s *SmartContract) GetDepartmentTransfers(ctx contractapi.TransactionContextInterface, studentID string) ([]*DepartmentTransfer, error) {
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+	return getDepartmentTransfers(ctx, studentID)
+}
+
+// getDepartmentTransfers walks a student's transfer entries in chronological order
+func getDepartmentTransfers(ctx contractapi.TransactionContextInterface, studentID string) ([]*DepartmentTransfer, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("transfer", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfers: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	transfers := []*DepartmentTransfer{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var transfer DepartmentTransfer
+		if err := json.Unmarshal(response.Value, &transfer); err != nil {
+			continue
+		}
+		transfers = append(transfers, &transfer)
+	}
+	return transfers, nil
+}
+
+// recordDepartment returns the department a record belongs to: the one stamped on it, or for
+// older records the department the transfers place the student in for that semester
+func recordDepartment(record *AcademicRecord, student *Student, transfers []*DepartmentTransfer) string {
+	if record.Department != "" {
+		return record.Department
+	}
+	department := student.Department
+	for i := len(transfers) - 1; i >= 0; i-- {
+		if record.Semester < transfers[i].EffectiveSemester {
+			department = transfers[i].FromDepartment
+		}
+	}
+	return department
+}
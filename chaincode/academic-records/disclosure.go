@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== COURSE DISCLOSURE ==========
+//
+// A student can prove one course result without revealing the rest of the transcript. When a
+// record is approved, or created as a superseding replacement, each course gets a public
+// commitment on the record:
+//
+//	SHA-256("courseCode|grade|credits|salt")
+//
+// with credits in shortest decimal form (4, 3.5) and the salt in lowercase hex. The salts are
+// kept in a private collection only NITWarangal peers hold. GenerateCourseDisclosure releases
+// one course's values and salt, which anyone can then check with VerifyCourseDisclosure.
+//
+// The salts have to be unguessable, since grades and credits take few values, yet every endorser
+// has to derive the same ones, so they come from a secret seed the approving client passes in the
+// transient map under disclosureSeed: salt = HMAC-SHA256(seed, "recordID|courseCode"). Records
+// approved without a seed have no commitments and cannot be disclosed course by course.
+
+// collectionCourseSalts holds the commitment salts of approved records, see collections_config.json
+const collectionCourseSalts = "collectionCourseSalts"
+
+// transientDisclosureSeed is the transient map key the commitment seed is read from
+const transientDisclosureSeed = "disclosureSeed"
+
+// minDisclosureSeedLength is the shortest seed accepted, in bytes
+const minDisclosureSeedLength = 32
+
+// Outcomes reported by VerifyCourseDisclosure
+const (
+	disclosureOutcomeValid        = "VALID"
+	disclosureOutcomeUnverified   = "UNVERIFIED"
+	disclosureOutcomeSuperseded   = "SUPERSEDED"
+	disclosureOutcomeMismatch     = "MISMATCH"
+	disclosureOutcomeNoCommitment = "NO_COMMITMENT"
+)
+
+// CourseSalts is the private salt list of one record
+type CourseSalts struct {
+	RecordID string            `json:"recordId"`
+	Salts    map[string]string `json:"salts"` // course code -> hex salt
+}
+
+// CourseDisclosure is what a student hands over to prove one course result
+type CourseDisclosure struct {
+	RecordID   string  `json:"recordId"`
+	StudentID  string  `json:"studentId"`
+	Semester   int     `json:"semester"`
+	CourseCode string  `json:"courseCode"`
+	Grade      string  `json:"grade"`
+	Credits    float64 `json:"credits"`
+	Salt       string  `json:"salt"`
+	Commitment string  `json:"commitment"`
+}
+
+// CourseDisclosureVerdict is the result of checking a disclosure against the ledger
+type CourseDisclosureVerdict struct {
+	RecordID     string `json:"recordId"`
+	StudentID    string `json:"studentId"`
+	CourseCode   string `json:"courseCode"`
+	RecordStatus string `json:"recordStatus"`
+	Matches      bool   `json:"matches"` // the values and salt reproduce the on-ledger commitment
+	Valid        bool   `json:"valid"`   // they match and the record is VERIFIED
+	Outcome      string `json:"outcome"` // VALID, UNVERIFIED, SUPERSEDED, MISMATCH, NO_COMMITMENT
+
+	// For superseded records: the record now standing for the semester, following every correction
+	CurrentRecordID string `json:"currentRecordId,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// GenerateCourseDisclosure returns the values and salt of one course of an approved or verified
+// record (NITWarangal only). Superseded records are refused; disclose from the replacement.
+func (s *SmartContract) GenerateCourseDisclosure(ctx contractapi.TransactionContextInterface, recordID string, courseCode string) (*CourseDisclosure, error) {
+	if _, err := requireOrgRole(ctx, "generate course disclosures", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	switch record.Status {
+	case recordStatusApproved, recordStatusVerified:
+	case recordStatusSuperseded:
+		return nil, fmt.Errorf("record %s was superseded by %s; disclose the course from the replacement", recordID, record.SupersededBy)
+	default:
+		return nil, fmt.Errorf("record %s is %s; only approved or verified records can be disclosed", recordID, record.Status)
+	}
+
+	commitment, ok := record.CourseCommitments[courseCode]
+	if !ok {
+		return nil, fmt.Errorf("record %s has no commitment for course %s", recordID, courseCode)
+	}
+	course := findCourse(record, courseCode)
+	if course == nil {
+		return nil, fmt.Errorf("record %s has no course %s", recordID, courseCode)
+	}
+
+	saltsJSON, err := ctx.GetStub().GetPrivateData(collectionCourseSalts, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data: %v", err)
+	}
+	if saltsJSON == nil {
+		return nil, fmt.Errorf("the commitment salts of record %s are not available on this peer", recordID)
+	}
+	var salts CourseSalts
+	if err := json.Unmarshal(saltsJSON, &salts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal course salts: %v", err)
+	}
+	salt, ok := salts.Salts[courseCode]
+	if !ok {
+		return nil, fmt.Errorf("record %s has no salt for course %s", recordID, courseCode)
+	}
+
+	return &CourseDisclosure{
+		RecordID:   recordID,
+		StudentID:  record.StudentID,
+		Semester:   record.Semester,
+		CourseCode: courseCode,
+		Grade:      course.Grade,
+		Credits:    course.Credits,
+		Salt:       salt,
+		Commitment: commitment,
+	}, nil
+}
+
+// VerifyCourseDisclosure recomputes a course commitment from disclosed values and compares it
+// with the one on the record. A disclosure from a superseded record still matches what was
+// committed, but is reported as SUPERSEDED with the record now standing for the semester.
+func (s *SmartContract) VerifyCourseDisclosure(ctx contractapi.TransactionContextInterface, recordID string, courseCode string, grade string, credits float64, salt string) (*CourseDisclosureVerdict, error) {
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &CourseDisclosureVerdict{
+		RecordID:     recordID,
+		StudentID:    record.StudentID,
+		CourseCode:   courseCode,
+		RecordStatus: record.Status,
+	}
+
+	committed, ok := record.CourseCommitments[courseCode]
+	if !ok {
+		verdict.Outcome = disclosureOutcomeNoCommitment
+		verdict.Reason = fmt.Sprintf("record %s has no commitment for course %s", recordID, courseCode)
+		return verdict, nil
+	}
+	if !hmac.Equal([]byte(courseCommitment(courseCode, grade, credits, salt)), []byte(committed)) {
+		verdict.Outcome = disclosureOutcomeMismatch
+		verdict.Reason = "the disclosed values do not reproduce the committed hash"
+		return verdict, nil
+	}
+	verdict.Matches = true
+
+	switch record.Status {
+	case recordStatusVerified:
+		verdict.Valid = true
+		verdict.Outcome = disclosureOutcomeValid
+	case recordStatusSuperseded:
+		current, err := currentRecord(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		verdict.CurrentRecordID = current.RecordID
+		verdict.Outcome = disclosureOutcomeSuperseded
+		verdict.Reason = fmt.Sprintf("record %s was superseded: %s", recordID, record.SupersessionReason)
+	default:
+		verdict.Outcome = disclosureOutcomeUnverified
+		verdict.Reason = fmt.Sprintf("record %s is %s, not VERIFIED", recordID, record.Status)
+	}
+	return verdict, nil
+}
+
+// commitRecordCourses sets the course commitments of a record that is being approved and stores
+// their salts, when the client supplied a disclosure seed. The caller still has to save the record.
+func commitRecordCourses(ctx contractapi.TransactionContextInterface, record *AcademicRecord) error {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to read transient data: %v", err)
+	}
+	seed, ok := transient[transientDisclosureSeed]
+	if !ok {
+		return nil
+	}
+	if len(seed) < minDisclosureSeedLength {
+		return fmt.Errorf("%s must be at least %d bytes", transientDisclosureSeed, minDisclosureSeedLength)
+	}
+
+	salts := CourseSalts{RecordID: record.RecordID, Salts: map[string]string{}}
+	record.CourseCommitments = map[string]string{}
+	for _, course := range record.Courses {
+		mac := hmac.New(sha256.New, seed)
+		mac.Write([]byte(record.RecordID + "|" + course.CourseCode))
+		salt := hex.EncodeToString(mac.Sum(nil))
+
+		salts.Salts[course.CourseCode] = salt
+		record.CourseCommitments[course.CourseCode] = courseCommitment(course.CourseCode, course.Grade, course.Credits, salt)
+	}
+
+	saltsJSON, err := json.Marshal(salts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal course salts: %v", err)
+	}
+	if err := ctx.GetStub().PutPrivateData(collectionCourseSalts, record.RecordID, saltsJSON); err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+	return nil
+}
+
+// courseCommitment is the hex SHA-256 of "courseCode|grade|credits|salt"
+func courseCommitment(courseCode string, grade string, credits float64, salt string) string {
+	preimage := courseCode + "|" + grade + "|" + strconv.FormatFloat(credits, 'f', -1, 64) + "|" + salt
+	hash := sha256.Sum256([]byte(preimage))
+	return hex.EncodeToString(hash[:])
+}
+
+// findCourse returns the record's course with the given code, or nil
+func findCourse(record *AcademicRecord, courseCode string) *CourseGrade {
+	for i := range record.Courses {
+		if record.Courses[i].CourseCode == courseCode {
+			return &record.Courses[i]
+		}
+	}
+	return nil
+}
+
+// currentRecord follows SupersededBy links to the record now standing for the semester
+func currentRecord(ctx contractapi.TransactionContextInterface, record *AcademicRecord) (*AcademicRecord, error) {
+	seen := map[string]bool{record.RecordID: true}
+	for record.Status == recordStatusSuperseded && record.SupersededBy != "" {
+		if seen[record.SupersededBy] {
+			return nil, fmt.Errorf("record %s is in a supersession cycle", record.SupersededBy)
+		}
+		seen[record.SupersededBy] = true
+
+		next, err := readAcademicRecord(ctx, record.SupersededBy)
+		if err != nil {
+			return nil, err
+		}
+		record = next
+	}
+	return record, nil
+}
@@ -0,0 +1,595 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT DOSSIER EXPORT ==========
+//
+// ExportStudentDossier gathers everything the ledger holds on one student: the student with its
+// PII, every record including superseded ones, the private course lists of unapproved records,
+// every certificate and the audit trail of all of them. The payload is serialized as canonical
+// JSON and hashed, and the hash is written to the student's audit trail by the exporting
+// transaction, so a file can later be checked against the ledger. Payloads larger than
+// dossierChunkSize are returned in chunks; concatenating the Data of every chunk gives the payload.
+
+// dossierFormat identifies the layout of DossierPayload
+const dossierFormat = "academic-records-dossier/v1"
+
+// dossierChunkSize is the most payload bytes returned by one export call
+const dossierChunkSize = 256 * 1024
+
+// DossierPayload is the content of a dossier
+type DossierPayload struct {
+	Format       string                   `json:"format"`
+	Student      *Student                 `json:"student"` // PII included
+	Records      []*AcademicRecord        `json:"records"` // all versions, oldest semester first
+	DraftCourses map[string][]CourseGrade `json:"draftCourses"`
+	Certificates []*Certificate           `json:"certificates"`
+	AuditTrail   []*AuditLog              `json:"auditTrail"` // of the student, its records and certificates
+}
+
+// DossierEnvelope carries one chunk of a dossier with what is needed to check it
+type DossierEnvelope struct {
+	Format            string `json:"format"`
+	StudentID         string `json:"studentId"`
+	PayloadHash       string `json:"payloadHash"` // SHA-256 of the canonical payload JSON
+	PayloadSize       int    `json:"payloadSize"`
+	ExportTxID        string `json:"exportTxId"` // transaction that logged PayloadHash
+	ExportedAt        string `json:"exportedAt"`
+	Chunk             int    `json:"chunk"` // zero-based
+	TotalChunks       int    `json:"totalChunks"`
+	Data              string `json:"data"`                        // this chunk of the canonical payload JSON
+	ContinuationToken string `json:"continuationToken,omitempty"` // pass to get the next chunk; empty on the last
+}
+
+// ExportStudentDossier returns the first chunk of a student's dossier and logs its hash
+// (NITWarangal only); submit it
+func (s *SmartContract) ExportStudentDossier(ctx contractapi.TransactionContextInterface, studentID string) (*DossierEnvelope, error) {
+	return s.ExportStudentDossierWithContinuation(ctx, studentID, "")
+}
+
+// ExportStudentDossierWithContinuation returns the chunk named by continuationToken, or the first
+// chunk when it is empty (NITWarangal only). A continuation fails if the dossier changed since
+// the first chunk; start the export again in that case.
+func (s *SmartContract) ExportStudentDossierWithContinuation(ctx contractapi.TransactionContextInterface, studentID string, continuationToken string) (*DossierEnvelope, error) {
+	if _, err := requireOrgRole(ctx, "export student dossiers", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	expectedHash, chunk := "", 0
+	if continuationToken != "" {
+		var err error
+		expectedHash, chunk, err = parseDossierToken(continuationToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := buildDossier(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	payloadJSON, err := canonicalJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(payloadJSON)
+	payloadHash := hex.EncodeToString(hash[:])
+	if expectedHash != "" && expectedHash != payloadHash {
+		return nil, fmt.Errorf("dossier of student %s changed since the export started; export it again", studentID)
+	}
+
+	totalChunks := (len(payloadJSON) + dossierChunkSize - 1) / dossierChunkSize
+	if totalChunks == 0 {
+		totalChunks = 1
+	}
+	if chunk >= totalChunks {
+		return nil, fmt.Errorf("continuation token points past the last chunk")
+	}
+	end := (chunk + 1) * dossierChunkSize
+	if end > len(payloadJSON) {
+		end = len(payloadJSON)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	envelope := &DossierEnvelope{
+		Format:      dossierFormat,
+		StudentID:   studentID,
+		PayloadHash: payloadHash,
+		PayloadSize: len(payloadJSON),
+		ExportTxID:  ctx.GetStub().GetTxID(),
+		ExportedAt:  txTime.UTC().Format(time.RFC3339),
+		Chunk:       chunk,
+		TotalChunks: totalChunks,
+		Data:        string(payloadJSON[chunk*dossierChunkSize : end]),
+	}
+	if chunk+1 < totalChunks {
+		envelope.ContinuationToken = fmt.Sprintf("%s:%d", payloadHash, chunk+1)
+	}
+
+	// Only the first chunk is logged; later ones belong to the same export
+	if continuationToken == "" {
+		details := fmt.Sprintf("Dossier exported: sha256 %s, %d bytes in %d chunks", payloadHash, len(payloadJSON), totalChunks)
+		if err := logAudit(ctx, "ExportStudentDossier", "STUDENT", studentID, details); err != nil {
+			return nil, err
+		}
+	}
+
+	return envelope, nil
+}
+
+// buildDossier collects a student's dossier payload
+func buildDossier(ctx contractapi.TransactionContextInterface, studentID string) (*DossierPayload, error) {
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	pii, err := readStudentPII(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	mergeStudentPII(student, pii)
+
+	records, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	sortRecordsBySemester(records, false)
+
+	payload := &DossierPayload{
+		Format:       dossierFormat,
+		Student:      student,
+		Records:      records,
+		DraftCourses: map[string][]CourseGrade{},
+		Certificates: []*Certificate{},
+		AuditTrail:   []*AuditLog{},
+	}
+	assetIDs := []string{studentID}
+	for _, record := range records {
+		assetIDs = append(assetIDs, record.RecordID)
+		if record.CoursesHash != "" && len(record.Courses) == 0 {
+			courses, err := readDraftCourses(ctx, record)
+			if err != nil {
+				return nil, err
+			}
+			payload.DraftCourses[record.RecordID] = courses
+		}
+	}
+
+	certificateIDs, err := indexedCertificateIDs(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, certificateID := range certificateIDs {
+		cert, err := readCertificate(ctx, certificateID)
+		if err != nil {
+			continue
+		}
+		payload.Certificates = append(payload.Certificates, cert)
+		assetIDs = append(assetIDs, certificateID)
+	}
+
+	for _, assetID := range assetIDs {
+		logs, err := readAuditTrail(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			// Earlier exports would change the hash of every later one
+			if log.Action != "ExportStudentDossier" {
+				payload.AuditTrail = append(payload.AuditTrail, log)
+			}
+		}
+	}
+	sort.SliceStable(payload.AuditTrail, func(i, j int) bool {
+		return payload.AuditTrail[i].LogID < payload.AuditTrail[j].LogID
+	})
+
+	return payload, nil
+}
+
+// readAuditTrail returns every audit entry of one asset, oldest first
+func readAuditTrail(ctx contractapi.TransactionContextInterface, recordID string) ([]*AuditLog, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("audit", []string{recordID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	logs := []*AuditLog{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		logJSON, err := ctx.GetStub().GetState(compositeKeyParts[1])
+		if err != nil || logJSON == nil {
+			continue
+		}
+
+		var log AuditLog
+		if err := json.Unmarshal(logJSON, &log); err != nil {
+			continue
+		}
+		logs = append(logs, &log)
+	}
+	return logs, nil
+}
+
+// parseDossierToken splits a continuation token into the payload hash and the chunk number
+func parseDossierToken(token string) (string, int, error) {
+	parts := strings.Split(token, ":")
+	if len(parts) != 2 || len(parts[0]) != sha256.Size*2 {
+		return "", 0, fmt.Errorf("invalid continuation token")
+	}
+	chunk, err := strconv.Atoi(parts[1])
+	if err != nil || chunk < 1 {
+		return "", 0, fmt.Errorf("invalid continuation token")
+	}
+	return parts[0], chunk, nil
+}
+
+// ========== STUDENT DOSSIER IMPORT ==========
+//
+// ImportStudentDossier restores a dossier written by ExportStudentDossier, for disaster recovery
+// and moves between networks. The assets are written as new: CreatedAt is the import's tx
+// timestamp, the source value moves to OriginalCreatedAt and ImportedFrom names the dossier hash.
+// Approval, verification and issuance dates are kept, so certificate hashes still check. The
+// source audit trail is not replayed; the IMPORT entry ties the assets to the dossier carrying it.
+// Any failure fails the transaction, so nothing of a rejected dossier is written.
+
+// DossierImportResult lists the assets ImportStudentDossier wrote
+type DossierImportResult struct {
+	StudentID      string   `json:"studentId"`
+	SourceHash     string   `json:"sourceHash"`
+	RecordIDs      []string `json:"recordIds"`
+	CertificateIDs []string `json:"certificateIds"`
+	Overwritten    []string `json:"overwritten"` // existing assets replaced because force was set
+}
+
+// ImportStudentDossier recreates a student, its records and its certificates from a dossier
+// envelope whose data holds the whole payload, i.e. every chunk joined (NITWarangal admin
+// identities only). Existing assets are only replaced when force is set.
+func (s *SmartContract) ImportStudentDossier(ctx contractapi.TransactionContextInterface, dossierJSON string, force bool) (*DossierImportResult, error) {
+	if _, err := requireOrgRole(ctx, "import student dossiers", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("importing dossiers requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	payload, sourceHash, err := parseDossier(dossierJSON)
+	if err != nil {
+		return nil, err
+	}
+	student := payload.Student
+	studentID := student.StudentID
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	// The importing institution holds the student from now on
+	institutionID, err := callerInstitution(ctx)
+	if err != nil {
+		return nil, err
+	}
+	student.InstitutionID = institutionID
+	for _, record := range payload.Records {
+		record.InstitutionID = institutionID
+	}
+	for _, cert := range payload.Certificates {
+		cert.InstitutionID = institutionID
+	}
+
+	result := &DossierImportResult{StudentID: studentID, SourceHash: sourceHash, RecordIDs: []string{}, CertificateIDs: []string{}, Overwritten: []string{}}
+
+	// Clear whatever force lets the import replace before anything is written
+	overwrite, err := claimImportKey(ctx, studentID, docTypeStudent, force)
+	if err != nil {
+		return nil, err
+	}
+	if overwrite {
+		if err := dropStudentIndexes(ctx, studentID); err != nil {
+			return nil, err
+		}
+		result.Overwritten = append(result.Overwritten, studentID)
+	}
+	for _, record := range payload.Records {
+		overwrite, err := claimImportKey(ctx, record.RecordID, docTypeRecord, force)
+		if err != nil {
+			return nil, err
+		}
+		if overwrite {
+			if err := dropRecordIndexes(ctx, record.RecordID); err != nil {
+				return nil, err
+			}
+			result.Overwritten = append(result.Overwritten, record.RecordID)
+		}
+	}
+	for _, cert := range payload.Certificates {
+		overwrite, err := claimImportKey(ctx, cert.CertificateID, docTypeCertificate, force)
+		if err != nil {
+			return nil, err
+		}
+		if overwrite {
+			if err := dropCertificateIndexes(ctx, cert.CertificateID); err != nil {
+				return nil, err
+			}
+			result.Overwritten = append(result.Overwritten, cert.CertificateID)
+		}
+	}
+
+	// Student, with its PII back in the private collection
+	pii := &StudentPII{DocType: docTypeStudentPII, StudentID: studentID, Name: student.Name, Email: student.Email, Phone: student.Phone, Address: student.Address}
+	stampImported(&student.CreatedAt, &student.OriginalCreatedAt, &student.ImportedFrom, now, sourceHash)
+	if err := putStudent(ctx, student); err != nil {
+		return nil, err
+	}
+	if pii.Name != "" || pii.Email != "" {
+		if err := putStudentPII(ctx, pii); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case student.Archived:
+		if err := putIndex(ctx, "student~archived", []string{studentID}); err != nil {
+			return nil, err
+		}
+	case student.Status == studentStatusMerged:
+		if err := putIndex(ctx, "student~status", []string{student.Status, studentID}); err != nil {
+			return nil, err
+		}
+	default:
+		if pii.Email != "" {
+			if err := assertEmailAvailable(ctx, pii.Email, studentID); err != nil {
+				return nil, err
+			}
+			if err := putEmailIndex(ctx, pii.Email, studentID); err != nil {
+				return nil, err
+			}
+		}
+		if err := putIndex(ctx, "student~status", []string{student.Status, studentID}); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "student~department", []string{student.Department, studentID}); err != nil {
+			return nil, err
+		}
+	}
+	if err := recordStatusChange(ctx, studentID, "", student.Status, fmt.Sprintf("Imported from dossier %s", sourceHash)); err != nil {
+		return nil, err
+	}
+
+	for _, record := range payload.Records {
+		stampImported(&record.CreatedAt, &record.OriginalCreatedAt, &record.ImportedFrom, now, sourceHash)
+		if courses, ok := payload.DraftCourses[record.RecordID]; ok {
+			matches, err := coursesHashMatches(courses, record.CoursesHash)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				return nil, fmt.Errorf("draft courses of record %s do not match its courses hash", record.RecordID)
+			}
+			if err := stageDraftCourses(ctx, record, courses); err != nil {
+				return nil, err
+			}
+		} else if err := putInstructorIndexes(ctx, record, record.Courses); err != nil {
+			return nil, err
+		}
+		if err := putAcademicRecord(ctx, record); err != nil {
+			return nil, err
+		}
+		if err := putRecordIndexes(ctx, record); err != nil {
+			return nil, err
+		}
+		if record.Status == recordStatusApproved {
+			if err := putIndex(ctx, "record~awaitingverification", []string{record.ApprovedAt, record.RecordID}); err != nil {
+				return nil, err
+			}
+		}
+		if err := setRecordEndorsementPolicy(ctx, record); err != nil {
+			return nil, err
+		}
+		result.RecordIDs = append(result.RecordIDs, record.RecordID)
+	}
+
+	for _, cert := range payload.Certificates {
+		stampImported(&cert.CreatedAt, &cert.OriginalCreatedAt, &cert.ImportedFrom, now, sourceHash)
+		if err := putCertificate(ctx, cert); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "cert~student~type", []string{studentID, cert.CertificationType, cert.CertificateID}); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "certhash~id", []string{cert.CertificateHash, cert.CertificateID}); err != nil {
+			return nil, err
+		}
+		result.CertificateIDs = append(result.CertificateIDs, cert.CertificateID)
+	}
+
+	details := fmt.Sprintf("Imported from dossier sha256 %s: %d records, %d certificates; overwritten: [%s]",
+		sourceHash, len(result.RecordIDs), len(result.CertificateIDs), strings.Join(result.Overwritten, ", "))
+	if err := logAudit(ctx, "IMPORT", "STUDENT", studentID, details); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseDossier checks a dossier envelope against its hash and returns the payload and hash
+func parseDossier(dossierJSON string) (*DossierPayload, string, error) {
+	var envelope DossierEnvelope
+	if err := json.Unmarshal([]byte(dossierJSON), &envelope); err != nil {
+		return nil, "", fmt.Errorf("invalid dossier JSON: %v", err)
+	}
+	if envelope.Format != dossierFormat {
+		return nil, "", fmt.Errorf("unsupported dossier format %q: expected %s", envelope.Format, dossierFormat)
+	}
+	if len(envelope.Data) != envelope.PayloadSize {
+		return nil, "", fmt.Errorf("dossier data is %d bytes but the envelope declares %d; join every chunk first", len(envelope.Data), envelope.PayloadSize)
+	}
+	hash := sha256.Sum256([]byte(envelope.Data))
+	if hex.EncodeToString(hash[:]) != envelope.PayloadHash {
+		return nil, "", fmt.Errorf("dossier data does not match its payload hash %s", envelope.PayloadHash)
+	}
+
+	var payload DossierPayload
+	if err := json.Unmarshal([]byte(envelope.Data), &payload); err != nil {
+		return nil, "", fmt.Errorf("invalid dossier payload: %v", err)
+	}
+	if payload.Student == nil || payload.Student.StudentID != envelope.StudentID {
+		return nil, "", fmt.Errorf("dossier payload is not for student %s", envelope.StudentID)
+	}
+	for _, record := range payload.Records {
+		if record.StudentID != envelope.StudentID {
+			return nil, "", fmt.Errorf("record %s belongs to student %s, not %s", record.RecordID, record.StudentID, envelope.StudentID)
+		}
+	}
+	for _, cert := range payload.Certificates {
+		if cert.StudentID != envelope.StudentID {
+			return nil, "", fmt.Errorf("certificate %s belongs to student %s, not %s", cert.CertificateID, cert.StudentID, envelope.StudentID)
+		}
+	}
+	return &payload, envelope.PayloadHash, nil
+}
+
+// claimImportKey reports whether key holds an asset of the same type that force allows the
+// import to replace; an asset of another type is never replaced
+func claimImportKey(ctx contractapi.TransactionContextInterface, key string, docType string, force bool) (bool, error) {
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read state: %v", err)
+	}
+	if existing == nil {
+		return false, nil
+	}
+
+	var doc struct {
+		DocType string `json:"docType"`
+	}
+	if err := json.Unmarshal(existing, &doc); err != nil || doc.DocType != docType {
+		return false, fmt.Errorf("cannot import %s %s: ID already used by another asset", docType, key)
+	}
+	if !force {
+		return false, fmt.Errorf("%s %s already exists; import with force to replace it", docType, key)
+	}
+	return true, nil
+}
+
+// stampImported moves the source CreatedAt to OriginalCreatedAt, keeping the first one across
+// repeated imports, and marks the asset as imported
+func stampImported(createdAt *string, originalCreatedAt *string, importedFrom *string, now string, sourceHash string) {
+	if *originalCreatedAt == "" {
+		*originalCreatedAt = *createdAt
+	}
+	*createdAt = now
+	*importedFrom = sourceHash
+}
+
+// dropStudentIndexes removes the index entries of a stored student about to be replaced
+func dropStudentIndexes(ctx contractapi.TransactionContextInterface, studentID string) error {
+	existing, err := readStudent(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if err := deleteIndex(ctx, "student~status", []string{existing.Status, studentID}); err != nil {
+		return err
+	}
+	if err := deleteIndex(ctx, "student~department", []string{existing.Department, studentID}); err != nil {
+		return err
+	}
+	if err := deleteIndex(ctx, "student~archived", []string{studentID}); err != nil {
+		return err
+	}
+
+	pii, err := readStudentPII(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if pii == nil || pii.Email == "" {
+		return nil
+	}
+	owner, err := emailOwner(ctx, pii.Email)
+	if err != nil {
+		return err
+	}
+	if owner != studentID {
+		return nil
+	}
+	return deleteEmailIndex(ctx, pii.Email)
+}
+
+// dropRecordIndexes removes the index entries of a stored record about to be replaced
+func dropRecordIndexes(ctx contractapi.TransactionContextInterface, recordID string) error {
+	existing, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+	semester := strconv.Itoa(existing.Semester)
+	for _, index := range []struct {
+		objectType string
+		attributes []string
+	}{
+		{"record~student", []string{existing.StudentID, recordID}},
+		{"record~status", []string{existing.Status, recordID}},
+		{"record~student~semester", []string{existing.StudentID, semester, recordID}},
+		{"record~department", []string{existing.Department, fmt.Sprintf("%04d", existing.Year), semester, recordID}},
+		{"record~awaitingverification", []string{existing.ApprovedAt, recordID}},
+		{"gradescale~record", []string{fmt.Sprintf("%04d", existing.GradeScaleYear), recordID}},
+	} {
+		if err := deleteIndex(ctx, index.objectType, index.attributes); err != nil {
+			return err
+		}
+	}
+
+	courses := existing.Courses
+	if existing.CoursesHash != "" && len(courses) == 0 {
+		// A draft whose private copy is gone has no instructor entries worth keeping either
+		if courses, err = readDraftCourses(ctx, existing); err != nil {
+			courses = nil
+		}
+	}
+	for _, course := range courses {
+		if course.InstructorID == "" {
+			continue
+		}
+		if err := deleteIndex(ctx, "grade~faculty", []string{course.InstructorID, strconv.Itoa(existing.Year), semester, recordID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropCertificateIndexes removes the index entries of a stored certificate about to be replaced
+func dropCertificateIndexes(ctx contractapi.TransactionContextInterface, certificateID string) error {
+	existing, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return err
+	}
+	if err := deleteIndex(ctx, "cert~student~type", []string{existing.StudentID, existing.CertificationType, certificateID}); err != nil {
+		return err
+	}
+	return deleteIndex(ctx, "certhash~id", []string{existing.CertificateHash, certificateID})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== DRAFT GRADES ==========
+//
+// Until a record is approved its course list lives in a private collection shared by
+// Departments and NITWarangal; world state only carries CoursesHash, a SHA-256 over the
+// course list JSON. ApproveAcademicRecord checks the private copy against that hash and
+// publishes the courses on the record.
+
+// collectionDraftGrades holds course lists of records that are not approved yet,
+// see collections_config.json
+const collectionDraftGrades = "collectionDraftGrades"
+
+// transientCourses is the transient map key record functions read the course list JSON from
+const transientCourses = "courses"
+
+// DraftGrades is the private course list of an unapproved record
+type DraftGrades struct {
+	DocType  string        `json:"docType"`
+	RecordID string        `json:"recordId"`
+	Courses  []CourseGrade `json:"courses"`
+}
+
+// GetDraftCourses returns the private course list of an unapproved record (Departments and NITWarangal)
+func (s *SmartContract) GetDraftCourses(ctx contractapi.TransactionContextInterface, recordID string) ([]CourseGrade, error) {
+	if _, err := requireOrgRole(ctx, "view draft grades", orgRoleDepartment, orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	return readDraftCourses(ctx, record)
+}
+
+// readTransientCourses returns the course list JSON from the transient map; records that may
+// be empty get an empty list when none is supplied
+func readTransientCourses(ctx contractapi.TransactionContextInterface, allowEmpty bool) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("failed to read transient data: %v", err)
+	}
+	coursesJSON, ok := transient[transientCourses]
+	if !ok {
+		if allowEmpty {
+			return "[]", nil
+		}
+		return "", fmt.Errorf("%s must be supplied in the transient map", transientCourses)
+	}
+	return string(coursesJSON), nil
+}
+
+// stageDraftCourses stores the course list privately, commits to it on the public record and
+// indexes the record under each instructor
+func stageDraftCourses(ctx contractapi.TransactionContextInterface, record *AcademicRecord, courses []CourseGrade) error {
+	hash, err := hashCourses(courses)
+	if err != nil {
+		return err
+	}
+
+	draftJSON, err := json.Marshal(DraftGrades{DocType: docTypeDraftGrades, RecordID: record.RecordID, Courses: courses})
+	if err != nil {
+		return fmt.Errorf("failed to marshal draft grades: %v", err)
+	}
+	if err := ctx.GetStub().PutPrivateData(collectionDraftGrades, record.RecordID, draftJSON); err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+	if err := putInstructorIndexes(ctx, record, courses); err != nil {
+		return err
+	}
+
+	record.Courses = []CourseGrade{}
+	record.CoursesHash = hash
+	return nil
+}
+
+// readDraftCourses loads the private course list and checks it against the record's hash.
+// Records created before draft grades were private keep their courses on the record.
+func readDraftCourses(ctx contractapi.TransactionContextInterface, record *AcademicRecord) ([]CourseGrade, error) {
+	if record.CoursesHash == "" {
+		return record.Courses, nil
+	}
+
+	draftJSON, err := ctx.GetStub().GetPrivateData(collectionDraftGrades, record.RecordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data: %v", err)
+	}
+	if draftJSON == nil {
+		return nil, fmt.Errorf("draft grades for record %s not found", record.RecordID)
+	}
+
+	var draft DraftGrades
+	if err := json.Unmarshal(draftJSON, &draft); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal draft grades: %v", err)
+	}
+	if draft.Courses == nil {
+		draft.Courses = []CourseGrade{}
+	}
+
+	matches, err := coursesHashMatches(draft.Courses, record.CoursesHash)
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		return nil, fmt.Errorf("draft grades for record %s do not match the committed hash", record.RecordID)
+	}
+	return draft.Courses, nil
+}
+
+// publishDraftCourses copies the verified private course list onto the record and purges the
+// private copy
+func publishDraftCourses(ctx contractapi.TransactionContextInterface, record *AcademicRecord) error {
+	if record.CoursesHash == "" {
+		return nil
+	}
+
+	courses, err := readDraftCourses(ctx, record)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelPrivateData(collectionDraftGrades, record.RecordID); err != nil {
+		return fmt.Errorf("failed to delete private data: %v", err)
+	}
+
+	record.Courses = courses
+	return nil
+}
+
+// hashCourses hashes a course list's canonical JSON
+func hashCourses(courses []CourseGrade) (string, error) {
+	return canonicalHash(courses)
+}
+
+// coursesHashMatches checks a course list against a committed hash. Drafts staged before
+// canonical JSON were hashed over the JSON encoding/json emits, which is accepted too.
+func coursesHashMatches(courses []CourseGrade, committed string) (bool, error) {
+	hash, err := hashCourses(courses)
+	if err != nil {
+		return false, err
+	}
+	if hash == committed {
+		return true, nil
+	}
+	coursesJSON, err := json.Marshal(courses)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal courses: %v", err)
+	}
+	legacy := sha256.Sum256(coursesJSON)
+	return hex.EncodeToString(legacy[:]) == committed, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+func TestDraftGradesStayOffWorldState(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+
+	_, ws := ledgertest.Invoke(l, testExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(3))}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+	})
+	if ws.Err != nil {
+		t.Fatalf("CreateAcademicRecord: %v", ws.Err)
+	}
+
+	var draft DraftGrades
+	ledgertest.Decode(t, ws.PutPrivate(collectionDraftGrades, "REC1"), &draft, "draft grades")
+	wantHash, err := hashCourses(draft.Courses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := writtenRecord(t, ws, "REC1")
+	if len(record.Courses) != 0 || record.CoursesHash != wantHash {
+		t.Errorf("public record has %d courses and hash %q, want none and %q", len(record.Courses), record.CoursesHash, wantHash)
+	}
+	for _, op := range ws.Ops(ledgertest.OpPutState) {
+		if strings.Contains(string(op.Value), "TC001") {
+			t.Errorf("world state key %q carries a draft course", op.Key)
+		}
+	}
+
+	getDraft := func(ctx contractapi.TransactionContextInterface) ([]CourseGrade, error) {
+		return l.contract.GetDraftCourses(ctx, "REC1")
+	}
+	if courses := ledgertest.MustInvoke(t, l, testExamCell, ledgertest.TxOptions{}, getDraft); len(courses) != 3 {
+		t.Errorf("GetDraftCourses returned %d courses to the department", len(courses))
+	}
+	ledgertest.InvokeError(t, l, testVerifier, ledgertest.TxOptions{}, "only departments or the university can view draft grades", getDraft)
+}
+
+func TestApprovalPublishesDraftGrades(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+	staged := l.Stub.Private[collectionDraftGrades]["REC1"]
+
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.ApproveAcademicRecord(ctx, "REC1", testChecklist, "")
+	})
+	_, ws := ledgertest.Invoke(l, testDean, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.ApproveAcademicRecord(ctx, "REC1", testChecklist, "")
+	})
+	if ws.Err != nil {
+		t.Fatalf("final approval: %v", ws.Err)
+	}
+
+	var draft DraftGrades
+	ledgertest.Decode(t, staged, &draft, "draft grades")
+	published, _ := json.Marshal(writtenRecord(t, ws, "REC1").Courses)
+	if want, _ := json.Marshal(draft.Courses); string(published) != string(want) {
+		t.Errorf("published courses %s, want the staged %s", published, want)
+	}
+	if len(ws.Ops(ledgertest.OpDelPrivateData)) != 1 || l.Stub.Private[collectionDraftGrades]["REC1"] != nil {
+		t.Errorf("private copy was not removed after publishing")
+	}
+}
+
+func TestApprovalRefusesDraftGradesNotMatchingTheHash(t *testing.T) {
+	tamper := func(t *testing.T, l *testLedger) {
+		var draft DraftGrades
+		ledgertest.Decode(t, l.Stub.Private[collectionDraftGrades]["REC1"], &draft, "draft grades")
+		draft.Courses[0].Grade, draft.Courses[0].GradePoint = "F", 0
+		l.Stub.Private[collectionDraftGrades]["REC1"], _ = json.Marshal(draft)
+	}
+	purge := func(t *testing.T, l *testLedger) {
+		delete(l.Stub.Private[collectionDraftGrades], "REC1")
+	}
+
+	tests := []struct {
+		name    string
+		alter   func(t *testing.T, l *testLedger)
+		cosign  bool // alter the private copy between the two approvals
+		wantErr string
+	}{
+		{"tampered before the first approval", tamper, false, "draft grades for record REC1 do not match the committed hash"},
+		{"tampered before the final approval", tamper, true, "draft grades for record REC1 do not match the committed hash"},
+		{"private copy missing", purge, false, "draft grades for record REC1 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLedger(t)
+			NewTestStudent(t, l, "CS21001", "CSE")
+			NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+
+			approve := func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+				return l.contract.ApproveAcademicRecord(ctx, "REC1", testChecklist, "")
+			}
+			approver := testRegistrar
+			if tt.cosign {
+				ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, approve)
+				approver = testDean
+			}
+			tt.alter(t, l)
+			before := string(l.Stub.State["REC1"])
+
+			ws := ledgertest.InvokeError(t, l, approver, ledgertest.TxOptions{}, tt.wantErr, approve)
+			if len(ws.LedgerWrites()) != 0 || string(l.Stub.State["REC1"]) != before {
+				t.Errorf("refused approval changed the record")
+			}
+		})
+	}
+}
+
+func TestCoursesHashAcceptsLegacyEncoding(t *testing.T) {
+	courses := testCourses(2)
+	legacyJSON, _ := json.Marshal(courses)
+	legacy := sha256.Sum256(legacyJSON)
+	canonical, err := hashCourses(courses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, committed := range []string{canonical, hex.EncodeToString(legacy[:])} {
+		if ok, err := coursesHashMatches(courses, committed); err != nil || !ok {
+			t.Errorf("coursesHashMatches(%s) = %v, %v", committed, ok, err)
+		}
+	}
+	if ok, _ := coursesHashMatches(testCourses(3), canonical); ok {
+		t.Errorf("a different course list matches the committed hash")
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== ISSUANCE ELIGIBILITY ==========
+
+// EligibilityReport lists the requirements a student does not yet meet for a certification type
+type EligibilityReport struct {
+	StudentID         string   `json:"studentId"`
+	CertificationType string   `json:"certificationType"`
+	Eligible          bool     `json:"eligible"`
+	Unmet             []string `json:"unmet"`
+	EarnedCredits     float64  `json:"earnedCredits"`
+	RequiredCredits   float64  `json:"requiredCredits,omitempty"`
+
+	// Filled in for DEGREE checks so departments can see where the record is incomplete
+	StudentStatus         string   `json:"studentStatus,omitempty"`
+	Holds                 []string `json:"holds,omitempty"`                 // active DISCIPLINARY hold IDs
+	OutstandingClearances []string `json:"outstandingClearances,omitempty"` // when the config requires no-dues clearance
+	MissingSemesters      []int    `json:"missingSemesters,omitempty"`      // gaps below the latest recorded semester
+	UnverifiedSemesters   []int    `json:"unverifiedSemesters,omitempty"`   // semesters whose live record is not yet VERIFIED
+
+	// The degree rule set applied, and the student's standing against it
+	RegulationYear int     `json:"regulationYear,omitempty"`
+	CGPA           float64 `json:"cgpa,omitempty"`
+	RequiredCGPA   float64 `json:"requiredCgpa,omitempty"`
+	Backlogs       int     `json:"backlogs,omitempty"`
+	MaxBacklogs    int     `json:"maxBacklogs,omitempty"`
+}
+
+// EligibilityError is returned when issuance is refused; it carries the unmet requirements
+type EligibilityError struct {
+	Report *EligibilityReport
+}
+
+func (e *EligibilityError) Error() string {
+	return fmt.Sprintf("student %s is not eligible for a %s certificate; unmet requirements: [%s]",
+		e.Report.StudentID, e.Report.CertificationType, strings.Join(e.Report.Unmet, "; "))
+}
+
+// adminAttribute is the client certificate attribute marking admin identities, which may issue
+// despite unmet requirements and unfreeze graduated students
+const adminAttribute = "admin"
+
+// checkIssuanceEligibility refuses issuance unless the student meets the requirements for the
+// certification type. An override skips the refusal but is restricted to admin identities and audited.
+func checkIssuanceEligibility(ctx contractapi.TransactionContextInterface, certificateID string, studentID string, certificationType string, override bool) error {
+	if override {
+		if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+			return fmt.Errorf("eligibility override requires the %s=true attribute: %v", adminAttribute, err)
+		}
+	}
+
+	report, err := evaluateEligibility(ctx, studentID, certificationType)
+	if err != nil {
+		return err
+	}
+	if report.Eligible {
+		return nil
+	}
+
+	if !override {
+		return &EligibilityError{Report: report}
+	}
+
+	logAudit(ctx, "EligibilityOverride", "CERTIFICATE", certificateID, fmt.Sprintf("Issued despite unmet requirements: %s", strings.Join(report.Unmet, "; ")))
+	return nil
+}
+
+// GetGraduationEligibility reports whether a student currently meets the DEGREE requirements, using
+// the same evaluation IssueCertificate enforces (university and department orgs only)
+func (s *SmartContract) GetGraduationEligibility(ctx contractapi.TransactionContextInterface, studentID string) (*EligibilityReport, error) {
+	if _, err := requireOrgRole(ctx, "check graduation eligibility", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	return evaluateEligibility(ctx, studentID, "DEGREE")
+}
+
+// evaluateEligibility checks the student against the issuance rules for a certification type:
+// every certificate needs an existing, non-suspended student; a DEGREE needs a live record for
+// every semester up to the latest, all of them verified, and must satisfy the degree rules for the
+// student's department, program and enrollment year;
+// a TRANSCRIPT needs at least one verified record
+func evaluateEligibility(ctx contractapi.TransactionContextInterface, studentID string, certificationType string) (*EligibilityReport, error) {
+	report := &EligibilityReport{
+		StudentID:         studentID,
+		CertificationType: certificationType,
+		Unmet:             []string{},
+	}
+
+	studentJSON, err := ctx.GetStub().GetState(studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if studentJSON == nil {
+		report.Unmet = append(report.Unmet, "student does not exist")
+		return report, nil
+	}
+
+	var student Student
+	if err := json.Unmarshal(studentJSON, &student); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal student: %v", err)
+	}
+	if student.Status == studentStatusSuspended {
+		report.Unmet = append(report.Unmet, "student is SUSPENDED")
+	}
+	if certificationType == "DEGREE" {
+		report.StudentStatus = student.Status
+	}
+	holdIDs, err := disciplinaryHoldIDs(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, holdID := range holdIDs {
+		report.Holds = append(report.Holds, holdID)
+		report.Unmet = append(report.Unmet, fmt.Sprintf("active DISCIPLINARY hold %s", holdID))
+	}
+
+	records, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var verified []*AcademicRecord
+	recorded := map[int]bool{}
+	latestSemester := 0
+	for _, record := range records {
+		switch record.Status {
+		case recordStatusVerified:
+			verified = append(verified, record)
+		case recordStatusSuperseded:
+			// Replaced by a later record; only the replacement counts
+			continue
+		default:
+			if certificationType == "DEGREE" {
+				report.Unmet = append(report.Unmet, fmt.Sprintf("record %s is %s, not VERIFIED", record.RecordID, record.Status))
+				report.UnverifiedSemesters = append(report.UnverifiedSemesters, record.Semester)
+			}
+		}
+		recorded[record.Semester] = true
+		if record.Semester > latestSemester {
+			latestSemester = record.Semester
+		}
+	}
+	sort.SliceStable(verified, func(i, j int) bool {
+		return semesterAfter(verified[j], verified[i])
+	})
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.EarnedCredits = earnedCredits(verified, config.RepeatPolicy)
+
+	switch certificationType {
+	case "DEGREE":
+		if len(verified) == 0 {
+			report.Unmet = append(report.Unmet, "no verified academic records")
+		}
+		for semester := 1; semester < latestSemester; semester++ {
+			if !recorded[semester] {
+				report.MissingSemesters = append(report.MissingSemesters, semester)
+				report.Unmet = append(report.Unmet, fmt.Sprintf("semester %d has no record", semester))
+			}
+		}
+		sort.Ints(report.UnverifiedSemesters)
+		if config.RequireDegreeClearance {
+			clearance, err := readClearance(ctx, studentID)
+			if err != nil {
+				return nil, err
+			}
+			if len(clearance.Outstanding) > 0 {
+				report.OutstandingClearances = clearance.Outstanding
+				report.Unmet = append(report.Unmet, fmt.Sprintf("no-dues clearance outstanding: %s", strings.Join(clearance.Outstanding, ", ")))
+			}
+		}
+
+		rules, err := applicableDegreeRules(ctx, &student)
+		if err != nil {
+			return nil, err
+		}
+		if rules == nil {
+			report.Unmet = append(report.Unmet, fmt.Sprintf("no degree rules defined for %s %s covering enrollment in %s", student.Department, normalizeProgram(student.Program), student.EnrollmentDate))
+			break
+		}
+		report.RegulationYear = rules.RegulationYear
+		report.RequiredCredits = rules.MinCredits
+		report.RequiredCGPA = rules.MinCGPA
+		report.MaxBacklogs = rules.MaxBacklogs
+		report.CGPA, _ = calculateCGPA(verified, config.RepeatPolicy)
+		report.Backlogs = countBacklogs(verified, config.RepeatPolicy)
+		if report.EarnedCredits < report.RequiredCredits {
+			report.Unmet = append(report.Unmet, fmt.Sprintf("earned credits %.2f below required %.2f", report.EarnedCredits, report.RequiredCredits))
+		}
+		if report.CGPA < report.RequiredCGPA {
+			report.Unmet = append(report.Unmet, fmt.Sprintf("CGPA %.2f below required %.2f", report.CGPA, report.RequiredCGPA))
+		}
+		if report.Backlogs > report.MaxBacklogs {
+			report.Unmet = append(report.Unmet, fmt.Sprintf("%d backlogs exceed the permitted %d", report.Backlogs, report.MaxBacklogs))
+		}
+	case "TRANSCRIPT":
+		if len(verified) == 0 {
+			report.Unmet = append(report.Unmet, "no verified academic records")
+		}
+	}
+
+	report.Eligible = len(report.Unmet) == 0
+	return report, nil
+}
+
+// earnedCredits totals the credits of passed courses, counting one attempt of each course as the
+// repeat policy selects
+func earnedCredits(records []*AcademicRecord, repeatPolicy string) float64 {
+	var total int64
+	for _, course := range countedAttempts(records, repeatPolicy) {
+		if course.GradePoint > 0 {
+			total += toHundredths(course.Credits)
+		}
+	}
+	return fromHundredths(total)
+}
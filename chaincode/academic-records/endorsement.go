@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== RECORD ENDORSEMENT POLICY ==========
+
+// Each record key carries its own endorsement policy. While a record moves through the
+// workflow, writes to it need peers of both the owning department and the university, so
+// neither org can change grades alone. Once VERIFIED or SUPERSEDED the record is frozen and
+// only the university, which supersedes records, remains in the policy.
+
+// RecordEndorsementPolicy describes the key-level policy on a record key
+type RecordEndorsementPolicy struct {
+	RecordID string   `json:"recordId"`
+	Status   string   `json:"status"`
+	KeyLevel bool     `json:"keyLevel"` // false: only the chaincode endorsement policy applies
+	Orgs     []string `json:"orgs"`     // every listed org's peers must endorse
+}
+
+// GetRecordEndorsementPolicy returns the orgs whose peers must endorse writes to a record
+func (s *SmartContract) GetRecordEndorsementPolicy(ctx contractapi.TransactionContextInterface, recordID string) (*RecordEndorsementPolicy, error) {
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &RecordEndorsementPolicy{RecordID: recordID, Status: record.Status, Orgs: []string{}}
+
+	ep, err := ctx.GetStub().GetStateValidationParameter(recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endorsement policy: %v", err)
+	}
+	if len(ep) == 0 {
+		return policy, nil
+	}
+
+	keyPolicy, err := statebased.NewStateEP(ep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endorsement policy: %v", err)
+	}
+	policy.KeyLevel = true
+	policy.Orgs = keyPolicy.ListOrgs()
+	sort.Strings(policy.Orgs)
+	return policy, nil
+}
+
+// setRecordEndorsementPolicy sets the key-level policy matching the record's status
+func setRecordEndorsementPolicy(ctx contractapi.TransactionContextInterface, record *AcademicRecord) error {
+	orgs, err := recordEndorsementOrgs(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	keyPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy: %v", err)
+	}
+	if err := keyPolicy.AddOrgs(statebased.RoleTypePeer, orgs...); err != nil {
+		return fmt.Errorf("failed to add orgs to endorsement policy: %v", err)
+	}
+	ep, err := keyPolicy.Policy()
+	if err != nil {
+		return fmt.Errorf("failed to build endorsement policy: %v", err)
+	}
+
+	if err := ctx.GetStub().SetStateValidationParameter(record.RecordID, ep); err != nil {
+		return fmt.Errorf("failed to set endorsement policy: %v", err)
+	}
+	return nil
+}
+
+// recordEndorsementOrgs returns the university orgs, plus the owning department while the
+// record is not frozen
+func recordEndorsementOrgs(ctx contractapi.TransactionContextInterface, record *AcademicRecord) ([]string, error) {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := append([]string{}, config.UniversityOrgs...)
+	if record.Status == recordStatusVerified || record.Status == recordStatusSuperseded {
+		return orgs, nil
+	}
+
+	// Replacements are created by the university; the first department org then stands in
+	department := config.DepartmentOrgs[0]
+	if config.hasRole(record.CreatedBy, orgRoleDepartment) {
+		department = record.CreatedBy
+	}
+	return append(orgs, department), nil
+}
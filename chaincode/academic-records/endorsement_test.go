@@ -0,0 +1,102 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// endorsementOrgs returns the sorted orgs of the key-level policy a transaction set on key,
+// or nil when it set none
+func endorsementOrgs(t *testing.T, ws *ledgertest.WriteSet, key string) []string {
+	t.Helper()
+	var ep []byte
+	for _, op := range ws.Ops(ledgertest.OpSetStateEP) {
+		if op.Key == key {
+			ep = op.Value
+		}
+	}
+	if ep == nil {
+		return nil
+	}
+	policy, err := statebased.NewStateEP(ep)
+	if err != nil {
+		t.Fatalf("failed to parse endorsement policy of %s: %v", key, err)
+	}
+	orgs := policy.ListOrgs()
+	sort.Strings(orgs)
+	return orgs
+}
+
+func TestRecordEndorsementPolicyFollowsStatus(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	both := []string{testExamCell.MSPID, testRegistrar.MSPID}
+	university := []string{testRegistrar.MSPID}
+
+	getPolicy := func(recordID string) func(ctx contractapi.TransactionContextInterface) (*RecordEndorsementPolicy, error) {
+		return func(ctx contractapi.TransactionContextInterface) (*RecordEndorsementPolicy, error) {
+			return l.contract.GetRecordEndorsementPolicy(ctx, recordID)
+		}
+	}
+	assertPolicy := func(recordID string, status string, want []string) {
+		t.Helper()
+		got := ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, getPolicy(recordID))
+		if got.Status != status || !got.KeyLevel || !reflect.DeepEqual(got.Orgs, want) {
+			t.Errorf("policy of %s = %+v, want %s endorsed by %v", recordID, got, status, want)
+		}
+	}
+	_, _, approve := recordActionCall(l, recordActionApprove, "REC1")
+	_, _, verify := recordActionCall(l, recordActionVerify, "REC1")
+
+	_, ws := ledgertest.Invoke(l, testExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2))}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+	})
+	if got := endorsementOrgs(t, ws, "REC1"); !reflect.DeepEqual(got, both) {
+		t.Errorf("policy set on create = %v, want %v", got, both)
+	}
+	assertPolicy("REC1", recordStatusSubmitted, both)
+
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, approve)
+	ledgertest.MustInvoke(t, l, testDean, ledgertest.TxOptions{}, approve)
+	assertPolicy("REC1", recordStatusApproved, both)
+
+	_, ws = ledgertest.Invoke(l, testVerifier, ledgertest.TxOptions{}, verify)
+	if got := endorsementOrgs(t, ws, "REC1"); !reflect.DeepEqual(got, university) {
+		t.Errorf("policy set on verify = %v, want %v", got, university)
+	}
+	assertPolicy("REC1", recordStatusVerified, university)
+
+	supersedeTestRecord(t, l, "REC1", "REC1_V2")
+	assertPolicy("REC1", recordStatusSuperseded, university)
+	// The replacement is created by the university, so the first department org stands in
+	assertPolicy("REC1_V2", recordStatusApproved, both)
+
+	delete(l.Stub.Validation, "REC1")
+	if got := ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, getPolicy("REC1")); got.KeyLevel || len(got.Orgs) != 0 {
+		t.Errorf("policy of a key without one = %+v, want the chaincode policy", got)
+	}
+	ledgertest.InvokeError(t, l, testVerifier, ledgertest.TxOptions{}, "record not found", getPolicy("REC9"))
+}
+
+func TestRecordEndorsementPolicyNamesTheCreatingDepartment(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	cseExamCell := testExamCell.InMSP("CSEDepartmentMSP")
+	l.addFoundingInstitutionOrgs(t, cseExamCell.MSPID)
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.DepartmentOrgs = append(config.DepartmentOrgs, cseExamCell.MSPID)
+	})
+
+	_, ws := ledgertest.Invoke(l, cseExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2))}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+	})
+	want := []string{cseExamCell.MSPID, testRegistrar.MSPID}
+	if got := endorsementOrgs(t, ws, "REC1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("policy set on create = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== CHAINCODE EVENTS ==========
+//
+// Fabric keeps only one event per transaction, so every event payload is an
+// envelope that can carry several lifecycle changes:
+//
+//	{
+//	  "events": [
+//	    {
+//	      "name":        "RecordApproved",       // lifecycle event name, see below
+//	      "assetType":   "RECORD",               // STUDENT, RECORD or CERTIFICATE
+//	      "assetId":     "REC-2024-001",
+//	      "status":      "APPROVED",             // status of the asset after the change
+//	      "org":         "NITWarangalMSP",       // MSP ID of the submitting client
+//	      "txId":        "<transaction id>",
+//	      "txTimestamp": "2024-06-01T10:00:00Z"  // RFC3339, UTC
+//	    }
+//	  ]
+//	}
+//
+// The Fabric event name equals the single entry's name, or a composite name such as
+// RecordSuperseded when a transaction changes several assets.
+
+// Lifecycle event names
+const (
+	eventStudentCreated       = "StudentCreated"
+	eventStudentStatusChanged = "StudentStatusChanged"
+	eventRecordSubmitted      = "RecordSubmitted"
+	eventRecordApproved       = "RecordApproved"
+	eventRecordRejected       = "RecordRejected"
+	eventRecordVerified       = "RecordVerified"
+	eventRecordSuperseded     = "RecordSuperseded"
+	eventCertificateIssued    = "CertificateIssued"
+	eventCertificateRevoked   = "CertificateRevoked"
+	eventCertificateReissued  = "CertificateReissued"
+	eventCertificateFlagged   = "CertificateFlagged"
+)
+
+// LifecycleEvent describes one asset status change
+type LifecycleEvent struct {
+	Name        string `json:"name"`
+	AssetType   string `json:"assetType"`
+	AssetID     string `json:"assetId"`
+	Status      string `json:"status"`
+	Org         string `json:"org"`
+	TxID        string `json:"txId"`
+	TxTimestamp string `json:"txTimestamp"`
+}
+
+// EventPayload is the body of every chaincode event
+type EventPayload struct {
+	Events []*LifecycleEvent `json:"events"`
+}
+
+// newLifecycleEvent fills in the acting org and transaction details for an event
+func newLifecycleEvent(ctx contractapi.TransactionContextInterface, name string, assetType string, assetID string, status string) (*LifecycleEvent, error) {
+	org, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	return &LifecycleEvent{
+		Name:        name,
+		AssetType:   assetType,
+		AssetID:     assetID,
+		Status:      status,
+		Org:         org,
+		TxID:        ctx.GetStub().GetTxID(),
+		TxTimestamp: txTime.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// emitLifecycleEvent sets the transaction's event for a single asset change
+func emitLifecycleEvent(ctx contractapi.TransactionContextInterface, name string, assetType string, assetID string, status string) error {
+	event, err := newLifecycleEvent(ctx, name, assetType, assetID, status)
+	if err != nil {
+		return err
+	}
+	return emitEvents(ctx, name, event)
+}
+
+// emitEvents sets the transaction's single event with all the given changes in its payload
+func emitEvents(ctx contractapi.TransactionContextInterface, eventName string, events ...*LifecycleEvent) error {
+	payload, err := json.Marshal(EventPayload{Events: events})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %v", err)
+	}
+	if err := ctx.GetStub().SetEvent(eventName, payload); err != nil {
+		return fmt.Errorf("failed to set event %s: %v", eventName, err)
+	}
+	return nil
+}
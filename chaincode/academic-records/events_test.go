@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// wantEventPayload is the exact payload of a transaction changing a single asset
+func wantEventPayload(name string, assetType string, assetID string, status string, org string, ws *ledgertest.WriteSet, at time.Time) string {
+	return fmt.Sprintf(`{"events":[{"name":%q,"assetType":%q,"assetId":%q,"status":%q,"org":%q,"txId":%q,"txTimestamp":%q}]}`,
+		name, assetType, assetID, status, org, ws.TxID, at.Format(time.RFC3339))
+}
+
+// assertSingleEvent checks that a transaction set exactly one event with the given name and payload
+func assertSingleEvent(t *testing.T, ws *ledgertest.WriteSet, wantName string, wantPayload string) {
+	t.Helper()
+	if ws.Err != nil {
+		t.Fatalf("transaction failed: %v", ws.Err)
+	}
+	if n := len(ws.Ops(ledgertest.OpSetEvent)); n != 1 {
+		t.Fatalf("transaction set %d events, want 1", n)
+	}
+	name, payload, _ := ws.Event()
+	if name != wantName {
+		t.Errorf("event name = %q, want %q", name, wantName)
+	}
+	if string(payload) != wantPayload {
+		t.Errorf("event payload =\n%s\nwant\n%s", payload, wantPayload)
+	}
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	l := newTestLedger(t)
+	at := func(hours int) time.Time { return ledgertest.Epoch.Add(time.Duration(hours) * time.Hour) }
+
+	steps := []struct {
+		name      string
+		as        *ledgertest.Identity
+		opts      ledgertest.TxOptions
+		run       func(ctx contractapi.TransactionContextInterface) error
+		event     string // empty when the step must not emit one
+		assetType string
+		assetID   string
+		status    string
+	}{
+		{"create student", testRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21001"), At: at(1)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.CreateStudent(ctx, "CS21001", "CSE", "")
+			return err
+		}, eventStudentCreated, "STUDENT", "CS21001", studentStatusActive},
+		{"submit record", testExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(3)), At: at(2)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+			return err
+		}, eventRecordSubmitted, "RECORD", "REC1", recordStatusSubmitted},
+		{"reject record", testRegistrar, ledgertest.TxOptions{At: at(3)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.RejectAcademicRecord(ctx, "REC1", "moderation pending")
+			return err
+		}, eventRecordRejected, "RECORD", "REC1", recordStatusRejected},
+		{"resubmit record", testExamCell, ledgertest.TxOptions{At: at(4)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.SubmitAcademicRecord(ctx, "REC1")
+			return err
+		}, eventRecordSubmitted, "RECORD", "REC1", recordStatusSubmitted},
+		{"first approval", testRegistrar, ledgertest.TxOptions{At: at(5)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.ApproveAcademicRecord(ctx, "REC1", testChecklist, "")
+			return err
+		}, "", "", "", ""},
+		{"final approval", testDean, ledgertest.TxOptions{At: at(6)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.ApproveAcademicRecord(ctx, "REC1", testChecklist, "")
+			return err
+		}, eventRecordApproved, "RECORD", "REC1", recordStatusApproved},
+		{"verify record", testVerifier, ledgertest.TxOptions{At: at(7)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.VerifyAcademicRecord(ctx, "REC1", "", false)
+			return err
+		}, eventRecordVerified, "RECORD", "REC1", recordStatusVerified},
+		{"suspend student", testRegistrar, ledgertest.TxOptions{At: at(8)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.UpdateStudentStatus(ctx, "CS21001", studentStatusSuspended, "fee default")
+			return err
+		}, eventStudentStatusChanged, "STUDENT", "CS21001", studentStatusSuspended},
+		{"reinstate student", testRegistrar, ledgertest.TxOptions{At: at(9)}, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := l.contract.UpdateStudentStatus(ctx, "CS21001", studentStatusActive, "fees paid")
+			return err
+		}, eventStudentStatusChanged, "STUDENT", "CS21001", studentStatusActive},
+	}
+	for _, step := range steps {
+		ws := l.Submit(step.as, step.opts, step.run)
+		if step.event == "" {
+			if ws.Err != nil {
+				t.Fatalf("%s: %v", step.name, ws.Err)
+			}
+			if events := ws.Ops(ledgertest.OpSetEvent); len(events) != 0 {
+				t.Errorf("%s: emitted %s", step.name, events[0].Key)
+			}
+			continue
+		}
+		assertSingleEvent(t, ws, step.event, wantEventPayload(step.event, step.assetType, step.assetID, step.status, step.as.MSPID, ws, step.opts.At))
+	}
+}
+
+func TestCertificateEvents(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	ensureTestTemplate(t, l, "BONAFIDE", l.storedStudent(t, "CS21001"))
+
+	issuedAt := ledgertest.Epoch.Add(24 * time.Hour)
+	_, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{At: issuedAt}, func(ctx contractapi.TransactionContextInterface) (*Certificate, error) {
+		return l.contract.IssueCertificate(ctx, "CERT1", "CS21001", "BONAFIDE", 30, false, "")
+	})
+	assertSingleEvent(t, ws, eventCertificateIssued, wantEventPayload(eventCertificateIssued, "CERTIFICATE", "CERT1", certStatusIssued, testRegistrar.MSPID, ws, issuedAt))
+
+	revokedAt := issuedAt.Add(time.Hour)
+	_, ws = ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{At: revokedAt}, func(ctx contractapi.TransactionContextInterface) (*Certificate, error) {
+		return l.contract.RevokeCertificate(ctx, "CERT1", "ADMIN_ERROR", "issued twice")
+	})
+	assertSingleEvent(t, ws, eventCertificateRevoked, wantEventPayload(eventCertificateRevoked, "CERTIFICATE", "CERT1", certStatusRevoked, testRegistrar.MSPID, ws, revokedAt))
+}
+
+func TestSupersedeEmitsOneCompositeEvent(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+	verifyTestRecord(t, l, "REC1")
+	NewTestCertificate(t, l, "TR1", "CS21001", "TRANSCRIPT")
+
+	courses := l.storedRecord(t, "REC1").Courses
+	courses[1].Grade, courses[1].GradePoint = "A", 10
+	coursesJSON := string(coursesTransient(courses)[transientCourses])
+	supersededAt := ledgertest.Epoch.Add(48 * time.Hour)
+	_, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{At: supersededAt}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.SupersedeAcademicRecord(ctx, "REC1", "REC1_V2", coursesJSON, "revaluation")
+	})
+
+	entry := func(name string, assetType string, assetID string, status string) string {
+		return fmt.Sprintf(`{"name":%q,"assetType":%q,"assetId":%q,"status":%q,"org":%q,"txId":%q,"txTimestamp":%q}`,
+			name, assetType, assetID, status, testRegistrar.MSPID, ws.TxID, supersededAt.Format(time.RFC3339))
+	}
+	want := `{"events":[` +
+		entry(eventRecordSuperseded, "RECORD", "REC1", recordStatusSuperseded) + "," +
+		entry(eventRecordApproved, "RECORD", "REC1_V2", recordStatusApproved) + "," +
+		entry(eventCertificateFlagged, "CERTIFICATE", "TR1", certStatusIssued) + `]}`
+	assertSingleEvent(t, ws, eventRecordSuperseded, want)
+}
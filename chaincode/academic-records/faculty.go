@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== FACULTY ==========
+
+// Faculty is an instructor who may award course grades for their department's courses
+type Faculty struct {
+	DocType    string `json:"docType"`
+	FacultyID  string `json:"facultyId"`
+	Name       string `json:"name"`
+	Department string `json:"department"`
+	Status     string `json:"status"` // ACTIVE, INACTIVE
+	CreatedBy  string `json:"createdBy"`
+	CreatedAt  string `json:"createdAt"`
+	UpdatedAt  string `json:"updatedAt"`
+}
+
+// Faculty statuses
+const (
+	facultyStatusActive   = "ACTIVE"
+	facultyStatusInactive = "INACTIVE"
+)
+
+// facultyObjectType prefixes the composite keys of faculty members
+const facultyObjectType = "faculty"
+
+// FacultyGrade is one grade an instructor awarded, with the record it belongs to
+type FacultyGrade struct {
+	RecordID     string      `json:"recordId"`
+	StudentID    string      `json:"studentId"`
+	Semester     int         `json:"semester"`
+	Year         int         `json:"year"`
+	RecordStatus string      `json:"recordStatus"`
+	Course       CourseGrade `json:"course"`
+}
+
+// CreateFaculty registers an ACTIVE faculty member (department identities, for their own department)
+func (s *SmartContract) CreateFaculty(ctx contractapi.TransactionContextInterface, facultyID string, name string, department string) (*Faculty, error) {
+	creatorOrg, err := requireOrgRole(ctx, "manage faculty", orgRoleDepartment)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireOwnDepartment(ctx, department); err != nil {
+		return nil, err
+	}
+
+	facultyID = strings.TrimSpace(facultyID)
+	if facultyID == "" {
+		return nil, fmt.Errorf("faculty ID is required")
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("faculty name is required")
+	}
+	existing, err := readFaculty(ctx, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("faculty %s already exists", facultyID)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	faculty := Faculty{
+		DocType:    docTypeFaculty,
+		FacultyID:  facultyID,
+		Name:       strings.TrimSpace(name),
+		Department: department,
+		Status:     facultyStatusActive,
+		CreatedBy:  creatorOrg,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := putFaculty(ctx, &faculty); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "CreateFaculty", "FACULTY", facultyID, fmt.Sprintf("Registered faculty %s in %s", facultyID, department))
+
+	return &faculty, nil
+}
+
+// UpdateFacultyStatus activates or deactivates a faculty member (department identities of
+// their department). Inactive faculty cannot be named on new grades.
+func (s *SmartContract) UpdateFacultyStatus(ctx contractapi.TransactionContextInterface, facultyID string, status string) (*Faculty, error) {
+	if _, err := requireOrgRole(ctx, "manage faculty", orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	if status != facultyStatusActive && status != facultyStatusInactive {
+		return nil, fmt.Errorf("invalid faculty status %q: must be %s or %s", status, facultyStatusActive, facultyStatusInactive)
+	}
+
+	faculty, err := readFaculty(ctx, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	if faculty == nil {
+		return nil, fmt.Errorf("faculty %s does not exist", facultyID)
+	}
+	if err := requireOwnDepartment(ctx, faculty.Department); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	oldStatus := faculty.Status
+	faculty.Status = status
+	faculty.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+	if err := putFaculty(ctx, faculty); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "UpdateFacultyStatus", "FACULTY", facultyID, fmt.Sprintf("Status changed from %s to %s", oldStatus, status))
+
+	return faculty, nil
+}
+
+// GetFaculty returns a faculty member
+func (s *SmartContract) GetFaculty(ctx contractapi.TransactionContextInterface, facultyID string) (*Faculty, error) {
+	faculty, err := readFaculty(ctx, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	if faculty == nil {
+		return nil, fmt.Errorf("faculty %s does not exist", facultyID)
+	}
+	return faculty, nil
+}
+
+// GetGradesByFaculty lists the grades an instructor awarded in one semester of one year, for
+// NITWarangal or identities of the instructor's own department
+func (s *SmartContract) GetGradesByFaculty(ctx contractapi.TransactionContextInterface, facultyID string, semester int, year int) ([]*FacultyGrade, error) {
+	callerOrg, err := requireOrgRole(ctx, "review faculty grades", orgRoleUniversity, orgRoleDepartment)
+	if err != nil {
+		return nil, err
+	}
+
+	faculty, err := readFaculty(ctx, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	if faculty == nil {
+		return nil, fmt.Errorf("faculty %s does not exist", facultyID)
+	}
+	isUniversity, err := orgHasRole(ctx, callerOrg, orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if !isUniversity {
+		if err := requireOwnDepartment(ctx, faculty.Department); err != nil {
+			return nil, err
+		}
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("grade~faculty", []string{facultyID, strconv.Itoa(year), strconv.Itoa(semester)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query faculty grades: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	grades := []*FacultyGrade{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 4 {
+			continue
+		}
+
+		record, err := readAcademicRecord(ctx, compositeKeyParts[3])
+		if err != nil {
+			continue
+		}
+		// Published records carry their courses; unapproved ones keep them in the draft collection
+		courses := record.Courses
+		if len(courses) == 0 {
+			courses, err = readDraftCourses(ctx, record)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// The index is not cleaned up when a course list is replaced, so match on the current list
+		for _, course := range courses {
+			if course.InstructorID == facultyID {
+				grades = append(grades, &FacultyGrade{
+					RecordID:     record.RecordID,
+					StudentID:    record.StudentID,
+					Semester:     record.Semester,
+					Year:         record.Year,
+					RecordStatus: record.Status,
+					Course:       course,
+				})
+			}
+		}
+	}
+
+	return grades, nil
+}
+
+// readFaculty reads a faculty member, returning nil if there is none
+func readFaculty(ctx contractapi.TransactionContextInterface, facultyID string) (*Faculty, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(facultyObjectType, []string{facultyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create faculty key: %v", err)
+	}
+	facultyJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if facultyJSON == nil {
+		return nil, nil
+	}
+
+	var faculty Faculty
+	if err := json.Unmarshal(facultyJSON, &faculty); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal faculty: %v", err)
+	}
+	return &faculty, nil
+}
+
+// putFaculty stores a faculty member under its composite key
+func putFaculty(ctx contractapi.TransactionContextInterface, faculty *Faculty) error {
+	key, err := ctx.GetStub().CreateCompositeKey(facultyObjectType, []string{faculty.FacultyID})
+	if err != nil {
+		return fmt.Errorf("failed to create faculty key: %v", err)
+	}
+	facultyJSON, err := json.Marshal(faculty)
+	if err != nil {
+		return fmt.Errorf("failed to marshal faculty: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, facultyJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
+
+// applyInstructors requires every course to name an ACTIVE instructor of its offering
+// department. Like the catalog check it is skipped for legacy imports.
+func applyInstructors(ctx contractapi.TransactionContextInterface, courses []CourseGrade) error {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if config.AllowUncataloguedCourses {
+		return nil
+	}
+
+	for _, grade := range courses {
+		if strings.TrimSpace(grade.InstructorID) == "" {
+			return fmt.Errorf("course %s: instructor ID is required", grade.CourseCode)
+		}
+		faculty, err := readFaculty(ctx, grade.InstructorID)
+		if err != nil {
+			return err
+		}
+		if faculty == nil {
+			return fmt.Errorf("course %s: instructor %s does not exist", grade.CourseCode, grade.InstructorID)
+		}
+		if faculty.Status != facultyStatusActive {
+			return fmt.Errorf("course %s: instructor %s is %s", grade.CourseCode, grade.InstructorID, faculty.Status)
+		}
+		course, err := readCourse(ctx, grade.CourseCode)
+		if err != nil {
+			return err
+		}
+		if course != nil && course.Department != faculty.Department {
+			return fmt.Errorf("course %s is offered by %s but instructor %s belongs to %s", grade.CourseCode, course.Department, grade.InstructorID, faculty.Department)
+		}
+	}
+	return nil
+}
+
+// putInstructorIndexes indexes a record under each instructor named on its courses
+func putInstructorIndexes(ctx contractapi.TransactionContextInterface, record *AcademicRecord, courses []CourseGrade) error {
+	indexed := map[string]bool{}
+	for _, course := range courses {
+		if course.InstructorID == "" || indexed[course.InstructorID] {
+			continue
+		}
+		indexed[course.InstructorID] = true
+		if err := putIndex(ctx, "grade~faculty", []string{course.InstructorID, strconv.Itoa(record.Year), strconv.Itoa(record.Semester), record.RecordID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
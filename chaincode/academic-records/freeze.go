@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== GRADUATION FREEZE ==========
+
+// A GRADUATED student is frozen: the status cannot be changed through UpdateStudentStatus, and
+// no record of the student can be created or moved through the workflow. Grades can still be
+// corrected with SupersedeAcademicRecord; anything else needs UnfreezeStudent first.
+
+// studentFrozen reports whether the student's profile and records are immutable
+func studentFrozen(student *Student) bool {
+	return student.Status == studentStatusGraduated
+}
+
+// frozenStudentError explains why a change was refused and how to lift the freeze
+func frozenStudentError(studentID string) error {
+	return fmt.Errorf("student %s is frozen because they have GRADUATED; correct grades with SupersedeAcademicRecord, "+
+		"or have an identity with %s=true call UnfreezeStudent with a reason", studentID, adminAttribute)
+}
+
+// assertStudentNotFrozen fails if the student has graduated
+func assertStudentNotFrozen(ctx contractapi.TransactionContextInterface, studentID string) error {
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if studentFrozen(student) {
+		return frozenStudentError(studentID)
+	}
+	return nil
+}
+
+// UnfreezeStudent moves a GRADUATED student back to another status (university admin identities
+// only). The reason is kept in the audit log.
+func (s *SmartContract) UnfreezeStudent(ctx contractapi.TransactionContextInterface, studentID string, status string, reason string) (*Student, error) {
+	if _, err := requireOrgRole(ctx, "unfreeze students", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("unfreezing a student requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to unfreeze a student")
+	}
+	if !validStudentStatuses[status] || status == studentStatusGraduated {
+		return nil, fmt.Errorf("invalid unfreeze status %q: must be ACTIVE or SUSPENDED", status)
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !studentFrozen(student) {
+		return nil, fmt.Errorf("student %s is %s, not frozen", studentID, student.Status)
+	}
+
+	if err := setStudentStatus(ctx, student, status, reason); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "UnfreezeStudent", "STUDENT", studentID, fmt.Sprintf("GRADUATED -> %s: %s", status, reason))
+
+	if err := emitLifecycleEvent(ctx, eventStudentStatusChanged, "STUDENT", studentID, status); err != nil {
+		return nil, err
+	}
+
+	return student, nil
+}
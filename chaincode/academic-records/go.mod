@@ -2,10 +2,15 @@ module github.com/nit-warangal/academic-records
 
 go 1.21
 
-require github.com/hyperledger/fabric-contract-api-go v1.2.2
+require (
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20230731094759-d626e9ab09b9
+	github.com/hyperledger/fabric-contract-api-go v1.2.2
+)
 
 require (
 	github.com/golang/protobuf v1.5.3
 	github.com/hyperledger/fabric-protos-go v0.3.3
 	google.golang.org/protobuf v1.31.0
 )
+
+require golang.org/x/crypto v0.14.0
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== GPA MATH ==========
+
+// GPA math runs on integer hundredths (credits and grade points are scaled by 100) so the
+// result is identical on every endorser; values become float64 only at the JSON boundary.
+
+// toHundredths converts a JSON number to integer hundredths, rounding half up.
+// The explicit float64 conversion stops the compiler fusing the multiply-add into an FMA,
+// which would round differently on some architectures.
+func toHundredths(value float64) int64 {
+	return int64(math.Floor(float64(value*100) + 0.5))
+}
+
+// fromHundredths converts integer hundredths back to a JSON number
+func fromHundredths(value int64) float64 {
+	return float64(value) / 100
+}
+
+// gpaHundredths returns the credit-weighted grade point average in hundredths, rounded half up
+func gpaHundredths(courses []CourseGrade) int64 {
+	var totalPoints int64  // grade point x credits, in ten-thousandths
+	var totalCredits int64 // in hundredths
+
+	for _, course := range courses {
+		credits := toHundredths(course.Credits)
+		totalPoints += toHundredths(course.GradePoint) * credits
+		totalCredits += credits
+	}
+
+	if totalCredits <= 0 {
+		return 0
+	}
+
+	// (2p + c) / 2c == round-half-up of p / c for non-negative p
+	return (2*totalPoints + totalCredits) / (2 * totalCredits)
+}
+
+// calculateSGPA calculates semester GPA
+func calculateSGPA(courses []CourseGrade) float64 {
+	return fromHundredths(gpaHundredths(courses))
+}
+
+// ========== GRADE SCALE ==========
+//
+// Grade scales are versioned by regulation year: a record is graded on the newest scale whose
+// regulation year is not after the record's, which is the student's enrollment year unless the
+// record names one. Regulations older than every versioned scale use the unversioned scale under
+// gradeScaleKey, which graded every record written before scales were versioned. Grade points
+// are stored on each course when it is graded, so GPAs always follow the scale of their record.
+// A scale can be replaced only until a record has been graded on it.
+
+// GradeScale maps grade letters to grade points; it is stored on the ledger so
+// NITWarangal can amend it without a chaincode upgrade
+type GradeScale struct {
+	DocType        string             `json:"docType"`
+	RegulationYear int                `json:"regulationYear,omitempty"` // 0 for the unversioned scale
+	Grades         map[string]float64 `json:"grades"`
+	UpdatedBy      string             `json:"updatedBy"`
+	UpdatedAt      string             `json:"updatedAt"`
+}
+
+// gradeScaleKey is the world state key of the unversioned grade scale config asset
+const gradeScaleKey = "CONFIG_GRADESCALE"
+
+// gradeScaleObjectType prefixes the composite keys of versioned grade scales
+const gradeScaleObjectType = "GRADE_SCALE"
+
+// defaultGradeScale is used until NITWarangal stores its own scale
+var defaultGradeScale = map[string]float64{
+	"A": 10,
+	"B": 8,
+	"C": 6,
+	"D": 4,
+	"F": 0,
+}
+
+// GetGradeScale returns the grade scale in force for a regulation year; 0 returns the
+// unversioned scale
+func (s *SmartContract) GetGradeScale(ctx contractapi.TransactionContextInterface, regulationYear int) (*GradeScale, error) {
+	if regulationYear == 0 {
+		return getGradeScale(ctx)
+	}
+	return gradeScaleForRegulation(ctx, regulationYear)
+}
+
+// ListGradeScales returns the unversioned scale followed by every versioned scale, oldest first
+func (s *SmartContract) ListGradeScales(ctx contractapi.TransactionContextInterface) ([]*GradeScale, error) {
+	unversioned, err := getGradeScale(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versioned, err := queryGradeScales(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*GradeScale{unversioned}, versioned...), nil
+}
+
+// UpdateGradeScale stores the grade scale of a regulation year (NITWarangal only). A new
+// regulation year creates a new version; an existing one may be replaced only while no record
+// has been graded on it. The unversioned scale cannot be changed.
+func (s *SmartContract) UpdateGradeScale(ctx contractapi.TransactionContextInterface, regulationYear int, gradesJSON string) (*GradeScale, error) {
+	creatorOrg, err := requireOrgRole(ctx, "update the grade scale", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var grades map[string]float64
+	if err := json.Unmarshal([]byte(gradesJSON), &grades); err != nil {
+		return nil, fmt.Errorf("invalid grades JSON: %v", err)
+	}
+	if len(grades) == 0 {
+		return nil, fmt.Errorf("grade scale must define at least one grade")
+	}
+
+	normalized := map[string]float64{}
+	for letter, point := range grades {
+		letter = normalizeGrade(letter)
+		if letter == "" {
+			return nil, fmt.Errorf("grade scale contains an empty grade letter")
+		}
+		if point < 0 || point > 10 {
+			return nil, fmt.Errorf("grade point for %s must be between 0 and 10", letter)
+		}
+		normalized[letter] = point
+	}
+
+	key, err := gradeScaleVersionKey(ctx, regulationYear)
+	if err != nil {
+		return nil, err
+	}
+	existingJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grade scale: %v", err)
+	}
+	if existingJSON != nil {
+		referenced, err := gradeScaleReferenced(ctx, regulationYear)
+		if err != nil {
+			return nil, err
+		}
+		if referenced {
+			return nil, fmt.Errorf("records have been graded on the %d grade scale; create a scale for a later regulation year instead", regulationYear)
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	scale := GradeScale{
+		DocType:        docTypeGradeScale,
+		RegulationYear: regulationYear,
+		Grades:         normalized,
+		UpdatedBy:      creatorOrg,
+		UpdatedAt:      txTime.UTC().Format(time.RFC3339),
+	}
+
+	scaleJSON, err := json.Marshal(scale)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal grade scale: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, scaleJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	if existingJSON == nil {
+		logAudit(ctx, "UpdateGradeScale", "CONFIG", key, fmt.Sprintf("Grade scale for regulation %d created: %s", regulationYear, string(scaleJSON)))
+	} else {
+		logAudit(ctx, "UpdateGradeScale", "CONFIG", key, fmt.Sprintf("Grade scale for regulation %d changed from %s to %s", regulationYear, string(existingJSON), string(scaleJSON)))
+	}
+
+	return &scale, nil
+}
+
+// getGradeScale reads the unversioned grade scale, falling back to the default
+func getGradeScale(ctx contractapi.TransactionContextInterface) (*GradeScale, error) {
+	scaleJSON, err := ctx.GetStub().GetState(gradeScaleKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grade scale: %v", err)
+	}
+	if scaleJSON == nil {
+		return &GradeScale{DocType: docTypeGradeScale, Grades: defaultGradeScale}, nil
+	}
+
+	var scale GradeScale
+	if err := json.Unmarshal(scaleJSON, &scale); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal grade scale: %v", err)
+	}
+	return &scale, nil
+}
+
+// gradeScaleForRegulation returns the newest versioned scale not after regulationYear, or the
+// unversioned scale when every version is newer
+func gradeScaleForRegulation(ctx contractapi.TransactionContextInterface, regulationYear int) (*GradeScale, error) {
+	scales, err := queryGradeScales(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var selected *GradeScale
+	for _, scale := range scales {
+		if scale.RegulationYear <= regulationYear {
+			selected = scale
+		}
+	}
+	if selected == nil {
+		return getGradeScale(ctx)
+	}
+	return selected, nil
+}
+
+// gradeScaleForStudent returns the scale a new record of the student is graded on, with the
+// regulation year it was selected for: regulationYear if given, otherwise the enrollment year
+func gradeScaleForStudent(ctx contractapi.TransactionContextInterface, student *Student, regulationYear int) (*GradeScale, int, error) {
+	if regulationYear == 0 {
+		enrolledAt, err := time.Parse(time.RFC3339, student.EnrollmentDate)
+		if err != nil {
+			return nil, 0, fmt.Errorf("student %s has an invalid enrollment date: %v", student.StudentID, err)
+		}
+		regulationYear = enrolledAt.Year()
+	}
+	if regulationYear < 1900 || regulationYear > 9999 {
+		return nil, 0, fmt.Errorf("invalid regulation year %d", regulationYear)
+	}
+	scale, err := gradeScaleForRegulation(ctx, regulationYear)
+	if err != nil {
+		return nil, 0, err
+	}
+	return scale, regulationYear, nil
+}
+
+// gradeScaleForRecord returns the scale an existing record is graded on; records written before
+// scales were versioned use the unversioned scale
+func gradeScaleForRecord(ctx contractapi.TransactionContextInterface, record *AcademicRecord) (*GradeScale, error) {
+	if record.GradeScaleYear == 0 {
+		return getGradeScale(ctx)
+	}
+
+	key, err := gradeScaleVersionKey(ctx, record.GradeScaleYear)
+	if err != nil {
+		return nil, err
+	}
+	scaleJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grade scale: %v", err)
+	}
+	if scaleJSON == nil {
+		return nil, fmt.Errorf("grade scale for regulation %d of record %s does not exist", record.GradeScaleYear, record.RecordID)
+	}
+	var scale GradeScale
+	if err := json.Unmarshal(scaleJSON, &scale); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal grade scale: %v", err)
+	}
+	return &scale, nil
+}
+
+// gradeScaleVersionKey builds the key of a versioned grade scale
+func gradeScaleVersionKey(ctx contractapi.TransactionContextInterface, regulationYear int) (string, error) {
+	if regulationYear < 1900 || regulationYear > 9999 {
+		return "", fmt.Errorf("invalid regulation year %d", regulationYear)
+	}
+	return ctx.GetStub().CreateCompositeKey(gradeScaleObjectType, []string{fmt.Sprintf("%04d", regulationYear)})
+}
+
+// gradeScaleReferenced reports whether any record has been graded on a versioned scale
+func gradeScaleReferenced(ctx contractapi.TransactionContextInterface, regulationYear int) (bool, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("gradescale~record", []string{fmt.Sprintf("%04d", regulationYear)})
+	if err != nil {
+		return false, fmt.Errorf("failed to query grade scale records: %v", err)
+	}
+	defer resultsIterator.Close()
+	return resultsIterator.HasNext(), nil
+}
+
+// queryGradeScales returns every versioned grade scale, oldest regulation first
+func queryGradeScales(ctx contractapi.TransactionContextInterface) ([]*GradeScale, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(gradeScaleObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grade scales: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	scales := []*GradeScale{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var scale GradeScale
+		if err := json.Unmarshal(response.Value, &scale); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal grade scale: %v", err)
+		}
+		scales = append(scales, &scale)
+	}
+	return scales, nil
+}
+
+// normalizeGrade trims and upper-cases a grade letter so "a " and "A" are the same grade
+func normalizeGrade(grade string) string {
+	return strings.ToUpper(strings.TrimSpace(grade))
+}
+
+// applyGradeScale derives each course's grade point from its letter. supplied holds the grade
+// points a client sent, nil where a course omitted one; every supplied value, zero included,
+// must match the scale. Callers re-grading stored courses pass a nil slice.
+func applyGradeScale(scale *GradeScale, courses []CourseGrade, supplied []*float64) error {
+	for i := range courses {
+		letter := normalizeGrade(courses[i].Grade)
+		if letter == "" {
+			return fmt.Errorf("course %s: missing grade", courses[i].CourseCode)
+		}
+
+		point, ok := scale.Grades[letter]
+		if !ok {
+			return fmt.Errorf("course %s: unknown grade %q", courses[i].CourseCode, courses[i].Grade)
+		}
+
+		if i < len(supplied) && supplied[i] != nil && toHundredths(*supplied[i]) != toHundredths(point) {
+			return fmt.Errorf("course %s: grade point %v does not match grade %s (%v)", courses[i].CourseCode, *supplied[i], letter, point)
+		}
+
+		courses[i].Grade = letter
+		courses[i].GradePoint = point
+	}
+	return nil
+}
+
+// ========== CGPA ==========
+
+// SemesterGPA is one semester's contribution to a student's CGPA
+type SemesterGPA struct {
+	RecordID string  `json:"recordId"`
+	Semester int     `json:"semester"`
+	Year     int     `json:"year"`
+	SGPA     float64 `json:"sgpa"`
+	Credits  float64 `json:"credits"`
+	Status   string  `json:"status"`
+}
+
+// CGPAReport is the cumulative GPA together with the semesters used to compute it
+type CGPAReport struct {
+	StudentID    string         `json:"studentId"`
+	CGPA         float64        `json:"cgpa"`
+	TotalCredits float64        `json:"totalCredits"`
+	Semesters    []*SemesterGPA `json:"semesters"`
+}
+
+// GetStudentCGPA computes the student's current CGPA from their approved and verified records
+func (s *SmartContract) GetStudentCGPA(ctx contractapi.TransactionContextInterface, studentID string) (*CGPAReport, error) {
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+
+	records, err := cgpaRecords(ctx, studentID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CGPAReport{
+		StudentID: studentID,
+		Semesters: []*SemesterGPA{},
+	}
+	for _, record := range records {
+		var credits int64
+		for _, course := range record.Courses {
+			credits += toHundredths(course.Credits)
+		}
+		report.Semesters = append(report.Semesters, &SemesterGPA{
+			RecordID: record.RecordID,
+			Semester: record.Semester,
+			Year:     record.Year,
+			SGPA:     record.SGPA,
+			Credits:  fromHundredths(credits),
+			Status:   record.Status,
+		})
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.CGPA, report.TotalCredits = calculateCGPA(records, config.RepeatPolicy)
+
+	return report, nil
+}
+
+// countsTowardCGPA reports whether a record in this status is part of the cumulative GPA
+func countsTowardCGPA(status string) bool {
+	return status == recordStatusApproved || status == recordStatusVerified
+}
+
+// cgpaRecords loads the student's approved/verified records sorted by (year, semester).
+// If current is given it is included regardless of status, replacing any stored record
+// for the same semester, and only semesters up to and including it are returned.
+func cgpaRecords(ctx contractapi.TransactionContextInterface, studentID string, current *AcademicRecord) ([]*AcademicRecord, error) {
+	stored, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*AcademicRecord
+	for _, record := range stored {
+		if current != nil && (sameSemester(record, current) || semesterAfter(record, current)) {
+			continue
+		}
+		if countsTowardCGPA(record.Status) {
+			records = append(records, record)
+		}
+	}
+	if current != nil {
+		records = append(records, current)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return semesterAfter(records[j], records[i])
+	})
+	return records, nil
+}
+
+// semesterAfter reports whether record a belongs to a later semester than record b
+func semesterAfter(a, b *AcademicRecord) bool {
+	if a.Year != b.Year {
+		return a.Year > b.Year
+	}
+	return a.Semester > b.Semester
+}
+
+// sameSemester reports whether two records cover the same semester
+func sameSemester(a, b *AcademicRecord) bool {
+	return a.Year == b.Year && a.Semester == b.Semester
+}
+
+// calculateCGPA computes the cumulative GPA over records sorted oldest first, counting one
+// attempt of each repeated course as the repeat policy selects
+func calculateCGPA(records []*AcademicRecord, repeatPolicy string) (float64, float64) {
+	courses := countedAttempts(records, repeatPolicy)
+
+	var totalCredits int64
+	for _, course := range courses {
+		totalCredits += toHundredths(course.Credits)
+	}
+
+	return calculateSGPA(courses), fromHundredths(totalCredits)
+}
+
+// countedAttempts picks the attempt of each course that counts toward CGPA from records sorted
+// oldest first: the latest one, or under the BEST policy the one with the most grade points
+// (the later one on a tie). Courses come back in the order they were first taken.
+func countedAttempts(records []*AcademicRecord, repeatPolicy string) []CourseGrade {
+	counted := map[string]CourseGrade{}
+	var order []string
+	for _, record := range records {
+		for _, course := range record.Courses {
+			previous, seen := counted[course.CourseCode]
+			if !seen {
+				order = append(order, course.CourseCode)
+			}
+			if seen && repeatPolicy == repeatPolicyBest && toHundredths(course.GradePoint) < toHundredths(previous.GradePoint) {
+				continue
+			}
+			counted[course.CourseCode] = course
+		}
+	}
+
+	courses := make([]CourseGrade, 0, len(order))
+	for _, code := range order {
+		courses = append(courses, counted[code])
+	}
+	return courses
+}
+
+// getStudentRecordList walks the record~student index without pagination so it can be
+// used inside submit transactions
+func getStudentRecordList(ctx contractapi.TransactionContextInterface, studentID string) ([]*AcademicRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~student", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var records []*AcademicRecord
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		record, err := readAcademicRecord(ctx, compositeKeyParts[1])
+		if err == nil {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
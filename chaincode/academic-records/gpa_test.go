@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// gradedCourses builds courses from grade point and credit pairs
+func gradedCourses(pairs ...[2]float64) []CourseGrade {
+	courses := make([]CourseGrade, len(pairs))
+	for i, pair := range pairs {
+		courses[i] = CourseGrade{CourseCode: fmt.Sprintf("C%04d", i), GradePoint: pair[0], Credits: pair[1]}
+	}
+	return courses
+}
+
+func TestCalculateSGPARounding(t *testing.T) {
+	alternating := make([][2]float64, 0, 5000)
+	for i := 0; i < 2500; i++ {
+		alternating = append(alternating, [2]float64{10, 4}, [2]float64{8, 3})
+	}
+
+	tests := []struct {
+		name    string
+		courses []CourseGrade
+		want    float64
+	}{
+		{"no courses", gradedCourses(), 0},
+		{"zero credits", gradedCourses([2]float64{10, 0}), 0},
+		{"zero-credit course is ignored", gradedCourses([2]float64{10, 0}, [2]float64{8, 4}), 8},
+		{"exact average", gradedCourses([2]float64{10, 3}, [2]float64{8, 3}), 9},
+		{"8.995 rounds half up", gradedCourses([2]float64{8.99, 1}, [2]float64{9, 1}), 9},
+		{"8.9933 rounds down", gradedCourses([2]float64{8.99, 2}, [2]float64{9, 1}), 8.99},
+		{"8.999 is not truncated", gradedCourses([2]float64{9, 999}, [2]float64{8, 1}), 9},
+		{"6.857 rounds up", gradedCourses([2]float64{10, 3}, [2]float64{8, 4}, [2]float64{6, 3}, [2]float64{4, 4}), 6.86},
+		{"fractional credits", gradedCourses([2]float64{10, 1.5}, [2]float64{6, 4.5}), 7},
+		{"large credit totals", gradedCourses([2]float64{10, 1e9}, [2]float64{9, 1e9}, [2]float64{4, 1e9}), 7.67},
+		{"many courses", gradedCourses(alternating...), 9.14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateSGPA(tt.courses); got != tt.want {
+				t.Errorf("calculateSGPA = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateCGPAMatchesSGPA(t *testing.T) {
+	tests := []struct {
+		name        string
+		semesters   [][]CourseGrade
+		wantCGPA    float64
+		wantCredits float64
+	}{
+		{"8.995 across two semesters", [][]CourseGrade{
+			{{CourseCode: "MA101", GradePoint: 8.99, Credits: 1}},
+			{{CourseCode: "MA102", GradePoint: 9, Credits: 1}},
+		}, 9, 2},
+		{"zero credits", [][]CourseGrade{
+			{{CourseCode: "NSS101", GradePoint: 10, Credits: 0}},
+		}, 0, 0},
+		{"large credit totals", [][]CourseGrade{
+			{{CourseCode: "MA101", GradePoint: 10, Credits: 1e9}},
+			{{CourseCode: "MA102", GradePoint: 9, Credits: 1e9}},
+			{{CourseCode: "MA103", GradePoint: 4, Credits: 1e9}},
+		}, 7.67, 3e9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []*AcademicRecord
+			var all []CourseGrade
+			for i, courses := range tt.semesters {
+				records = append(records, &AcademicRecord{Semester: i + 1, Courses: courses})
+				all = append(all, courses...)
+			}
+
+			cgpa, credits := calculateCGPA(records, repeatPolicyLatest)
+			if cgpa != tt.wantCGPA || credits != tt.wantCredits {
+				t.Errorf("calculateCGPA = %v over %v credits, want %v over %v", cgpa, credits, tt.wantCGPA, tt.wantCredits)
+			}
+			if sgpa := calculateSGPA(all); sgpa != cgpa {
+				t.Errorf("CGPA %v differs from the SGPA %v of the same courses", cgpa, sgpa)
+			}
+		})
+	}
+}
+
+func TestCreateRecordDerivesGradePoints(t *testing.T) {
+	course := func(grade string, gradePoint string) string {
+		c := `{"courseCode":"CS201","courseName":"Data Structures","credits":4,"grade":` + grade
+		if gradePoint != "" {
+			c += `,"gradePoint":` + gradePoint
+		}
+		return "[" + c + "}]"
+	}
+
+	tests := []struct {
+		name       string
+		courses    string
+		wantErr    string
+		wantGrade  string
+		wantPoints float64
+	}{
+		{"matching grade point", course(`"B"`, "8"), "", "B", 8},
+		{"omitted grade point", course(`"C"`, ""), "", "C", 6},
+		{"lowercase letter", course(`"a"`, "10"), "", "A", 10},
+		{"padded lowercase letter", course(`" d "`, ""), "", "D", 4},
+		{"explicit zero for F", course(`"F"`, "0"), "", "F", 0},
+		{"inflated grade point", course(`"F"`, "10"), "grade point 10 does not match grade F", "", 0},
+		{"zero grade point for A", course(`"A"`, "0"), "grade point 0 does not match grade A", "", 0},
+		{"grade point of another letter", course(`"b"`, "10"), "grade point 10 does not match grade B", "", 0},
+		{"empty letter", course(`""`, "10"), "missing grade", "", 0},
+		{"blank letter", course(`"  "`, ""), "missing grade", "", 0},
+		{"absent letter", `[{"courseCode":"CS201","courseName":"Data Structures","credits":4,"gradePoint":8}]`, "missing grade", "", 0},
+		{"unknown letter", course(`"E"`, "5"), `unknown grade "E"`, "", 0},
+	}
+
+	l := newTestLedger(t)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			studentID := fmt.Sprintf("CS2100%d", i)
+			recordID := "REC_" + studentID
+			NewTestStudent(t, l, studentID, "CSE")
+
+			opts := ledgertest.TxOptions{Transient: map[string][]byte{transientCourses: []byte(tt.courses)}}
+			_, ws := ledgertest.Invoke(l, testExamCell, opts, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+				return l.contract.CreateAcademicRecord(ctx, recordID, studentID, 1, 2023, 0)
+			})
+			if tt.wantErr != "" {
+				ledgertest.AssertTxError(t, ws, tt.wantErr)
+				return
+			}
+			if ws.Err != nil {
+				t.Fatalf("CreateAcademicRecord: %v", ws.Err)
+			}
+
+			var draft DraftGrades
+			ledgertest.Decode(t, ws.PutPrivate(collectionDraftGrades, recordID), &draft, "draft grades")
+			if got := draft.Courses[0]; got.Grade != tt.wantGrade || got.GradePoint != tt.wantPoints {
+				t.Errorf("staged course graded %q %v, want %q %v", got.Grade, got.GradePoint, tt.wantGrade, tt.wantPoints)
+			}
+		})
+	}
+}
+
+// attempt is a graded attempt of a course in the semester of a test record
+func attempt(code string, grade string, credits float64) CourseGrade {
+	return CourseGrade{CourseCode: code, CourseName: "Course " + code, Credits: credits, Grade: grade, GradePoint: defaultGradeScale[grade]}
+}
+
+func TestCountedAttemptsWithRepeats(t *testing.T) {
+	semesters := func(semesters ...[]CourseGrade) []*AcademicRecord {
+		records := make([]*AcademicRecord, len(semesters))
+		for i, courses := range semesters {
+			records[i] = &AcademicRecord{RecordID: fmt.Sprintf("REC%d", i+1), Semester: i + 1, Courses: courses}
+		}
+		return records
+	}
+	failedThenRecovered := semesters(
+		[]CourseGrade{attempt("MA101", "F", 4), attempt("CS101", "B", 3)},
+		[]CourseGrade{attempt("MA101", "A", 4), attempt("PH101", "C", 3)},
+		[]CourseGrade{attempt("MA101", "C", 4)},
+	)
+	failedThrice := semesters(
+		[]CourseGrade{attempt("MA101", "F", 4)},
+		[]CourseGrade{attempt("MA101", "F", 4)},
+		[]CourseGrade{attempt("MA101", "F", 4)},
+		[]CourseGrade{attempt("MA101", "D", 4)},
+	)
+	// Equal grade points: the later attempt, here with revised credits, counts under both policies
+	tied := semesters(
+		[]CourseGrade{attempt("MA101", "B", 4)},
+		[]CourseGrade{attempt("MA101", "B", 3)},
+	)
+
+	tests := []struct {
+		name        string
+		records     []*AcademicRecord
+		policy      string
+		wantGrades  string // counted grade of each course, in the order first taken
+		wantCGPA    float64
+		wantCredits float64
+	}{
+		{"latest of three attempts", failedThenRecovered, repeatPolicyLatest, "MA101:C CS101:B PH101:C", 6.6, 10},
+		{"best of three attempts", failedThenRecovered, repeatPolicyBest, "MA101:A CS101:B PH101:C", 8.2, 10},
+		{"latest after repeated failures", failedThrice, repeatPolicyLatest, "MA101:D", 4, 4},
+		{"best after repeated failures", failedThrice, repeatPolicyBest, "MA101:D", 4, 4},
+		{"latest on a tie", tied, repeatPolicyLatest, "MA101:B", 8, 3},
+		{"best on a tie", tied, repeatPolicyBest, "MA101:B", 8, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			courses := countedAttempts(tt.records, tt.policy)
+			grades := make([]string, len(courses))
+			for i, course := range courses {
+				grades[i] = course.CourseCode + ":" + course.Grade
+			}
+			if got := strings.Join(grades, " "); got != tt.wantGrades {
+				t.Errorf("counted attempts = %s, want %s", got, tt.wantGrades)
+			}
+			if cgpa, credits := calculateCGPA(tt.records, tt.policy); cgpa != tt.wantCGPA || credits != tt.wantCredits {
+				t.Errorf("calculateCGPA = %v over %v credits, want %v over %v", cgpa, credits, tt.wantCGPA, tt.wantCredits)
+			}
+		})
+	}
+}
+
+func TestTranscriptMarksRepeatAttempts(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantCGPA    float64
+		wantCounted string // record whose MA101 attempt counts
+	}{
+		{repeatPolicyLatest, 6.86, "REC3"},
+		{repeatPolicyBest, 9.14, "REC2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			l := newTestLedger(t)
+			l.updateConfig(t, func(config *OrgConfig) { config.RepeatPolicy = tt.policy })
+			NewTestStudent(t, l, "CS21001", "CSE")
+			for i, courses := range [][]CourseGrade{
+				{attempt("MA101", "F", 4), attempt("CS101", "B", 3)},
+				{attempt("MA101", "A", 4)},
+				{attempt("MA101", "C", 4)},
+			} {
+				recordID := fmt.Sprintf("REC%d", i+1)
+				newTestRecordWithCourses(t, l, recordID, "CS21001", i+1, courses)
+				approveTestRecord(t, l, recordID)
+			}
+
+			transcript := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Transcript, error) {
+				return l.contract.GetStudentTranscript(ctx, "CS21001")
+			})
+			if transcript.RepeatPolicy != tt.policy || transcript.CGPA != tt.wantCGPA || transcript.TotalCredits != 7 {
+				t.Errorf("transcript under %s has CGPA %v over %v credits, want %v over 7", transcript.RepeatPolicy, transcript.CGPA, transcript.TotalCredits, tt.wantCGPA)
+			}
+			var attempts []string
+			for _, semester := range transcript.Semesters {
+				for _, course := range semester.Courses {
+					if course.CourseCode != "MA101" {
+						continue
+					}
+					attempts = append(attempts, fmt.Sprintf("%s:%s%d%s", semester.RecordID, course.Grade, course.Attempt, course.Marker))
+					if course.Counted != (semester.RecordID == tt.wantCounted) {
+						t.Errorf("attempt %d in %s counted = %t", course.Attempt, semester.RecordID, course.Counted)
+					}
+				}
+			}
+			if got, want := strings.Join(attempts, " "), "REC1:F1 REC2:A2R REC3:C3R"; got != want {
+				t.Errorf("MA101 attempts = %s, want %s", got, want)
+			}
+
+			report := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*CGPAReport, error) {
+				return l.contract.GetStudentCGPA(ctx, "CS21001")
+			})
+			if report.CGPA != tt.wantCGPA || report.TotalCredits != 7 {
+				t.Errorf("GetStudentCGPA = %v over %v credits, want %v over 7", report.CGPA, report.TotalCredits, tt.wantCGPA)
+			}
+		})
+	}
+}
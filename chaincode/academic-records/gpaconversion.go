@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== GPA CONVERSION ==========
+//
+// Students applying abroad need their 10-point CGPA on another scale. The institute's official
+// conversion tables are config assets, one per target scale, each a piecewise-linear mapping of
+// CGPA ranges onto target ranges. Tables are versioned and a version is never changed once
+// published, so a converted GPA can always be reproduced from the version it reports.
+
+// gpaConversionObjectType prefixes the composite keys of conversion table versions
+const gpaConversionObjectType = "GPA_CONVERSION"
+
+// Target scales with a default table
+const (
+	targetScaleUS4        = "4.0-US"
+	targetScalePercentage = "percentage"
+)
+
+// maxGPAConversionTables is the most versions a target scale can have, as keys hold four digits
+const maxGPAConversionTables = 9999
+
+// GPAConversionSegment maps CGPAs in [MinCGPA, MaxCGPA) linearly onto [MinValue, MaxValue];
+// the last segment also covers a CGPA of exactly MaxCGPA
+type GPAConversionSegment struct {
+	MinCGPA  float64 `json:"minCgpa"`
+	MaxCGPA  float64 `json:"maxCgpa"`
+	MinValue float64 `json:"minValue"`
+	MaxValue float64 `json:"maxValue"`
+}
+
+// GPAConversionTable is one published version of the conversion to a target scale
+type GPAConversionTable struct {
+	DocType     string                  `json:"docType"`
+	TargetScale string                  `json:"targetScale"`
+	Version     int                     `json:"version"`
+	ScaleMax    float64                 `json:"scaleMax"`         // highest value on the target scale, e.g. 4 or 100
+	Segments    []*GPAConversionSegment `json:"segments"`         // ascending, covering 0 to 10 without gaps
+	Source      string                  `json:"source,omitempty"` // e.g. the Senate resolution adopting the table
+	UpdatedBy   string                  `json:"updatedBy"`
+	UpdatedAt   string                  `json:"updatedAt"`
+}
+
+// ConvertedGPA is a student's CGPA on a target scale with the table version it was converted by
+type ConvertedGPA struct {
+	StudentID         string   `json:"studentId"`
+	TargetScale       string   `json:"targetScale"`
+	TableVersion      int      `json:"tableVersion"`
+	CGPA              float64  `json:"cgpa"`
+	ConvertedValue    float64  `json:"convertedValue"`
+	ScaleMax          float64  `json:"scaleMax"`
+	Provisional       bool     `json:"provisional"` // computed with records still awaiting verification
+	UnverifiedRecords []string `json:"unverifiedRecords,omitempty"`
+	ComputedAt        string   `json:"computedAt"`
+}
+
+// defaultGPAConversionTables are stored as version 1 by InitLedger
+var defaultGPAConversionTables = []GPAConversionTable{
+	{
+		TargetScale: targetScaleUS4,
+		ScaleMax:    4,
+		Segments: []*GPAConversionSegment{
+			{MinCGPA: 0, MaxCGPA: 5, MinValue: 0, MaxValue: 2},
+			{MinCGPA: 5, MaxCGPA: 5.5, MinValue: 2, MaxValue: 2.5},
+			{MinCGPA: 5.5, MaxCGPA: 6.5, MinValue: 2.5, MaxValue: 3},
+			{MinCGPA: 6.5, MaxCGPA: 7.5, MinValue: 3, MaxValue: 3.3},
+			{MinCGPA: 7.5, MaxCGPA: 8.5, MinValue: 3.3, MaxValue: 3.7},
+			{MinCGPA: 8.5, MaxCGPA: 10, MinValue: 3.7, MaxValue: 4},
+		},
+	},
+	{
+		// Percentage = (CGPA - 0.5) x 10, never below zero
+		TargetScale: targetScalePercentage,
+		ScaleMax:    100,
+		Segments: []*GPAConversionSegment{
+			{MinCGPA: 0, MaxCGPA: 0.5, MinValue: 0, MaxValue: 0},
+			{MinCGPA: 0.5, MaxCGPA: 10, MinValue: 0, MaxValue: 95},
+		},
+	},
+}
+
+// PublishGPAConversionTable stores a conversion table as the next version for its target scale
+// (NITWarangal only). Earlier versions stay readable and unchanged.
+func (s *SmartContract) PublishGPAConversionTable(ctx contractapi.TransactionContextInterface, tableJSON string) (*GPAConversionTable, error) {
+	creatorOrg, err := requireOrgRole(ctx, "publish GPA conversion tables", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var table GPAConversionTable
+	if err := json.Unmarshal([]byte(tableJSON), &table); err != nil {
+		return nil, fmt.Errorf("invalid conversion table JSON: %v", err)
+	}
+	table.TargetScale = strings.TrimSpace(table.TargetScale)
+	if err := validateGPAConversionTable(&table); err != nil {
+		return nil, err
+	}
+
+	latest, err := latestGPAConversionTable(ctx, table.TargetScale)
+	if err != nil {
+		return nil, err
+	}
+	table.Version = 1
+	if latest != nil {
+		table.Version = latest.Version + 1
+	}
+	if table.Version > maxGPAConversionTables {
+		return nil, fmt.Errorf("target scale %s has reached %d table versions", table.TargetScale, maxGPAConversionTables)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	table.DocType = docTypeGPAConversion
+	table.UpdatedBy = creatorOrg
+	table.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	key, err := gpaConversionKey(ctx, table.TargetScale, table.Version)
+	if err != nil {
+		return nil, err
+	}
+	storedJSON, err := json.Marshal(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal conversion table: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "PublishGPAConversionTable", "CONFIG", key, fmt.Sprintf("GPA conversion table %s version %d published: %s", table.TargetScale, table.Version, string(storedJSON)))
+
+	return &table, nil
+}
+
+// GetGPAConversionTable returns one version of a target scale's table; version 0 means the latest
+func (s *SmartContract) GetGPAConversionTable(ctx contractapi.TransactionContextInterface, targetScale string, version int) (*GPAConversionTable, error) {
+	return readGPAConversionTable(ctx, targetScale, version)
+}
+
+// ListGPAConversionTables returns every version of a target scale's table, oldest first, or of
+// every scale when targetScale is empty
+func (s *SmartContract) ListGPAConversionTables(ctx contractapi.TransactionContextInterface, targetScale string) ([]*GPAConversionTable, error) {
+	attributes := []string{}
+	if targetScale != "" {
+		attributes = append(attributes, targetScale)
+	}
+	return queryGPAConversionTables(ctx, attributes)
+}
+
+// GetConvertedGPA converts a student's CGPA to targetScale with the latest table. Students with
+// records awaiting verification are refused unless includeProvisional is set, in which case
+// approved records count too and the result is marked provisional.
+func (s *SmartContract) GetConvertedGPA(ctx contractapi.TransactionContextInterface, studentID string, targetScale string, includeProvisional bool) (*ConvertedGPA, error) {
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+	table, err := readGPAConversionTable(ctx, targetScale, 0)
+	if err != nil {
+		return nil, err
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	unverified := []string{}
+	for _, record := range records {
+		switch record.Status {
+		case recordStatusVerified, recordStatusSuperseded, recordStatusRejected:
+		default:
+			unverified = append(unverified, record.RecordID)
+		}
+	}
+	if len(unverified) > 0 && !includeProvisional {
+		return nil, fmt.Errorf("student %s has records awaiting verification (%s); pass includeProvisional for a provisional conversion", studentID, strings.Join(unverified, ", "))
+	}
+
+	counted := []*AcademicRecord{}
+	for _, record := range records {
+		if record.Status == recordStatusVerified || (includeProvisional && countsTowardCGPA(record.Status)) {
+			counted = append(counted, record)
+		}
+	}
+	if len(counted) == 0 {
+		return nil, fmt.Errorf("student %s has no records to convert", studentID)
+	}
+	sort.SliceStable(counted, func(i, j int) bool {
+		return semesterAfter(counted[j], counted[i])
+	})
+	cgpa, _ := calculateCGPA(counted, config.RepeatPolicy)
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	result := &ConvertedGPA{
+		StudentID:      studentID,
+		TargetScale:    table.TargetScale,
+		TableVersion:   table.Version,
+		CGPA:           cgpa,
+		ConvertedValue: convertGPA(table, cgpa),
+		ScaleMax:       table.ScaleMax,
+		Provisional:    len(unverified) > 0,
+		ComputedAt:     txTime.UTC().Format(time.RFC3339),
+	}
+	if result.Provisional {
+		result.UnverifiedRecords = unverified
+	}
+	return result, nil
+}
+
+// convertGPA maps a CGPA through the table's segments, rounded to two decimals
+func convertGPA(table *GPAConversionTable, cgpa float64) float64 {
+	for i, segment := range table.Segments {
+		last := i == len(table.Segments)-1
+		if cgpa < segment.MinCGPA || (cgpa >= segment.MaxCGPA && !last) {
+			continue
+		}
+		fraction := (cgpa - segment.MinCGPA) / (segment.MaxCGPA - segment.MinCGPA)
+		return fromHundredths(toHundredths(segment.MinValue + fraction*(segment.MaxValue-segment.MinValue)))
+	}
+	return 0
+}
+
+// validateGPAConversionTable checks the segments cover 0 to 10 in order without gaps and map
+// onto non-decreasing values within the target scale
+func validateGPAConversionTable(table *GPAConversionTable) error {
+	if table.TargetScale == "" {
+		return fmt.Errorf("target scale is required")
+	}
+	if table.ScaleMax <= 0 {
+		return fmt.Errorf("scaleMax must be positive")
+	}
+	if len(table.Segments) == 0 {
+		return fmt.Errorf("conversion table must have at least one segment")
+	}
+
+	for i, segment := range table.Segments {
+		if segment == nil {
+			return fmt.Errorf("segment %d is empty", i)
+		}
+		if i == 0 && toHundredths(segment.MinCGPA) != 0 {
+			return fmt.Errorf("the first segment must start at CGPA 0")
+		}
+		if i > 0 && toHundredths(segment.MinCGPA) != toHundredths(table.Segments[i-1].MaxCGPA) {
+			return fmt.Errorf("segment %d must start where segment %d ends", i, i-1)
+		}
+		if toHundredths(segment.MaxCGPA) <= toHundredths(segment.MinCGPA) {
+			return fmt.Errorf("segment %d must cover a non-empty CGPA range", i)
+		}
+		if segment.MinValue < 0 || segment.MaxValue > table.ScaleMax || segment.MaxValue < segment.MinValue {
+			return fmt.Errorf("segment %d must map onto an ascending range within 0 and %g", i, table.ScaleMax)
+		}
+		if i > 0 && segment.MinValue < table.Segments[i-1].MaxValue {
+			return fmt.Errorf("segment %d must not map below segment %d", i, i-1)
+		}
+	}
+	if toHundredths(table.Segments[len(table.Segments)-1].MaxCGPA) != 1000 {
+		return fmt.Errorf("the last segment must end at CGPA 10")
+	}
+	return nil
+}
+
+// gpaConversionKey builds the key of one table version
+func gpaConversionKey(ctx contractapi.TransactionContextInterface, targetScale string, version int) (string, error) {
+	if targetScale == "" {
+		return "", fmt.Errorf("target scale is required")
+	}
+	return ctx.GetStub().CreateCompositeKey(gpaConversionObjectType, []string{targetScale, fmt.Sprintf("%04d", version)})
+}
+
+// readGPAConversionTable reads one version of a table; version 0 reads the latest
+func readGPAConversionTable(ctx contractapi.TransactionContextInterface, targetScale string, version int) (*GPAConversionTable, error) {
+	if version == 0 {
+		table, err := latestGPAConversionTable(ctx, targetScale)
+		if err != nil {
+			return nil, err
+		}
+		if table == nil {
+			return nil, fmt.Errorf("no GPA conversion table for target scale %s", targetScale)
+		}
+		return table, nil
+	}
+
+	key, err := gpaConversionKey(ctx, targetScale, version)
+	if err != nil {
+		return nil, err
+	}
+	tableJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if tableJSON == nil {
+		return nil, fmt.Errorf("no GPA conversion table %s version %d", targetScale, version)
+	}
+	var table GPAConversionTable
+	if err := json.Unmarshal(tableJSON, &table); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal conversion table: %v", err)
+	}
+	return &table, nil
+}
+
+// latestGPAConversionTable returns the newest version of a target scale's table, or nil if none
+func latestGPAConversionTable(ctx contractapi.TransactionContextInterface, targetScale string) (*GPAConversionTable, error) {
+	if targetScale == "" {
+		return nil, fmt.Errorf("target scale is required")
+	}
+	tables, err := queryGPAConversionTables(ctx, []string{targetScale})
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, nil
+	}
+	return tables[len(tables)-1], nil
+}
+
+// queryGPAConversionTables returns the table versions under a partial key, oldest first
+func queryGPAConversionTables(ctx contractapi.TransactionContextInterface, attributes []string) ([]*GPAConversionTable, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(gpaConversionObjectType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversion tables: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	tables := []*GPAConversionTable{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var table GPAConversionTable
+		if err := json.Unmarshal(response.Value, &table); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal conversion table: %v", err)
+		}
+		tables = append(tables, &table)
+	}
+	return tables, nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// ========== TEST HARNESS ==========
+//
+// The contract runs on a ledgertest.Ledger; this file adds what depends on the contract itself:
+// the identities of the reference network, typed readers of the committed assets, and fixture
+// builders that go through the real creation code.
+
+// Identities of the reference network; registrar and dean are distinct approvers
+var (
+	testRegistrar = &ledgertest.Identity{MSPID: "NITWarangalMSP", Name: "registrar1", Attrs: map[string]string{"role": "registrar"}}
+	testDean      = &ledgertest.Identity{MSPID: "NITWarangalMSP", Name: "dean1", Attrs: map[string]string{"role": "dean"}}
+	testAdmin     = &ledgertest.Identity{MSPID: "NITWarangalMSP", Name: "admin1", Attrs: map[string]string{"role": "registrar", "admin": "true"}}
+	testExamCell  = &ledgertest.Identity{MSPID: "DepartmentsMSP", Name: "examcell.cse", Attrs: map[string]string{"role": "exam_cell", "department": "CSE"}}
+	testVerifier  = &ledgertest.Identity{MSPID: "VerifiersMSP", Name: "verifier1", Attrs: map[string]string{}}
+	testRegulator = &ledgertest.Identity{MSPID: "AICTEMSP", Name: "inspector1", Attrs: map[string]string{}}
+)
+
+// testLedger is the contract deployed on a ledgertest.Ledger
+type testLedger struct {
+	*ledgertest.Ledger
+	contract *SmartContract
+}
+
+// newEmptyTestLedger returns a freshly deployed contract with nothing on the ledger
+func newEmptyTestLedger() *testLedger {
+	ledger := ledgertest.NewLedger()
+	ledger.NewContext = func() ledgertest.Context { return new(TransactionContext) }
+	ledger.Before = rejectRegulatorWrites
+	return &testLedger{Ledger: ledger, contract: &SmartContract{}}
+}
+
+// newTestLedger returns a ledger initialized by InitLedger, configured to accept uncatalogued
+// courses, with testRegulator's MSP registered as a regulator and the founding institution
+// registered for the university, department and verifier orgs
+func newTestLedger(t *testing.T) *testLedger {
+	t.Helper()
+	l := newEmptyTestLedger()
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*LedgerInit, error) {
+		return l.contract.InitLedger(ctx, false)
+	})
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.AllowUncataloguedCourses = true
+		config.RegulatorOrgs = []string{testRegulator.MSPID}
+	})
+	l.registerFoundingInstitution(t)
+	return l
+}
+
+// registerFoundingInstitution registers defaultInstitutionID for the orgs of the reference
+// network other than the regulator
+func (l *testLedger) registerFoundingInstitution(t *testing.T) *Institution {
+	t.Helper()
+	founding := Institution{
+		InstitutionID:         defaultInstitutionID,
+		Name:                  defaultInstitution.Name,
+		MSPIDs:                []string{testRegistrar.MSPID, testExamCell.MSPID, testVerifier.MSPID},
+		VerificationURLPrefix: verificationURLPrefix,
+	}
+	return ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, saveInstitutionCall(t, l, false, founding))
+}
+
+// addFoundingInstitutionOrgs lists further MSP IDs for defaultInstitutionID through
+// UpdateInstitution
+func (l *testLedger) addFoundingInstitutionOrgs(t *testing.T, mspIDs ...string) *Institution {
+	t.Helper()
+	var founding Institution
+	l.Stored(t, l.CompositeKey(t, institutionObjectType, defaultInstitutionID), &founding)
+	founding.MSPIDs = append(founding.MSPIDs, mspIDs...)
+	return ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, saveInstitutionCall(t, l, true, founding))
+}
+
+// updateConfig applies change to the stored org config through UpdateConfig
+func (l *testLedger) updateConfig(t *testing.T, change func(config *OrgConfig)) *OrgConfig {
+	t.Helper()
+	var config OrgConfig
+	l.Stored(t, orgConfigKey, &config)
+	change(&config)
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("failed to marshal org config: %v", err)
+	}
+	return ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+		return l.contract.UpdateConfig(ctx, string(configJSON))
+	})
+}
+
+// saveInstitutionCall returns a RegisterInstitution call, or an UpdateInstitution call when
+// replace is set
+func saveInstitutionCall(t *testing.T, l *testLedger, replace bool, institution Institution) func(ctx contractapi.TransactionContextInterface) (*Institution, error) {
+	t.Helper()
+	institutionJSON, err := json.Marshal(institution)
+	if err != nil {
+		t.Fatalf("failed to marshal institution: %v", err)
+	}
+	return func(ctx contractapi.TransactionContextInterface) (*Institution, error) {
+		if replace {
+			return l.contract.UpdateInstitution(ctx, string(institutionJSON))
+		}
+		return l.contract.RegisterInstitution(ctx, string(institutionJSON))
+	}
+}
+
+// storedRecord returns the committed record, bypassing access checks and response filtering
+func (l *testLedger) storedRecord(t *testing.T, recordID string) *AcademicRecord {
+	t.Helper()
+	var record AcademicRecord
+	l.Stored(t, recordID, &record)
+	return &record
+}
+
+// storedStudent returns the committed public student document
+func (l *testLedger) storedStudent(t *testing.T, studentID string) *Student {
+	t.Helper()
+	var student Student
+	l.Stored(t, studentID, &student)
+	return &student
+}
+
+// storedCertificate returns the committed certificate
+func (l *testLedger) storedCertificate(t *testing.T, certificateID string) *Certificate {
+	t.Helper()
+	var cert Certificate
+	l.Stored(t, certificateID, &cert)
+	return &cert
+}
+
+// writtenRecord decodes the academic record the transaction put under recordID
+func writtenRecord(t *testing.T, ws *ledgertest.WriteSet, recordID string) *AcademicRecord {
+	t.Helper()
+	var record AcademicRecord
+	ws.Decode(t, recordID, &record)
+	return &record
+}
+
+// writtenAuditEntries decodes the audit entries the transaction wrote, in call order
+func writtenAuditEntries(t *testing.T, ws *ledgertest.WriteSet) []*AuditLog {
+	t.Helper()
+	entries := []*AuditLog{}
+	for _, op := range ws.Ops(ledgertest.OpPutState) {
+		if !strings.HasPrefix(op.Key, "audit_") {
+			continue
+		}
+		var entry AuditLog
+		ledgertest.Decode(t, op.Value, &entry, "audit entry "+op.Key)
+		entries = append(entries, &entry)
+	}
+	return entries
+}
+
+// writtenAuditActions lists the actions of the audit entries the transaction wrote
+func writtenAuditActions(t *testing.T, ws *ledgertest.WriteSet) []string {
+	t.Helper()
+	actions := []string{}
+	for _, entry := range writtenAuditEntries(t, ws) {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+// ========== FIXTURES ==========
+
+// testChecklist ticks every item of the default approval checklist
+const testChecklist = `{"items":{"gradeSheetReceived":true,"hodSignatureSighted":true,"moderationDone":true},"notes":""}`
+
+// testGrades are cycled through by testCourses
+var testGrades = []string{"A", "B", "C", "D"}
+
+// piiTransient is the transient map CreateStudent reads a student's name and email from
+func piiTransient(studentID string) map[string][]byte {
+	pii, _ := json.Marshal(map[string]string{
+		"name":  "Test Student " + studentID,
+		"email": strings.ToLower(studentID) + "@student.nitw.ac.in",
+	})
+	return map[string][]byte{transientStudentPII: pii}
+}
+
+// coursesTransient is the transient map record creation and updates read courses from
+func coursesTransient(courses []CourseGrade) map[string][]byte {
+	coursesJSON, _ := json.Marshal(courses)
+	return map[string][]byte{transientCourses: coursesJSON}
+}
+
+// testCourses returns n courses of 3 or 4 credits graded A, B, C, D in turn, with the grade
+// points of the default grade scale as a client would send them
+func testCourses(n int) []CourseGrade {
+	courses := make([]CourseGrade, n)
+	for i := range courses {
+		grade := testGrades[i%len(testGrades)]
+		courses[i] = CourseGrade{
+			CourseCode: fmt.Sprintf("TC%03d", i+1),
+			CourseName: fmt.Sprintf("Test Course %d", i+1),
+			Credits:    float64(3 + i%2),
+			Grade:      grade,
+			GradePoint: defaultGradeScale[grade],
+		}
+	}
+	return courses
+}
+
+// NewTestStudent creates an ACTIVE BTECH student through CreateStudent
+func NewTestStudent(t *testing.T, l *testLedger, studentID string, department string) *Student {
+	t.Helper()
+	return ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{Transient: piiTransient(studentID)}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.CreateStudent(ctx, studentID, department, "")
+	})
+}
+
+// NewTestRecord creates a SUBMITTED record of n test courses through CreateAcademicRecord
+func NewTestRecord(t *testing.T, l *testLedger, recordID string, studentID string, semester int, n int) *AcademicRecord {
+	t.Helper()
+	return newTestRecordWithCourses(t, l, recordID, studentID, semester, testCourses(n))
+}
+
+// newTestRecordWithCourses creates a SUBMITTED record of the given courses
+func newTestRecordWithCourses(t *testing.T, l *testLedger, recordID string, studentID string, semester int, courses []CourseGrade) *AcademicRecord {
+	t.Helper()
+	year := 2023 + (semester-1)/2
+	return ledgertest.MustInvoke(t, l, testExamCell, ledgertest.TxOptions{Transient: coursesTransient(courses)}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.CreateAcademicRecord(ctx, recordID, studentID, semester, year, 0)
+	})
+}
+
+// approveTestRecord has the registrar and the dean approve a submitted record
+func approveTestRecord(t *testing.T, l *testLedger, recordID string) *AcademicRecord {
+	t.Helper()
+	var record *AcademicRecord
+	for _, approver := range []*ledgertest.Identity{testRegistrar, testDean} {
+		record = ledgertest.MustInvoke(t, l, approver, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.ApproveAcademicRecord(ctx, recordID, testChecklist, "")
+		})
+	}
+	return record
+}
+
+// verifyTestRecord approves a submitted record and has the verifier verify it
+func verifyTestRecord(t *testing.T, l *testLedger, recordID string) *AcademicRecord {
+	t.Helper()
+	approveTestRecord(t, l, recordID)
+	return ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.VerifyAcademicRecord(ctx, recordID, "", false)
+	})
+}
+
+// ensureTestTemplate creates the certificate template of a type for the student's department
+// and program unless one exists
+func ensureTestTemplate(t *testing.T, l *testLedger, certificationType string, student *Student) {
+	t.Helper()
+	key := l.CompositeKey(t, certTemplateObjectType, certificationType, student.Department, normalizeProgram(student.Program))
+	if _, ok := l.Stub.State[key]; ok {
+		return
+	}
+	templateJSON, _ := json.Marshal(CertificateTemplate{
+		CertificationType: certificationType,
+		Department:        student.Department,
+		Program:           student.Program,
+		DegreeTitle:       "Bachelor of Technology in " + student.Department,
+		DurationYears:     4,
+		RegulationYear:    2021,
+	})
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*CertificateTemplate, error) {
+		return l.contract.CreateCertificateTemplate(ctx, string(templateJSON))
+	})
+}
+
+// NewTestCertificate issues a permanent certificate through IssueCertificate, creating its
+// template first; DEGREE and DIPLOMA certificates graduate the student on the ledger's date
+func NewTestCertificate(t *testing.T, l *testLedger, certificateID string, studentID string, certificationType string) *Certificate {
+	t.Helper()
+	ensureTestTemplate(t, l, certificationType, l.storedStudent(t, studentID))
+
+	graduationDate := ""
+	if graduationCertificationTypes[certificationType] {
+		graduationDate = l.Now.Format(time.RFC3339)
+	}
+	return ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Certificate, error) {
+		return l.contract.IssueCertificate(ctx, certificateID, studentID, certificationType, 0, false, graduationDate)
+	})
+}
+
+// ========== SCENARIOS ==========
+
+// recordLifecycleSteps takes a new record of the student through create, submit, two
+// approvals and verification, checking the record's status and event after each step
+func (l *testLedger) recordLifecycleSteps(recordID string, studentID string, semester int, courses []CourseGrade) []ledgertest.Step {
+	expect := func(status string, event string) func(t *testing.T, ws *ledgertest.WriteSet) {
+		return func(t *testing.T, ws *ledgertest.WriteSet) {
+			t.Helper()
+			if got := writtenRecord(t, ws, recordID).Status; got != status {
+				t.Errorf("record status = %s, want %s", got, status)
+			}
+			name, _, ok := ws.Event()
+			if event == "" && ok {
+				t.Errorf("unexpected event %s", name)
+			}
+			if event != "" && name != event {
+				t.Errorf("event = %q, want %q", name, event)
+			}
+		}
+	}
+	approve := func(ctx contractapi.TransactionContextInterface) error {
+		_, err := l.contract.ApproveAcademicRecord(ctx, recordID, testChecklist, "")
+		return err
+	}
+
+	return []ledgertest.Step{
+		{
+			Name: "create draft",
+			As:   testExamCell,
+			Opts: ledgertest.TxOptions{Transient: coursesTransient(courses)},
+			Run: func(ctx contractapi.TransactionContextInterface) error {
+				_, err := l.contract.CreateDraftRecord(ctx, recordID, studentID, semester, 2023+(semester-1)/2, 0)
+				return err
+			},
+			Check: expect(recordStatusDraft, ""),
+		},
+		{
+			Name: "submit",
+			As:   testExamCell,
+			Run: func(ctx contractapi.TransactionContextInterface) error {
+				_, err := l.contract.SubmitAcademicRecord(ctx, recordID)
+				return err
+			},
+			Check: expect(recordStatusSubmitted, eventRecordSubmitted),
+		},
+		{
+			Name:  "first approval",
+			As:    testRegistrar,
+			Run:   approve,
+			Check: expect(recordStatusSubmitted, ""),
+		},
+		{
+			Name:    "repeat approval by the same officer",
+			As:      testRegistrar,
+			Run:     approve,
+			WantErr: "already approved",
+		},
+		{
+			Name:  "second approval",
+			As:    testDean,
+			Run:   approve,
+			Check: expect(recordStatusApproved, eventRecordApproved),
+		},
+		{
+			Name: "verify",
+			As:   testVerifier,
+			Run: func(ctx contractapi.TransactionContextInterface) error {
+				_, err := l.contract.VerifyAcademicRecord(ctx, recordID, "", false)
+				return err
+			},
+			Check: expect(recordStatusVerified, eventRecordVerified),
+		},
+	}
+}
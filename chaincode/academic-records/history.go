@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== KEY HISTORY ==========
+
+// keyVersion is one entry of a key's history before its value is decoded
+type keyVersion struct {
+	TxID      string
+	Timestamp string
+	IsDelete  bool
+	Value     []byte
+}
+
+// RecordHistoryEntry is one version of an academic record. Versions that no longer unmarshal
+// into AcademicRecord carry the raw JSON with ParseError set instead of being dropped.
+type RecordHistoryEntry struct {
+	TxID       string          `json:"txId"`
+	Timestamp  string          `json:"timestamp"`
+	IsDelete   bool            `json:"isDelete"`
+	Value      *AcademicRecord `json:"value,omitempty"`
+	RawValue   string          `json:"rawValue,omitempty"`
+	ParseError bool            `json:"parseError"`
+}
+
+// GetRecordHistory returns every version of an academic record, oldest first
+func (s *SmartContract) GetRecordHistory(ctx contractapi.TransactionContextInterface, recordID string) ([]*RecordHistoryEntry, error) {
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, record.StudentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+
+	versions, err := getKeyHistory(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []*RecordHistoryEntry{}
+	for _, version := range versions {
+		entry := &RecordHistoryEntry{
+			TxID:      version.TxID,
+			Timestamp: version.Timestamp,
+			IsDelete:  version.IsDelete,
+		}
+		if !version.IsDelete {
+			var value AcademicRecord
+			if err := json.Unmarshal(version.Value, &value); err != nil {
+				entry.RawValue = string(version.Value)
+				entry.ParseError = true
+			} else {
+				entry.Value = &value
+			}
+		}
+		history = append(history, entry)
+	}
+
+	if err := filterResponse(ctx, history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// getKeyHistory reads every committed version of a key, oldest first. Deletes are kept as
+// entries with IsDelete set and no value.
+func getKeyHistory(ctx contractapi.TransactionContextInterface, key string) ([]*keyVersion, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %v", key, err)
+	}
+	defer resultsIterator.Close()
+
+	// The history iterator returns the newest version first
+	versions := []*keyVersion{}
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		version := &keyVersion{
+			TxID:     modification.GetTxId(),
+			IsDelete: modification.GetIsDelete(),
+		}
+		if ts := modification.GetTimestamp(); ts != nil {
+			version.Timestamp = ts.AsTime().UTC().Format(time.RFC3339)
+		}
+		if !version.IsDelete {
+			version.Value = modification.GetValue()
+		}
+		versions = append([]*keyVersion{version}, versions...)
+	}
+
+	return versions, nil
+}
+
+// ========== CERTIFICATE HISTORY ==========
+
+// Certificate history event types
+const (
+	certEventIssued                = "ISSUED"
+	certEventUpdated               = "UPDATED"
+	certEventRevoked               = "REVOKED"
+	certEventSuperseded            = "SUPERSEDED"
+	certEventDeleted               = "DELETED"
+	certEventVerification          = "VERIFICATION"
+	certEventVerificationRequested = "VERIFICATION_REQUESTED"
+	certEventVerificationResponded = "VERIFICATION_RESPONDED"
+	certEventVerificationExpired   = "VERIFICATION_REQUEST_EXPIRED"
+)
+
+// CertificateHistoryEvent is one entry on a certificate's timeline; exactly one of
+// Certificate, Verification and Request is set, matching Type
+type CertificateHistoryEvent struct {
+	Type         string               `json:"type"`
+	TxID         string               `json:"txId"`
+	Timestamp    string               `json:"timestamp"`
+	Org          string               `json:"org"`
+	Details      string               `json:"details"`
+	Certificate  *Certificate         `json:"certificate,omitempty"`
+	Verification *VerificationEvent   `json:"verification,omitempty"`
+	Request      *VerificationRequest `json:"request,omitempty"`
+}
+
+// GetCertificateHistory returns everything that happened to a certificate on one timeline,
+// oldest first (NITWarangal and regulators): its key history, verifications and verification requests.
+// Events in the same second are ordered by tx ID.
+func (s *SmartContract) GetCertificateHistory(ctx contractapi.TransactionContextInterface, certificateID string) ([]*CertificateHistoryEvent, error) {
+	if _, err := requireOrgRole(ctx, "view certificate history", orgRoleUniversity, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+	if _, err := readCertificate(ctx, certificateID); err != nil {
+		return nil, err
+	}
+
+	events, err := certificateVersionEvents(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications, err := certificateVerificationEvents(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, verifications...)
+
+	requests, err := certificateRequestEvents(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, requests...)
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp != events[j].Timestamp {
+			return events[i].Timestamp < events[j].Timestamp
+		}
+		return events[i].TxID < events[j].TxID
+	})
+	return events, nil
+}
+
+// certificateVersionEvents turns the certificate's key history into issue, change and
+// revocation events; the acting org comes from the audit entry of the same transaction
+func certificateVersionEvents(ctx contractapi.TransactionContextInterface, certificateID string) ([]*CertificateHistoryEvent, error) {
+	versions, err := getKeyHistory(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	audits, err := auditEntriesByTx(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*CertificateHistoryEvent{}
+	previousStatus := ""
+	for i, version := range versions {
+		event := &CertificateHistoryEvent{TxID: version.TxID, Timestamp: version.Timestamp}
+		if audit, ok := audits[version.TxID]; ok {
+			event.Org = audit.Organization
+			event.Details = audit.Details
+		}
+
+		if version.IsDelete {
+			event.Type = certEventDeleted
+			events = append(events, event)
+			continue
+		}
+
+		var cert Certificate
+		if err := json.Unmarshal(version.Value, &cert); err != nil {
+			event.Type = certEventUpdated
+			event.Details = "unreadable certificate version: " + string(version.Value)
+			events = append(events, event)
+			continue
+		}
+		event.Certificate = &cert
+
+		switch {
+		case i == 0:
+			event.Type = certEventIssued
+			if event.Org == "" {
+				event.Org = cert.IssuedBy
+			}
+		case cert.Status != previousStatus && cert.Status == certStatusRevoked:
+			event.Type = certEventRevoked
+			if event.Org == "" {
+				event.Org = cert.RevokedBy
+			}
+		case cert.Status != previousStatus && cert.Status == certStatusSuperseded:
+			event.Type = certEventSuperseded
+		default:
+			event.Type = certEventUpdated
+		}
+		previousStatus = cert.Status
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// certificateVerificationEvents lists the verification events of every epoch
+func certificateVerificationEvents(ctx contractapi.TransactionContextInterface, certificateID string) ([]*CertificateHistoryEvent, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("verify", []string{certificateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verifications: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	events := []*CertificateHistoryEvent{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var verification VerificationEvent
+		if err := json.Unmarshal(response.Value, &verification); err != nil {
+			continue
+		}
+		events = append(events, &CertificateHistoryEvent{
+			Type:         certEventVerification,
+			TxID:         verification.TxID,
+			Timestamp:    verification.Timestamp,
+			Org:          verification.RequesterOrg,
+			Details:      fmt.Sprintf("Verification requested by %s: %s", verification.RequestedBy, verification.Outcome),
+			Verification: &verification,
+		})
+	}
+	return events, nil
+}
+
+// certificateRequestEvents walks the key history of each verification request about the
+// certificate: its creation, the response and any expiry
+func certificateRequestEvents(ctx contractapi.TransactionContextInterface, certificateID string) ([]*CertificateHistoryEvent, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("vreq~cert", []string{certificateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verification requests: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	events := []*CertificateHistoryEvent{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+		requestID := compositeKeyParts[1]
+
+		versions, err := getKeyHistory(ctx, requestID)
+		if err != nil {
+			return nil, err
+		}
+		audits, err := auditEntriesByTx(ctx, requestID)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, version := range versions {
+			var request VerificationRequest
+			if version.IsDelete || json.Unmarshal(version.Value, &request) != nil {
+				continue
+			}
+
+			event := &CertificateHistoryEvent{TxID: version.TxID, Timestamp: version.Timestamp, Request: &request}
+			if audit, ok := audits[version.TxID]; ok {
+				event.Org = audit.Organization
+				event.Details = audit.Details
+			}
+			switch {
+			case i == 0:
+				event.Type = certEventVerificationRequested
+				event.Org = request.RequesterOrg
+			case request.Status == verificationRequestExpired:
+				event.Type = certEventVerificationExpired
+			default:
+				event.Type = certEventVerificationResponded
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// auditEntriesByTx indexes the audit entries of an asset by transaction ID
+func auditEntriesByTx(ctx contractapi.TransactionContextInterface, recordID string) (map[string]*AuditLog, error) {
+	logs, err := auditEntries(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string]*AuditLog{}
+	for _, log := range logs {
+		entries[log.TransactionID] = log
+	}
+	return entries, nil
+}
+
+// auditEntries reads every audit entry of an asset, oldest first
+func auditEntries(ctx contractapi.TransactionContextInterface, recordID string) ([]*AuditLog, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("audit", []string{recordID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	logs := []*AuditLog{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		logJSON, err := ctx.GetStub().GetState(compositeKeyParts[1])
+		if err != nil || logJSON == nil {
+			continue
+		}
+		var entry AuditLog
+		if err := json.Unmarshal(logJSON, &entry); err != nil {
+			continue
+		}
+		logs = append(logs, &entry)
+	}
+	return logs, nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT HOLDS ==========
+
+// Hold types; an ACTIVE DISCIPLINARY hold blocks every certificate for the student
+const (
+	holdTypeDisciplinary   = "DISCIPLINARY"
+	holdTypeAdministrative = "ADMINISTRATIVE"
+)
+
+// Hold statuses
+const (
+	holdStatusActive   = "ACTIVE"
+	holdStatusReleased = "RELEASED"
+)
+
+// Hold is a restriction placed on a student pending an inquiry, without suspending them
+type Hold struct {
+	DocType    string `json:"docType"`
+	HoldID     string `json:"holdId"`
+	StudentID  string `json:"studentId"`
+	HoldType   string `json:"holdType"`
+	Reason     string `json:"reason"`
+	Status     string `json:"status"`
+	PlacedBy   string `json:"placedBy"`
+	PlacedAt   string `json:"placedAt"`
+	Resolution string `json:"resolution,omitempty"`
+	ReleasedBy string `json:"releasedBy,omitempty"`
+	ReleasedAt string `json:"releasedAt,omitempty"`
+}
+
+// PlaceHold puts an ACTIVE hold on a student (NITWarangal identities with a hold-managing role)
+func (s *SmartContract) PlaceHold(ctx contractapi.TransactionContextInterface, studentID string, holdType string, reason string) (*Hold, error) {
+	if _, err := requireOrgRole(ctx, "place holds", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationManageHold); err != nil {
+		return nil, err
+	}
+
+	if holdType != holdTypeDisciplinary && holdType != holdTypeAdministrative {
+		return nil, fmt.Errorf("invalid hold type %q: must be %s or %s", holdType, holdTypeDisciplinary, holdTypeAdministrative)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to place a hold")
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	hold := Hold{
+		DocType:   docTypeHold,
+		HoldID:    "HOLD_" + ctx.GetStub().GetTxID(),
+		StudentID: studentID,
+		HoldType:  holdType,
+		Reason:    reason,
+		Status:    holdStatusActive,
+		PlacedBy:  getClientCommonName(ctx),
+		PlacedAt:  txTime.UTC().Format(time.RFC3339),
+	}
+	if err := putHold(ctx, &hold); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "hold~student", []string{studentID, hold.HoldID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "PlaceHold", "STUDENT", studentID, fmt.Sprintf("Placed %s hold %s: %s", holdType, hold.HoldID, reason))
+
+	return &hold, nil
+}
+
+// ReleaseHold lifts an ACTIVE hold with a resolution (NITWarangal identities with a hold-managing role)
+func (s *SmartContract) ReleaseHold(ctx contractapi.TransactionContextInterface, holdID string, resolution string) (*Hold, error) {
+	if _, err := requireOrgRole(ctx, "release holds", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationManageHold); err != nil {
+		return nil, err
+	}
+
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return nil, fmt.Errorf("a resolution is required to release a hold")
+	}
+
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != holdStatusActive {
+		return nil, fmt.Errorf("hold %s is already %s", holdID, hold.Status)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	hold.Status = holdStatusReleased
+	hold.Resolution = resolution
+	hold.ReleasedBy = getClientCommonName(ctx)
+	hold.ReleasedAt = txTime.UTC().Format(time.RFC3339)
+	if err := putHold(ctx, hold); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "ReleaseHold", "STUDENT", hold.StudentID, fmt.Sprintf("Released %s hold %s: %s", hold.HoldType, holdID, resolution))
+
+	return hold, nil
+}
+
+// GetActiveHolds lists a student's ACTIVE holds (NITWarangal and Departments)
+func (s *SmartContract) GetActiveHolds(ctx contractapi.TransactionContextInterface, studentID string) ([]*Hold, error) {
+	if _, err := requireOrgRole(ctx, "view holds", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	return getActiveHolds(ctx, studentID)
+}
+
+// getActiveHolds walks the hold~student index and keeps the ACTIVE holds
+func getActiveHolds(ctx contractapi.TransactionContextInterface, studentID string) ([]*Hold, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("hold~student", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holds: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	holds := []*Hold{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		hold, err := readHold(ctx, compositeKeyParts[1])
+		if err == nil && hold.Status == holdStatusActive {
+			holds = append(holds, hold)
+		}
+	}
+	return holds, nil
+}
+
+// disciplinaryHoldIDs returns the IDs of the student's ACTIVE DISCIPLINARY holds
+func disciplinaryHoldIDs(ctx contractapi.TransactionContextInterface, studentID string) ([]string, error) {
+	holds, err := getActiveHolds(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	holdIDs := []string{}
+	for _, hold := range holds {
+		if hold.HoldType == holdTypeDisciplinary {
+			holdIDs = append(holdIDs, hold.HoldID)
+		}
+	}
+	return holdIDs, nil
+}
+
+// assertNoDisciplinaryHold refuses certificate issuance while a DISCIPLINARY hold is ACTIVE
+func assertNoDisciplinaryHold(ctx contractapi.TransactionContextInterface, studentID string) error {
+	holdIDs, err := disciplinaryHoldIDs(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if len(holdIDs) > 0 {
+		return fmt.Errorf("student %s has an active DISCIPLINARY hold (%s); no certificate can be issued", studentID, strings.Join(holdIDs, ", "))
+	}
+	return nil
+}
+
+// readHold loads a hold from world state
+func readHold(ctx contractapi.TransactionContextInterface, holdID string) (*Hold, error) {
+	holdJSON, err := ctx.GetStub().GetState(holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if holdJSON == nil {
+		return nil, fmt.Errorf("hold %s not found", holdID)
+	}
+
+	var hold Hold
+	if err := json.Unmarshal(holdJSON, &hold); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal hold: %v", err)
+	}
+	if hold.DocType != docTypeHold {
+		return nil, fmt.Errorf("hold %s not found", holdID)
+	}
+	return &hold, nil
+}
+
+// putHold saves a hold under its hold ID
+func putHold(ctx contractapi.TransactionContextInterface, hold *Hold) error {
+	holdJSON, err := json.Marshal(hold)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hold: %v", err)
+	}
+	if err := ctx.GetStub().PutState(hold.HoldID, holdJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
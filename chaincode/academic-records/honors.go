@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== HONORS AND DEAN'S LIST ==========
+//
+// A student earns honors with a CGPA over verified records of at least the configured minimum
+// and, unless the config allows backlogs, no course ever failed, even if cleared later. A record
+// is flagged for the dean's list when it is approved with an SGPA of at least the configured
+// minimum; a superseding replacement is flagged afresh and the superseded record loses its flag.
+
+// Honors thresholds used until the org config sets others
+const (
+	defaultHonorsMinCGPA    = 9.0
+	defaultDeansListMinSGPA = 9.0
+)
+
+// HonorsRule is one honors condition and how the student fares against it
+type HonorsRule struct {
+	Rule   string `json:"rule"`
+	Met    bool   `json:"met"`
+	Detail string `json:"detail"`
+}
+
+// HonorsReport is a student's honors eligibility with the evaluation of each rule
+type HonorsReport struct {
+	StudentID      string        `json:"studentId"`
+	Eligible       bool          `json:"eligible"`
+	CGPA           float64       `json:"cgpa"`
+	RequiredCGPA   float64       `json:"requiredCgpa"`
+	FailedCourses  []string      `json:"failedCourses"` // courses with a failed attempt in a verified record
+	Rules          []*HonorsRule `json:"rules"`
+	PendingRecords []string      `json:"pendingRecords,omitempty"` // not yet verified, so not counted
+}
+
+// DeansListEntry is one student on a semester's dean's list
+type DeansListEntry struct {
+	StudentID string  `json:"studentId"`
+	RecordID  string  `json:"recordId"`
+	SGPA      float64 `json:"sgpa"`
+	Status    string  `json:"status"`
+}
+
+// ComputeHonors evaluates a student's honors eligibility over their verified records
+// (NITWarangal and Departments)
+func (s *SmartContract) ComputeHonors(ctx contractapi.TransactionContextInterface, studentID string) (*HonorsReport, error) {
+	if _, err := requireOrgRole(ctx, "compute honors", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	report := &HonorsReport{StudentID: studentID, RequiredCGPA: config.honorsMinCGPA(), FailedCourses: []string{}, Rules: []*HonorsRule{}}
+	verified := []*AcademicRecord{}
+	for _, record := range records {
+		switch record.Status {
+		case recordStatusVerified:
+			verified = append(verified, record)
+		case recordStatusSuperseded, recordStatusRejected:
+		default:
+			report.PendingRecords = append(report.PendingRecords, record.RecordID)
+		}
+	}
+	sort.SliceStable(verified, func(i, j int) bool {
+		return semesterAfter(verified[j], verified[i])
+	})
+
+	if len(verified) == 0 {
+		report.Rules = append(report.Rules, &HonorsRule{Rule: "verified records", Detail: "no verified records"})
+		return report, nil
+	}
+
+	report.CGPA, _ = calculateCGPA(verified, config.RepeatPolicy)
+	cgpaRule := &HonorsRule{
+		Rule:   fmt.Sprintf("CGPA at least %.2f", report.RequiredCGPA),
+		Met:    toHundredths(report.CGPA) >= toHundredths(report.RequiredCGPA),
+		Detail: fmt.Sprintf("CGPA %.2f over %d verified records", report.CGPA, len(verified)),
+	}
+	report.Rules = append(report.Rules, cgpaRule)
+
+	failed := map[string]bool{}
+	for _, record := range verified {
+		for _, course := range record.Courses {
+			if toHundredths(course.GradePoint) == 0 && !failed[course.CourseCode] {
+				failed[course.CourseCode] = true
+				report.FailedCourses = append(report.FailedCourses, course.CourseCode)
+			}
+		}
+	}
+	backlogRule := &HonorsRule{Rule: "no backlogs", Met: len(report.FailedCourses) == 0, Detail: fmt.Sprintf("%d courses failed", len(report.FailedCourses))}
+	if config.HonorsAllowBacklogs {
+		backlogRule.Met = true
+		backlogRule.Detail += "; backlogs are allowed by the org config"
+	}
+	report.Rules = append(report.Rules, backlogRule)
+
+	report.Eligible = cgpaRule.Met && backlogRule.Met
+	return report, nil
+}
+
+// GetDeansList returns the students of a department flagged for the dean's list in one semester
+// of an academic year (NITWarangal and Departments). Records created before departments were
+// stamped on them are not included.
+func (s *SmartContract) GetDeansList(ctx contractapi.TransactionContextInterface, department string, year int, semester int) ([]*DeansListEntry, error) {
+	if _, err := requireOrgRole(ctx, "view the dean's list", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	if department == "" {
+		return nil, fmt.Errorf("department is required")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~department", []string{department, fmt.Sprintf("%04d", year), strconv.Itoa(semester)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	entries := []*DeansListEntry{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 4 {
+			continue
+		}
+
+		recordJSON, err := ctx.GetStub().GetState(compositeKeyParts[3])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state: %v", err)
+		}
+		var record AcademicRecord
+		if recordJSON == nil || json.Unmarshal(recordJSON, &record) != nil {
+			continue
+		}
+		if !record.DeansListed || !countsTowardCGPA(record.Status) {
+			continue
+		}
+		entries = append(entries, &DeansListEntry{StudentID: record.StudentID, RecordID: record.RecordID, SGPA: record.SGPA, Status: record.Status})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if toHundredths(entries[i].SGPA) != toHundredths(entries[j].SGPA) {
+			return toHundredths(entries[i].SGPA) > toHundredths(entries[j].SGPA)
+		}
+		return entries[i].StudentID < entries[j].StudentID
+	})
+	return entries, nil
+}
+
+// deansListed reports whether an approved record's SGPA reaches the dean's list threshold
+func deansListed(record *AcademicRecord, config *OrgConfig) bool {
+	return len(record.Courses) > 0 && toHundredths(record.SGPA) >= toHundredths(config.deansListMinSGPA())
+}
+
+// honorsMinCGPA returns the honors CGPA threshold, defaulting for configs stored before it existed
+func (c *OrgConfig) honorsMinCGPA() float64 {
+	if c.HonorsMinCGPA == 0 {
+		return defaultHonorsMinCGPA
+	}
+	return c.HonorsMinCGPA
+}
+
+// deansListMinSGPA returns the dean's list SGPA threshold, defaulting for configs stored before it existed
+func (c *OrgConfig) deansListMinSGPA() float64 {
+	if c.DeansListMinSGPA == 0 {
+		return defaultDeansListMinSGPA
+	}
+	return c.DeansListMinSGPA
+}
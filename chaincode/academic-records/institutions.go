@@ -0,0 +1,472 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== INSTITUTIONS ==========
+//
+// Several institutes can share the network. Each institution lists the MSP IDs that act for it;
+// students, records and certificates are stamped with the institution of the org that created
+// them, and readStudent and readAcademicRecord refuse callers from another institution unless
+// their org is a verifier or regulator org. Certificate hashes stay publicly checkable, and
+// verification responses name the issuing institution. Orgs no institution lists cannot act for
+// any, except that the university orgs act for defaultInstitutionID until it is registered so
+// they can register it. Assets stored before institutions existed belong to
+// defaultInstitutionID until BackfillInstitutionIDs stamps them.
+
+// institutionObjectType prefixes the composite keys of institutions
+const institutionObjectType = "INSTITUTION"
+
+// defaultInstitutionID is the founding institution of the network
+const defaultInstitutionID = "NITW"
+
+// institutionIDPattern restricts institution IDs to short upper-case codes
+var institutionIDPattern = regexp.MustCompile(`^[A-Z0-9_-]{2,32}$`)
+
+// Institution is one institute on the network and the orgs that act for it
+type Institution struct {
+	DocType               string   `json:"docType"`
+	InstitutionID         string   `json:"institutionId"`
+	Name                  string   `json:"name"`
+	MSPIDs                []string `json:"mspIds"`
+	VerificationURLPrefix string   `json:"verificationUrlPrefix"` // certificate IDs are appended to it
+	UpdatedBy             string   `json:"updatedBy"`
+	UpdatedAt             string   `json:"updatedAt"`
+}
+
+// defaultInstitution applies until the founding institution is registered explicitly
+var defaultInstitution = Institution{
+	DocType:               docTypeInstitution,
+	InstitutionID:         defaultInstitutionID,
+	Name:                  "National Institute of Technology Warangal",
+	MSPIDs:                []string{},
+	VerificationURLPrefix: verificationURLPrefix,
+}
+
+// callerScope is the caller's institution scope, kept for the transaction as every student and
+// record read checks it
+type callerScope struct {
+	mspID         string
+	institutionID string // "" when the caller's org acts for no institution
+	crossReads    bool
+}
+
+// RegisterInstitution adds an institution to the network (NITWarangal only)
+func (s *SmartContract) RegisterInstitution(ctx contractapi.TransactionContextInterface, institutionJSON string) (*Institution, error) {
+	return saveInstitution(ctx, institutionJSON, false)
+}
+
+// UpdateInstitution replaces the name, MSP IDs or verification URL prefix of an institution
+// (NITWarangal only). Certificates already issued keep their verification URL.
+func (s *SmartContract) UpdateInstitution(ctx contractapi.TransactionContextInterface, institutionJSON string) (*Institution, error) {
+	return saveInstitution(ctx, institutionJSON, true)
+}
+
+// GetInstitution returns one institution
+func (s *SmartContract) GetInstitution(ctx contractapi.TransactionContextInterface, institutionID string) (*Institution, error) {
+	institution, err := readInstitution(ctx, institutionID)
+	if err != nil {
+		return nil, err
+	}
+	if institution == nil {
+		return nil, fmt.Errorf("institution %s not found", institutionID)
+	}
+	return institution, nil
+}
+
+// ListInstitutions returns every institution, including the founding one while it is only a default
+func (s *SmartContract) ListInstitutions(ctx contractapi.TransactionContextInterface) ([]*Institution, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(institutionObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query institutions: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	institutions := []*Institution{}
+	foundDefault := false
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var institution Institution
+		if err := json.Unmarshal(response.Value, &institution); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal institution: %v", err)
+		}
+		foundDefault = foundDefault || institution.InstitutionID == defaultInstitutionID
+		institutions = append(institutions, &institution)
+	}
+	if !foundDefault {
+		fallback := defaultInstitution
+		institutions = append([]*Institution{&fallback}, institutions...)
+	}
+	return institutions, nil
+}
+
+// InstitutionBackfillResult reports the assets stamped by one BackfillInstitutionIDs call
+type InstitutionBackfillResult struct {
+	Stamped []string `json:"stamped"`
+	More    bool     `json:"more"` // true when the batch limit was hit; call again to continue
+}
+
+// BackfillInstitutionIDs stamps defaultInstitutionID onto students and certificates stored before
+// institutions existed (NITWarangal admin identities only). At most maxPageSize assets are
+// stamped per call. Records are left unstamped: transcript hashes cover the whole record, so
+// rewriting a verified one would break VerifyTranscriptIntegrity for certificates issued over it,
+// and readers resolve their empty institution ID through assetInstitution instead.
+func (s *SmartContract) BackfillInstitutionIDs(ctx contractapi.TransactionContextInterface) (*InstitutionBackfillResult, error) {
+	if _, err := requireOrgRole(ctx, "backfill institution IDs", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("backfilling institution IDs requires the %s=true attribute: %v", adminAttribute, err)
+	}
+	if err := assertInstitutionWrite(ctx, defaultInstitutionID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := assetRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	result := &InstitutionBackfillResult{Stamped: []string{}}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var asset struct {
+			DocType       string `json:"docType"`
+			InstitutionID string `json:"institutionId"`
+		}
+		if err := json.Unmarshal(response.Value, &asset); err != nil || asset.InstitutionID != "" {
+			continue
+		}
+		if asset.DocType != docTypeStudent && asset.DocType != docTypeCertificate {
+			continue
+		}
+		if int32(len(result.Stamped)) == maxPageSize {
+			result.More = true
+			break
+		}
+
+		switch asset.DocType {
+		case docTypeStudent:
+			var student Student
+			if err := json.Unmarshal(response.Value, &student); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal student: %v", err)
+			}
+			student.InstitutionID = defaultInstitutionID
+			err = putStudent(ctx, &student)
+		case docTypeCertificate:
+			var cert Certificate
+			if err := json.Unmarshal(response.Value, &cert); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal certificate: %v", err)
+			}
+			cert.InstitutionID = defaultInstitutionID
+			err = putCertificate(ctx, &cert)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Stamped = append(result.Stamped, response.Key)
+	}
+
+	logAudit(ctx, "BackfillInstitutionIDs", "CONFIG", institutionObjectType, fmt.Sprintf("Stamped %s on %d assets: %v", defaultInstitutionID, len(result.Stamped), result.Stamped))
+
+	return result, nil
+}
+
+// saveInstitution validates and stores an institution with its MSP lookup entries, auditing the
+// old and new values
+func saveInstitution(ctx contractapi.TransactionContextInterface, institutionJSON string, replace bool) (*Institution, error) {
+	creatorOrg, err := requireOrgRole(ctx, "manage institutions", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertInstitutionWrite(ctx, defaultInstitutionID); err != nil {
+		return nil, err
+	}
+
+	var institution Institution
+	if err := json.Unmarshal([]byte(institutionJSON), &institution); err != nil {
+		return nil, fmt.Errorf("invalid institution JSON: %v", err)
+	}
+	institution.InstitutionID = strings.ToUpper(strings.TrimSpace(institution.InstitutionID))
+	if !institutionIDPattern.MatchString(institution.InstitutionID) {
+		return nil, fmt.Errorf("institution ID %q must be 2-32 upper-case letters, digits, underscores or hyphens", institution.InstitutionID)
+	}
+	institution.Name = strings.TrimSpace(institution.Name)
+	if institution.Name == "" {
+		return nil, fmt.Errorf("institution name is required")
+	}
+	if !strings.HasPrefix(institution.VerificationURLPrefix, "https://") || !strings.HasSuffix(institution.VerificationURLPrefix, "/") {
+		return nil, fmt.Errorf("verification URL prefix must be an https URL ending in /")
+	}
+	if len(institution.MSPIDs) == 0 {
+		return nil, fmt.Errorf("institution must list at least one MSP ID")
+	}
+	seen := map[string]bool{}
+	for _, mspID := range institution.MSPIDs {
+		if strings.TrimSpace(mspID) == "" {
+			return nil, fmt.Errorf("institution contains an empty MSP ID")
+		}
+		if seen[mspID] {
+			return nil, fmt.Errorf("MSP ID %s is listed more than once", mspID)
+		}
+		seen[mspID] = true
+		owner, err := institutionOfMSP(ctx, mspID)
+		if err != nil {
+			return nil, err
+		}
+		if owner != "" && owner != institution.InstitutionID {
+			return nil, fmt.Errorf("MSP ID %s already acts for institution %s", mspID, owner)
+		}
+	}
+
+	// Until the founding institution is registered its university orgs act for it by default;
+	// it must be registered first, and it must keep listing the caller's org, or no org could
+	// manage institutions afterwards
+	foundingRegistered, err := institutionRegistered(ctx, defaultInstitutionID)
+	if err != nil {
+		return nil, err
+	}
+	if !foundingRegistered && institution.InstitutionID != defaultInstitutionID {
+		return nil, fmt.Errorf("institution %s must be registered before any other", defaultInstitutionID)
+	}
+	if institution.InstitutionID == defaultInstitutionID && !seen[creatorOrg] {
+		return nil, fmt.Errorf("institution %s must list the caller's org %s", defaultInstitutionID, creatorOrg)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(institutionObjectType, []string{institution.InstitutionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create institution key: %v", err)
+	}
+	existingJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if replace && existingJSON == nil {
+		return nil, fmt.Errorf("institution %s not found", institution.InstitutionID)
+	}
+	if !replace && existingJSON != nil {
+		return nil, fmt.Errorf("institution %s already exists", institution.InstitutionID)
+	}
+
+	if existingJSON != nil {
+		var existing Institution
+		if err := json.Unmarshal(existingJSON, &existing); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal institution: %v", err)
+		}
+		for _, mspID := range existing.MSPIDs {
+			if err := deleteIndex(ctx, "institution~msp", []string{mspID, existing.InstitutionID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, mspID := range institution.MSPIDs {
+		if err := putIndex(ctx, "institution~msp", []string{mspID, institution.InstitutionID}); err != nil {
+			return nil, err
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	institution.DocType = docTypeInstitution
+	institution.UpdatedBy = creatorOrg
+	institution.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	storedJSON, err := json.Marshal(institution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal institution: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	if existingJSON == nil {
+		logAudit(ctx, "RegisterInstitution", "CONFIG", key, fmt.Sprintf("Institution created: %s", string(storedJSON)))
+	} else {
+		logAudit(ctx, "UpdateInstitution", "CONFIG", key, fmt.Sprintf("Institution changed from %s to %s", string(existingJSON), string(storedJSON)))
+	}
+
+	return &institution, nil
+}
+
+// readInstitution reads an institution, returning nil if there is none; the founding
+// institution falls back to its default
+func readInstitution(ctx contractapi.TransactionContextInterface, institutionID string) (*Institution, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(institutionObjectType, []string{institutionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create institution key: %v", err)
+	}
+	institutionJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if institutionJSON == nil {
+		if institutionID == defaultInstitutionID {
+			fallback := defaultInstitution
+			return &fallback, nil
+		}
+		return nil, nil
+	}
+
+	var institution Institution
+	if err := json.Unmarshal(institutionJSON, &institution); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal institution: %v", err)
+	}
+	return &institution, nil
+}
+
+// institutionRegistered reports whether the institution has been registered explicitly
+func institutionRegistered(ctx contractapi.TransactionContextInterface, institutionID string) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(institutionObjectType, []string{institutionID})
+	if err != nil {
+		return false, fmt.Errorf("failed to create institution key: %v", err)
+	}
+	institutionJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read state: %v", err)
+	}
+	return institutionJSON != nil, nil
+}
+
+// institutionOfMSP returns the institution an MSP ID is registered for, or "" if none
+func institutionOfMSP(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("institution~msp", []string{mspID})
+	if err != nil {
+		return "", fmt.Errorf("failed to query institution index: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	if !resultsIterator.HasNext() {
+		return "", nil
+	}
+	response, err := resultsIterator.Next()
+	if err != nil {
+		return "", err
+	}
+	_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+	if err != nil || len(compositeKeyParts) < 2 {
+		return "", fmt.Errorf("malformed institution index entry %s", response.Key)
+	}
+	return compositeKeyParts[1], nil
+}
+
+// institutionScope returns the caller's scope: the institution its org is registered for, and
+// whether it may read across institutions, as verifier and regulator orgs may
+func institutionScope(ctx contractapi.TransactionContextInterface) (*callerScope, error) {
+	txCtx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if txCtx.scope != nil {
+		return txCtx.scope, nil
+	}
+
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	institutionID, err := institutionOfMSP(ctx, creatorOrg)
+	if err != nil {
+		return nil, err
+	}
+	if institutionID == "" {
+		foundingRegistered, err := institutionRegistered(ctx, defaultInstitutionID)
+		if err != nil {
+			return nil, err
+		}
+		university, err := orgHasRole(ctx, creatorOrg, orgRoleUniversity)
+		if err != nil {
+			return nil, err
+		}
+		if !foundingRegistered && university {
+			institutionID = defaultInstitutionID
+		}
+	}
+	crossReads, err := orgHasRole(ctx, creatorOrg, orgRoleVerifier, orgRoleRegulator)
+	if err != nil {
+		return nil, err
+	}
+
+	txCtx.scope = &callerScope{mspID: creatorOrg, institutionID: institutionID, crossReads: crossReads}
+	return txCtx.scope, nil
+}
+
+// callerInstitution returns the institution the caller's org acts for
+func callerInstitution(ctx contractapi.TransactionContextInterface) (string, error) {
+	scope, err := institutionScope(ctx)
+	if err != nil {
+		return "", err
+	}
+	if scope.institutionID == "" {
+		return "", fmt.Errorf("FORBIDDEN: org %s is not registered for any institution", scope.mspID)
+	}
+	return scope.institutionID, nil
+}
+
+// assetInstitution returns the institution an asset belongs to; unstamped assets predate
+// institutions and belong to the founding one
+func assetInstitution(institutionID string) string {
+	if institutionID == "" {
+		return defaultInstitutionID
+	}
+	return institutionID
+}
+
+// assertInstitutionRead allows verifier and regulator orgs and the asset's own institution to read it
+func assertInstitutionRead(ctx contractapi.TransactionContextInterface, institutionID string) error {
+	scope, err := institutionScope(ctx)
+	if err != nil {
+		return err
+	}
+	if scope.crossReads {
+		return nil
+	}
+	callerID, err := callerInstitution(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID == assetInstitution(institutionID) {
+		return nil
+	}
+	return fmt.Errorf("FORBIDDEN: institution %s cannot access data of institution %s", callerID, assetInstitution(institutionID))
+}
+
+// assertInstitutionWrite allows only the asset's own institution to change it
+func assertInstitutionWrite(ctx contractapi.TransactionContextInterface, institutionID string) error {
+	callerID, err := callerInstitution(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != assetInstitution(institutionID) {
+		return fmt.Errorf("FORBIDDEN: institution %s cannot act for institution %s", callerID, assetInstitution(institutionID))
+	}
+	return nil
+}
+
+// institutionName returns the display name of an institution, or its ID if it is not registered
+func institutionName(ctx contractapi.TransactionContextInterface, institutionID string) (string, error) {
+	institution, err := readInstitution(ctx, assetInstitution(institutionID))
+	if err != nil {
+		return "", err
+	}
+	if institution == nil {
+		return assetInstitution(institutionID), nil
+	}
+	return institution.Name, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// callerInstitutionCall returns a call reporting the caller's institution
+func callerInstitutionCall(ctx contractapi.TransactionContextInterface) (string, error) {
+	return callerInstitution(ctx)
+}
+
+// testInstitution returns an institution with a valid verification URL prefix
+func testInstitution(institutionID string, mspIDs ...string) Institution {
+	return Institution{
+		InstitutionID:         institutionID,
+		Name:                  institutionID + " Institute",
+		MSPIDs:                mspIDs,
+		VerificationURLPrefix: "https://verify.example.edu/" + institutionID + "/",
+	}
+}
+
+func TestFoundingInstitutionBootstrap(t *testing.T) {
+	l := newEmptyTestLedger()
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, initLedgerCall(l, false))
+
+	// Before the founding institution is registered only the university orgs act for it
+	if got := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, callerInstitutionCall); got != defaultInstitutionID {
+		t.Errorf("university org acts for %q before registration, want %q", got, defaultInstitutionID)
+	}
+	ledgertest.InvokeError(t, l, testExamCell, ledgertest.TxOptions{}, "FORBIDDEN: org DepartmentsMSP is not registered for any institution", callerInstitutionCall)
+	ledgertest.InvokeError(t, l, testVerifier, ledgertest.TxOptions{}, "FORBIDDEN: org VerifiersMSP is not registered for any institution", callerInstitutionCall)
+
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "institution NITW must be registered before any other",
+		saveInstitutionCall(t, l, false, testInstitution("IITH", testRegistrar.MSPID)))
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "institution NITW must list the caller's org NITWarangalMSP",
+		saveInstitutionCall(t, l, false, testInstitution(defaultInstitutionID, testExamCell.MSPID)))
+
+	founding := l.registerFoundingInstitution(t)
+	for _, as := range []*ledgertest.Identity{testRegistrar, testExamCell, testVerifier} {
+		if got := ledgertest.MustInvoke(t, l, as, ledgertest.TxOptions{}, callerInstitutionCall); got != defaultInstitutionID {
+			t.Errorf("%s acts for %q after registration, want %q", as.MSPID, got, defaultInstitutionID)
+		}
+	}
+
+	// Once registered, the founding institution keeps the caller's org, and a university org it
+	// does not list acts for no institution
+	founding.MSPIDs = []string{testExamCell.MSPID, testVerifier.MSPID}
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "institution NITW must list the caller's org NITWarangalMSP", saveInstitutionCall(t, l, true, *founding))
+	otherRegistrar := testRegistrar.InMSP("OtherUniversityMSP")
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.UniversityOrgs = append(config.UniversityOrgs, otherRegistrar.MSPID)
+	})
+	ledgertest.InvokeError(t, l, otherRegistrar, ledgertest.TxOptions{}, "FORBIDDEN: org OtherUniversityMSP is not registered for any institution", callerInstitutionCall)
+}
+
+func TestUnregisteredOrgsActForNoInstitution(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	eceExamCell := testExamCell.InMSP("ECEDepartmentMSP")
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.DepartmentOrgs = append(config.DepartmentOrgs, eceExamCell.MSPID)
+	})
+
+	ledgertest.InvokeError(t, l, eceExamCell, ledgertest.TxOptions{}, "FORBIDDEN: org ECEDepartmentMSP is not registered for any institution", func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.GetStudent(ctx, "CS21001", false, "")
+	})
+	ledgertest.InvokeError(t, l, eceExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2))}, "FORBIDDEN: org ECEDepartmentMSP is not registered for any institution", func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+	})
+
+	// Regulators read across institutions without being registered for one, but still act for none
+	readNITW := func(ctx contractapi.TransactionContextInterface) (bool, error) {
+		return true, assertInstitutionRead(ctx, defaultInstitutionID)
+	}
+	ledgertest.MustInvoke(t, l, testRegulator, ledgertest.TxOptions{}, readNITW)
+	ledgertest.InvokeError(t, l, testRegulator, ledgertest.TxOptions{}, "FORBIDDEN: org AICTEMSP is not registered for any institution", callerInstitutionCall)
+}
@@ -0,0 +1,84 @@
+package ledgertest
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// ========== MOCK CLIENT IDENTITY ==========
+
+// Identity describes the enrolled client a transaction is submitted as
+type Identity struct {
+	MSPID string
+	Name  string            // subject common name of the enrollment certificate
+	Attrs map[string]string // certificate attributes such as role and department
+
+	// HideCertificate makes GetX509Certificate fail, as for identities whose certificate the
+	// peer cannot parse, so callers fall back to the decoded client ID
+	HideCertificate bool
+}
+
+// With returns a copy of the identity with the attributes added or replaced
+func (id *Identity) With(attrs map[string]string) *Identity {
+	copied := *id
+	copied.Attrs = map[string]string{}
+	for name, value := range id.Attrs {
+		copied.Attrs[name] = value
+	}
+	for name, value := range attrs {
+		copied.Attrs[name] = value
+	}
+	return &copied
+}
+
+// InMSP returns a copy of the identity enrolled with another MSP
+func (id *Identity) InMSP(mspID string) *Identity {
+	copied := id.With(nil)
+	copied.MSPID = mspID
+	return copied
+}
+
+// ClientID is the identity's cid.GetID value, base64 of "x509::<subject>::<issuer>"
+func (id *Identity) ClientID() string {
+	raw := fmt.Sprintf("x509::CN=%s,OU=client::CN=ca.%s", id.Name, strings.ToLower(id.MSPID))
+	return base64.StdEncoding.EncodeToString([]byte(raw))
+}
+
+// ClientIdentity serves an Identity through cid.ClientIdentity
+type ClientIdentity struct {
+	Identity *Identity
+}
+
+func (c *ClientIdentity) GetID() (string, error) {
+	return c.Identity.ClientID(), nil
+}
+
+func (c *ClientIdentity) GetMSPID() (string, error) {
+	return c.Identity.MSPID, nil
+}
+
+func (c *ClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, found := c.Identity.Attrs[attrName]
+	return value, found, nil
+}
+
+func (c *ClientIdentity) AssertAttributeValue(attrName string, attrValue string) error {
+	value, found := c.Identity.Attrs[attrName]
+	if !found {
+		return fmt.Errorf("attribute '%s' was not found", attrName)
+	}
+	if value != attrValue {
+		return fmt.Errorf("attribute '%s' equals '%s', not '%s'", attrName, value, attrValue)
+	}
+	return nil
+}
+
+func (c *ClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	if c.Identity.HideCertificate {
+		return nil, fmt.Errorf("no certificate available")
+	}
+	return &x509.Certificate{Subject: pkix.Name{CommonName: c.Identity.Name}}, nil
+}
@@ -0,0 +1,186 @@
+// Package ledgertest runs chaincode against an in-memory ledger that follows the Fabric
+// semantics the chaincode relies on: a transaction does not read its own writes, range queries
+// skip composite keys, history is returned newest first, and a failed transaction commits
+// nothing. Every transaction's writes are captured in a WriteSet so tests can assert on exactly
+// what a call put, deleted and emitted.
+package ledgertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== TEST LEDGER ==========
+
+// Epoch is the time of the first transaction of every ledger
+var Epoch = time.Date(2024, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+// txCounter numbers transactions across all ledgers, so no two transactions of a test share a
+// transaction ID
+var txCounter uint64
+
+// TxOptions are the transaction details beyond the identity submitting it
+type TxOptions struct {
+	Function  string            // Fabric function name; when set the Before hook runs first
+	Args      []string          // reported by GetFunctionAndParameters
+	Transient map[string][]byte // transient map of the proposal
+	At        time.Time         // transaction time; the ledger clock's next tick when zero
+}
+
+// Context is a transaction context the ledger can hand a stub and client identity to
+type Context interface {
+	contractapi.TransactionContextInterface
+	contractapi.SettableTransactionContextInterface
+}
+
+// Ledger is a MockStub with a clock advancing one minute per transaction
+type Ledger struct {
+	Stub *MockStub
+	Now  time.Time
+
+	// NewContext returns the empty context of one transaction, as the contract's
+	// TransactionContextHandler does on a peer; a plain contractapi context when nil
+	NewContext func() Context
+
+	// Before runs ahead of transactions submitted with a function name, as the contract's
+	// BeforeTransaction hook does on a peer
+	Before func(ctx contractapi.TransactionContextInterface) error
+}
+
+// Submitter runs one transaction; Ledger and types embedding it implement it
+type Submitter interface {
+	Submit(as *Identity, opts TxOptions, fn func(ctx contractapi.TransactionContextInterface) error) *WriteSet
+}
+
+// NewLedger returns an empty ledger whose clock starts at Epoch
+func NewLedger() *Ledger {
+	return &Ledger{Stub: NewMockStub(), Now: Epoch}
+}
+
+// Submit runs fn as one transaction of the identity and returns its write set, committing the
+// writes only when fn succeeds
+func (l *Ledger) Submit(as *Identity, opts TxOptions, fn func(ctx contractapi.TransactionContextInterface) error) *WriteSet {
+	if opts.At.IsZero() {
+		l.Now = l.Now.Add(time.Minute)
+		opts.At = l.Now
+	} else if opts.At.After(l.Now) {
+		l.Now = opts.At
+	}
+	l.Stub.beginTx(opts.At, opts)
+
+	var ctx Context = &contractapi.TransactionContext{}
+	if l.NewContext != nil {
+		ctx = l.NewContext()
+	}
+	ctx.SetStub(l.Stub)
+	ctx.SetClientIdentity(&ClientIdentity{Identity: as})
+
+	var err error
+	if opts.Function != "" && l.Before != nil {
+		err = l.Before(ctx)
+	}
+	if err == nil {
+		err = fn(ctx)
+	}
+	return l.Stub.endTx(err)
+}
+
+// Invoke runs a contract call as one transaction and returns its result and write set
+func Invoke[T any](l Submitter, as *Identity, opts TxOptions, fn func(ctx contractapi.TransactionContextInterface) (T, error)) (T, *WriteSet) {
+	var result T
+	ws := l.Submit(as, opts, func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, ws
+}
+
+// MustInvoke runs a contract call that must succeed and returns its result
+func MustInvoke[T any](t *testing.T, l Submitter, as *Identity, opts TxOptions, fn func(ctx contractapi.TransactionContextInterface) (T, error)) T {
+	t.Helper()
+	result, ws := Invoke(l, as, opts, fn)
+	if ws.Err != nil {
+		t.Fatalf("transaction %s failed: %v", ws.TxID, ws.Err)
+	}
+	return result
+}
+
+// InvokeError runs a contract call that must fail with an error containing want, and returns
+// the failed transaction's write set
+func InvokeError[T any](t *testing.T, l Submitter, as *Identity, opts TxOptions, want string, fn func(ctx contractapi.TransactionContextInterface) (T, error)) *WriteSet {
+	t.Helper()
+	_, ws := Invoke(l, as, opts, fn)
+	AssertTxError(t, ws, want)
+	return ws
+}
+
+// AssertTxError fails unless the transaction failed with an error containing want
+func AssertTxError(t *testing.T, ws *WriteSet, want string) {
+	t.Helper()
+	if ws.Err == nil {
+		t.Fatalf("transaction %s succeeded, want error containing %q", ws.TxID, want)
+	}
+	if !strings.Contains(ws.Err.Error(), want) {
+		t.Fatalf("transaction %s failed with %q, want error containing %q", ws.TxID, ws.Err, want)
+	}
+}
+
+// Stored decodes the committed world state value under key into v
+func (l *Ledger) Stored(t *testing.T, key string, v interface{}) {
+	t.Helper()
+	Decode(t, l.Stub.State[key], v, key)
+}
+
+// CompositeKey builds a composite key the way the chaincode does
+func (l *Ledger) CompositeKey(t *testing.T, objectType string, attributes ...string) string {
+	t.Helper()
+	key, err := l.Stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		t.Fatalf("failed to create composite key: %v", err)
+	}
+	return key
+}
+
+// HasIndex reports whether the committed state holds the composite index entry
+func (l *Ledger) HasIndex(t *testing.T, objectType string, attributes ...string) bool {
+	t.Helper()
+	_, ok := l.Stub.State[l.CompositeKey(t, objectType, attributes...)]
+	return ok
+}
+
+// ========== SCENARIOS ==========
+
+// Step is one transaction of a scenario with the assertions on its outcome
+type Step struct {
+	Name    string
+	As      *Identity
+	Opts    TxOptions
+	Run     func(ctx contractapi.TransactionContextInterface) error
+	WantErr string                           // the step must fail with an error containing this; empty means it must succeed
+	Check   func(t *testing.T, ws *WriteSet) // optional assertions on the step's write set
+}
+
+// RunScenario submits the steps in order, stopping at the first step that fails its assertions
+func RunScenario(t *testing.T, l Submitter, steps ...Step) {
+	t.Helper()
+	for i, step := range steps {
+		ws := l.Submit(step.As, step.Opts, step.Run)
+		label := fmt.Sprintf("step %d (%s)", i+1, step.Name)
+		switch {
+		case step.WantErr == "" && ws.Err != nil:
+			t.Fatalf("%s: %v", label, ws.Err)
+		case step.WantErr != "" && ws.Err == nil:
+			t.Fatalf("%s: succeeded, want error containing %q", label, step.WantErr)
+		case step.WantErr != "" && !strings.Contains(ws.Err.Error(), step.WantErr):
+			t.Fatalf("%s: failed with %q, want error containing %q", label, ws.Err, step.WantErr)
+		}
+		if step.Check != nil {
+			step.Check(t, ws)
+		}
+	}
+}
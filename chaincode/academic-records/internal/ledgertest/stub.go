@@ -0,0 +1,575 @@
+package ledgertest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ========== MOCK STUB ==========
+
+// MockStub is an in-memory shim.ChaincodeStubInterface; writes are buffered in the current
+// transaction's WriteSet and committed by endTx when the transaction succeeds. Tests may read
+// and seed the committed maps directly.
+type MockStub struct {
+	State      map[string][]byte
+	Private    map[string]map[string][]byte
+	Validation map[string][]byte
+	History    map[string][]*queryresult.KeyModification // newest first
+
+	// InvokeFunc answers InvokeChaincode; other chaincodes are unreachable when it is nil
+	InvokeFunc func(chaincodeName string, args [][]byte, channel string) pb.Response
+	ChannelID  string
+
+	txID      string
+	txTime    time.Time
+	function  string
+	args      []string
+	transient map[string][]byte
+	current   *WriteSet
+}
+
+// NewMockStub returns an empty ledger on the academic channel
+func NewMockStub() *MockStub {
+	return &MockStub{
+		State:      map[string][]byte{},
+		Private:    map[string]map[string][]byte{},
+		Validation: map[string][]byte{},
+		History:    map[string][]*queryresult.KeyModification{},
+		ChannelID:  "academic-channel",
+	}
+}
+
+// beginTx starts recording a new transaction
+func (m *MockStub) beginTx(txTime time.Time, opts TxOptions) {
+	m.txID = fmt.Sprintf("tx%08d", atomic.AddUint64(&txCounter, 1))
+	m.txTime = txTime
+	m.function = opts.Function
+	m.args = opts.Args
+	m.transient = opts.Transient
+	m.current = &WriteSet{TxID: m.txID}
+}
+
+// endTx finishes the current transaction, committing its writes unless it failed
+func (m *MockStub) endTx(err error) *WriteSet {
+	ws := m.current
+	ws.Err = err
+	m.current = nil
+	if err != nil {
+		return ws
+	}
+
+	// Fabric keeps one history entry per key and transaction, holding its final value
+	final := map[string]WriteOp{}
+	order := []string{}
+	for _, op := range ws.Writes {
+		switch op.Kind {
+		case OpPutState:
+			m.State[op.Key] = op.Value
+		case OpDelState:
+			delete(m.State, op.Key)
+		case OpPutPrivateData:
+			if m.Private[op.Collection] == nil {
+				m.Private[op.Collection] = map[string][]byte{}
+			}
+			m.Private[op.Collection][op.Key] = op.Value
+		case OpDelPrivateData, OpPurgePrivate:
+			delete(m.Private[op.Collection], op.Key)
+		case OpSetStateEP:
+			m.Validation[op.Key] = op.Value
+		default:
+			continue
+		}
+		if op.Kind == OpPutState || op.Kind == OpDelState {
+			if _, seen := final[op.Key]; !seen {
+				order = append(order, op.Key)
+			}
+			final[op.Key] = op
+		}
+	}
+	for _, key := range order {
+		op := final[key]
+		modification := &queryresult.KeyModification{
+			TxId:      ws.TxID,
+			Value:     op.Value,
+			Timestamp: timestamppb.New(m.txTime),
+			IsDelete:  op.Kind == OpDelState,
+		}
+		m.History[key] = append([]*queryresult.KeyModification{modification}, m.History[key]...)
+	}
+	return ws
+}
+
+func (m *MockStub) record(op WriteOp) {
+	if m.current == nil {
+		panic("stub write outside a transaction")
+	}
+	m.current.Writes = append(m.current.Writes, op)
+}
+
+func (m *MockStub) GetArgs() [][]byte {
+	args := [][]byte{[]byte(m.function)}
+	for _, arg := range m.args {
+		args = append(args, []byte(arg))
+	}
+	return args
+}
+
+func (m *MockStub) GetStringArgs() []string {
+	return append([]string{m.function}, m.args...)
+}
+
+func (m *MockStub) GetFunctionAndParameters() (string, []string) {
+	return m.function, m.args
+}
+
+func (m *MockStub) GetArgsSlice() ([]byte, error) {
+	return bytes.Join(m.GetArgs(), nil), nil
+}
+
+func (m *MockStub) GetTxID() string {
+	return m.txID
+}
+
+func (m *MockStub) GetChannelID() string {
+	return m.ChannelID
+}
+
+func (m *MockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	if m.InvokeFunc == nil {
+		return shim.Error(fmt.Sprintf("chaincode %s is not reachable from the test ledger", chaincodeName))
+	}
+	return m.InvokeFunc(chaincodeName, args, channel)
+}
+
+func (m *MockStub) GetState(key string) ([]byte, error) {
+	return m.State[key], nil
+}
+
+func (m *MockStub) PutState(key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("empty key not allowed")
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	m.record(WriteOp{Kind: OpPutState, Key: key, Value: value})
+	return nil
+}
+
+func (m *MockStub) DelState(key string) error {
+	m.record(WriteOp{Kind: OpDelState, Key: key})
+	return nil
+}
+
+func (m *MockStub) SetStateValidationParameter(key string, ep []byte) error {
+	m.record(WriteOp{Kind: OpSetStateEP, Key: key, Value: ep})
+	return nil
+}
+
+func (m *MockStub) GetStateValidationParameter(key string) ([]byte, error) {
+	return m.Validation[key], nil
+}
+
+func (m *MockStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return &mockStateIterator{results: rangeResults(m.State, startKey, endKey)}, nil
+}
+
+func (m *MockStub) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, metadata := paginateByKey(rangeResults(m.State, startKey, endKey), pageSize, bookmark)
+	return &mockStateIterator{results: results}, metadata, nil
+}
+
+func (m *MockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := m.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &mockStateIterator{results: prefixResults(m.State, prefix)}, nil
+}
+
+func (m *MockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	prefix, err := m.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, metadata := paginateByKey(prefixResults(m.State, prefix), pageSize, bookmark)
+	return &mockStateIterator{results: results}, metadata, nil
+}
+
+func (m *MockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	key := CompositeKeyStart + objectType + CompositeKeyStart
+	for _, attribute := range attributes {
+		if strings.Contains(attribute, CompositeKeyStart) {
+			return "", fmt.Errorf("composite key attribute %q contains a null byte", attribute)
+		}
+		key += attribute + CompositeKeyStart
+	}
+	return key, nil
+}
+
+func (m *MockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, CompositeKeyStart) {
+		return "", nil, fmt.Errorf("%q is not a composite key", compositeKey)
+	}
+	parts := strings.Split(strings.TrimSuffix(compositeKey[1:], CompositeKeyStart), CompositeKeyStart)
+	return parts[0], parts[1:], nil
+}
+
+func (m *MockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := mangoResults(m.State, query)
+	if err != nil {
+		return nil, err
+	}
+	return &mockStateIterator{results: results}, nil
+}
+
+func (m *MockStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	results, err := mangoResults(m.State, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// CouchDB bookmarks are opaque; the offset of the next page stands in for one
+	offset := 0
+	if bookmark != "" {
+		if offset, err = strconv.Atoi(bookmark); err != nil {
+			return nil, nil, fmt.Errorf("invalid bookmark %q", bookmark)
+		}
+	}
+	if offset > len(results) {
+		offset = len(results)
+	}
+	end := len(results)
+	if pageSize > 0 && offset+int(pageSize) < end {
+		end = offset + int(pageSize)
+	}
+	next := ""
+	if end < len(results) {
+		next = strconv.Itoa(end)
+	}
+	page := results[offset:end]
+	return &mockStateIterator{results: page}, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}, nil
+}
+
+func (m *MockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &mockHistoryIterator{results: append([]*queryresult.KeyModification{}, m.History[key]...)}, nil
+}
+
+func (m *MockStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	return m.Private[collection][key], nil
+}
+
+func (m *MockStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	value, ok := m.Private[collection][key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (m *MockStub) PutPrivateData(collection string, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("empty key not allowed")
+	}
+	m.record(WriteOp{Kind: OpPutPrivateData, Collection: collection, Key: key, Value: value})
+	return nil
+}
+
+func (m *MockStub) DelPrivateData(collection string, key string) error {
+	m.record(WriteOp{Kind: OpDelPrivateData, Collection: collection, Key: key})
+	return nil
+}
+
+func (m *MockStub) PurgePrivateData(collection string, key string) error {
+	m.record(WriteOp{Kind: OpPurgePrivate, Collection: collection, Key: key})
+	return nil
+}
+
+func (m *MockStub) SetPrivateDataValidationParameter(collection string, key string, ep []byte) error {
+	m.record(WriteOp{Kind: OpSetPrivateEP, Collection: collection, Key: key, Value: ep})
+	return nil
+}
+
+func (m *MockStub) GetPrivateDataValidationParameter(collection string, key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockStub) GetPrivateDataByRange(collection string, startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return &mockStateIterator{results: rangeResults(m.Private[collection], startKey, endKey)}, nil
+}
+
+func (m *MockStub) GetPrivateDataByPartialCompositeKey(collection string, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := m.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &mockStateIterator{results: prefixResults(m.Private[collection], prefix)}, nil
+}
+
+func (m *MockStub) GetPrivateDataQueryResult(collection string, query string) (shim.StateQueryIteratorInterface, error) {
+	results, err := mangoResults(m.Private[collection], query)
+	if err != nil {
+		return nil, err
+	}
+	return &mockStateIterator{results: results}, nil
+}
+
+func (m *MockStub) GetCreator() ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockStub) GetTransient() (map[string][]byte, error) {
+	if m.transient == nil {
+		return map[string][]byte{}, nil
+	}
+	return m.transient, nil
+}
+
+func (m *MockStub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockStub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+func (m *MockStub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return &pb.SignedProposal{}, nil
+}
+
+func (m *MockStub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return timestamppb.New(m.txTime), nil
+}
+
+func (m *MockStub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+	m.record(WriteOp{Kind: OpSetEvent, Key: name, Value: payload})
+	return nil
+}
+
+// sortedKeys returns the keys of values in ascending order
+func sortedKeys(values map[string][]byte) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rangeResults returns the simple keys in [startKey, endKey); like Fabric, an empty start key
+// begins after the composite key namespace so composite keys never appear
+func rangeResults(values map[string][]byte, startKey string, endKey string) []*queryresult.KV {
+	results := []*queryresult.KV{}
+	for _, key := range sortedKeys(values) {
+		if strings.HasPrefix(key, CompositeKeyStart) || key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		results = append(results, &queryresult.KV{Key: key, Value: values[key]})
+	}
+	return results
+}
+
+// prefixResults returns the keys starting with a partial composite key, in key order
+func prefixResults(values map[string][]byte, prefix string) []*queryresult.KV {
+	results := []*queryresult.KV{}
+	for _, key := range sortedKeys(values) {
+		if strings.HasPrefix(key, prefix) {
+			results = append(results, &queryresult.KV{Key: key, Value: values[key]})
+		}
+	}
+	return results
+}
+
+// paginateByKey returns one page of key-ordered results; the bookmark is the first key of the
+// next page and is empty on the last page
+func paginateByKey(results []*queryresult.KV, pageSize int32, bookmark string) ([]*queryresult.KV, *pb.QueryResponseMetadata) {
+	start := 0
+	for start < len(results) && bookmark != "" && results[start].Key < bookmark {
+		start++
+	}
+	end := len(results)
+	if pageSize > 0 && start+int(pageSize) < end {
+		end = start + int(pageSize)
+	}
+	next := ""
+	if end < len(results) {
+		next = results[end].Key
+	}
+	page := results[start:end]
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: next}
+}
+
+// mangoResults evaluates the subset of CouchDB Mango queries the chaincode issues: field
+// equality, $eq, $gt, $gte, $lt, $lte and $exists, and an ascending or descending sort
+func mangoResults(values map[string][]byte, query string) ([]*queryresult.KV, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid query %q: %v", query, err)
+	}
+
+	type match struct {
+		kv  *queryresult.KV
+		doc map[string]interface{}
+	}
+	matches := []match{}
+	for _, key := range sortedKeys(values) {
+		if strings.HasPrefix(key, CompositeKeyStart) {
+			continue
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(values[key], &doc); err != nil {
+			continue
+		}
+		ok, err := mangoMatches(doc, parsed.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, match{kv: &queryresult.KV{Key: key, Value: values[key]}, doc: doc})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, field := range parsed.Sort {
+			for name, direction := range field {
+				c := mangoCompare(matches[i].doc[name], matches[j].doc[name])
+				if c == 0 {
+					continue
+				}
+				if direction == "desc" {
+					return c > 0
+				}
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	results := make([]*queryresult.KV, len(matches))
+	for i := range matches {
+		results[i] = matches[i].kv
+	}
+	return results, nil
+}
+
+// mangoMatches reports whether doc satisfies every condition of a selector
+func mangoMatches(doc map[string]interface{}, selector map[string]interface{}) (bool, error) {
+	for field, condition := range selector {
+		value, present := doc[field]
+		operators, ok := condition.(map[string]interface{})
+		if !ok {
+			if !present || !reflect.DeepEqual(value, condition) {
+				return false, nil
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			var ok bool
+			switch operator {
+			case "$exists":
+				ok = present == operand.(bool)
+			case "$eq":
+				ok = present && reflect.DeepEqual(value, operand)
+			case "$gt":
+				ok = present && mangoCompare(value, operand) > 0
+			case "$gte":
+				ok = present && mangoCompare(value, operand) >= 0
+			case "$lt":
+				ok = present && mangoCompare(value, operand) < 0
+			case "$lte":
+				ok = present && mangoCompare(value, operand) <= 0
+			default:
+				return false, fmt.Errorf("test ledger does not support Mango operator %s", operator)
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// mangoCompare orders two JSON values of the same type; values of other types compare equal
+func mangoCompare(a interface{}, b interface{}) int {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+// mockStateIterator iterates a fixed list of query results
+type mockStateIterator struct {
+	results []*queryresult.KV
+	next    int
+}
+
+func (it *mockStateIterator) HasNext() bool {
+	return it.next < len(it.results)
+}
+
+func (it *mockStateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("iterator exhausted")
+	}
+	it.next++
+	return it.results[it.next-1], nil
+}
+
+func (it *mockStateIterator) Close() error {
+	return nil
+}
+
+// mockHistoryIterator iterates a key's history, newest first
+type mockHistoryIterator struct {
+	results []*queryresult.KeyModification
+	next    int
+}
+
+func (it *mockHistoryIterator) HasNext() bool {
+	return it.next < len(it.results)
+}
+
+func (it *mockHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("iterator exhausted")
+	}
+	it.next++
+	return it.results[it.next-1], nil
+}
+
+func (it *mockHistoryIterator) Close() error {
+	return nil
+}
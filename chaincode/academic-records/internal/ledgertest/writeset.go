@@ -0,0 +1,133 @@
+package ledgertest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// ========== WRITE SET ==========
+
+// Write kinds, named after the stub calls that produce them
+const (
+	OpPutState       = "PutState"
+	OpDelState       = "DelState"
+	OpPutPrivateData = "PutPrivateData"
+	OpDelPrivateData = "DelPrivateData"
+	OpPurgePrivate   = "PurgePrivateData"
+	OpSetStateEP     = "SetStateValidationParameter"
+	OpSetPrivateEP   = "SetPrivateDataValidationParameter"
+	OpSetEvent       = "SetEvent"
+)
+
+// CompositeKeyStart begins every composite key and separates its attributes
+const CompositeKeyStart = "\x00"
+
+// WriteOp is one write a transaction made, in call order
+type WriteOp struct {
+	Kind       string
+	Collection string // private data writes only
+	Key        string // the event name for SetEvent
+	Value      []byte // nil for deletes
+}
+
+// WriteSet records everything one transaction wrote; Err is the transaction's error, in which
+// case none of the writes were committed
+type WriteSet struct {
+	TxID   string
+	Writes []WriteOp
+	Err    error
+}
+
+// Ops returns the writes of the given kinds, all writes when none are given
+func (ws *WriteSet) Ops(kinds ...string) []WriteOp {
+	var ops []WriteOp
+	for _, op := range ws.Writes {
+		if len(kinds) == 0 || ContainsString(kinds, op.Kind) {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Put returns the last value the transaction put under key, nil if it put none
+func (ws *WriteSet) Put(key string) []byte {
+	var value []byte
+	for _, op := range ws.Writes {
+		if op.Kind == OpPutState && op.Key == key {
+			value = op.Value
+		}
+	}
+	return value
+}
+
+// Deleted reports whether the transaction deleted key
+func (ws *WriteSet) Deleted(key string) bool {
+	for _, op := range ws.Writes {
+		if op.Kind == OpDelState && op.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// PutPrivate returns the last value the transaction put under key in collection
+func (ws *WriteSet) PutPrivate(collection string, key string) []byte {
+	var value []byte
+	for _, op := range ws.Writes {
+		if op.Kind == OpPutPrivateData && op.Collection == collection && op.Key == key {
+			value = op.Value
+		}
+	}
+	return value
+}
+
+// PutKeys lists the keys the transaction put, in call order
+func (ws *WriteSet) PutKeys() []string {
+	keys := []string{}
+	for _, op := range ws.Ops(OpPutState) {
+		keys = append(keys, op.Key)
+	}
+	return keys
+}
+
+// Event returns the transaction's chaincode event; Fabric keeps only the last one set
+func (ws *WriteSet) Event() (string, []byte, bool) {
+	events := ws.Ops(OpSetEvent)
+	if len(events) == 0 {
+		return "", nil, false
+	}
+	last := events[len(events)-1]
+	return last.Key, last.Value, true
+}
+
+// LedgerWrites returns every write that would change world state or private data
+func (ws *WriteSet) LedgerWrites() []WriteOp {
+	return ws.Ops(OpPutState, OpDelState, OpPutPrivateData, OpDelPrivateData, OpPurgePrivate, OpSetStateEP, OpSetPrivateEP)
+}
+
+// Decode unmarshals the last value the transaction put under key into v
+func (ws *WriteSet) Decode(t *testing.T, key string, v interface{}) {
+	t.Helper()
+	Decode(t, ws.Put(key), v, key)
+}
+
+// Decode unmarshals a stored JSON value, failing the test when it is absent or invalid
+func Decode(t *testing.T, value []byte, v interface{}, what string) {
+	t.Helper()
+	if value == nil {
+		t.Fatalf("%s was not written", what)
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", what, err)
+	}
+}
+
+// ContainsString reports whether values holds want
+func ContainsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
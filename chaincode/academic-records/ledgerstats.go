@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== LEDGER STATS ==========
+//
+// A single counter key would be written by nearly every transaction and make concurrent ones
+// fail MVCC validation, so each transaction writes its own delta instead:
+// stats~delta~category~name~qualifier~txID. GetLedgerStats adds the deltas to the snapshot
+// RebuildStats last stored, and RebuildStats folds them away again. Counts move with the
+// student~status, student~archived, record~status and audit index entries and with every
+// certificate written, so run RebuildStats once after deploying and whenever the deltas grow long.
+
+// statsKey is the world state key of the snapshot written by RebuildStats
+const statsKey = "STATS_LEDGER"
+
+// Counter categories
+const (
+	statsStudents     = "students"
+	statsRecords      = "records"
+	statsCertificates = "certificates"
+	statsAudit        = "audit"
+)
+
+// statsArchived counts archived students, which leave the student~status index
+const statsArchived = "ARCHIVED"
+
+// LedgerStats counts the assets on the ledger
+type LedgerStats struct {
+	DocType      string                    `json:"docType"`
+	Students     map[string]int            `json:"students"`     // by status, plus ARCHIVED
+	Records      map[string]int            `json:"records"`      // by status
+	Certificates map[string]map[string]int `json:"certificates"` // by type, then status
+	AuditEntries int                       `json:"auditEntries"`
+	RebuiltAt    string                    `json:"rebuiltAt,omitempty"`
+	UpdatedAt    string                    `json:"updatedAt,omitempty"` // last counter change
+}
+
+// statsDelta is one transaction's change to one counter
+type statsDelta struct {
+	Count      int    `json:"count"`
+	UpdatedAt  string `json:"updatedAt"`
+	Correction bool   `json:"correction,omitempty"` // written by CorrectMonthlyStats
+}
+
+// txStats accumulates one transaction's deltas, since GetState cannot see them
+type txStats struct {
+	deltas     map[string]*statsDelta
+	indexKeys  map[string]bool   // counted index entries put (true) or deleted (false) by this transaction
+	certStatus map[string]string // certificate statuses written by this transaction
+}
+
+// GetLedgerStats returns the asset counts (NITWarangal and regulators)
+func (s *SmartContract) GetLedgerStats(ctx contractapi.TransactionContextInterface) (*LedgerStats, error) {
+	if _, err := requireOrgRole(ctx, "view ledger stats", orgRoleUniversity, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+
+	stats, err := readStatsSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("stats~delta", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats deltas: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 4 {
+			continue
+		}
+		var delta statsDelta
+		if err := json.Unmarshal(response.Value, &delta); err != nil {
+			continue
+		}
+		stats.add(compositeKeyParts[0], compositeKeyParts[1], compositeKeyParts[2], delta.Count)
+		if delta.UpdatedAt > stats.UpdatedAt {
+			stats.UpdatedAt = delta.UpdatedAt
+		}
+	}
+
+	return stats, nil
+}
+
+// RebuildStats recounts every asset from scratch, stores the snapshot and deletes the deltas
+// (NITWarangal admin identities only). It reads the whole world state.
+func (s *SmartContract) RebuildStats(ctx contractapi.TransactionContextInterface) (*LedgerStats, error) {
+	if _, err := requireOrgRole(ctx, "rebuild ledger stats", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("rebuilding ledger stats requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+	stats := newLedgerStats()
+	stats.RebuiltAt = now
+	stats.UpdatedAt = now
+
+	resultsIterator, err := assetRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var asset struct {
+			DocType           string `json:"docType"`
+			Status            string `json:"status"`
+			Archived          bool   `json:"archived"`
+			CertificationType string `json:"certificationType"`
+			LogID             string `json:"logId"`
+		}
+		if err := json.Unmarshal(response.Value, &asset); err != nil {
+			continue
+		}
+		switch {
+		case asset.DocType == docTypeStudent && asset.Archived:
+			stats.add(statsStudents, statsArchived, "", 1)
+		case asset.DocType == docTypeStudent:
+			stats.add(statsStudents, asset.Status, "", 1)
+		case asset.DocType == docTypeRecord:
+			stats.add(statsRecords, asset.Status, "", 1)
+		case asset.DocType == docTypeCertificate:
+			stats.add(statsCertificates, asset.CertificationType, asset.Status, 1)
+		case asset.DocType == "" && asset.LogID != "":
+			stats.add(statsAudit, "", "", 1)
+		}
+	}
+
+	deltaIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("stats~delta", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats deltas: %v", err)
+	}
+	defer deltaIterator.Close()
+
+	folded := 0
+	for deltaIterator.HasNext() {
+		response, err := deltaIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.GetStub().DelState(response.Key); err != nil {
+			return nil, fmt.Errorf("failed to delete stats delta: %v", err)
+		}
+		folded++
+	}
+
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger stats: %v", err)
+	}
+	if err := ctx.GetStub().PutState(statsKey, statsJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	// The audit entry below leaves a fresh delta, so it is still counted
+	logAudit(ctx, "RebuildStats", "CONFIG", statsKey, fmt.Sprintf("Ledger stats rebuilt, %d deltas folded: %s", folded, string(statsJSON)))
+
+	return stats, nil
+}
+
+// newLedgerStats returns empty counts
+func newLedgerStats() *LedgerStats {
+	return &LedgerStats{
+		DocType:      docTypeLedgerStats,
+		Students:     map[string]int{},
+		Records:      map[string]int{},
+		Certificates: map[string]map[string]int{},
+	}
+}
+
+// add moves one counter by count
+func (stats *LedgerStats) add(category string, name string, qualifier string, count int) {
+	switch category {
+	case statsStudents:
+		stats.Students[name] += count
+	case statsRecords:
+		stats.Records[name] += count
+	case statsCertificates:
+		if stats.Certificates[name] == nil {
+			stats.Certificates[name] = map[string]int{}
+		}
+		stats.Certificates[name][qualifier] += count
+	case statsAudit:
+		stats.AuditEntries += count
+	}
+}
+
+// readStatsSnapshot loads the last RebuildStats snapshot, or empty counts if there is none
+func readStatsSnapshot(ctx contractapi.TransactionContextInterface) (*LedgerStats, error) {
+	stats := newLedgerStats()
+	statsJSON, err := ctx.GetStub().GetState(statsKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if statsJSON == nil {
+		return stats, nil
+	}
+	if err := json.Unmarshal(statsJSON, stats); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ledger stats: %v", err)
+	}
+	if stats.Students == nil {
+		stats.Students = map[string]int{}
+	}
+	if stats.Records == nil {
+		stats.Records = map[string]int{}
+	}
+	if stats.Certificates == nil {
+		stats.Certificates = map[string]map[string]int{}
+	}
+	return stats, nil
+}
+
+// transactionStats returns the deltas of the current transaction, starting them on first use
+func transactionStats(ctx contractapi.TransactionContextInterface) (*txStats, error) {
+	txCtx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if txCtx.stats == nil {
+		txCtx.stats = &txStats{
+			deltas:     map[string]*statsDelta{},
+			indexKeys:  map[string]bool{},
+			certStatus: map[string]string{},
+		}
+	}
+	return txCtx.stats, nil
+}
+
+// adjustStat writes this transaction's accumulated delta for one counter
+func adjustStat(ctx contractapi.TransactionContextInterface, stats *txStats, category string, name string, qualifier string, by int) error {
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey("stats~delta", []string{category, name, qualifier, ctx.GetStub().GetTxID()})
+	if err != nil {
+		return fmt.Errorf("failed to create stats delta key: %v", err)
+	}
+	return putStatsDelta(ctx, stats, key, by, txTime)
+}
+
+// putStatsDelta adds to the delta stored under key by this transaction
+func putStatsDelta(ctx contractapi.TransactionContextInterface, stats *txStats, key string, by int, txTime time.Time) error {
+	delta := stats.deltas[key]
+	if delta == nil {
+		delta = &statsDelta{}
+		stats.deltas[key] = delta
+	}
+	delta.Count += by
+	delta.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	deltaJSON, err := json.Marshal(delta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats delta: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, deltaJSON); err != nil {
+		return fmt.Errorf("failed to put stats delta: %v", err)
+	}
+	return nil
+}
+
+// countIndexEntry moves the counter fed by an index entry that was put (by 1) or deleted (by -1).
+// Entries that already existed, or were already gone, leave the counter alone.
+func countIndexEntry(ctx contractapi.TransactionContextInterface, objectType string, attributes []string, indexKey string, by int) error {
+	var name string
+	category := statsStudents
+	switch objectType {
+	case "student~status":
+		name = attributes[0]
+	case "student~archived":
+		name = statsArchived
+	case "record~status":
+		category, name = statsRecords, attributes[0]
+	case "audit":
+		category = statsAudit
+	default:
+		return nil
+	}
+
+	stats, err := transactionStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Audit entries are only ever put once and deleted once, so skip the extra read
+	if category != statsAudit {
+		present, seen := stats.indexKeys[indexKey]
+		if !seen {
+			existing, err := ctx.GetStub().GetState(indexKey)
+			if err != nil {
+				return fmt.Errorf("failed to read state: %v", err)
+			}
+			present = existing != nil
+		}
+		stats.indexKeys[indexKey] = by > 0
+		if present == (by > 0) {
+			return nil
+		}
+	}
+	return adjustStat(ctx, stats, category, name, "", by)
+}
+
+// countCertificate moves the certificate counters when a certificate is created or changes status
+func countCertificate(ctx contractapi.TransactionContextInterface, cert *Certificate) error {
+	stats, err := transactionStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous, seen := stats.certStatus[cert.CertificateID]
+	if !seen {
+		existing, err := readCertificate(ctx, cert.CertificateID)
+		if err == nil && existing.DocType == docTypeCertificate {
+			previous = existing.Status
+		}
+	}
+	stats.certStatus[cert.CertificateID] = cert.Status
+
+	if previous == cert.Status {
+		return nil
+	}
+	if previous != "" {
+		if err := adjustStat(ctx, stats, statsCertificates, cert.CertificationType, previous, -1); err != nil {
+			return err
+		}
+	}
+	return adjustStat(ctx, stats, statsCertificates, cert.CertificationType, cert.Status, 1)
+}
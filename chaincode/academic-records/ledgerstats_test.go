@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// countRecordStatus counts a new record~status index entry of a record
+func countRecordStatus(t *testing.T, ctx contractapi.TransactionContextInterface, recordID string) {
+	t.Helper()
+	attributes := []string{recordStatusSubmitted, recordID}
+	indexKey, _ := ctx.GetStub().CreateCompositeKey("record~status", attributes)
+	if err := countIndexEntry(ctx, "record~status", attributes, indexKey, 1); err != nil {
+		t.Fatalf("countIndexEntry(%s): %v", recordID, err)
+	}
+}
+
+func TestStatsDeltasSurviveInterleavedTransactions(t *testing.T) {
+	l := newTestLedger(t)
+	other := newTestLedger(t)
+
+	// Another transaction counts while the first one is still running, as concurrent
+	// transactions do in one chaincode process
+	ws := l.Submit(testExamCell, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) error {
+		countRecordStatus(t, ctx, "REC1")
+		NewTestStudent(t, other, "CS21001", "CSE")
+		countRecordStatus(t, ctx, "REC2")
+		return nil
+	})
+	if ws.Err != nil {
+		t.Fatalf("counting transaction failed: %v", ws.Err)
+	}
+
+	key := l.CompositeKey(t, "stats~delta", statsRecords, recordStatusSubmitted, "", ws.TxID)
+	var delta statsDelta
+	ws.Decode(t, key, &delta)
+	if delta.Count != 2 {
+		t.Errorf("delta of the interleaved transaction = %d, want 2", delta.Count)
+	}
+	stats := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*LedgerStats, error) {
+		return l.contract.GetLedgerStats(ctx)
+	})
+	if stats.Records[recordStatusSubmitted] != 2 {
+		statsJSON, _ := json.Marshal(stats)
+		t.Errorf("ledger stats = %s, want 2 SUBMITTED records", statsJSON)
+	}
+}
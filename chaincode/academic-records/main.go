@@ -2,13 +2,18 @@ package main
 
 import (
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -17,482 +22,1803 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// GetEvaluateTransactions marks the read-only functions in the contract metadata so SDK
+// users can tell which to evaluate and which to submit. The *Logged reads write an access
+// audit entry and must be submitted, so they are deliberately not listed.
+func (s *SmartContract) GetEvaluateTransactions() []string {
+	return []string{
+		"GetStudent",
+		"GetAllStudents",
+		"QueryStudents",
+		"GetStudentsByStatus",
+		"GetStudentStatusHistory",
+		"GetAcademicRecord",
+		"GetRecordHistory",
+		"GetRecordEndorsementPolicy",
+		"GetStudentRecords",
+		"GetStudentRecordsWithPagination",
+		"GetRecordsByStatus",
+		"GetRecordsByStatusWithPagination",
+		"GetRecordsAwaitingVerification",
+		"QueryRecordsByPeriod",
+		"ExportAnonymizedRecords",
+		"GetDraftCourses",
+		"GetStudentCGPA",
+		"GetConvertedGPA",
+		"GetGPAConversionTable",
+		"ListGPAConversionTables",
+		"GetTopStudents",
+		"GetDepartmentStats",
+		"GetCGPADistribution",
+		"ComputeHonors",
+		"GetDeansList",
+		"GetComplianceReport",
+		"GetLedgerStats",
+		"GetMonthlyStats",
+		"GetGradeScale",
+		"GetInstitution",
+		"ListInstitutions",
+		"ListGradeScales",
+		"GetApprovalChecklistSchema",
+		"GetPendingApprovals",
+		"GetCertificate",
+		"GetCertificateByHash",
+		"GetCertificateHistory",
+		"GetStudentCertificates",
+		"GetMyRecords",
+		"GetMyCertificates",
+		"GetIdentityBinding",
+		"GetStudentIdentities",
+		"GetShareTokens",
+		"ResolveShareToken",
+		"GetCertificatePolicy",
+		"GetGraduationEligibility",
+		"GetDegreeRules",
+		"ListDegreeRules",
+		"GetCertificateTemplate",
+		"ListCertificateTemplates",
+		"GetCourse",
+		"GetFaculty",
+		"GetGradesByFaculty",
+		"GetAppealsByStudent",
+		"GetOpenAppeals",
+		"GetStudentTranscript",
+		"GetActiveHolds",
+		"GetClearanceStatus",
+		"GetDepartmentRegistry",
+		"GetDepartmentTransfers",
+		"GetStudentByEmail",
+		"GetRolePolicy",
+		"GetConfig",
+		"GetVerificationCount",
+		"GetVerificationHistory",
+		"GetVerificationRequest",
+		"GetVerificationRequests",
+		"IsCertificateValid",
+		"CheckCertificate",
+		"CheckCertificates",
+		"GetCertificateSigningInfo",
+		"VerifySignedCertificate",
+		"ExportOpenBadge",
+		"GetBadgeClass",
+		"ListBadgeClasses",
+		"GetCertificateAnchor",
+		"GetCertificateInclusionProof",
+		"GenerateCourseDisclosure",
+		"VerifyCourseDisclosure",
+		"DecodeAndCheckQR",
+		"VerifyTranscriptIntegrity",
+		"GetAccessGrants",
+		"GetAuditLog",
+		"GetAuditLogWithPagination",
+		"QueryAuditLogs",
+		"GetStudentAuditTrail",
+	}
+}
+
 // ========== DATA MODELS ==========
 
 // Student represents a student record
 type Student struct {
-	StudentID    string    `json:"studentId"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Department   string    `json:"department"`
-	EnrollmentDate string  `json:"enrollmentDate"`
-	Status       string    `json:"status"` // ACTIVE, GRADUATED, SUSPENDED
-	CreatedBy    string    `json:"createdBy"`
-	CreatedAt    string    `json:"createdAt"`
+	DocType        string `json:"docType"`
+	StudentID      string `json:"studentId"`
+	Department     string `json:"department"`
+	Program        string `json:"program,omitempty"` // BTECH when empty
+	EnrollmentDate string `json:"enrollmentDate"`
+	Status         string `json:"status"` // ACTIVE, GRADUATED, SUSPENDED, MERGED, TRANSFERRED_OUT
+	CreatedBy      string `json:"createdBy"`
+	CreatedAt      string `json:"createdAt"`
+
+	// Institution the student belongs to; empty on students stored before institutions existed
+	InstitutionID string `json:"institutionId,omitempty"`
+
+	// Tombstone set by ArchiveStudent; archived students are left out of student reads
+	Archived      bool   `json:"archived,omitempty"`
+	ArchivedBy    string `json:"archivedBy,omitempty"`
+	ArchivedAt    string `json:"archivedAt,omitempty"`
+	ArchiveReason string `json:"archiveReason,omitempty"`
+
+	// Set by InitiateTransferOut on the sending ledger (TransferredTo, TransferChaincode) and by
+	// AcceptTransferIn on the receiving one (TransferredFrom); TransferHash is the payload hash
+	TransferredTo     string `json:"transferredTo,omitempty"`
+	TransferChaincode string `json:"transferChaincode,omitempty"`
+	TransferredFrom   string `json:"transferredFrom,omitempty"`
+	TransferHash      string `json:"transferHash,omitempty"`
+
+	// Survivor of a MergeStudents call that folded this student into another
+	MergedInto string `json:"mergedInto,omitempty"`
+
+	// Set by ImportStudentDossier: hash of the source dossier and CreatedAt on the source ledger
+	ImportedFrom      string `json:"importedFrom,omitempty"`
+	OriginalCreatedAt string `json:"originalCreatedAt,omitempty"`
+
+	// PII lives in the student PII collection and is only filled in for collection members
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
 }
 
 // AcademicRecord represents semester-wise academic performance
 type AcademicRecord struct {
-	RecordID      string                 `json:"recordId"`
-	StudentID     string                 `json:"studentId"`
-	Semester      int                    `json:"semester"`
-	Year          int                    `json:"year"`
-	Courses       []CourseGrade          `json:"courses"`
-	SGPA          float64                `json:"sgpa"`
-	CGPA          float64                `json:"cgpa"`
-	Status        string                 `json:"status"` // DRAFT, SUBMITTED, APPROVED, VERIFIED
-	CreatedBy     string                 `json:"createdBy"`
-	ApprovedBy    string                 `json:"approvedBy"`
-	VerifiedBy    string                 `json:"verifiedBy"`
-	CreatedAt     string                 `json:"createdAt"`
-	ApprovedAt    string                 `json:"approvedAt"`
-	VerifiedAt    string                 `json:"verifiedAt"`
-	Remarks       string                 `json:"remarks"`
+	DocType       string        `json:"docType"`
+	RecordID      string        `json:"recordId"`
+	StudentID     string        `json:"studentId"`
+	Department    string        `json:"department,omitempty"` // student's department when the record was created
+	InstitutionID string        `json:"institutionId,omitempty"`
+	Semester      int           `json:"semester"`
+	Year          int           `json:"year"`
+	Courses       []CourseGrade `json:"courses"` // empty until approval, see CoursesHash
+	SGPA          float64       `json:"sgpa"`
+	CGPA          float64       `json:"cgpa"`
+	Status        string        `json:"status"` // DRAFT, SUBMITTED, APPROVED, VERIFIED, REJECTED, SUPERSEDED
+	CreatedBy     string        `json:"createdBy"`
+	ApprovedBy    string        `json:"approvedBy"`
+	VerifiedBy    string        `json:"verifiedBy"`
+	CreatedAt     string        `json:"createdAt"`
+	ApprovedAt    string        `json:"approvedAt"`
+	VerifiedAt    string        `json:"verifiedAt"`
+	Remarks       string        `json:"remarks"` // newline-separated "[timestamp] org: text" entries
+	Rejections    []*Rejection  `json:"rejections,omitempty"`
+	Version       int           `json:"version"`
+
+	SupersedesRecordID string `json:"supersedesRecordId,omitempty"`
+	SupersededBy       string `json:"supersededBy,omitempty"`
+	SupersessionReason string `json:"supersessionReason,omitempty"`
+
+	Approvals []*Approval `json:"approvals,omitempty"`
+
+	// SHA-256 of the course list held in the draft grades collection before approval
+	CoursesHash string `json:"coursesHash,omitempty"`
+
+	// Regulation the record falls under and the regulation year of the grade scale it is graded
+	// on; GradeScaleYear is 0 for records graded on the unversioned scale
+	RegulationYear int `json:"regulationYear,omitempty"`
+	GradeScaleYear int `json:"gradeScaleYear,omitempty"`
+
+	// Set at approval when the SGPA reaches the dean's list threshold, see GetDeansList
+	DeansListed bool `json:"deansListed,omitempty"`
+
+	// Course code -> SHA-256 commitment for selective disclosure, see GenerateCourseDisclosure
+	CourseCommitments map[string]string `json:"courseCommitments,omitempty"`
+
+	// Set by ImportStudentDossier: hash of the source dossier and CreatedAt on the source ledger
+	ImportedFrom      string `json:"importedFrom,omitempty"`
+	OriginalCreatedAt string `json:"originalCreatedAt,omitempty"`
+}
+
+// Rejection records one time a record was sent back to its department
+type Rejection struct {
+	RejectedBy   string `json:"rejectedBy"`
+	RejectedByID string `json:"rejectedById"`
+	RejectedAt   string `json:"rejectedAt"`
+	Remarks      string `json:"remarks"`
 }
 
 // CourseGrade represents individual course performance
 type CourseGrade struct {
-	CourseCode   string  `json:"courseCode"`
-	CourseName   string  `json:"courseName"`
-	Credits      float64 `json:"credits"`
-	Grade        string  `json:"grade"` // A, B, C, D, F
-	GradePoint   float64 `json:"gradePoint"`
+	CourseCode string  `json:"courseCode"`
+	CourseName string  `json:"courseName"`
+	Credits    float64 `json:"credits"`
+	Grade      string  `json:"grade"` // A, B, C, D, F
+	GradePoint float64 `json:"gradePoint"`
+
+	// Faculty member who awarded the grade; absent on records created before instructor attribution
+	InstructorID string `json:"instructorId,omitempty"`
 }
 
 // Certificate represents issued certificate
 type Certificate struct {
-	CertificateID  string    `json:"certificateId"`
-	StudentID      string    `json:"studentId"`
+	DocType           string `json:"docType"`
+	CertificateID     string `json:"certificateId"`
+	StudentID         string `json:"studentId"`
 	CertificationType string `json:"certificationType"` // DEGREE, TRANSCRIPT, DIPLOMA
-	IssuedDate     string    `json:"issuedDate"`
-	CertificateHash string   `json:"certificateHash"` // SHA256 hash for verification
-	QRCode         string    `json:"qrCode"`
-	Status         string    `json:"status"` // ISSUED, VERIFIED, REVOKED
-	IssuedBy       string    `json:"issuedBy"`
-	VerificationCount int    `json:"verificationCount"`
-	CreatedAt      string    `json:"createdAt"`
+	IssuedDate        string `json:"issuedDate"`
+	CertificateHash   string `json:"certificateHash"`           // SHA256 hash for verification
+	HashFormat        string `json:"hashFormat,omitempty"`      // canonical-json/v1; empty for legacy pipe-joined hashes
+	HashAlgorithm     string `json:"hashAlgorithm,omitempty"`   // sha256 or sha3-256; sha256 when empty
+	QRCode            string `json:"qrCode"`                    // compact payload, see DecodeAndCheckQR; the URL on older certificates
+	VerificationURL   string `json:"verificationUrl,omitempty"` // absent on certificates whose QRCode is the URL
+	Status            string `json:"status"`                    // ISSUED, REVOKED, SUPERSEDED
+	IssuedBy          string `json:"issuedBy"`
+	InstitutionID     string `json:"institutionId,omitempty"` // empty on certificates issued before institutions existed
+	VerificationCount int    `json:"verificationCount"`       // computed on read, see GetVerificationCount
+	CreatedAt         string `json:"createdAt"`
+	ValidFrom         string `json:"validFrom,omitempty"`
+	ValidUntil        string `json:"validUntil,omitempty"` // omitted for permanent certificates
+
+	RevokedAt         string `json:"revokedAt,omitempty"`
+	RevokedBy         string `json:"revokedBy,omitempty"`
+	RevocationReason  string `json:"revocationReason,omitempty"` // FRAUD, ADMIN_ERROR, SUPERSEDED, COURT_ORDER
+	RevocationDetails string `json:"revocationDetails,omitempty"`
+
+	// TRANSCRIPT and DEGREE certificates attest the verified records they were issued from
+	RecordsHash      string   `json:"recordsHash,omitempty"`
+	CoveredRecordIDs []string `json:"coveredRecordIds,omitempty"`
+
+	// Set when a covered record is superseded; the certificate stays ISSUED until it is revoked
+	// or reissued from the corrected records
+	ReviewStatus string `json:"reviewStatus,omitempty"` // RECORD_SUPERSEDED
+	ReviewReason string `json:"reviewReason,omitempty"`
+	FlaggedAt    string `json:"flaggedAt,omitempty"`
+
+	// Student ID the hash was generated for, kept when MergeStudents re-points the certificate
+	IssuedToStudentID string `json:"issuedToStudentId,omitempty"`
+
+	SupersedesCertificateID string `json:"supersedesCertificateId,omitempty"`
+	SupersededBy            string `json:"supersededBy,omitempty"`
+
+	// Free-form details supplied at issuance, e.g. by BulkIssueCertificates
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Printable fields copied from the certificate template at issue time
+	Template *CertificateTemplateSnapshot `json:"template,omitempty"`
+
+	// DEGREE and DIPLOMA: graduation date on the parchment and the class of the degree, with the
+	// final CGPA and the bands it was classified against as they stood at issuance
+	GraduationDate      string               `json:"graduationDate,omitempty"`
+	Classification      string               `json:"classification,omitempty"`
+	ClassificationCGPA  float64              `json:"classificationCgpa,omitempty"`
+	ClassificationBands []ClassificationBand `json:"classificationBands,omitempty"`
+
+	// Set by ImportStudentDossier: hash of the source dossier and CreatedAt on the source ledger
+	ImportedFrom      string `json:"importedFrom,omitempty"`
+	OriginalCreatedAt string `json:"originalCreatedAt,omitempty"`
+
+	// Detached issuer signature attached by SignCertificate, see GetCertificateSigningInfo
+	Signature          string `json:"signature,omitempty"` // base64
+	SignatureAlgorithm string `json:"signatureAlgorithm,omitempty"`
+	SignerFingerprint  string `json:"signerFingerprint,omitempty"` // hex SHA-256 of the signer certificate DER
+	SignerSerial       string `json:"signerSerial,omitempty"`
+	SignedAt           string `json:"signedAt,omitempty"`
 }
 
 // AuditLog represents transaction history
 type AuditLog struct {
-	LogID         string    `json:"logId"`
-	Timestamp     string    `json:"timestamp"`
-	Organization  string    `json:"organization"`
-	User          string    `json:"user"`
-	Action        string    `json:"action"`
-	RecordType    string    `json:"recordType"` // STUDENT, RECORD, CERTIFICATE
-	RecordID      string    `json:"recordId"`
-	Details       string    `json:"details"`
-	TransactionID string    `json:"transactionId"`
+	LogID         string `json:"logId"`
+	Timestamp     string `json:"timestamp"`
+	Organization  string `json:"organization"`
+	User          string `json:"user"`             // common name of the acting client certificate
+	UserID        string `json:"userId,omitempty"` // full client identity, for forensics
+	Action        string `json:"action"`
+	RecordType    string `json:"recordType"` // STUDENT, RECORD, CERTIFICATE
+	RecordID      string `json:"recordId"`
+	Details       string `json:"details"`
+	TransactionID string `json:"transactionId"`
 }
 
 // VerificationRequest represents external verification queries
 type VerificationRequest struct {
-	RequestID     string `json:"requestId"`
-	CertificateID string `json:"certificateId"`
+	DocType         string `json:"docType"`
+	RequestID       string `json:"requestId"`
+	CertificateID   string `json:"certificateId"`
 	CertificateHash string `json:"certificateHash"`
-	RequestedBy   string `json:"requestedBy"`
-	RequestedAt   string `json:"requestedAt"`
-	Status        string `json:"status"` // PENDING, VERIFIED, INVALID
+	RequestedBy     string `json:"requestedBy"`
+	RequesterOrg    string `json:"requesterOrg"`
+	RequesterID     string `json:"requesterId"`
+	RequestedAt     string `json:"requestedAt"`
+	ExpiresAt       string `json:"expiresAt"`
+	Status          string `json:"status"` // PENDING, VERIFIED, INVALID, EXPIRED
+
+	RespondedBy    string `json:"respondedBy,omitempty"`
+	RespondedAt    string `json:"respondedAt,omitempty"`
+	ResponseReason string `json:"responseReason,omitempty"`
+}
+
+// PaginatedRecords holds one page of academic records and the bookmark for the next page
+type PaginatedRecords struct {
+	Records      []*AcademicRecord `json:"records"`
+	FetchedCount int32             `json:"fetchedCount"`
+	Bookmark     string            `json:"bookmark"`
+}
+
+// VerificationQueueEntry is an approved record waiting for a verifier
+type VerificationQueueEntry struct {
+	RecordID   string          `json:"recordId"`
+	StudentID  string          `json:"studentId"`
+	ApprovedBy string          `json:"approvedBy"`
+	ApprovedAt string          `json:"approvedAt"`
+	Record     *AcademicRecord `json:"record,omitempty"` // nil unless the caller may read the student's records
+}
+
+// PaginatedVerificationQueue holds one page of the verifier work queue and the bookmark for the next page
+type PaginatedVerificationQueue struct {
+	Entries      []*VerificationQueueEntry `json:"entries"`
+	FetchedCount int32                     `json:"fetchedCount"`
+	Bookmark     string                    `json:"bookmark"`
+}
+
+// PaginatedAuditLogs holds one page of audit entries and the bookmark for the next page
+type PaginatedAuditLogs struct {
+	Logs         []*AuditLog `json:"logs"`
+	FetchedCount int32       `json:"fetchedCount"`
+	Bookmark     string      `json:"bookmark"`
+}
+
+// PaginatedStudents holds one page of students and the bookmark for the next page
+type PaginatedStudents struct {
+	Students     []*Student `json:"students"`
+	FetchedCount int32      `json:"fetchedCount"`
+	Bookmark     string     `json:"bookmark"`
+}
+
+// Student statuses
+const (
+	studentStatusActive    = "ACTIVE"
+	studentStatusGraduated = "GRADUATED"
+	studentStatusSuspended = "SUSPENDED"
+	studentStatusMerged    = "MERGED" // set only by MergeStudents
+
+	studentStatusTransferredOut = "TRANSFERRED_OUT" // set only by InitiateTransferOut
+)
+
+var validStudentStatuses = map[string]bool{
+	studentStatusActive:    true,
+	studentStatusGraduated: true,
+	studentStatusSuspended: true,
+}
+
+// Academic record statuses
+const (
+	recordStatusDraft      = "DRAFT"
+	recordStatusSubmitted  = "SUBMITTED"
+	recordStatusApproved   = "APPROVED"
+	recordStatusVerified   = "VERIFIED"
+	recordStatusRejected   = "REJECTED"
+	recordStatusSuperseded = "SUPERSEDED"
+)
+
+var validRecordStatuses = map[string]bool{
+	recordStatusDraft:      true,
+	recordStatusSubmitted:  true,
+	recordStatusApproved:   true,
+	recordStatusVerified:   true,
+	recordStatusRejected:   true,
+	recordStatusSuperseded: true,
 }
 
+// docType values let CouchDB selectors tell asset types apart
+const (
+	docTypeStudent            = "student"
+	docTypeRecord             = "academicRecord"
+	docTypeCertificate        = "certificate"
+	docTypeGradeScale         = "gradeScale"
+	docTypeChecklistSchema    = "checklistSchema"
+	docTypeCertPolicy         = "certificatePolicy"
+	docTypeVerificationReq    = "verificationRequest"
+	docTypeAccessGrant        = "accessGrant"
+	docTypeStudentPII         = "studentPII"
+	docTypeDraftGrades        = "draftGrades"
+	docTypeRolePolicy         = "rolePolicy"
+	docTypeOrgConfig          = "orgConfig"
+	docTypeLedgerInit         = "ledgerInit"
+	docTypeLedgerStats        = "ledgerStats"
+	docTypeStatusChange       = "studentStatusChange"
+	docTypeDegreeRules        = "degreeRules"
+	docTypeCourse             = "course"
+	docTypeFaculty            = "faculty"
+	docTypeGradeAppeal        = "gradeAppeal"
+	docTypeHold               = "hold"
+	docTypeClearance          = "clearance"
+	docTypeDepartmentRegistry = "departmentRegistry"
+	docTypeDepartmentTransfer = "departmentTransfer"
+	docTypeStudentCorrection  = "studentCorrection"
+	docTypeSigningKey         = "signingKey"
+	docTypeBadgeClass         = "badgeClass"
+	docTypeCertificateAnchor  = "certificateAnchor"
+	docTypeCertTemplate       = "certTemplate"
+	docTypeGPAConversion      = "gpaConversion"
+	docTypeInstitution        = "institution"
+	docTypeIdentityBinding    = "identityBinding"
+	docTypeShareToken         = "shareToken"
+)
+
+// maxCourseCredits is the upper bound on credits for a single course
+const maxCourseCredits = 10
+
+const (
+	defaultPageSize int32 = 100
+	maxPageSize     int32 = 1000
+)
+
 // ========== STUDENT MANAGEMENT ==========
 
-// CreateStudent creates a new student record
-func (s *SmartContract) CreateStudent(ctx contractapi.TransactionContextInterface, studentID string, name string, email string, department string) (*Student, error) {
+// CreateStudent creates a new student record; an empty program means BTECH
+func (s *SmartContract) CreateStudent(ctx contractapi.TransactionContextInterface, studentID string, department string, program string) (*Student, error) {
 	// Verify caller is from NITWarangal org
-	creatorOrg, err := getCreatorOrganization(ctx)
+	creatorOrg, err := requireOrgRole(ctx, "create students", orgRoleUniversity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+		return nil, err
 	}
 
-	if creatorOrg != "NITWarangalMSP" {
-		return nil, fmt.Errorf("only NITWarangal can create students")
+	// Check if student (or any other asset) already exists under this key
+	if err := assertKeyUnused(ctx, studentID, docTypeStudent); err != nil {
+		return nil, err
 	}
 
-	// Check if student already exists
-	existing, err := ctx.GetStub().GetState(studentID)
+	// Name and email arrive as transient data so they never reach the public ledger
+	pii, err := readTransientPII(ctx, studentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state: %v", err)
+		return nil, err
+	}
+
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := getDepartmentRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateNewStudent(config, registry, studentID, department, pii); err != nil {
+		return nil, err
+	}
+
+	student, err := createStudent(ctx, creatorOrg, studentID, department, program, pii)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := emitLifecycleEvent(ctx, eventStudentCreated, "STUDENT", studentID, student.Status); err != nil {
+		return nil, err
+	}
+
+	return student, nil
+}
+
+// createStudent stores a new ACTIVE student with its PII, indexes and audit entry
+func createStudent(ctx contractapi.TransactionContextInterface, creatorOrg string, studentID string, department string, program string, pii *StudentPII) (*Student, error) {
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
 	}
-	if existing != nil {
-		return nil, fmt.Errorf("student %s already exists", studentID)
+
+	institutionID, err := callerInstitution(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create student object
 	student := Student{
+		DocType:        docTypeStudent,
 		StudentID:      studentID,
-		Name:           name,
-		Email:          email,
 		Department:     department,
-		EnrollmentDate: time.Now().Format(time.RFC3339),
-		Status:         "ACTIVE",
+		Program:        normalizeProgram(program),
+		EnrollmentDate: txTime.UTC().Format(time.RFC3339),
+		Status:         studentStatusActive,
 		CreatedBy:      creatorOrg,
-		CreatedAt:      time.Now().Format(time.RFC3339),
+		CreatedAt:      txTime.UTC().Format(time.RFC3339),
+		InstitutionID:  institutionID,
 	}
 
-	// Save to blockchain
-	studentJSON, err := json.Marshal(student)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal student: %v", err)
+	if err := assertEmailAvailable(ctx, pii.Email, studentID); err != nil {
+		return nil, err
 	}
 
-	err = ctx.GetStub().PutState(studentID, studentJSON)
-	if err != nil {
-		return nil, fmt.Errorf("failed to put state: %v", err)
+	// Save to blockchain
+	if err := putStudent(ctx, &student); err != nil {
+		return nil, err
+	}
+	if err := putStudentPII(ctx, pii); err != nil {
+		return nil, err
+	}
+	if err := putEmailIndex(ctx, pii.Email, studentID); err != nil {
+		return nil, err
 	}
 
 	// Create index for student queries
-	err = ctx.GetStub().CreateCompositeKey("student~department", []string{department, studentID})
+	err = putIndex(ctx, "student~department", []string{department, studentID})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create index: %v", err)
+		return nil, err
+	}
+	err = putIndex(ctx, "student~status", []string{student.Status, studentID})
+	if err != nil {
+		return nil, err
 	}
 
 	// Log audit entry
-	logAudit(ctx, "CreateStudent", "STUDENT", studentID, fmt.Sprintf("Created student %s", name))
+	logAudit(ctx, "CreateStudent", "STUDENT", studentID, fmt.Sprintf("Created student %s", studentID))
 
+	mergeStudentPII(&student, pii)
 	return &student, nil
 }
 
-// GetStudent retrieves a student record
-func (s *SmartContract) GetStudent(ctx contractapi.TransactionContextInterface, studentID string) (*Student, error) {
-	studentJSON, err := ctx.GetStub().GetState(studentID)
+// validateNewStudent checks a new student's ID against the configured pattern, their department
+// against the registry and their name and email formats
+func validateNewStudent(config *OrgConfig, registry *DepartmentRegistry, studentID string, department string, pii *StudentPII) error {
+	pattern := config.StudentIDPattern
+	if pattern == "" {
+		pattern = defaultStudentIDPattern // configs stored before the pattern existed
+	}
+	matched, err := regexp.MatchString(pattern, studentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state: %v", err)
+		return fmt.Errorf("invalid studentIdPattern in org config: %v", err)
 	}
-	if studentJSON == nil {
-		return nil, fmt.Errorf("student %s not found", studentID)
+	if !matched {
+		return fmt.Errorf("studentId %q does not match the required format %s", studentID, pattern)
 	}
-
-	var student Student
-	err = json.Unmarshal(studentJSON, &student)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal student: %v", err)
+	if !registry.has(department) {
+		return fmt.Errorf("department %q is not registered: expected one of %s", department, strings.Join(registry.Departments, ", "))
 	}
-
-	return &student, nil
+	if err := validateStudentField("name", pii.Name); err != nil {
+		return err
+	}
+	return validateStudentField("email", pii.Email)
 }
 
-// UpdateStudentStatus updates student status
-func (s *SmartContract) UpdateStudentStatus(ctx contractapi.TransactionContextInterface, studentID string, status string) (*Student, error) {
-	creatorOrg, err := getCreatorOrganization(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+// GetStudent retrieves a student record; PII is merged in for members of the PII collection.
+// Archived students are only returned to admins passing includeArchived. Verifiers name the
+// student's certificate they are verifying in certificateID, or hold the student's consent.
+func (s *SmartContract) GetStudent(ctx contractapi.TransactionContextInterface, studentID string, includeArchived bool, certificateID string) (*Student, error) {
+	if err := assertIncludeArchivedAllowed(ctx, includeArchived); err != nil {
+		return nil, err
 	}
-
-	if creatorOrg != "NITWarangalMSP" {
-		return nil, fmt.Errorf("only NITWarangal can update student status")
+	if err := assertStudentReadAllowed(ctx, studentID, certificateID); err != nil {
+		return nil, err
 	}
 
-	student, err := s.GetStudent(ctx, studentID)
+	student, err := readStudent(ctx, studentID)
 	if err != nil {
 		return nil, err
 	}
+	if student.Archived && !includeArchived {
+		return nil, fmt.Errorf("student %s not found", studentID)
+	}
+	// A merged student is returned as a redirect: only its ID, status and survivor
+	if student.Status == studentStatusMerged {
+		return &Student{DocType: docTypeStudent, StudentID: studentID, Status: studentStatusMerged, MergedInto: student.MergedInto}, nil
+	}
 
-	student.Status = status
-
-	studentJSON, _ := json.Marshal(student)
-	ctx.GetStub().PutState(studentID, studentJSON)
-
-	logAudit(ctx, "UpdateStudentStatus", "STUDENT", studentID, fmt.Sprintf("Updated status to %s", status))
-
-	return student, nil
-}
-
-// GetAllStudents retrieves all students
-func (s *SmartContract) GetAllStudents(ctx contractapi.TransactionContextInterface) ([]*Student, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	canReadPII, err := callerInPIICollection(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get state range: %v", err)
+		return nil, err
 	}
-	defer resultsIterator.Close()
-
-	var students []*Student
-	for resultsIterator.HasNext() {
-		response, err := resultsIterator.Next()
+	if canReadPII {
+		pii, err := readStudentPII(ctx, studentID)
 		if err != nil {
 			return nil, err
 		}
-
-		var student Student
-		err = json.Unmarshal(response.Value, &student)
-		if err != nil {
-			continue // Skip non-student records
-		}
-		students = append(students, &student)
+		mergeStudentPII(student, pii)
 	}
 
-	return students, nil
+	if err := filterResponse(ctx, student); err != nil {
+		return nil, err
+	}
+	return student, nil
 }
 
-// ========== ACADEMIC RECORDS ==========
-
-// CreateAcademicRecord creates a new semester record (Department submits)
-func (s *SmartContract) CreateAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string, studentID string, semester int, year int, coursesJSON string) (*AcademicRecord, error) {
-	creatorOrg, err := getCreatorOrganization(ctx)
+// readStudent loads the public part of a student from world state
+func readStudent(ctx contractapi.TransactionContextInterface, studentID string) (*Student, error) {
+	studentJSON, err := ctx.GetStub().GetState(studentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get creator organization: %v", err)
-	}
-
-	if creatorOrg != "DepartmentsMSP" {
-		return nil, fmt.Errorf("only Departments can create academic records")
+		return nil, fmt.Errorf("failed to read state: %v", err)
 	}
-
-	// Verify student exists
-	_, err = s.GetStudent(ctx, studentID)
-	if err != nil {
-		return nil, fmt.Errorf("student not found: %v", err)
+	if studentJSON == nil {
+		return nil, fmt.Errorf("student %s not found", studentID)
 	}
 
-	// Parse courses
-	var courses []CourseGrade
-	err = json.Unmarshal([]byte(coursesJSON), &courses)
+	var student Student
+	err = json.Unmarshal(studentJSON, &student)
 	if err != nil {
-		return nil, fmt.Errorf("invalid courses JSON: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal student: %v", err)
 	}
-
-	// Calculate SGPA
-	sgpa := calculateSGPA(courses)
-
-	record := AcademicRecord{
-		RecordID:   recordID,
-		StudentID:  studentID,
-		Semester:   semester,
-		Year:       year,
-		Courses:    courses,
-		SGPA:       sgpa,
-		Status:     "SUBMITTED",
-		CreatedBy:  creatorOrg,
-		CreatedAt:  time.Now().Format(time.RFC3339),
+	if err := assertInstitutionRead(ctx, student.InstitutionID); err != nil {
+		return nil, err
 	}
 
-	recordJSON, _ := json.Marshal(record)
-	ctx.GetStub().PutState(recordID, recordJSON)
-
-	// Create index for querying
-	ctx.GetStub().CreateCompositeKey("record~student", []string{studentID, recordID})
-
-	logAudit(ctx, "CreateAcademicRecord", "RECORD", recordID, fmt.Sprintf("Created record for student %s, semester %d", studentID, semester))
-
-	return &record, nil
+	return &student, nil
 }
 
-// ApproveAcademicRecord approves record (NITWarangal approves)
-func (s *SmartContract) ApproveAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
-	creatorOrg, err := getCreatorOrganization(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get creator organization: %v", err)
-	}
-
-	if creatorOrg != "NITWarangalMSP" {
-		return nil, fmt.Errorf("only NITWarangal can approve records")
+// putStudent saves the public part of a student; PII fields are never written to world state
+func putStudent(ctx contractapi.TransactionContextInterface, student *Student) error {
+	if err := assertInstitutionWrite(ctx, student.InstitutionID); err != nil {
+		return err
 	}
+	public := *student
+	public.Name, public.Email, public.Phone, public.Address = "", "", "", ""
 
-	recordJSON, err := ctx.GetStub().GetState(recordID)
+	studentJSON, err := json.Marshal(public)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state: %v", err)
+		return fmt.Errorf("failed to marshal student: %v", err)
 	}
-	if recordJSON == nil {
-		return nil, fmt.Errorf("record not found")
+	if err := ctx.GetStub().PutState(student.StudentID, studentJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
 	}
-
-	var record AcademicRecord
-	json.Unmarshal(recordJSON, &record)
-
-	record.Status = "APPROVED"
-	record.ApprovedBy = creatorOrg
-	record.ApprovedAt = time.Now().Format(time.RFC3339)
-
-	recordJSON, _ = json.Marshal(record)
-	ctx.GetStub().PutState(recordID, recordJSON)
-
-	logAudit(ctx, "ApproveAcademicRecord", "RECORD", recordID, "Record approved by NITWarangal")
-
-	return &record, nil
+	return nil
 }
 
-// VerifyAcademicRecord verifies record (Verifier final check)
-func (s *SmartContract) VerifyAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
-	creatorOrg, err := getCreatorOrganization(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+// UpdateStudentStatus updates student status; the reason is kept in the status history
+func (s *SmartContract) UpdateStudentStatus(ctx contractapi.TransactionContextInterface, studentID string, status string, reason string) (*Student, error) {
+	if _, err := requireOrgRole(ctx, "update student status", orgRoleUniversity); err != nil {
+		return nil, err
 	}
 
-	if creatorOrg != "VerifiersMSP" {
-		return nil, fmt.Errorf("only Verifiers can verify records")
+	if !validStudentStatuses[status] {
+		return nil, fmt.Errorf("invalid student status %q: must be one of ACTIVE, GRADUATED, SUSPENDED", status)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to change a student's status")
 	}
 
-	recordJSON, err := ctx.GetStub().GetState(recordID)
+	student, err := readStudent(ctx, studentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state: %v", err)
+		return nil, err
 	}
-	if recordJSON == nil {
-		return nil, fmt.Errorf("record not found")
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+	if studentFrozen(student) {
+		return nil, frozenStudentError(studentID)
 	}
 
-	var record AcademicRecord
-	json.Unmarshal(recordJSON, &record)
-
-	record.Status = "VERIFIED"
-	record.VerifiedBy = creatorOrg
-	record.VerifiedAt = time.Now().Format(time.RFC3339)
+	if err := setStudentStatus(ctx, student, status, reason); err != nil {
+		return nil, err
+	}
 
-	recordJSON, _ = json.Marshal(record)
-	ctx.GetStub().PutState(recordID, recordJSON)
+	logAudit(ctx, "UpdateStudentStatus", "STUDENT", studentID, fmt.Sprintf("Updated status to %s: %s", status, reason))
+	if studentFrozen(student) {
+		logAudit(ctx, "FreezeStudent", "STUDENT", studentID, "Student graduated; profile and records frozen")
+	}
 
-	logAudit(ctx, "VerifyAcademicRecord", "RECORD", recordID, "Record verified by external verifier")
+	if err := emitLifecycleEvent(ctx, eventStudentStatusChanged, "STUDENT", studentID, status); err != nil {
+		return nil, err
+	}
 
-	return &record, nil
+	return student, nil
 }
 
-// GetAcademicRecord retrieves a specific record
-func (s *SmartContract) GetAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
-	recordJSON, err := ctx.GetStub().GetState(recordID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read state: %v", err)
+// GetStudentsByStatus retrieves all students with the given status via the student~status index;
+// includeArchived (admins only) adds archived students with that status
+func (s *SmartContract) GetStudentsByStatus(ctx contractapi.TransactionContextInterface, status string, includeArchived bool) ([]*Student, error) {
+	if !validStudentStatuses[status] {
+		return nil, fmt.Errorf("invalid student status %q: must be one of ACTIVE, GRADUATED, SUSPENDED", status)
 	}
-	if recordJSON == nil {
-		return nil, fmt.Errorf("record not found")
+	if err := assertCanListStudents(ctx); err != nil {
+		return nil, err
+	}
+	if err := assertIncludeArchivedAllowed(ctx, includeArchived); err != nil {
+		return nil, err
 	}
 
-	var record AcademicRecord
-	json.Unmarshal(recordJSON, &record)
-	return &record, nil
-}
-
-// GetStudentRecords retrieves all records for a student
-func (s *SmartContract) GetStudentRecords(ctx contractapi.TransactionContextInterface, studentID string) ([]*AcademicRecord, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~student", []string{studentID})
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("student~status", []string{status})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to query students: %v", err)
 	}
 	defer resultsIterator.Close()
 
-	var records []*AcademicRecord
+	students := []*Student{}
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		// Extract record ID from composite key
 		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
 		if err != nil || len(compositeKeyParts) < 2 {
 			continue
 		}
 
-		recordID := compositeKeyParts[1]
-		record, err := s.GetAcademicRecord(ctx, recordID)
+		student, err := readStudent(ctx, compositeKeyParts[1])
 		if err == nil {
-			records = append(records, record)
+			students = append(students, student)
 		}
 	}
 
-	return records, nil
-}
-
-// ========== CERTIFICATE MANAGEMENT ==========
-
-// IssueCertificate issues a certificate (NITWarangal issues)
-func (s *SmartContract) IssueCertificate(ctx contractapi.TransactionContextInterface, certificateID string, studentID string, certificationType string) (*Certificate, error) {
-	creatorOrg, err := getCreatorOrganization(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get creator organization: %v", err)
-	}
-
-	if creatorOrg != "NITWarangalMSP" {
-		return nil, fmt.Errorf("only NITWarangal can issue certificates")
+	if includeArchived {
+		archived, err := getArchivedStudents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, student := range archived {
+			if student.Status == status {
+				students = append(students, student)
+			}
+		}
 	}
 
-	// Generate certificate hash
-	certHash := generateCertificateHash(certificateID, studentID)
-	qrCode := fmt.Sprintf("https://verify.nit.edu/cert/%s", certificateID)
-
-	cert := Certificate{
-		CertificateID:     certificateID,
-		StudentID:         studentID,
-		CertificationType: certificationType,
-		IssuedDate:        time.Now().Format(time.RFC3339),
-		CertificateHash:   certHash,
-		QRCode:            qrCode,
-		Status:            "ISSUED",
-		IssuedBy:          creatorOrg,
-		VerificationCount: 0,
-		CreatedAt:         time.Now().Format(time.RFC3339),
+	if err := filterResponse(ctx, students); err != nil {
+		return nil, err
 	}
-
-	certJSON, _ := json.Marshal(cert)
-	ctx.GetStub().PutState(certificateID, certJSON)
-
-	logAudit(ctx, "IssueCertificate", "CERTIFICATE", certificateID, fmt.Sprintf("Certificate issued to student %s", studentID))
-
-	return &cert, nil
+	return students, nil
 }
 
-// VerifyCertificate verifies a certificate (Public endpoint)
-func (s *SmartContract) VerifyCertificate(ctx contractapi.TransactionContextInterface, certificateID string, certHash string) (bool, error) {
-	certJSON, err := ctx.GetStub().GetState(certificateID)
-	if err != nil || certJSON == nil {
-		return false, nil
+// GetAllStudents retrieves all students of an institution, the caller's own when institutionID
+// is empty (orgs of the studentListRoles); only orgs that read across institutions may list
+// another institution's students. includeArchived (admins only) keeps archived ones.
+func (s *SmartContract) GetAllStudents(ctx contractapi.TransactionContextInterface, includeArchived bool, institutionID string) ([]*Student, error) {
+	if err := assertCanListStudents(ctx); err != nil {
+		return nil, err
 	}
-
-	var cert Certificate
-	json.Unmarshal(certJSON, &cert)
-
-	// Verify hash matches
-	if cert.CertificateHash != certHash {
-		return false, nil
+	if err := assertIncludeArchivedAllowed(ctx, includeArchived); err != nil {
+		return nil, err
 	}
-
-	// Increment verification count
-	cert.VerificationCount++
-	certJSON, _ = json.Marshal(cert)
-	ctx.GetStub().PutState(certificateID, certJSON)
-
-	// Log verification
-	logAudit(ctx, "VerifyCertificate", "CERTIFICATE", certificateID, "Certificate verified by external party")
-
-	return true, nil
-}
-
-// GetCertificate retrieves certificate details
-func (s *SmartContract) GetCertificate(ctx contractapi.TransactionContextInterface, certificateID string) (*Certificate, error) {
-	certJSON, err := ctx.GetStub().GetState(certificateID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read state: %v", err)
+	if institutionID == "" {
+		callerID, err := callerInstitution(ctx)
+		if err != nil {
+			return nil, err
+		}
+		institutionID = callerID
 	}
-	if certJSON == nil {
-		return nil, fmt.Errorf("certificate not found")
+	if err := assertInstitutionRead(ctx, institutionID); err != nil {
+		return nil, err
 	}
 
-	var cert Certificate
-	json.Unmarshal(certJSON, &cert)
-	return &cert, nil
-}
-
-// GetStudentCertificates retrieves all certificates for a student
-func (s *SmartContract) GetStudentCertificates(ctx contractapi.TransactionContextInterface, studentID string) ([]*Certificate, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	resultsIterator, err := assetRange(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	var certificates []*Certificate
+	var students []*Student
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var cert Certificate
-		if err := json.Unmarshal(response.Value, &cert); err != nil {
+		var student Student
+		err = json.Unmarshal(response.Value, &student)
+		if err != nil || student.DocType != docTypeStudent {
+			continue // Skip non-student records
+		}
+		if student.Archived && !includeArchived {
 			continue
 		}
-
-		if cert.StudentID == studentID {
-			certificates = append(certificates, &cert)
+		if assetInstitution(student.InstitutionID) != institutionID {
+			continue
 		}
+		students = append(students, &student)
+	}
+
+	if err := filterResponse(ctx, students); err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
+// studentSelector is the Mango selector used by QueryStudents; empty filters are omitted
+type studentSelector struct {
+	DocType    string `json:"docType"`
+	Department string `json:"department,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+// QueryStudents runs a CouchDB rich query on department and/or status (empty means any).
+// Archived students are dropped from the page unless an admin passes includeArchived.
+func (s *SmartContract) QueryStudents(ctx contractapi.TransactionContextInterface, department string, status string, includeArchived bool, pageSize int32, bookmark string) (*PaginatedStudents, error) {
+	if err := assertCanListStudents(ctx); err != nil {
+		return nil, err
+	}
+	if err := assertIncludeArchivedAllowed(ctx, includeArchived); err != nil {
+		return nil, err
+	}
+
+	// Marshal the selector from a struct so caller input can never alter the query shape
+	query := struct {
+		Selector studentSelector `json:"selector"`
+		UseIndex []string        `json:"use_index,omitempty"`
+	}{
+		Selector: studentSelector{DocType: docTypeStudent, Department: department, Status: status},
+	}
+	switch {
+	case department != "" && status != "":
+		query.UseIndex = []string{"_design/indexStudentDepartmentStatusDoc", "indexStudentDepartmentStatus"}
+	case department != "":
+		query.UseIndex = []string{"_design/indexStudentDepartmentDoc", "indexStudentDepartment"}
+	case status != "":
+		query.UseIndex = []string{"_design/indexStudentStatusDoc", "indexStudentStatus"}
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query students: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	students := []*Student{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var student Student
+		if err := json.Unmarshal(response.Value, &student); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal student: %v", err)
+		}
+		if student.Archived && !includeArchived {
+			continue
+		}
+		// Other institutions' students are only visible to verifier orgs
+		if assertInstitutionRead(ctx, student.InstitutionID) != nil {
+			continue
+		}
+		students = append(students, &student)
+	}
+
+	page := &PaginatedStudents{
+		Students:     students,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}
+	if err := filterResponse(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ========== ACADEMIC RECORDS ==========
+
+// CreateAcademicRecord creates a new semester record (Department submits); the course list
+// is read from the "courses" transient key. Grades are pointed on the scale of regulationYear,
+// or of the student's enrollment year when it is 0.
+func (s *SmartContract) CreateAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string, studentID string, semester int, year int, regulationYear int) (*AcademicRecord, error) {
+	return s.createRecord(ctx, recordID, studentID, semester, year, regulationYear, recordStatusSubmitted)
+}
+
+// createRecord stores a new record as SUBMITTED, or as DRAFT when it is assembled incrementally.
+// Its courses stay in the draft grades collection until approval.
+func (s *SmartContract) createRecord(ctx contractapi.TransactionContextInterface, recordID string, studentID string, semester int, year int, regulationYear int, status string) (*AcademicRecord, error) {
+	creatorOrg, err := requireOrgRole(ctx, "create academic records", orgRoleDepartment)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationCreateRecord); err != nil {
+		return nil, err
+	}
+
+	if err := assertKeyUnused(ctx, recordID, docTypeRecord); err != nil {
+		return nil, err
+	}
+
+	// Verify student exists and has not graduated
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("student not found: %v", err)
+	}
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+	if studentFrozen(student) {
+		return nil, frozenStudentError(studentID)
+	}
+
+	// Validate before any state is written so a bad payload leaves nothing behind.
+	// Drafts may start with no courses.
+	coursesJSON, err := readTransientCourses(ctx, status == recordStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+	scale, regulationYear, err := gradeScaleForStudent(ctx, student, regulationYear)
+	if err != nil {
+		return nil, err
+	}
+	courses, err := prepareCourses(ctx, coursesJSON, scale, status == recordStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	// One live record per student and semester
+	if err := checkSemesterUnique(ctx, studentID, semester, ""); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	record := AcademicRecord{
+		DocType:    docTypeRecord,
+		RecordID:   recordID,
+		StudentID:  studentID,
+		Department: student.Department,
+		Semester:   semester,
+		Year:       year,
+		Status:     status,
+		CreatedBy:  creatorOrg,
+		CreatedAt:  txTime.UTC().Format(time.RFC3339),
+		Version:    1,
+
+		InstitutionID:  assetInstitution(student.InstitutionID),
+		RegulationYear: regulationYear,
+		GradeScaleYear: scale.RegulationYear,
+	}
+
+	// GPA is computed on approval, when the grades are published
+	if err := stageDraftCourses(ctx, &record, courses); err != nil {
+		return nil, err
+	}
+
+	if err := putAcademicRecord(ctx, &record); err != nil {
+		return nil, err
+	}
+
+	if err := putRecordIndexes(ctx, &record); err != nil {
+		return nil, err
+	}
+	if err := setRecordEndorsementPolicy(ctx, &record); err != nil {
+		return nil, err
+	}
+
+	action := "CreateAcademicRecord"
+	if status == recordStatusDraft {
+		action = "CreateDraftRecord"
+	}
+	logAudit(ctx, action, "RECORD", recordID, fmt.Sprintf("Created %s record for student %s, semester %d", status, studentID, semester))
+
+	if status == recordStatusSubmitted {
+		if err := emitLifecycleEvent(ctx, eventRecordSubmitted, "RECORD", recordID, status); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+// putRecordIndexes creates the query indexes for a newly stored record
+func putRecordIndexes(ctx contractapi.TransactionContextInterface, record *AcademicRecord) error {
+	if err := putIndex(ctx, "record~student", []string{record.StudentID, record.RecordID}); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, "record~status", []string{record.Status, record.RecordID}); err != nil {
+		return err
+	}
+	if err := putIndex(ctx, "record~student~semester", []string{record.StudentID, strconv.Itoa(record.Semester), record.RecordID}); err != nil {
+		return err
+	}
+	// Marks a versioned grade scale as in use so it can no longer be replaced
+	if record.GradeScaleYear != 0 {
+		if err := putIndex(ctx, "gradescale~record", []string{fmt.Sprintf("%04d", record.GradeScaleYear), record.RecordID}); err != nil {
+			return err
+		}
+	}
+	// Records created before departments were stamped on them are not in this index
+	if record.Department == "" {
+		return nil
+	}
+	return putIndex(ctx, "record~department", []string{record.Department, fmt.Sprintf("%04d", record.Year), strconv.Itoa(record.Semester), record.RecordID})
+}
+
+// prepareCourses parses and validates a course list, checks it against the course catalog and
+// faculty, and derives grade points from the record's grade scale
+func prepareCourses(ctx contractapi.TransactionContextInterface, coursesJSON string, scale *GradeScale, allowEmpty bool) ([]CourseGrade, error) {
+	courses := []CourseGrade{}
+	if err := json.Unmarshal([]byte(coursesJSON), &courses); err != nil {
+		return nil, fmt.Errorf("invalid courses JSON: %v", err)
+	}
+	if courses == nil {
+		courses = []CourseGrade{}
+	}
+	if allowEmpty && len(courses) == 0 {
+		return courses, nil
+	}
+
+	// A second decode tells an omitted grade point from an explicit zero
+	var gradePoints []struct {
+		GradePoint *float64 `json:"gradePoint"`
+	}
+	if err := json.Unmarshal([]byte(coursesJSON), &gradePoints); err != nil {
+		return nil, fmt.Errorf("invalid courses JSON: %v", err)
+	}
+	supplied := make([]*float64, len(gradePoints))
+	for i := range gradePoints {
+		supplied[i] = gradePoints[i].GradePoint
+	}
+
+	if err := validateCourses(courses); err != nil {
+		return nil, err
+	}
+	if err := applyCourseCatalog(ctx, courses); err != nil {
+		return nil, err
+	}
+	if err := applyInstructors(ctx, courses); err != nil {
+		return nil, err
+	}
+
+	// Grade points come from the on-chain grade scale, never from the client
+	if err := applyGradeScale(scale, courses, supplied); err != nil {
+		return nil, err
+	}
+	return courses, nil
+}
+
+// computeRecordGPA sets SGPA from the record's courses and CGPA over the student's
+// approved semesters plus this one
+func computeRecordGPA(ctx contractapi.TransactionContextInterface, record *AcademicRecord) error {
+	record.SGPA = calculateSGPA(record.Courses)
+
+	cumulative, err := cgpaRecords(ctx, record.StudentID, record)
+	if err != nil {
+		return err
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	record.CGPA, _ = calculateCGPA(cumulative, config.RepeatPolicy)
+	return nil
+}
+
+// ApproveAcademicRecord records one officer's approval (NITWarangal approves) together with the
+// exam section's checklist of what was verified; the record becomes APPROVED once the number of
+// distinct approvers set in the org config have signed. Remarks are optional. A disclosureSeed
+// in the transient map of the final approval commits each course for selective disclosure.
+func (s *SmartContract) ApproveAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string, checklistJSON string, remarks string) (*AcademicRecord, error) {
+	creatorOrg, err := requireOrgRole(ctx, "approve records", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationApproveRecord); err != nil {
+		return nil, err
+	}
+
+	schema, err := getChecklistSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checklist, err := parseApprovalChecklist(schema, checklistJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	approverID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApproval(ctx, record, approverID); err != nil {
+		return nil, err
+	}
+
+	approved, err := applyApproval(ctx, record, creatorOrg, approverID, checklist, remarks)
+	if err != nil {
+		return nil, err
+	}
+	if approved {
+		if err := emitLifecycleEvent(ctx, eventRecordApproved, "RECORD", recordID, record.Status); err != nil {
+			return nil, err
+		}
+	}
+
+	return record, nil
+}
+
+// checkApproval refuses an approval before anything is written: the student must not be frozen,
+// the record must be approvable, this identity must not have signed the current round yet, and
+// the private grades must match the committed hash
+func checkApproval(ctx contractapi.TransactionContextInterface, record *AcademicRecord, approverID string) error {
+	if err := assertStudentNotFrozen(ctx, record.StudentID); err != nil {
+		return err
+	}
+	if _, err := validateTransition(record.Status, recordActionApprove); err != nil {
+		return err
+	}
+	for _, approval := range currentApprovals(record) {
+		if approval.ApproverID == approverID {
+			return fmt.Errorf("this identity has already approved record %s; a different officer must co-sign", record.RecordID)
+		}
+	}
+	_, err := readDraftCourses(ctx, record)
+	return err
+}
+
+// applyApproval adds the caller's approval to a record that passed checkApproval and saves it.
+// Each approval round needs distinct officers; once enough have signed the record becomes
+// APPROVED, its grades are published and it is queued for verifiers. It reports whether the
+// record was approved.
+func applyApproval(ctx contractapi.TransactionContextInterface, record *AcademicRecord, creatorOrg string, approverID string, checklist *ApprovalChecklist, remarks string) (bool, error) {
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	required := config.approvalsRequired()
+
+	record.Approvals = append(record.Approvals, &Approval{
+		ApprovedBy: creatorOrg,
+		ApproverID: approverID,
+		ApprovedAt: txTime.UTC().Format(time.RFC3339),
+		Checklist:  checklist.Items,
+		Notes:      checklist.Notes,
+		Round:      len(record.Rejections),
+	})
+	appendRemark(record, creatorOrg, txTime, remarks)
+
+	signed := len(currentApprovals(record))
+	if signed < required {
+		if err := putAcademicRecord(ctx, record); err != nil {
+			return false, err
+		}
+		logAudit(ctx, "ApproveAcademicRecord", "RECORD", record.RecordID, fmt.Sprintf("Approval %d of %d recorded by %s", signed, required, approverID))
+		return false, nil
+	}
+
+	fromStatus, err := transitionRecord(ctx, record, recordActionApprove)
+	if err != nil {
+		return false, err
+	}
+	record.ApprovedBy = creatorOrg
+	record.ApprovedAt = txTime.UTC().Format(time.RFC3339)
+
+	// Publish the privately held grades, refusing them if they differ from the committed hash
+	if err := publishDraftCourses(ctx, record); err != nil {
+		return false, err
+	}
+	if err := computeRecordGPA(ctx, record); err != nil {
+		return false, err
+	}
+	record.DeansListed = deansListed(record, config)
+	if err := commitRecordCourses(ctx, record); err != nil {
+		return false, err
+	}
+
+	if err := putAcademicRecord(ctx, record); err != nil {
+		return false, err
+	}
+
+	// Queue the record for verifiers, ordered by approval time
+	if err := putIndex(ctx, "record~awaitingverification", []string{record.ApprovedAt, record.RecordID}); err != nil {
+		return false, err
+	}
+
+	logAudit(ctx, "ApproveAcademicRecord", "RECORD", record.RecordID, transitionDetails(fromStatus, record.Status, "Record approved by NITWarangal"))
+
+	return true, nil
+}
+
+// VerifyAcademicRecord verifies record (Verifier final check). Remarks are optional unless the
+// verifier reports minor discrepancies, in which case they must describe them.
+func (s *SmartContract) VerifyAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string, remarks string, discrepanciesFound bool) (*AcademicRecord, error) {
+	creatorOrg, err := requireOrgRole(ctx, "verify records", orgRoleVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if discrepanciesFound && strings.TrimSpace(remarks) == "" {
+		return nil, fmt.Errorf("remarks are required when verification finds discrepancies")
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertStudentNotFrozen(ctx, record.StudentID); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	fromStatus, err := transitionRecord(ctx, record, recordActionVerify)
+	if err != nil {
+		return nil, err
+	}
+	record.VerifiedBy = creatorOrg
+	record.VerifiedAt = txTime.UTC().Format(time.RFC3339)
+	if discrepanciesFound {
+		remarks = "Discrepancies found: " + strings.TrimSpace(remarks)
+	}
+	appendRemark(record, creatorOrg, txTime, remarks)
+
+	if err := putAcademicRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	// Verified records are frozen: only the university stays in the key's policy
+	if err := setRecordEndorsementPolicy(ctx, record); err != nil {
+		return nil, err
+	}
+
+	if err := deleteIndex(ctx, "record~awaitingverification", []string{record.ApprovedAt, recordID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "VerifyAcademicRecord", "RECORD", recordID, transitionDetails(fromStatus, record.Status, "Record verified by external verifier"))
+
+	if err := emitLifecycleEvent(ctx, eventRecordVerified, "RECORD", recordID, record.Status); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetAcademicRecord retrieves a specific record
+func (s *SmartContract) GetAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
+	if err := assertUnloggedReadAllowed(ctx, "GetStudentRecordsLogged"); err != nil {
+		return nil, err
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, record.StudentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+	if err := filterResponse(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// readAcademicRecord loads a record from world state
+func readAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
+	recordJSON, err := ctx.GetStub().GetState(recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if recordJSON == nil {
+		return nil, fmt.Errorf("record not found")
+	}
+
+	var record AcademicRecord
+	if err := json.Unmarshal(recordJSON, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record: %v", err)
+	}
+	if err := assertInstitutionRead(ctx, record.InstitutionID); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Sort orders accepted by GetStudentRecords
+const (
+	sortOrderAscending  = "ASC"
+	sortOrderDescending = "DESC"
+)
+
+// GetStudentRecords retrieves all records for a student ordered by (year, semester), oldest first
+// for ASC (or empty) and newest first for DESC. Within a semester the latest version comes first
+// and the records it superseded follow; includeSuperseded false drops the superseded ones.
+// status narrows the result to one status or a comma-separated list, e.g. "VERIFIED" for a
+// transcript; empty means all. A status filter that names SUPERSEDED includes those records.
+func (s *SmartContract) GetStudentRecords(ctx contractapi.TransactionContextInterface, studentID string, status string, order string, includeSuperseded bool) ([]*AcademicRecord, error) {
+	statuses, err := parseRecordStatuses(status)
+	if err != nil {
+		return nil, err
+	}
+	if order == "" {
+		order = sortOrderAscending
+	}
+	if order != sortOrderAscending && order != sortOrderDescending {
+		return nil, fmt.Errorf("invalid order %q: must be %s or %s", order, sortOrderAscending, sortOrderDescending)
+	}
+	if err := assertUnloggedReadAllowed(ctx, "GetStudentRecordsLogged"); err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+
+	all, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*AcademicRecord{}
+	for _, record := range all {
+		if statuses != nil {
+			if !statuses[record.Status] {
+				continue
+			}
+		} else if record.Status == recordStatusSuperseded && !includeSuperseded {
+			continue
+		}
+		records = append(records, record)
+	}
+	sortRecordsBySemester(records, order == sortOrderDescending)
+	if err := filterResponse(ctx, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// parseRecordStatuses parses a comma-separated status filter; nil means no filter
+func parseRecordStatuses(filter string) (map[string]bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	statuses := map[string]bool{}
+	for _, status := range strings.Split(filter, ",") {
+		status = strings.ToUpper(strings.TrimSpace(status))
+		if !validRecordStatuses[status] {
+			return nil, fmt.Errorf("invalid record status %q in status filter: must be DRAFT, SUBMITTED, APPROVED, VERIFIED, REJECTED or SUPERSEDED", status)
+		}
+		statuses[status] = true
+	}
+	return statuses, nil
+}
+
+// sortRecordsBySemester orders records by (year, semester); within one semester the live record
+// comes before superseded ones and higher versions before lower, whatever the direction
+func sortRecordsBySemester(records []*AcademicRecord, descending bool) {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if !sameSemester(a, b) {
+			if descending {
+				return semesterAfter(a, b)
+			}
+			return semesterAfter(b, a)
+		}
+		if (a.Status == recordStatusSuperseded) != (b.Status == recordStatusSuperseded) {
+			return b.Status == recordStatusSuperseded
+		}
+		if a.Version != b.Version {
+			return a.Version > b.Version
+		}
+		return a.RecordID < b.RecordID
+	})
+}
+
+// GetStudentRecordsWithPagination retrieves one page of records for a student
+func (s *SmartContract) GetStudentRecordsWithPagination(ctx contractapi.TransactionContextInterface, studentID string, pageSize int32, bookmark string) (*PaginatedRecords, error) {
+	if err := assertUnloggedReadAllowed(ctx, "GetStudentRecordsLogged"); err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("record~student", []string{studentID}, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*AcademicRecord{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		// Extract record ID from composite key
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		recordID := compositeKeyParts[1]
+		record, err := readAcademicRecord(ctx, recordID)
+		if err == nil {
+			records = append(records, record)
+		}
+	}
+
+	page := &PaginatedRecords{
+		Records:      records,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}
+	if err := filterResponse(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetRecordsByStatus retrieves records in the given status (first page only, see
+// GetRecordsByStatusWithPagination; NITWarangal, Departments and regulators)
+func (s *SmartContract) GetRecordsByStatus(ctx contractapi.TransactionContextInterface, status string) ([]*AcademicRecord, error) {
+	page, err := s.GetRecordsByStatusWithPagination(ctx, status, defaultPageSize, "")
+	if err != nil {
+		return nil, err
+	}
+	return page.Records, nil
+}
+
+// GetRecordsByStatusWithPagination retrieves one page of records in the given status via the
+// record~status index (NITWarangal, Departments and regulators)
+func (s *SmartContract) GetRecordsByStatusWithPagination(ctx contractapi.TransactionContextInterface, status string, pageSize int32, bookmark string) (*PaginatedRecords, error) {
+	if _, err := requireOrgRole(ctx, "list records by status", orgRoleUniversity, orgRoleDepartment, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+	if !validRecordStatuses[status] {
+		return nil, fmt.Errorf("invalid record status %q", status)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("record~status", []string{status}, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*AcademicRecord{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		record, err := readAcademicRecord(ctx, compositeKeyParts[1])
+		if err == nil {
+			records = append(records, record)
+		}
+	}
+
+	page := &PaginatedRecords{
+		Records:      records,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}
+	if err := filterResponse(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetRecordsAwaitingVerification lists approved records not yet verified, oldest approval first.
+// An entry carries the full record only when the caller may read the student's records; other
+// verifiers get the queue metadata and fetch records through their consent grants.
+func (s *SmartContract) GetRecordsAwaitingVerification(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedVerificationQueue, error) {
+	if _, err := requireOrgRole(ctx, "view the verification queue", orgRoleVerifier, orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	// Index keys start with the UTC ApprovedAt timestamp, so iteration order is oldest first
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("record~awaitingverification", []string{}, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verification queue: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	entries := []*VerificationQueueEntry{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		record, err := readAcademicRecord(ctx, compositeKeyParts[1])
+		if err != nil {
+			continue
+		}
+		entry := &VerificationQueueEntry{
+			RecordID:   record.RecordID,
+			StudentID:  record.StudentID,
+			ApprovedBy: record.ApprovedBy,
+			ApprovedAt: record.ApprovedAt,
+		}
+		if assertRecordAccess(ctx, record.StudentID, accessScopeRecords) == nil {
+			entry.Record = record
+		}
+		entries = append(entries, entry)
+	}
+
+	page := &PaginatedVerificationQueue{
+		Entries:      entries,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}
+	if err := filterResponse(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// checkSemesterUnique fails if the student already has a live (not rejected or superseded)
+// record for the semester
+// other than excludeRecordID
+func checkSemesterUnique(ctx contractapi.TransactionContextInterface, studentID string, semester int, excludeRecordID string) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~student~semester", []string{studentID, strconv.Itoa(semester)})
+	if err != nil {
+		return fmt.Errorf("failed to query semester index: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 3 || compositeKeyParts[2] == excludeRecordID {
+			continue
+		}
+
+		existing, err := readAcademicRecord(ctx, compositeKeyParts[2])
+		if err != nil {
+			continue
+		}
+		if existing.Status != recordStatusRejected && existing.Status != recordStatusSuperseded {
+			return fmt.Errorf("student %s already has record %s for semester %d (status %s); correct or supersede it instead", studentID, existing.RecordID, semester, existing.Status)
+		}
+	}
+	return nil
+}
+
+// appendRemark adds a timestamped, attributed line to the record's remarks so earlier
+// comments are never overwritten; empty remarks are ignored
+func appendRemark(record *AcademicRecord, org string, txTime time.Time, remarks string) {
+	remarks = strings.TrimSpace(remarks)
+	if remarks == "" {
+		return
+	}
+
+	entry := fmt.Sprintf("[%s] %s: %s", txTime.UTC().Format(time.RFC3339), org, remarks)
+	if record.Remarks == "" {
+		record.Remarks = entry
+		return
+	}
+	record.Remarks += "\n" + entry
+}
+
+// putAcademicRecord saves a record under its record ID
+func putAcademicRecord(ctx contractapi.TransactionContextInterface, record *AcademicRecord) error {
+	if err := assertInstitutionWrite(ctx, record.InstitutionID); err != nil {
+		return err
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %v", err)
+	}
+	if err := ctx.GetStub().PutState(record.RecordID, recordJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
+
+// ========== CERTIFICATE MANAGEMENT ==========
+
+// IssueCertificate issues a certificate (NITWarangal issues); validityDays of 0 issues a permanent certificate.
+// overrideEligibility issues despite unmet requirements and needs the admin attribute. graduationDate
+// (RFC3339) is required for DEGREE and DIPLOMA certificates, which are also classified.
+func (s *SmartContract) IssueCertificate(ctx contractapi.TransactionContextInterface, certificateID string, studentID string, certificationType string, validityDays int, overrideEligibility bool, graduationDate string) (*Certificate, error) {
+	cert, err := s.issueCertificate(ctx, certificateID, studentID, certificationType, validityDays, graduationDate, "", overrideEligibility)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := emitLifecycleEvent(ctx, eventCertificateIssued, "CERTIFICATE", certificateID, cert.Status); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// issueCertificate validates and stores a new certificate; replacesCertificateID names the
+// certificate being reissued, which is then ignored by the uniqueness check and exempts the
+// replacement from eligibility checks the original already passed and from supplying a
+// graduation date the original may predate
+func (s *SmartContract) issueCertificate(ctx contractapi.TransactionContextInterface, certificateID string, studentID string, certificationType string, validityDays int, graduationDate string, replacesCertificateID string, overrideEligibility bool) (*Certificate, error) {
+	creatorOrg, err := requireOrgRole(ctx, "issue certificates", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationIssueCertificate); err != nil {
+		return nil, err
+	}
+
+	if err := assertKeyUnused(ctx, certificateID, docTypeCertificate); err != nil {
+		return nil, err
+	}
+
+	if err := validateValidityDays(certificationType, validityDays); err != nil {
+		return nil, err
+	}
+	if graduationDate == "" && graduationCertificationTypes[certificationType] && replacesCertificateID == "" {
+		return nil, fmt.Errorf("a graduation date is required for %s certificates", certificationType)
+	}
+	if graduationDate != "" {
+		if graduationDate, err = parseGraduationDate(ctx, graduationDate); err != nil {
+			return nil, err
+		}
+	}
+
+	// One live certificate per student for unique certification types
+	if err := checkCertificateUnique(ctx, studentID, certificationType, replacesCertificateID); err != nil {
+		return nil, err
+	}
+
+	// A disciplinary hold blocks reissues too, and no eligibility override lifts it
+	if err := assertNoDisciplinaryHold(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	if replacesCertificateID == "" {
+		if err := checkIssuanceEligibility(ctx, certificateID, studentID, certificationType, overrideEligibility); err != nil {
+			return nil, err
+		}
+	}
+
+	// The printed fields are copied now, so later template edits leave this certificate alone
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	template, err := certificateTemplateSnapshot(ctx, certificationType, student)
+	if err != nil {
+		return nil, err
+	}
+	institution, err := readInstitution(ctx, assetInstitution(student.InstitutionID))
+	if err != nil {
+		return nil, err
+	}
+	if institution == nil {
+		return nil, fmt.Errorf("institution %s of student %s is not registered", student.InstitutionID, studentID)
+	}
+	var classificationCGPA float64
+	var classification string
+	var classificationBands []ClassificationBand
+	if graduationCertificationTypes[certificationType] {
+		classificationCGPA, classification, classificationBands, err = classifyStudent(ctx, student)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	issuedDate := txTime.UTC().Format(time.RFC3339)
+
+	// Generate certificate hash with the algorithm configured at issuance
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hashAlgorithm := config.CertificateHashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = hashAlgorithmSHA256
+	}
+	certHash, err := generateCertificateHash(hashAlgorithm, certificateID, studentID, certificationType, issuedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := Certificate{
+		DocType:                 docTypeCertificate,
+		CertificateID:           certificateID,
+		StudentID:               studentID,
+		CertificationType:       certificationType,
+		IssuedDate:              issuedDate,
+		CertificateHash:         certHash,
+		HashFormat:              hashFormatCanonical,
+		HashAlgorithm:           hashAlgorithm,
+		VerificationURL:         institution.VerificationURLPrefix + certificateID,
+		Status:                  certStatusIssued,
+		IssuedBy:                creatorOrg,
+		InstitutionID:           institution.InstitutionID,
+		VerificationCount:       0,
+		CreatedAt:               issuedDate,
+		ValidFrom:               issuedDate,
+		SupersedesCertificateID: replacesCertificateID,
+		Template:                template,
+		GraduationDate:          graduationDate,
+		Classification:          classification,
+		ClassificationCGPA:      classificationCGPA,
+		ClassificationBands:     classificationBands,
+	}
+	if validityDays > 0 {
+		cert.ValidUntil = txTime.AddDate(0, 0, validityDays).UTC().Format(time.RFC3339)
+	}
+	cert.QRCode, err = encodeQRPayload(&cert)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotCertificationTypes[certificationType] {
+		cert.CoveredRecordIDs, cert.RecordsHash, err = snapshotVerifiedRecords(ctx, studentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := putCertificate(ctx, &cert); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "cert~student~type", []string{studentID, certificationType, certificateID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "certhash~id", []string{certHash, certificateID}); err != nil {
+		return nil, err
+	}
+	if err := countMonthly(ctx, monthlyIssued); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "IssueCertificate", "CERTIFICATE", certificateID, fmt.Sprintf("Certificate issued to student %s", studentID))
+
+	return &cert, nil
+}
+
+// VerifyCertificate verifies a certificate and records the verification.
+//
+// Deprecated: use CheckCertificate for a read-only check or RecordVerification to register
+// the verification on the ledger. VerifyCertificate will be removed in the next release.
+func (s *SmartContract) VerifyCertificate(ctx contractapi.TransactionContextInterface, certificateID string, certHash string) (bool, error) {
+	result, err := s.RecordVerification(ctx, certificateID, certHash, "")
+	if err != nil {
+		return false, nil
+	}
+	return result.Verified, nil
+}
+
+// GetCertificate retrieves certificate details with the current verification count
+func (s *SmartContract) GetCertificate(ctx contractapi.TransactionContextInterface, certificateID string) (*Certificate, error) {
+	if err := assertUnloggedReadAllowed(ctx, "GetCertificateLogged"); err != nil {
+		return nil, err
+	}
+	return readCertificateView(ctx, certificateID)
+}
+
+// readCertificateView loads a certificate with its verification count filled in
+func readCertificateView(ctx contractapi.TransactionContextInterface, certificateID string) (*Certificate, error) {
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertInstitutionRead(ctx, cert.InstitutionID); err != nil {
+		return nil, err
+	}
+
+	cert.VerificationCount, err = countVerifications(ctx, cert)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// GetStudentCertificates retrieves all certificates for a student via the cert~student~type index.
+// Certificates issued before the index existed need BackfillCertificateIndex to show up.
+func (s *SmartContract) GetStudentCertificates(ctx contractapi.TransactionContextInterface, studentID string) ([]*Certificate, error) {
+	certificateIDs, err := indexedCertificateIDs(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := []*Certificate{}
+	for _, certificateID := range certificateIDs {
+		cert, err := readCertificate(ctx, certificateID)
+		if err != nil || cert.StudentID != studentID {
+			continue // stale index entry
+		}
+		certificates = append(certificates, cert)
 	}
 
 	return certificates, nil
@@ -500,84 +1826,277 @@ func (s *SmartContract) GetStudentCertificates(ctx contractapi.TransactionContex
 
 // ========== AUDIT & VERIFICATION ==========
 
-// GetAuditLog retrieves audit trail for a record
+// GetAuditLog retrieves the full audit trail for a record, oldest entry first (NITWarangal and
+// regulators); see GetAuditLogWithPagination for long trails
 func (s *SmartContract) GetAuditLog(ctx contractapi.TransactionContextInterface, recordID string) ([]*AuditLog, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("audit", []string{recordID})
-	if err != nil {
+	if _, err := requireOrgRole(ctx, "view audit logs", orgRoleUniversity, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+	return auditEntries(ctx, recordID)
+}
+
+// GetAuditLogWithPagination retrieves one page of the audit trail for a record, oldest entry
+// first (NITWarangal and regulators)
+func (s *SmartContract) GetAuditLogWithPagination(ctx contractapi.TransactionContextInterface, recordID string, pageSize int32, bookmark string) (*PaginatedAuditLogs, error) {
+	if _, err := requireOrgRole(ctx, "view audit logs", orgRoleUniversity, orgRoleRegulator); err != nil {
 		return nil, err
 	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("audit", []string{recordID}, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
 	defer resultsIterator.Close()
 
-	var logs []*AuditLog
+	// Log IDs start with the zero-padded tx timestamp, so key order is chronological
+	logs := []*AuditLog{}
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		logJSON, err := ctx.GetStub().GetState(compositeKeyParts[1])
+		if err != nil || logJSON == nil {
+			continue
+		}
+
 		var log AuditLog
-		if err := json.Unmarshal(response.Value, &log); err != nil {
+		if err := json.Unmarshal(logJSON, &log); err != nil {
 			continue
 		}
 		logs = append(logs, &log)
 	}
 
-	return logs, nil
+	return &PaginatedAuditLogs{
+		Logs:         logs,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}, nil
 }
 
 // ========== HELPER FUNCTIONS ==========
 
-// calculateSGPA calculates semester GPA
-func calculateSGPA(courses []CourseGrade) float64 {
-	var totalPoints float64
-	var totalCredits float64
-
-	for _, course := range courses {
-		totalPoints += course.GradePoint * course.Credits
-		totalCredits += course.Credits
+// validateCourses rejects empty course lists, missing codes or names, out-of-range
+// credits and duplicate course codes
+func validateCourses(courses []CourseGrade) error {
+	if len(courses) == 0 {
+		return fmt.Errorf("academic record must contain at least one course")
 	}
 
-	if totalCredits == 0 {
-		return 0
+	seen := map[string]bool{}
+	for i, course := range courses {
+		code := strings.TrimSpace(course.CourseCode)
+		if code == "" {
+			return fmt.Errorf("course at position %d: course code is required", i)
+		}
+		if strings.TrimSpace(course.CourseName) == "" {
+			return fmt.Errorf("course %s: course name is required", code)
+		}
+		if course.Credits <= 0 || course.Credits > maxCourseCredits {
+			return fmt.Errorf("course %s: credits %v must be greater than 0 and at most %d", code, course.Credits, maxCourseCredits)
+		}
+		if seen[code] {
+			return fmt.Errorf("course %s: listed more than once", code)
+		}
+		seen[code] = true
 	}
-
-	sgpa := totalPoints / totalCredits
-	// Round to 2 decimal places
-	return float64(int(sgpa*100)) / 100
+	return nil
 }
 
 // getCreatorOrganization extracts organization name from certificate
 func getCreatorOrganization(ctx contractapi.TransactionContextInterface) (string, error) {
-	clientID, err := ctx.GetClientIdentity().GetID()
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
 		return "", err
 	}
+	return mspID, nil
+}
+
+// getClientCommonName returns the subject CN of the client certificate, which names the
+// enrolled user; it comes from the signed proposal, so every endorser sees the same value
+func getClientCommonName(ctx contractapi.TransactionContextInterface) string {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err == nil && cert != nil && cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
 
-	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	// Fall back to the decoded identity, "x509::CN=user,OU=...::CN=issuer,..."
+	clientID, err := ctx.GetClientIdentity().GetID()
 	if err != nil {
-		return "", err
+		return ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(clientID)
+	if err != nil {
+		return ""
 	}
+	parts := strings.Split(string(decoded), "::")
+	if len(parts) < 2 {
+		return ""
+	}
+	for _, attribute := range strings.Split(parts[1], ",") {
+		if strings.HasPrefix(attribute, "CN=") {
+			return strings.TrimPrefix(attribute, "CN=")
+		}
+	}
+	return ""
+}
 
-	log.Printf("Client: %s, MSPID: %s", clientID, mspID)
-	return mspID, nil
+// certificateHashPayload is the content a certificate hash covers
+type certificateHashPayload struct {
+	CertificateID     string `json:"certificateId"`
+	StudentID         string `json:"studentId"`
+	CertificationType string `json:"certificationType"`
+	IssuedDate        string `json:"issuedDate"`
+}
+
+// generateCertificateHash creates a deterministic hash for certificate over canonical JSON
+func generateCertificateHash(algorithm string, certificateID string, studentID string, certificationType string, issuedDate string) (string, error) {
+	return canonicalDigest(certificateHashPayload{
+		CertificateID:     certificateID,
+		StudentID:         studentID,
+		CertificationType: certificationType,
+		IssuedDate:        issuedDate,
+	}, algorithm)
 }
 
-// generateCertificateHash creates SHA256 hash for certificate
-func generateCertificateHash(certificateID string, studentID string) string {
-	data := certificateID + studentID + time.Now().String()
+// legacyCertificateHash is the pipe-joined hash of certificates issued before canonical JSON
+func legacyCertificateHash(certificateID string, studentID string, certificationType string, issuedDate string) string {
+	data := certificateID + "|" + studentID + "|" + certificationType + "|" + issuedDate
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
 
+// certificateHashMatches recomputes a certificate's hash in the format and algorithm it was
+// issued with. Legacy pipe-joined hashes are always SHA-256.
+func certificateHashMatches(cert *Certificate) (bool, error) {
+	studentID := cert.StudentID
+	if cert.IssuedToStudentID != "" {
+		studentID = cert.IssuedToStudentID
+	}
+	if !knownHashAlgorithm(cert.HashAlgorithm) {
+		return false, fmt.Errorf("certificate %s has unsupported hash algorithm %q", cert.CertificateID, cert.HashAlgorithm)
+	}
+	switch cert.HashFormat {
+	case "":
+		return legacyCertificateHash(cert.CertificateID, studentID, cert.CertificationType, cert.IssuedDate) == cert.CertificateHash, nil
+	case hashFormatCanonical:
+		hash, err := generateCertificateHash(cert.HashAlgorithm, cert.CertificateID, studentID, cert.CertificationType, cert.IssuedDate)
+		if err != nil {
+			return false, err
+		}
+		return hash == cert.CertificateHash, nil
+	}
+	return false, fmt.Errorf("certificate %s has unknown hash format %q", cert.CertificateID, cert.HashFormat)
+}
+
+// getTxTimestamp returns the transaction timestamp, which is identical on every endorser
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.AsTime(), nil
+}
+
+// normalizePageSize falls back to the default page size for missing or oversized values
+func normalizePageSize(pageSize int32) int32 {
+	if pageSize <= 0 || pageSize > maxPageSize {
+		return defaultPageSize
+	}
+	return pageSize
+}
+
+// putIndex writes a composite key index entry with an empty marker value
+func putIndex(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return fmt.Errorf("failed to create %s index: %v", objectType, err)
+	}
+	if err := countIndexEntry(ctx, objectType, attributes, indexKey, 1); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(indexKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to put %s index: %v", objectType, err)
+	}
+	return nil
+}
+
+// assertKeyUnused fails if any asset is already stored under key, so creating one asset
+// can never overwrite another (including one of a different type)
+func assertKeyUnused(ctx contractapi.TransactionContextInterface, key string, docType string) error {
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read state: %v", err)
+	}
+	if existing == nil {
+		return nil
+	}
+
+	var doc struct {
+		DocType string `json:"docType"`
+	}
+	if err := json.Unmarshal(existing, &doc); err == nil && doc.DocType != "" && doc.DocType != docType {
+		return fmt.Errorf("cannot create %s %s: ID already used by a %s", docType, key, doc.DocType)
+	}
+	return fmt.Errorf("%s %s already exists", docType, key)
+}
+
+// deleteIndex removes a composite key index entry
+func deleteIndex(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) error {
+	indexKey, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return fmt.Errorf("failed to create %s index: %v", objectType, err)
+	}
+	if err := countIndexEntry(ctx, objectType, attributes, indexKey, -1); err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return fmt.Errorf("failed to delete %s index: %v", objectType, err)
+	}
+	return nil
+}
+
+// assetRange iterates every asset in world state. A range over simple keys skips composite
+// keys, so index entries and other composite keys are never visited.
+func assetRange(ctx contractapi.TransactionContextInterface) (shim.StateQueryIteratorInterface, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state range: %v", err)
+	}
+	return resultsIterator, nil
+}
+
 // logAudit creates audit log entry
 func logAudit(ctx contractapi.TransactionContextInterface, action string, recordType string, recordID string, details string) error {
 	org, _ := getCreatorOrganization(ctx)
+	userID, _ := ctx.GetClientIdentity().GetID()
 
-	logID := fmt.Sprintf("audit_%d_%s", time.Now().UnixNano(), recordID)
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	txCtx, err := txContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Zero-padded tx timestamp keeps audit keys in chronological order; the sequence number
+	// keeps the entries of one transaction apart and in call order, even for the same record
+	// and action
+	logID := fmt.Sprintf("audit_%019d_%06d_%s_%s", txTime.UnixNano(), txCtx.auditSeq, recordID, action)
+	txCtx.auditSeq++
 	auditLog := AuditLog{
 		LogID:         logID,
-		Timestamp:     time.Now().Format(time.RFC3339),
+		Timestamp:     txTime.Format(time.RFC3339),
 		Organization:  org,
+		User:          getClientCommonName(ctx),
+		UserID:        userID,
 		Action:        action,
 		RecordType:    recordType,
 		RecordID:      recordID,
@@ -586,18 +2105,23 @@ func logAudit(ctx contractapi.TransactionContextInterface, action string, record
 	}
 
 	logJSON, _ := json.Marshal(auditLog)
-	ctx.GetStub().PutState(logID, logJSON)
+	if err := ctx.GetStub().PutState(logID, logJSON); err != nil {
+		return fmt.Errorf("failed to put audit log: %v", err)
+	}
 
 	// Create index for audit queries
-	ctx.GetStub().CreateCompositeKey("audit", []string{recordID, logID})
-
-	return nil
+	return putIndex(ctx, "audit", []string{recordID, logID})
 }
 
 // ========== ENTRY POINT ==========
 
 func main() {
-	chaincode, err := contractapi.NewChaincode(&SmartContract{})
+	// Regulators are refused every write before it runs, and each transaction keeps its own
+	// state on a fresh TransactionContext
+	chaincode, err := contractapi.NewChaincode(&SmartContract{Contract: contractapi.Contract{
+		BeforeTransaction:         rejectRegulatorWrites,
+		TransactionContextHandler: new(TransactionContext),
+	}})
 	if err != nil {
 		log.Panicf("Error creating academic-records chaincode: %v", err)
 	}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT MERGE ==========
+//
+// When one person was enrolled under two IDs, MergeStudents moves the duplicate's records and
+// certificates to the survivor and leaves the duplicate as a MERGED tombstone pointing at it.
+// Certificates keep the hash they were issued with; IssuedToStudentID records the ID it covers.
+
+// MergeResult lists the assets MergeStudents re-pointed
+type MergeResult struct {
+	SurvivorID     string   `json:"survivorId"`
+	DuplicateID    string   `json:"duplicateId"`
+	RecordIDs      []string `json:"recordIds"`
+	CertificateIDs []string `json:"certificateIds"`
+}
+
+// MergeStudents folds duplicateID into survivorID (NITWarangal admin identities only). It refuses
+// when both students have a live record for the same semester; resolve those by hand first.
+func (s *SmartContract) MergeStudents(ctx contractapi.TransactionContextInterface, survivorID string, duplicateID string, reason string) (*MergeResult, error) {
+	if _, err := requireOrgRole(ctx, "merge students", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("merging students requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to merge students")
+	}
+	if survivorID == duplicateID {
+		return nil, fmt.Errorf("cannot merge student %s into itself", survivorID)
+	}
+
+	survivor, err := readStudent(ctx, survivorID)
+	if err != nil {
+		return nil, err
+	}
+	duplicate, err := readStudent(ctx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, student := range []*Student{survivor, duplicate} {
+		if err := closedStudentError(student); err != nil {
+			return nil, err
+		}
+		if studentFrozen(student) {
+			return nil, frozenStudentError(student.StudentID)
+		}
+	}
+
+	records, err := getStudentRecordList(ctx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Status == recordStatusRejected || record.Status == recordStatusSuperseded {
+			continue
+		}
+		if err := checkSemesterUnique(ctx, survivorID, record.Semester, ""); err != nil {
+			return nil, fmt.Errorf("cannot merge: %v; duplicate's record %s covers the same semester", err, record.RecordID)
+		}
+	}
+
+	result := &MergeResult{SurvivorID: survivorID, DuplicateID: duplicateID, RecordIDs: []string{}, CertificateIDs: []string{}}
+
+	for _, record := range records {
+		if err := deleteIndex(ctx, "record~student", []string{duplicateID, record.RecordID}); err != nil {
+			return nil, err
+		}
+		if err := deleteIndex(ctx, "record~student~semester", []string{duplicateID, strconv.Itoa(record.Semester), record.RecordID}); err != nil {
+			return nil, err
+		}
+		record.StudentID = survivorID
+		if err := putAcademicRecord(ctx, record); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "record~student", []string{survivorID, record.RecordID}); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "record~student~semester", []string{survivorID, strconv.Itoa(record.Semester), record.RecordID}); err != nil {
+			return nil, err
+		}
+		result.RecordIDs = append(result.RecordIDs, record.RecordID)
+	}
+
+	certificateIDs, err := indexedCertificateIDs(ctx, duplicateID)
+	if err != nil {
+		return nil, err
+	}
+	for _, certificateID := range certificateIDs {
+		cert, err := readCertificate(ctx, certificateID)
+		if err != nil {
+			return nil, err
+		}
+		if err := deleteIndex(ctx, "cert~student~type", []string{duplicateID, cert.CertificationType, certificateID}); err != nil {
+			return nil, err
+		}
+		if cert.IssuedToStudentID == "" {
+			cert.IssuedToStudentID = cert.StudentID
+		}
+		cert.StudentID = survivorID
+		if err := putCertificate(ctx, cert); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "cert~student~type", []string{survivorID, cert.CertificationType, certificateID}); err != nil {
+			return nil, err
+		}
+		result.CertificateIDs = append(result.CertificateIDs, certificateID)
+	}
+
+	duplicate.MergedInto = survivorID
+	if err := setStudentStatus(ctx, duplicate, studentStatusMerged, fmt.Sprintf("Merged into %s: %s", survivorID, reason)); err != nil {
+		return nil, err
+	}
+	if err := deleteIndex(ctx, "student~department", []string{duplicate.Department, duplicateID}); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("Merged %s into %s: %s; records re-pointed: [%s]; certificates re-pointed: [%s]",
+		duplicateID, survivorID, reason, strings.Join(result.RecordIDs, ", "), strings.Join(result.CertificateIDs, ", "))
+	logAudit(ctx, "MergeStudents", "STUDENT", duplicateID, details)
+	logAudit(ctx, "MergeStudents", "STUDENT", survivorID, details)
+
+	if err := emitLifecycleEvent(ctx, eventStudentStatusChanged, "STUDENT", duplicateID, studentStatusMerged); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== MONTHLY CERTIFICATE STATS ==========
+//
+// Issuances, verifications and revocations are counted per calendar month (UTC) of the
+// transaction timestamp, as stats~certs~YYYY-MM~metric~txID deltas like the ledger stats. A
+// transaction can only count into its own month, so a month stops changing once it is over;
+// CorrectMonthlyStats is the only way to amend a past month.
+
+// Monthly metrics
+const (
+	monthlyIssued   = "ISSUED"
+	monthlyVerified = "VERIFIED"
+	monthlyRevoked  = "REVOKED"
+)
+
+// monthLayout is the format of a month bucket
+const monthLayout = "2006-01"
+
+// maxStatsMonths bounds the span GetMonthlyStats returns in one call
+const maxStatsMonths = 120
+
+// MonthlyCertStats is one month of the certificate series
+type MonthlyCertStats struct {
+	Month     string `json:"month"` // YYYY-MM
+	Issued    int    `json:"issued"`
+	Verified  int    `json:"verified"`
+	Revoked   int    `json:"revoked"`
+	Corrected bool   `json:"corrected"` // true when CorrectMonthlyStats amended the month
+}
+
+// GetMonthlyStats returns the certificate series for each month from fromMonth to toMonth,
+// both YYYY-MM and inclusive (NITWarangal and regulators)
+func (s *SmartContract) GetMonthlyStats(ctx contractapi.TransactionContextInterface, fromMonth string, toMonth string) ([]*MonthlyCertStats, error) {
+	if _, err := requireOrgRole(ctx, "view monthly stats", orgRoleUniversity, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+
+	from, err := time.Parse(monthLayout, fromMonth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fromMonth %q: expected YYYY-MM", fromMonth)
+	}
+	to, err := time.Parse(monthLayout, toMonth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid toMonth %q: expected YYYY-MM", toMonth)
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("fromMonth %s is after toMonth %s", fromMonth, toMonth)
+	}
+
+	series := []*MonthlyCertStats{}
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		if len(series) == maxStatsMonths {
+			return nil, fmt.Errorf("range spans more than %d months", maxStatsMonths)
+		}
+		entry, err := readMonthlyStats(ctx, month.Format(monthLayout))
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, entry)
+	}
+	return series, nil
+}
+
+// CorrectMonthlyStats sets one metric of a past month to count (NITWarangal admin identities
+// only). The current month cannot be corrected; its counts are still moving.
+func (s *SmartContract) CorrectMonthlyStats(ctx contractapi.TransactionContextInterface, month string, metric string, count int, reason string) (*MonthlyCertStats, error) {
+	if _, err := requireOrgRole(ctx, "correct monthly stats", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("correcting monthly stats requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to correct monthly stats")
+	}
+	if metric != monthlyIssued && metric != monthlyVerified && metric != monthlyRevoked {
+		return nil, fmt.Errorf("invalid metric %q: must be %s, %s or %s", metric, monthlyIssued, monthlyVerified, monthlyRevoked)
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("count cannot be negative")
+	}
+	bucket, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month %q: expected YYYY-MM", month)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	if bucket.Format(monthLayout) >= txTime.UTC().Format(monthLayout) {
+		return nil, fmt.Errorf("only months before %s can be corrected", txTime.UTC().Format(monthLayout))
+	}
+
+	entry, err := readMonthlyStats(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[string]int{monthlyIssued: entry.Issued, monthlyVerified: entry.Verified, monthlyRevoked: entry.Revoked}[metric]
+	if previous == count {
+		return nil, fmt.Errorf("%s %s is already %d", month, metric, count)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey("stats~certs", []string{month, metric, ctx.GetStub().GetTxID()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stats key: %v", err)
+	}
+	correctionJSON, err := json.Marshal(statsDelta{Count: count - previous, UpdatedAt: txTime.UTC().Format(time.RFC3339), Correction: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stats delta: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, correctionJSON); err != nil {
+		return nil, fmt.Errorf("failed to put stats delta: %v", err)
+	}
+
+	logAudit(ctx, "CorrectMonthlyStats", "CONFIG", "STATS_"+month, fmt.Sprintf("%s %s corrected from %d to %d: %s", month, metric, previous, count, reason))
+
+	entry, err = readMonthlyStats(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	// GetState does not see the correction just written
+	switch metric {
+	case monthlyIssued:
+		entry.Issued = count
+	case monthlyVerified:
+		entry.Verified = count
+	case monthlyRevoked:
+		entry.Revoked = count
+	}
+	entry.Corrected = true
+	return entry, nil
+}
+
+// readMonthlyStats sums the deltas of one month
+func readMonthlyStats(ctx contractapi.TransactionContextInterface, month string) (*MonthlyCertStats, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("stats~certs", []string{month})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query monthly stats: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	entry := &MonthlyCertStats{Month: month}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 3 {
+			continue
+		}
+		var delta statsDelta
+		if err := json.Unmarshal(response.Value, &delta); err != nil {
+			continue
+		}
+		switch compositeKeyParts[1] {
+		case monthlyIssued:
+			entry.Issued += delta.Count
+		case monthlyVerified:
+			entry.Verified += delta.Count
+		case monthlyRevoked:
+			entry.Revoked += delta.Count
+		}
+		entry.Corrected = entry.Corrected || delta.Correction
+	}
+	return entry, nil
+}
+
+// countMonthly adds one to a metric in the month of the transaction timestamp; a transaction
+// counting several times, as bulk issuance does, writes its running total
+func countMonthly(ctx contractapi.TransactionContextInterface, metric string) error {
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	key, err := ctx.GetStub().CreateCompositeKey("stats~certs", []string{txTime.UTC().Format(monthLayout), metric, ctx.GetStub().GetTxID()})
+	if err != nil {
+		return fmt.Errorf("failed to create stats key: %v", err)
+	}
+
+	stats, err := transactionStats(ctx)
+	if err != nil {
+		return err
+	}
+	return putStatsDelta(ctx, stats, key, 1, txTime)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+func TestMonthlyCountsSurviveInterleavedTransactions(t *testing.T) {
+	l := newTestLedger(t)
+	other := newTestLedger(t)
+	NewTestStudent(t, other, "CS21001", "CSE")
+
+	// A bulk issuance counts once per certificate while other issuances run alongside it
+	ws := l.Submit(testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) error {
+		for i, certificateID := range []string{"BON1", "BON2", "BON3"} {
+			if err := countMonthly(ctx, monthlyIssued); err != nil {
+				return err
+			}
+			NewTestCertificate(t, other, certificateID, "CS21001", "BONAFIDE")
+			if i == 1 {
+				ledgertest.MustInvoke(t, other, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Certificate, error) {
+					return other.contract.RevokeCertificate(ctx, "BON1", "ADMIN_ERROR", "issued in error")
+				})
+			}
+		}
+		return nil
+	})
+	if ws.Err != nil {
+		t.Fatalf("counting transaction failed: %v", ws.Err)
+	}
+
+	month := l.Now.Format(monthLayout)
+	var delta statsDelta
+	ws.Decode(t, l.CompositeKey(t, "stats~certs", month, monthlyIssued, ws.TxID), &delta)
+	if delta.Count != 3 {
+		t.Errorf("issued delta of the bulk transaction = %d, want 3", delta.Count)
+	}
+	for _, tt := range []struct {
+		l    *testLedger
+		want MonthlyCertStats
+	}{
+		{l, MonthlyCertStats{Month: month, Issued: 3}},
+		{other, MonthlyCertStats{Month: month, Issued: 3, Revoked: 1}},
+	} {
+		series := ledgertest.MustInvoke(t, tt.l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) ([]*MonthlyCertStats, error) {
+			return tt.l.contract.GetMonthlyStats(ctx, month, month)
+		})
+		if len(series) != 1 || *series[0] != tt.want {
+			t.Errorf("monthly stats = %+v, want %+v", series[0], tt.want)
+		}
+	}
+}
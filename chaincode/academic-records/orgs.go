@@ -0,0 +1,442 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== ORGANIZATION CONFIG ==========
+
+// Org roles an MSP ID can be configured for
+const (
+	orgRoleUniversity = "university"
+	orgRoleDepartment = "department"
+	orgRoleVerifier   = "verifier"
+	orgRoleRegulator  = "regulator" // read-only accreditation bodies, see rejectRegulatorWrites
+)
+
+// orgRoleLabels name each org role in permission errors
+var orgRoleLabels = map[string]string{
+	orgRoleUniversity: "the university",
+	orgRoleDepartment: "departments",
+	orgRoleVerifier:   "verifiers",
+	orgRoleRegulator:  "regulators",
+}
+
+// OrgConfig maps org roles to MSP IDs so the chaincode runs on networks with other MSP IDs
+// and further orgs can be authorized without a code change
+type OrgConfig struct {
+	DocType        string   `json:"docType"`
+	UniversityOrgs []string `json:"universityOrgs"`
+	DepartmentOrgs []string `json:"departmentOrgs"`
+	VerifierOrgs   []string `json:"verifierOrgs"`
+
+	// Accreditation bodies such as AICTE and NBA: read-only, and may be empty
+	RegulatorOrgs []string `json:"regulatorOrgs"`
+
+	// Distinct university identities that must approve an academic record
+	RequiredApprovals int `json:"requiredApprovals"`
+
+	// Deny verifier orgs the plain student record and certificate getters so their reads go
+	// through the *Logged variants and leave an access audit entry
+	RequireLoggedReads bool `json:"requireLoggedReads"`
+
+	// Accept course codes missing from the course catalog, for importing legacy records
+	AllowUncataloguedCourses bool `json:"allowUncataloguedCourses"`
+
+	// Which attempt of a repeated course counts toward CGPA: LATEST or BEST
+	RepeatPolicy string `json:"repeatPolicy"`
+
+	// Refuse DEGREE certificates until every no-dues clearance item is CLEARED
+	RequireDegreeClearance bool `json:"requireDegreeClearance"`
+
+	// Regular expression new student IDs must match, such as the institutional roll number format
+	StudentIDPattern string `json:"studentIdPattern"`
+
+	// Days audit entries must be kept before PurgeAuditLogs may remove them
+	AuditRetentionDays int `json:"auditRetentionDays"`
+
+	// Hash algorithm new certificates are issued with: sha256 or sha3-256
+	CertificateHashAlgorithm string `json:"certificateHashAlgorithm"`
+
+	// Degree classes per program, highest first; "*" covers programs without their own
+	ClassificationThresholds map[string][]ClassificationBand `json:"classificationThresholds"`
+
+	// Honors need this CGPA and, unless backlogs are allowed, no failed course; records reaching
+	// DeansListMinSGPA are flagged for the dean's list at approval
+	HonorsMinCGPA       float64 `json:"honorsMinCgpa"`
+	HonorsAllowBacklogs bool    `json:"honorsAllowBacklogs"`
+	DeansListMinSGPA    float64 `json:"deansListMinSgpa"`
+
+	// MSP ID -> FULL, DEPARTMENT or PUBLIC, shaping what getters return to that org; orgs not
+	// listed get the default of their role, see filterResponse
+	VisibilityProfiles map[string]string `json:"visibilityProfiles"`
+
+	// Roles whose orgs may list students, and roles whose orgs may read any single student; other
+	// orgs read a student only with a certificate they are verifying or the student's consent
+	StudentListRoles []string `json:"studentListRoles"`
+	StudentReadRoles []string `json:"studentReadRoles"`
+
+	UpdatedBy string `json:"updatedBy"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// orgConfigKey is the world state key of the org config asset
+const orgConfigKey = "CONFIG_ORGS"
+
+// defaultOrgConfig matches the MSP IDs of the reference network and applies until a config is stored
+var defaultOrgConfig = OrgConfig{
+	DocType:        docTypeOrgConfig,
+	UniversityOrgs: []string{"NITWarangalMSP"},
+	DepartmentOrgs: []string{"DepartmentsMSP"},
+	VerifierOrgs:   []string{"VerifiersMSP"},
+	RegulatorOrgs:  []string{},
+
+	RequiredApprovals:  defaultRequiredApprovals,
+	AuditRetentionDays: defaultAuditRetentionDays,
+	RepeatPolicy:       repeatPolicyLatest,
+	StudentIDPattern:   defaultStudentIDPattern,
+
+	CertificateHashAlgorithm: hashAlgorithmSHA256,
+	ClassificationThresholds: defaultClassificationThresholds,
+	HonorsMinCGPA:            defaultHonorsMinCGPA,
+	DeansListMinSGPA:         defaultDeansListMinSGPA,
+	VisibilityProfiles:       map[string]string{},
+	StudentListRoles:         defaultStudentListRoles,
+	StudentReadRoles:         defaultStudentReadRoles,
+}
+
+// defaultRequiredApprovals is the two-person rule for record approval
+const defaultRequiredApprovals = 2
+
+// defaultAuditRetentionDays is the seven-year audit retention policy
+const defaultAuditRetentionDays = 7 * 365
+
+// defaultStudentIDPattern accepts letters, digits, underscores and hyphens
+const defaultStudentIDPattern = `^[A-Za-z0-9_-]{1,64}$`
+
+// Repeat policies: NITW counts the latest attempt of a repeated course by default
+const (
+	repeatPolicyLatest = "LATEST"
+	repeatPolicyBest   = "BEST"
+)
+
+// InitConfig stores the initial org config; run it once at instantiation. It fails if a
+// config already exists, and the caller's org must be one of the configured university orgs.
+func (s *SmartContract) InitConfig(ctx contractapi.TransactionContextInterface, configJSON string) (*OrgConfig, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+
+	existing, err := ctx.GetStub().GetState(orgConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read org config: %v", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("org config is already initialized; use UpdateConfig")
+	}
+
+	config, err := parseOrgConfig(configJSON)
+	if err != nil {
+		return nil, err
+	}
+	if !config.hasRole(creatorOrg, orgRoleUniversity) {
+		return nil, fmt.Errorf("caller org %s must be listed in universityOrgs", creatorOrg)
+	}
+
+	return storeOrgConfig(ctx, "InitConfig", creatorOrg, config)
+}
+
+// GetConfig returns the org config in force
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+	return getOrgConfig(ctx)
+}
+
+// UpdateConfig replaces the org config (university orgs only). The caller's org must remain
+// a university org so the config cannot be locked out by mistake.
+func (s *SmartContract) UpdateConfig(ctx contractapi.TransactionContextInterface, configJSON string) (*OrgConfig, error) {
+	creatorOrg, err := requireOrgRole(ctx, "update the org config", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := parseOrgConfig(configJSON)
+	if err != nil {
+		return nil, err
+	}
+	if !config.hasRole(creatorOrg, orgRoleUniversity) {
+		return nil, fmt.Errorf("caller org %s must stay listed in universityOrgs", creatorOrg)
+	}
+
+	return storeOrgConfig(ctx, "UpdateConfig", creatorOrg, config)
+}
+
+// requireOrgRole returns the caller's MSP ID if its org is configured for one of the roles;
+// action completes the error message "only <roles> can <action>"
+func requireOrgRole(ctx contractapi.TransactionContextInterface, action string, roles ...string) (string, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get creator organization: %v", err)
+	}
+
+	allowed, err := orgHasRole(ctx, creatorOrg, roles...)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		labels := make([]string, 0, len(roles))
+		for _, role := range roles {
+			labels = append(labels, orgRoleLabels[role])
+		}
+		return "", fmt.Errorf("only %s can %s", strings.Join(labels, " or "), action)
+	}
+	return creatorOrg, nil
+}
+
+// orgHasRole reports whether mspID is configured for any of the roles
+func orgHasRole(ctx contractapi.TransactionContextInterface, mspID string, roles ...string) (bool, error) {
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range roles {
+		if config.hasRole(mspID, role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getOrgConfig returns a copy of the org config in force, falling back to the default. The
+// stored config is read once per transaction, so the many permission checks in one transaction
+// share a single GetState; callers may change their copy freely.
+func getOrgConfig(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+	txCtx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if txCtx.orgConfigJSON == nil {
+		configJSON, err := ctx.GetStub().GetState(orgConfigKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read org config: %v", err)
+		}
+		if configJSON == nil {
+			if configJSON, err = json.Marshal(defaultOrgConfig); err != nil {
+				return nil, fmt.Errorf("failed to marshal org config: %v", err)
+			}
+		}
+		txCtx.orgConfigJSON = configJSON
+	}
+
+	var config OrgConfig
+	if err := json.Unmarshal(txCtx.orgConfigJSON, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal org config: %v", err)
+	}
+	return &config, nil
+}
+
+// parseOrgConfig validates a config payload; every role needs at least one MSP ID
+func parseOrgConfig(configJSON string) (*OrgConfig, error) {
+	var config OrgConfig
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return nil, fmt.Errorf("invalid config JSON: %v", err)
+	}
+
+	for role, orgs := range map[string][]string{
+		"universityOrgs": config.UniversityOrgs,
+		"departmentOrgs": config.DepartmentOrgs,
+		"verifierOrgs":   config.VerifierOrgs,
+	} {
+		if len(orgs) == 0 {
+			return nil, fmt.Errorf("%s must list at least one MSP ID", role)
+		}
+		for _, org := range orgs {
+			if strings.TrimSpace(org) == "" {
+				return nil, fmt.Errorf("%s contains an empty MSP ID", role)
+			}
+		}
+	}
+	for _, org := range config.RegulatorOrgs {
+		if strings.TrimSpace(org) == "" {
+			return nil, fmt.Errorf("regulatorOrgs contains an empty MSP ID")
+		}
+		// A regulator that also held a writing role could not be kept read-only
+		if config.hasRole(org, orgRoleUniversity) || config.hasRole(org, orgRoleDepartment) || config.hasRole(org, orgRoleVerifier) {
+			return nil, fmt.Errorf("regulator org %s cannot also be listed under another role", org)
+		}
+	}
+	if config.RequiredApprovals < 0 {
+		return nil, fmt.Errorf("requiredApprovals cannot be negative")
+	}
+	if config.RequiredApprovals == 0 {
+		config.RequiredApprovals = defaultRequiredApprovals
+	}
+	if config.AuditRetentionDays < 0 {
+		return nil, fmt.Errorf("auditRetentionDays cannot be negative")
+	}
+	if config.AuditRetentionDays == 0 {
+		config.AuditRetentionDays = defaultAuditRetentionDays
+	}
+	if config.RepeatPolicy == "" {
+		config.RepeatPolicy = repeatPolicyLatest
+	}
+	if config.RepeatPolicy != repeatPolicyLatest && config.RepeatPolicy != repeatPolicyBest {
+		return nil, fmt.Errorf("repeatPolicy must be %s or %s", repeatPolicyLatest, repeatPolicyBest)
+	}
+	if config.StudentIDPattern == "" {
+		config.StudentIDPattern = defaultStudentIDPattern
+	}
+	if _, err := regexp.Compile(config.StudentIDPattern); err != nil {
+		return nil, fmt.Errorf("studentIdPattern is not a valid regular expression: %v", err)
+	}
+	if config.CertificateHashAlgorithm == "" {
+		config.CertificateHashAlgorithm = hashAlgorithmSHA256
+	}
+	if config.CertificateHashAlgorithm != hashAlgorithmSHA256 && config.CertificateHashAlgorithm != hashAlgorithmSHA3256 {
+		return nil, fmt.Errorf("certificateHashAlgorithm must be %s or %s", hashAlgorithmSHA256, hashAlgorithmSHA3256)
+	}
+	if len(config.ClassificationThresholds) == 0 {
+		config.ClassificationThresholds = defaultClassificationThresholds
+	}
+	normalized := map[string][]ClassificationBand{}
+	for program, bands := range config.ClassificationThresholds {
+		if program != anyProgram {
+			program = normalizeProgram(program)
+		}
+		if _, duplicate := normalized[program]; duplicate {
+			return nil, fmt.Errorf("classificationThresholds lists program %s more than once", program)
+		}
+		normalized[program] = bands
+	}
+	config.ClassificationThresholds = normalized
+	if err := validateClassificationThresholds(config.ClassificationThresholds); err != nil {
+		return nil, err
+	}
+	if config.HonorsMinCGPA == 0 {
+		config.HonorsMinCGPA = defaultHonorsMinCGPA
+	}
+	if config.HonorsMinCGPA < 0 || config.HonorsMinCGPA > 10 {
+		return nil, fmt.Errorf("honorsMinCgpa must be between 0 and 10")
+	}
+	if config.DeansListMinSGPA == 0 {
+		config.DeansListMinSGPA = defaultDeansListMinSGPA
+	}
+	if config.DeansListMinSGPA < 0 || config.DeansListMinSGPA > 10 {
+		return nil, fmt.Errorf("deansListMinSgpa must be between 0 and 10")
+	}
+	if config.VisibilityProfiles == nil {
+		config.VisibilityProfiles = map[string]string{}
+	}
+	for org, profile := range config.VisibilityProfiles {
+		if strings.TrimSpace(org) == "" {
+			return nil, fmt.Errorf("visibilityProfiles contains an empty MSP ID")
+		}
+		if !validVisibilityProfiles[profile] {
+			return nil, fmt.Errorf("visibilityProfiles: profile %q of %s must be %s, %s or %s", profile, org, visibilityFull, visibilityDepartment, visibilityPublic)
+		}
+	}
+	if len(config.StudentListRoles) == 0 {
+		config.StudentListRoles = defaultStudentListRoles
+	}
+	if len(config.StudentReadRoles) == 0 {
+		config.StudentReadRoles = defaultStudentReadRoles
+	}
+	for setting, roles := range map[string][]string{
+		"studentListRoles": config.StudentListRoles,
+		"studentReadRoles": config.StudentReadRoles,
+	} {
+		for _, role := range roles {
+			if _, known := orgRoleLabels[role]; !known {
+				return nil, fmt.Errorf("%s contains unknown role %q", setting, role)
+			}
+		}
+	}
+	return &config, nil
+}
+
+// storeOrgConfig saves the config, puts it in force for the rest of the transaction and audits
+// old and new values
+func storeOrgConfig(ctx contractapi.TransactionContextInterface, action string, creatorOrg string, config *OrgConfig) (*OrgConfig, error) {
+	previous, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previousJSON, err := json.Marshal(previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal org config: %v", err)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	config.DocType = docTypeOrgConfig
+	config.UpdatedBy = creatorOrg
+	config.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal org config: %v", err)
+	}
+	if err := ctx.GetStub().PutState(orgConfigKey, configJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	// The rest of the transaction checks against the new config, which GetState does not return
+	txCtx, err := txContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txCtx.orgConfigJSON = configJSON
+
+	logAudit(ctx, action, "CONFIG", orgConfigKey, fmt.Sprintf("Org config %s -> %s", string(previousJSON), string(configJSON)))
+
+	return config, nil
+}
+
+// hasRole reports whether mspID is listed for the role
+func (c *OrgConfig) hasRole(mspID string, role string) bool {
+	var orgs []string
+	switch role {
+	case orgRoleUniversity:
+		orgs = c.UniversityOrgs
+	case orgRoleDepartment:
+		orgs = c.DepartmentOrgs
+	case orgRoleVerifier:
+		orgs = c.VerifierOrgs
+	case orgRoleRegulator:
+		orgs = c.RegulatorOrgs
+	}
+	for _, org := range orgs {
+		if org == mspID {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalsRequired returns how many distinct identities must approve a record; configs stored
+// before the setting existed use the default
+func (c *OrgConfig) approvalsRequired() int {
+	if c.RequiredApprovals <= 0 {
+		return defaultRequiredApprovals
+	}
+	return c.RequiredApprovals
+}
+
+// auditRetention returns how many days audit entries are kept; configs stored before the
+// setting existed use the default
+func (c *OrgConfig) auditRetention() int {
+	if c.AuditRetentionDays <= 0 {
+		return defaultAuditRetentionDays
+	}
+	return c.AuditRetentionDays
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+func TestUniversityOrgsFollowConfig(t *testing.T) {
+	l := newTestLedger(t)
+	newRegistrar := testRegistrar.InMSP("NITWUniversityMSP")
+	l.addFoundingInstitutionOrgs(t, newRegistrar.MSPID)
+	createStudent := func(studentID string) func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+			return l.contract.CreateStudent(ctx, studentID, "CSE", "")
+		}
+	}
+
+	ledgertest.InvokeError(t, l, newRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21001")}, "only the university can create students", createStudent("CS21001"))
+
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.UniversityOrgs = append(config.UniversityOrgs, newRegistrar.MSPID)
+	})
+	ledgertest.MustInvoke(t, l, newRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21001")}, createStudent("CS21001"))
+
+	// The new MSP retires the old one; the old one must then be refused
+	config := l.storedConfig(t)
+	config.UniversityOrgs = []string{newRegistrar.MSPID}
+	ledgertest.MustInvoke(t, l, newRegistrar, ledgertest.TxOptions{}, updateConfigCall(t, l, config))
+
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21002")}, "only the university can create students", createStudent("CS21002"))
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "only the university can update the org config", updateConfigCall(t, l, config))
+	if got := ledgertest.MustInvoke(t, l, newRegistrar, ledgertest.TxOptions{Transient: piiTransient("CS21002")}, createStudent("CS21002")); got.CreatedBy != newRegistrar.MSPID {
+		t.Errorf("student created by %q, want %q", got.CreatedBy, newRegistrar.MSPID)
+	}
+}
+
+func TestDepartmentAndVerifierOrgsFollowConfig(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	cseExamCell := testExamCell.InMSP("CSEDepartmentMSP")
+	employer := testVerifier.InMSP("EmployersMSP")
+	l.addFoundingInstitutionOrgs(t, cseExamCell.MSPID, employer.MSPID)
+
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.DepartmentOrgs = []string{cseExamCell.MSPID}
+		config.VerifierOrgs = append(config.VerifierOrgs, employer.MSPID)
+	})
+
+	createRecord := func(recordID string) func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.CreateAcademicRecord(ctx, recordID, "CS21001", 1, 2023, 0)
+		}
+	}
+	ledgertest.InvokeError(t, l, testExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2))}, "only departments can create academic records", createRecord("REC1"))
+	ledgertest.MustInvoke(t, l, cseExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2))}, createRecord("REC1"))
+
+	approveTestRecord(t, l, "REC1")
+	verified := ledgertest.MustInvoke(t, l, employer, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.VerifyAcademicRecord(ctx, "REC1", "", false)
+	})
+	if verified.VerifiedBy != employer.MSPID {
+		t.Errorf("record verified by %q, want %q", verified.VerifiedBy, employer.MSPID)
+	}
+}
+
+func TestUpdateConfigIsRestrictedAndAudited(t *testing.T) {
+	l := newTestLedger(t)
+	valid := l.storedConfig(t)
+
+	tests := []struct {
+		name    string
+		as      *ledgertest.Identity
+		change  func(config *OrgConfig)
+		wantErr string
+	}{
+		{"department caller", testExamCell, func(config *OrgConfig) {}, "only the university can update the org config"},
+		{"regulator caller", testRegulator, func(config *OrgConfig) {}, "only the university can update the org config"},
+		{"caller drops its own org", testRegistrar, func(config *OrgConfig) {
+			config.UniversityOrgs = []string{"OtherUniversityMSP"}
+		}, "caller org NITWarangalMSP must stay listed in universityOrgs"},
+		{"no verifier orgs", testRegistrar, func(config *OrgConfig) {
+			config.VerifierOrgs = nil
+		}, "verifierOrgs must list at least one MSP ID"},
+		{"blank department org", testRegistrar, func(config *OrgConfig) {
+			config.DepartmentOrgs = append(config.DepartmentOrgs, " ")
+		}, "departmentOrgs contains an empty MSP ID"},
+		{"regulator with a writing role", testRegistrar, func(config *OrgConfig) {
+			config.RegulatorOrgs = append(config.RegulatorOrgs, testVerifier.MSPID)
+		}, "regulator org VerifiersMSP cannot also be listed under another role"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := *valid
+			config.UniversityOrgs = append([]string(nil), valid.UniversityOrgs...)
+			config.DepartmentOrgs = append([]string(nil), valid.DepartmentOrgs...)
+			config.VerifierOrgs = append([]string(nil), valid.VerifierOrgs...)
+			config.RegulatorOrgs = append([]string(nil), valid.RegulatorOrgs...)
+			tt.change(&config)
+
+			before := string(l.Stub.State[orgConfigKey])
+			ws := ledgertest.InvokeError(t, l, tt.as, ledgertest.TxOptions{}, tt.wantErr, updateConfigCall(t, l, &config))
+			if len(ws.LedgerWrites()) != 0 || string(l.Stub.State[orgConfigKey]) != before {
+				t.Errorf("refused update changed the config")
+			}
+		})
+	}
+
+	config := *valid
+	config.VerifierOrgs = append([]string{"EmployersMSP"}, valid.VerifierOrgs...)
+	_, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, updateConfigCall(t, l, &config))
+	if ws.Err != nil {
+		t.Fatalf("UpdateConfig: %v", ws.Err)
+	}
+	stored := l.storedConfig(t)
+	if stored.UpdatedBy != testRegistrar.MSPID || !ledgertest.ContainsString(stored.VerifierOrgs, "EmployersMSP") {
+		t.Errorf("stored config updated by %q with verifiers %v", stored.UpdatedBy, stored.VerifierOrgs)
+	}
+	entries := writtenAuditEntries(t, ws)
+	if len(entries) != 1 || entries[0].Action != "UpdateConfig" || !strings.Contains(entries[0].Details, "EmployersMSP") {
+		t.Errorf("audit entries = %+v, want one UpdateConfig entry naming the new verifier", entries)
+	}
+}
+
+func TestInitConfig(t *testing.T) {
+	l := newEmptyTestLedger()
+	getConfig := func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+		return l.contract.GetConfig(ctx)
+	}
+	if got := ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, getConfig); !ledgertest.ContainsString(got.UniversityOrgs, testRegistrar.MSPID) || got.UpdatedBy != "" {
+		t.Errorf("config before InitConfig = %+v, want the built-in default", got)
+	}
+
+	initConfig := func(configJSON string) func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+		return func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+			return l.contract.InitConfig(ctx, configJSON)
+		}
+	}
+	configJSON := `{"universityOrgs":["NITWarangalMSP"],"departmentOrgs":["CSEDepartmentMSP"],"verifierOrgs":["VerifiersMSP"]}`
+	ledgertest.InvokeError(t, l, testExamCell, ledgertest.TxOptions{}, "caller org DepartmentsMSP must be listed in universityOrgs", initConfig(configJSON))
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "departmentOrgs must list at least one MSP ID", initConfig(`{"universityOrgs":["NITWarangalMSP"],"verifierOrgs":["VerifiersMSP"]}`))
+
+	_, ws := ledgertest.Invoke(l, testRegistrar, ledgertest.TxOptions{}, initConfig(configJSON))
+	if ws.Err != nil {
+		t.Fatalf("InitConfig: %v", ws.Err)
+	}
+	if got := writtenAuditActions(t, ws); len(got) != 1 || got[0] != "InitConfig" {
+		t.Errorf("audit actions = %v, want [InitConfig]", got)
+	}
+	stored := l.storedConfig(t)
+	if stored.RequiredApprovals != defaultRequiredApprovals || stored.RepeatPolicy != repeatPolicyLatest || stored.DepartmentOrgs[0] != "CSEDepartmentMSP" {
+		t.Errorf("stored config = %+v, want defaults filled in", stored)
+	}
+
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "org config is already initialized; use UpdateConfig", initConfig(configJSON))
+}
+
+// storedConfig returns the committed org config
+func TestOrgConfigCopiesAreIndependent(t *testing.T) {
+	defaultJSON, _ := json.Marshal(defaultOrgConfig)
+	mutate := func(config *OrgConfig) {
+		config.UniversityOrgs[0] = "RogueMSP"
+		config.RegulatorOrgs = append(config.RegulatorOrgs, "RogueMSP")
+		config.VisibilityProfiles["RogueMSP"] = visibilityFull
+		config.StudentReadRoles[0] = orgRoleRegulator
+	}
+
+	for name, l := range map[string]*testLedger{"default config": newEmptyTestLedger(), "stored config": newTestLedger(t)} {
+		t.Run(name, func(t *testing.T) {
+			var before []byte
+			ws := l.Submit(testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) error {
+				config, err := getOrgConfig(ctx)
+				if err != nil {
+					return err
+				}
+				before, _ = json.Marshal(config)
+				mutate(config)
+
+				// The same transaction and its permission checks still see the config in force
+				again, err := getOrgConfig(ctx)
+				if err != nil {
+					return err
+				}
+				if againJSON, _ := json.Marshal(again); string(againJSON) != string(before) {
+					t.Errorf("changing a copy changed the config of the transaction to %s", againJSON)
+				}
+				_, err = requireOrgRole(ctx, "test", orgRoleUniversity)
+				return err
+			})
+			if ws.Err != nil {
+				t.Fatalf("transaction failed: %v", ws.Err)
+			}
+
+			got := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+				return l.contract.GetConfig(ctx)
+			})
+			if gotJSON, _ := json.Marshal(got); string(gotJSON) != string(before) {
+				t.Errorf("config of the next transaction = %s, want %s", gotJSON, before)
+			}
+		})
+	}
+	if afterJSON, _ := json.Marshal(defaultOrgConfig); string(afterJSON) != string(defaultJSON) {
+		t.Errorf("default org config changed to %s", afterJSON)
+	}
+}
+
+func (l *testLedger) storedConfig(t *testing.T) *OrgConfig {
+	t.Helper()
+	var config OrgConfig
+	l.Stored(t, orgConfigKey, &config)
+	return &config
+}
+
+// updateConfigCall returns an UpdateConfig call replacing the org config with config
+func updateConfigCall(t *testing.T, l *testLedger, config *OrgConfig) func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+	t.Helper()
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("failed to marshal org config: %v", err)
+	}
+	return func(ctx contractapi.TransactionContextInterface) (*OrgConfig, error) {
+		return l.contract.UpdateConfig(ctx, string(configJSON))
+	}
+}
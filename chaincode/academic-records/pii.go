@@ -0,0 +1,578 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT PII ==========
+
+// collectionStudentPII is the private data collection shared by NITWarangal and Departments,
+// see collections_config.json
+const collectionStudentPII = "collectionStudentPII"
+
+// transientStudentPII is the transient map key CreateStudent reads the PII JSON from
+const transientStudentPII = "student_pii"
+
+// transientBulkStudentPII is the transient map key BulkCreateStudents reads a JSON object of
+// student ID to PII from
+const transientBulkStudentPII = "students_pii"
+
+// transientStudentUpdate is the transient map key UpdateStudent reads the changed fields from
+const transientStudentUpdate = "student_update"
+
+// StudentPII is the part of a student kept out of world state
+type StudentPII struct {
+	DocType   string `json:"docType"`
+	StudentID string `json:"studentId"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone,omitempty"`
+	Address   string `json:"address,omitempty"`
+}
+
+// PIIMigrationResult reports the students moved by one MigrateStudentPII call
+type PIIMigrationResult struct {
+	Migrated []string `json:"migrated"`
+	More     bool     `json:"more"` // true when the batch limit was hit; call again to continue
+}
+
+// MigrateStudentPII moves names and emails stored in world state by earlier versions into the
+// PII collection and blanks them on the ledger (NITWarangal only). At most maxPageSize
+// students are migrated per call.
+func (s *SmartContract) MigrateStudentPII(ctx contractapi.TransactionContextInterface) (*PIIMigrationResult, error) {
+	if _, err := requireOrgRole(ctx, "migrate student PII", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("student~status", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query students: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var pending []*Student
+	result := &PIIMigrationResult{Migrated: []string{}}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		student, err := readStudent(ctx, compositeKeyParts[1])
+		if err != nil || (student.Name == "" && student.Email == "") {
+			continue
+		}
+
+		if int32(len(pending)) == maxPageSize {
+			result.More = true
+			break
+		}
+		pending = append(pending, student)
+	}
+
+	for _, student := range pending {
+		// Keep PII already in the collection; the ledger copy is the stale one
+		existing, err := readStudentPII(ctx, student.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			pii := &StudentPII{
+				DocType:   docTypeStudentPII,
+				StudentID: student.StudentID,
+				Name:      student.Name,
+				Email:     student.Email,
+			}
+			if err := putStudentPII(ctx, pii); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := putStudent(ctx, student); err != nil {
+			return nil, err
+		}
+
+		logAudit(ctx, "MigrateStudentPII", "STUDENT", student.StudentID, "Moved PII to private collection")
+		result.Migrated = append(result.Migrated, student.StudentID)
+	}
+
+	return result, nil
+}
+
+// readTransientPII parses the student PII from the transient map
+func readTransientPII(ctx contractapi.TransactionContextInterface, studentID string) (*StudentPII, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient data: %v", err)
+	}
+	piiJSON, ok := transient[transientStudentPII]
+	if !ok {
+		return nil, fmt.Errorf("%s must be supplied in the transient map", transientStudentPII)
+	}
+
+	var pii StudentPII
+	if err := json.Unmarshal(piiJSON, &pii); err != nil {
+		return nil, fmt.Errorf("invalid %s JSON: %v", transientStudentPII, err)
+	}
+	if pii.Name == "" || pii.Email == "" {
+		return nil, fmt.Errorf("%s must include name and email", transientStudentPII)
+	}
+
+	pii.DocType = docTypeStudentPII
+	pii.StudentID = studentID
+	return &pii, nil
+}
+
+// readTransientBulkPII parses the transient map of student ID to PII used by BulkCreateStudents
+func readTransientBulkPII(ctx contractapi.TransactionContextInterface) (map[string]*StudentPII, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient data: %v", err)
+	}
+	piiJSON, ok := transient[transientBulkStudentPII]
+	if !ok {
+		return nil, fmt.Errorf("%s must be supplied in the transient map", transientBulkStudentPII)
+	}
+
+	var piiByStudent map[string]*StudentPII
+	if err := json.Unmarshal(piiJSON, &piiByStudent); err != nil {
+		return nil, fmt.Errorf("invalid %s JSON: %v", transientBulkStudentPII, err)
+	}
+	return piiByStudent, nil
+}
+
+// readStudentPII loads a student's PII from the collection; nil if none is stored
+func readStudentPII(ctx contractapi.TransactionContextInterface, studentID string) (*StudentPII, error) {
+	piiJSON, err := ctx.GetStub().GetPrivateData(collectionStudentPII, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data: %v", err)
+	}
+	if piiJSON == nil {
+		return nil, nil
+	}
+
+	var pii StudentPII
+	if err := json.Unmarshal(piiJSON, &pii); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal student PII: %v", err)
+	}
+	return &pii, nil
+}
+
+// putStudentPII saves a student's PII to the collection
+func putStudentPII(ctx contractapi.TransactionContextInterface, pii *StudentPII) error {
+	piiJSON, err := json.Marshal(pii)
+	if err != nil {
+		return fmt.Errorf("failed to marshal student PII: %v", err)
+	}
+	if err := ctx.GetStub().PutPrivateData(collectionStudentPII, pii.StudentID, piiJSON); err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+	return nil
+}
+
+// mergeStudentPII copies PII onto the public student view
+func mergeStudentPII(student *Student, pii *StudentPII) {
+	if pii == nil {
+		return
+	}
+	student.Name = pii.Name
+	student.Email = pii.Email
+	student.Phone = pii.Phone
+	student.Address = pii.Address
+}
+
+// callerInPIICollection reports whether the caller's org may read the PII collection
+func callerInPIICollection(ctx contractapi.TransactionContextInterface) (bool, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	return orgHasRole(ctx, creatorOrg, orgRoleUniversity, orgRoleDepartment)
+}
+
+// ========== STUDENT CORRECTIONS ==========
+
+// mutableStudentFields are the fields UpdateStudent may change; all of them live in the PII collection
+var mutableStudentFields = map[string]bool{
+	"name":    true,
+	"email":   true,
+	"phone":   true,
+	"address": true,
+}
+
+// immutableStudentFields explains where to go instead when a caller tries to change other fields
+var immutableStudentFields = map[string]string{
+	"studentId":      "the student ID is permanent",
+	"docType":        "the document type is permanent",
+	"enrollmentDate": "the enrollment date is permanent",
+	"createdBy":      "the creating org is permanent",
+	"createdAt":      "the creation time is permanent",
+	"department":     "use TransferStudentDepartment",
+	"program":        "the program is set at enrollment",
+	"status":         "use UpdateStudentStatus",
+}
+
+// StudentCorrection keeps the old and new values of one UpdateStudent call in the PII
+// collection, so the correction trail never reaches world state
+type StudentCorrection struct {
+	DocType     string            `json:"docType"`
+	StudentID   string            `json:"studentId"`
+	OldValues   map[string]string `json:"oldValues"`
+	NewValues   map[string]string `json:"newValues"`
+	CorrectedBy string            `json:"correctedBy"`
+	CorrectedAt string            `json:"correctedAt"`
+	TxID        string            `json:"txId"`
+}
+
+// UpdateStudent corrects a student's name, email, phone or address (NITWarangal only); a new
+// email must not belong to another student. The new values are read as a JSON object from the
+// "student_update" transient key so they stay off the ledger. Any other field fails the call.
+// The public audit entry names the changed fields; the old and new values are kept in the PII
+// collection.
+func (s *SmartContract) UpdateStudent(ctx contractapi.TransactionContextInterface, studentID string) (*Student, error) {
+	if _, err := requireOrgRole(ctx, "update students", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+	if studentFrozen(student) {
+		return nil, frozenStudentError(studentID)
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient data: %v", err)
+	}
+	fieldsJSON, ok := transient[transientStudentUpdate]
+	if !ok {
+		return nil, fmt.Errorf("%s must be supplied in the transient map", transientStudentUpdate)
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(fieldsJSON, &fields); err != nil {
+		return nil, fmt.Errorf("invalid %s JSON: %v", transientStudentUpdate, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s must name at least one field", transientStudentUpdate)
+	}
+	for field := range fields {
+		if hint, immutable := immutableStudentFields[field]; immutable {
+			return nil, fmt.Errorf("field %s cannot be changed by UpdateStudent: %s", field, hint)
+		}
+		if !mutableStudentFields[field] {
+			return nil, fmt.Errorf("unknown student field %q", field)
+		}
+	}
+
+	pii, err := readStudentPII(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if pii == nil {
+		return nil, fmt.Errorf("no PII stored for student %s", studentID)
+	}
+
+	current := map[string]*string{
+		"name":    &pii.Name,
+		"email":   &pii.Email,
+		"phone":   &pii.Phone,
+		"address": &pii.Address,
+	}
+	oldValues := map[string]string{}
+	newValues := map[string]string{}
+	for field, value := range fields {
+		value = strings.TrimSpace(value)
+		if err := validateStudentField(field, value); err != nil {
+			return nil, err
+		}
+		if *current[field] == value {
+			continue
+		}
+		oldValues[field] = *current[field]
+		newValues[field] = value
+		*current[field] = value
+	}
+	if len(newValues) == 0 {
+		return nil, fmt.Errorf("no field of student %s would change", studentID)
+	}
+
+	if newEmail, changed := newValues["email"]; changed {
+		if err := assertEmailAvailable(ctx, newEmail, studentID); err != nil {
+			return nil, err
+		}
+		// Release the old address unless it is a case-only change or was never indexed to this student
+		oldEmail := oldValues["email"]
+		if oldEmail != "" && normalizeEmail(oldEmail) != normalizeEmail(newEmail) {
+			oldOwner, err := emailOwner(ctx, oldEmail)
+			if err != nil {
+				return nil, err
+			}
+			if oldOwner == studentID {
+				if err := deleteEmailIndex(ctx, oldEmail); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := putEmailIndex(ctx, newEmail, studentID); err != nil {
+			return nil, err
+		}
+	}
+	if err := putStudentPII(ctx, pii); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	txID := ctx.GetStub().GetTxID()
+	correction := StudentCorrection{
+		DocType:     docTypeStudentCorrection,
+		StudentID:   studentID,
+		OldValues:   oldValues,
+		NewValues:   newValues,
+		CorrectedBy: getClientCommonName(ctx),
+		CorrectedAt: txTime.UTC().Format(time.RFC3339),
+		TxID:        txID,
+	}
+	correctionKey, err := ctx.GetStub().CreateCompositeKey("correction", []string{studentID, fmt.Sprintf("%019d", txTime.UnixNano()), txID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create correction key: %v", err)
+	}
+	correctionJSON, err := json.Marshal(correction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal correction: %v", err)
+	}
+	if err := ctx.GetStub().PutPrivateData(collectionStudentPII, correctionKey, correctionJSON); err != nil {
+		return nil, fmt.Errorf("failed to put private data: %v", err)
+	}
+
+	changed := make([]string, 0, len(newValues))
+	for field := range newValues {
+		changed = append(changed, field)
+	}
+	sort.Strings(changed)
+	logAudit(ctx, "UpdateStudent", "STUDENT", studentID, fmt.Sprintf("Corrected %s; old and new values are in the PII collection", strings.Join(changed, ", ")))
+
+	mergeStudentPII(student, pii)
+	return student, nil
+}
+
+// validateStudentField checks the format of one PII field
+func validateStudentField(field string, value string) error {
+	switch field {
+	case "name":
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("name is required: expected non-blank text")
+		}
+		if len(value) > maxStudentNameLength {
+			return fmt.Errorf("name must be at most %d characters", maxStudentNameLength)
+		}
+	case "email":
+		return validateEmail(value)
+	}
+	return nil
+}
+
+// maxStudentNameLength bounds student names
+const maxStudentNameLength = 200
+
+// validateEmail accepts a bare address such as name@example.edu
+func validateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return fmt.Errorf("email %q is not a valid address: expected a bare address such as name@nitw.ac.in", email)
+	}
+	return nil
+}
+
+// ========== EMAIL INDEX ==========
+//
+// Email addresses are unique across students. The email -> student ID index lives in the PII
+// collection under email~<lower-cased address>, so addresses never reach world state.
+
+// EmailBackfillResult reports the students indexed by one BackfillEmailIndex call
+type EmailBackfillResult struct {
+	Indexed   []string         `json:"indexed"`
+	Conflicts []*BulkItemError `json:"conflicts"` // students whose address another student already owns
+	More      bool             `json:"more"`      // true when the batch limit was hit; call again to continue
+}
+
+// GetStudentByEmail looks a student up by email address (members of the PII collection of the
+// studentListRoles only)
+func (s *SmartContract) GetStudentByEmail(ctx contractapi.TransactionContextInterface, email string) (*Student, error) {
+	if err := assertCanListStudents(ctx); err != nil {
+		return nil, err
+	}
+
+	studentID, err := emailOwner(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if studentID == "" {
+		return nil, fmt.Errorf("no student has email %s", email)
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	pii, err := readStudentPII(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	mergeStudentPII(student, pii)
+	if err := filterResponse(ctx, student); err != nil {
+		return nil, err
+	}
+	return student, nil
+}
+
+// BackfillEmailIndex indexes the emails of students created before the index existed
+// (NITWarangal admin identities only). At most maxPageSize students are indexed per call;
+// addresses already owned by another student are reported rather than indexed.
+func (s *SmartContract) BackfillEmailIndex(ctx contractapi.TransactionContextInterface) (*EmailBackfillResult, error) {
+	if _, err := requireOrgRole(ctx, "backfill the email index", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("backfilling the email index requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("student~status", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query students: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	result := &EmailBackfillResult{Indexed: []string{}, Conflicts: []*BulkItemError{}}
+	claimed := map[string]string{} // addresses indexed by this call, which GetPrivateData cannot see yet
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+		studentID := compositeKeyParts[1]
+
+		pii, err := readStudentPII(ctx, studentID)
+		if err != nil {
+			return nil, err
+		}
+		if pii == nil || pii.Email == "" {
+			continue
+		}
+		owner, err := emailOwner(ctx, pii.Email)
+		if err != nil {
+			return nil, err
+		}
+		if owner == "" {
+			owner = claimed[normalizeEmail(pii.Email)]
+		}
+		if owner == studentID {
+			continue
+		}
+		if owner != "" {
+			result.Conflicts = append(result.Conflicts, &BulkItemError{ID: studentID, Error: fmt.Sprintf("email already belongs to student %s", owner)})
+			continue
+		}
+
+		if int32(len(result.Indexed)) == maxPageSize {
+			result.More = true
+			break
+		}
+		if err := putEmailIndex(ctx, pii.Email, studentID); err != nil {
+			return nil, err
+		}
+		claimed[normalizeEmail(pii.Email)] = studentID
+		result.Indexed = append(result.Indexed, studentID)
+	}
+
+	logAudit(ctx, "BackfillEmailIndex", "STUDENT", "EMAIL_INDEX", fmt.Sprintf("Indexed %d students, %d conflicts", len(result.Indexed), len(result.Conflicts)))
+
+	return result, nil
+}
+
+// normalizeEmail is the form addresses are compared and indexed in
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// emailIndexKey is the PII collection key of an address's index entry
+func emailIndexKey(ctx contractapi.TransactionContextInterface, email string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey("email", []string{normalizeEmail(email)})
+	if err != nil {
+		return "", fmt.Errorf("failed to create email index key: %v", err)
+	}
+	return key, nil
+}
+
+// emailOwner returns the student ID an address is indexed to, or "" if it is free
+func emailOwner(ctx contractapi.TransactionContextInterface, email string) (string, error) {
+	key, err := emailIndexKey(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	owner, err := ctx.GetStub().GetPrivateData(collectionStudentPII, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read private data: %v", err)
+	}
+	return string(owner), nil
+}
+
+// assertEmailAvailable fails if another student already owns the address
+func assertEmailAvailable(ctx contractapi.TransactionContextInterface, email string, studentID string) error {
+	owner, err := emailOwner(ctx, email)
+	if err != nil {
+		return err
+	}
+	if owner != "" && owner != studentID {
+		return fmt.Errorf("email %s already belongs to student %s", email, owner)
+	}
+	return nil
+}
+
+// putEmailIndex points an address at a student
+func putEmailIndex(ctx contractapi.TransactionContextInterface, email string, studentID string) error {
+	key, err := emailIndexKey(ctx, email)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutPrivateData(collectionStudentPII, key, []byte(studentID)); err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+	return nil
+}
+
+// deleteEmailIndex removes an address's index entry
+func deleteEmailIndex(ctx contractapi.TransactionContextInterface, email string) error {
+	key, err := emailIndexKey(ctx, email)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelPrivateData(collectionStudentPII, key); err != nil {
+		return fmt.Errorf("failed to delete private data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== QR PAYLOAD ==========
+//
+// The QR code printed on a certificate carries everything needed to check it: qrPayloadPrefix
+// followed by the unpadded base64url of the canonical JSON {"d","h","id","t"}, holding the issue
+// date, certificate hash, certificate ID and certification type. A QR pointing at a real
+// certificate ID from a forged document therefore also has to carry that certificate's hash.
+// Certificates issued before the compact payload have the verification URL in QRCode instead;
+// DecodeAndCheckQR accepts both.
+
+// qrPayloadPrefix marks a compact QR payload and its version
+const qrPayloadPrefix = "NITWCERT1:"
+
+// verificationURLPrefix is the public verification page a certificate ID is appended to
+const verificationURLPrefix = "https://verify.nit.edu/cert/"
+
+// QR payload formats reported by DecodeAndCheckQR
+const (
+	qrFormatCompact = "COMPACT"
+	qrFormatURL     = "URL"
+)
+
+// certOutcomeQRMismatch: the QR's printed fields differ from the certificate its hash belongs to
+const certOutcomeQRMismatch = "QR_MISMATCH"
+
+// qrPayload is the JSON encoded in a compact QR code
+type qrPayload struct {
+	CertificateID     string `json:"id"`
+	CertificateHash   string `json:"h"`
+	IssuedDate        string `json:"d"`
+	CertificationType string `json:"t"`
+}
+
+// QRCheckResult is the outcome of checking a scanned QR code
+type QRCheckResult struct {
+	CertificateCheckResult
+	Format        string `json:"format"`        // COMPACT or URL
+	HashPresented bool   `json:"hashPresented"` // false for URL codes, which carry no hash to check
+}
+
+// DecodeAndCheckQR parses a scanned QR code and checks the certificate it names without writing
+// to the ledger. Compact codes are checked like CheckCertificate with the hash they carry; URL
+// codes from older certificates carry no hash, so only the certificate's current validity is
+// checked and HashPresented is false.
+func (s *SmartContract) DecodeAndCheckQR(ctx contractapi.TransactionContextInterface, qrPayloadText string) (*QRCheckResult, error) {
+	qrPayloadText = strings.TrimSpace(qrPayloadText)
+
+	if strings.HasPrefix(qrPayloadText, verificationURLPrefix) {
+		certificateID := strings.TrimPrefix(qrPayloadText, verificationURLPrefix)
+		if certificateID == "" || strings.ContainsAny(certificateID, "/?#") {
+			return nil, fmt.Errorf("QR URL does not name a certificate")
+		}
+		verdict, err := s.IsCertificateValid(ctx, certificateID)
+		if err != nil {
+			return nil, err
+		}
+		return &QRCheckResult{
+			CertificateCheckResult: CertificateCheckResult{
+				CertificateID: certificateID,
+				Verified:      verdict.Valid,
+				Outcome:       verdict.Outcome,
+				Status:        verdict.Status,
+				Reason:        verdict.Reason,
+				CheckedAt:     verdict.CheckedAt,
+
+				InstitutionID:   verdict.InstitutionID,
+				InstitutionName: verdict.InstitutionName,
+			},
+			Format: qrFormatURL,
+		}, nil
+	}
+
+	payload, err := decodeQRPayload(qrPayloadText)
+	if err != nil {
+		return nil, err
+	}
+	check, err := s.CheckCertificate(ctx, payload.CertificateID, payload.CertificateHash)
+	if err != nil {
+		return nil, err
+	}
+	result := &QRCheckResult{CertificateCheckResult: *check, Format: qrFormatCompact, HashPresented: true}
+	if !check.Verified {
+		return result, nil
+	}
+
+	cert, err := readCertificate(ctx, payload.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+	if payload.IssuedDate != cert.IssuedDate || payload.CertificationType != cert.CertificationType {
+		result.Verified = false
+		result.Outcome = certOutcomeQRMismatch
+		result.Reason = "the QR code's issue date or type differs from the issued certificate"
+	}
+	return result, nil
+}
+
+// encodeQRPayload returns the compact QR payload of a certificate
+func encodeQRPayload(cert *Certificate) (string, error) {
+	payloadJSON, err := canonicalJSON(qrPayload{
+		CertificateID:     cert.CertificateID,
+		CertificateHash:   cert.CertificateHash,
+		IssuedDate:        cert.IssuedDate,
+		CertificationType: cert.CertificationType,
+	})
+	if err != nil {
+		return "", err
+	}
+	return qrPayloadPrefix + base64.RawURLEncoding.EncodeToString(payloadJSON), nil
+}
+
+// decodeQRPayload parses a compact QR payload
+func decodeQRPayload(text string) (*qrPayload, error) {
+	if !strings.HasPrefix(text, qrPayloadPrefix) {
+		return nil, fmt.Errorf("unrecognized QR payload: expected %s or %s", qrPayloadPrefix, verificationURLPrefix)
+	}
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(text, qrPayloadPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("QR payload is not valid base64url: %v", err)
+	}
+
+	var payload qrPayload
+	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+		return nil, fmt.Errorf("QR payload is not valid JSON: %v", err)
+	}
+	if payload.CertificateID == "" || payload.CertificateHash == "" {
+		return nil, fmt.Errorf("QR payload must name a certificate and its hash")
+	}
+	return &payload, nil
+}
+
+// certificateVerificationURL returns the verification page of a certificate; older certificates
+// hold it in QRCode
+func certificateVerificationURL(cert *Certificate) string {
+	if cert.VerificationURL != "" {
+		return cert.VerificationURL
+	}
+	if strings.HasPrefix(cert.QRCode, verificationURLPrefix) {
+		return cert.QRCode
+	}
+	return verificationURLPrefix + cert.CertificateID
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== MERIT LISTS ==========
+
+// maxTopStudents caps the length of a merit list
+const maxTopStudents = 100
+
+// RankedStudent is one entry of a merit list
+type RankedStudent struct {
+	Rank         int     `json:"rank"`
+	StudentID    string  `json:"studentId"`
+	CGPA         float64 `json:"cgpa"`
+	TotalCredits float64 `json:"totalCredits"`
+}
+
+// UnrankedStudent is a student of the batch left out of the ranking, with the reason
+type UnrankedStudent struct {
+	StudentID string `json:"studentId"`
+	Reason    string `json:"reason"`
+}
+
+// MeritList ranks a department's batch by CGPA over verified records
+type MeritList struct {
+	Department     string             `json:"department"`
+	EnrollmentYear int                `json:"enrollmentYear"`
+	Ranked         []*RankedStudent   `json:"ranked"`
+	Unranked       []*UnrankedStudent `json:"unranked"` // records pending verification or semesters missing
+}
+
+// GetTopStudents returns the top limit students of a department who enrolled in enrollmentYear,
+// by CGPA, then total credits, then student ID (NITWarangal and Departments). Only students whose
+// every semester is verified are ranked; the rest are listed as unranked. limit is capped at
+// maxTopStudents.
+func (s *SmartContract) GetTopStudents(ctx contractapi.TransactionContextInterface, department string, enrollmentYear int, limit int) (*MeritList, error) {
+	if _, err := requireOrgRole(ctx, "view merit lists", orgRoleUniversity, orgRoleDepartment); err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be at least 1")
+	}
+	if limit > maxTopStudents {
+		limit = maxTopStudents
+	}
+
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cohort, err := cohortStudents(ctx, department, enrollmentYear)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &MeritList{Department: department, EnrollmentYear: enrollmentYear, Ranked: []*RankedStudent{}, Unranked: []*UnrankedStudent{}}
+	for _, student := range cohort {
+		records, err := getStudentRecordList(ctx, student.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		verified, reason := rankableRecords(records)
+		if reason != "" {
+			list.Unranked = append(list.Unranked, &UnrankedStudent{StudentID: student.StudentID, Reason: reason})
+			continue
+		}
+
+		cgpa, credits := calculateCGPA(verified, config.RepeatPolicy)
+		list.Ranked = append(list.Ranked, &RankedStudent{StudentID: student.StudentID, CGPA: cgpa, TotalCredits: credits})
+	}
+
+	sort.Slice(list.Ranked, func(i, j int) bool {
+		a, b := list.Ranked[i], list.Ranked[j]
+		if toHundredths(a.CGPA) != toHundredths(b.CGPA) {
+			return toHundredths(a.CGPA) > toHundredths(b.CGPA)
+		}
+		if toHundredths(a.TotalCredits) != toHundredths(b.TotalCredits) {
+			return toHundredths(a.TotalCredits) > toHundredths(b.TotalCredits)
+		}
+		return a.StudentID < b.StudentID
+	})
+	if len(list.Ranked) > limit {
+		list.Ranked = list.Ranked[:limit]
+	}
+	for i, entry := range list.Ranked {
+		entry.Rank = i + 1
+	}
+
+	return list, nil
+}
+
+// cohortStudents lists a department's open students who enrolled in enrollmentYear
+func cohortStudents(ctx contractapi.TransactionContextInterface, department string, enrollmentYear int) ([]*Student, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("student~department", []string{department})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query students: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	students := []*Student{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		student, err := readStudent(ctx, compositeKeyParts[1])
+		if err != nil || closedStudentError(student) != nil {
+			continue
+		}
+		enrolledAt, err := time.Parse(time.RFC3339, student.EnrollmentDate)
+		if err != nil || enrolledAt.Year() != enrollmentYear {
+			continue
+		}
+		students = append(students, student)
+	}
+	return students, nil
+}
+
+// rankableRecords returns a student's verified records oldest first, or why the student cannot
+// be ranked: no verified record, a record still in the workflow, or a semester with no record
+func rankableRecords(records []*AcademicRecord) ([]*AcademicRecord, string) {
+	verified := []*AcademicRecord{}
+	semesters := map[int]bool{}
+	latest := 0
+	for _, record := range records {
+		switch record.Status {
+		case recordStatusVerified:
+			verified = append(verified, record)
+			semesters[record.Semester] = true
+			if record.Semester > latest {
+				latest = record.Semester
+			}
+		case recordStatusRejected, recordStatusSuperseded:
+		default:
+			return nil, fmt.Sprintf("record %s is %s, not VERIFIED", record.RecordID, record.Status)
+		}
+	}
+	if len(verified) == 0 {
+		return nil, "no verified records"
+	}
+	for semester := 1; semester < latest; semester++ {
+		if !semesters[semester] {
+			return nil, fmt.Sprintf("semester %d has no verified record", semester)
+		}
+	}
+
+	sort.SliceStable(verified, func(i, j int) bool {
+		return semesterAfter(verified[j], verified[i])
+	})
+	return verified, ""
+}
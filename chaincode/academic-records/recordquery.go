@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== RECORD PERIOD QUERIES ==========
+
+// maxSemester is the highest semester number a record can carry (five-year dual degrees run to 10)
+const maxSemester = 12
+
+// recordYearRange bounds the record year; both ends are inclusive
+type recordYearRange struct {
+	From int `json:"$gte"`
+	To   int `json:"$lte"`
+}
+
+// recordPeriodSelector is the Mango selector used by QueryRecordsByPeriod; a zero semester is omitted
+type recordPeriodSelector struct {
+	DocType  string          `json:"docType"`
+	Year     recordYearRange `json:"year"`
+	Semester int             `json:"semester,omitempty"`
+}
+
+// QueryRecordsByPeriod returns records of every student whose year lies in [yearFrom, yearTo],
+// optionally narrowed to one semester (0 means any), ordered by year and semester
+// (NITWarangal, Departments and regulators)
+func (s *SmartContract) QueryRecordsByPeriod(ctx contractapi.TransactionContextInterface, yearFrom int, yearTo int, semester int, pageSize int32, bookmark string) (*PaginatedRecords, error) {
+	if _, err := requireOrgRole(ctx, "query records by period", orgRoleUniversity, orgRoleDepartment, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+
+	if yearFrom > yearTo {
+		return nil, fmt.Errorf("yearFrom %d is after yearTo %d", yearFrom, yearTo)
+	}
+	if semester < 0 || semester > maxSemester {
+		return nil, fmt.Errorf("semester must be between 1 and %d, or 0 for any", maxSemester)
+	}
+
+	// Marshal the selector from a struct so caller input can never alter the query shape.
+	// Sorting on every index field keeps CouchDB on the index.
+	query := struct {
+		Selector recordPeriodSelector `json:"selector"`
+		Sort     []map[string]string  `json:"sort"`
+		UseIndex []string             `json:"use_index"`
+	}{
+		Selector: recordPeriodSelector{
+			DocType:  docTypeRecord,
+			Year:     recordYearRange{From: yearFrom, To: yearTo},
+			Semester: semester,
+		},
+		Sort:     []map[string]string{{"docType": "asc"}, {"year": "asc"}, {"semester": "asc"}},
+		UseIndex: []string{"_design/indexRecordYearSemesterDoc", "indexRecordYearSemester"},
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*AcademicRecord{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var record AcademicRecord
+		if err := json.Unmarshal(response.Value, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal record: %v", err)
+		}
+		records = append(records, &record)
+	}
+
+	page := &PaginatedRecords{
+		Records:      records,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}
+	if err := filterResponse(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== REGULATORS ==========
+//
+// Accreditation bodies such as AICTE and NBA are configured as regulator orgs. They may read
+// students, records, statistics and audit logs across institutions, and nothing else: every
+// transaction passes through rejectRegulatorWrites before it runs, which refuses a regulator any
+// function outside the read-only set, so no regulator call reaches PutState. The logged read
+// variants are the one exception, as they write the ACCESS entry the regulator's read is
+// recorded in; those entries are tagged as regulator reads.
+
+// regulatorAccessTag prefixes the ACCESS audit entries of regulator reads
+const regulatorAccessTag = "[REGULATOR] "
+
+// maxComplianceYears caps the year range one GetComplianceReport call covers
+const maxComplianceYears = 10
+
+// regulatorLoggedReads may be submitted by regulators although they write their access entry
+var regulatorLoggedReads = []string{
+	"GetStudentRecordsLogged",
+	"GetCertificateLogged",
+}
+
+// regulatorReadTransactions is every function a regulator may call
+var regulatorReadTransactions = func() map[string]bool {
+	allowed := map[string]bool{}
+	for _, name := range (&SmartContract{}).GetEvaluateTransactions() {
+		allowed[name] = true
+	}
+	for _, name := range regulatorLoggedReads {
+		allowed[name] = true
+	}
+	return allowed
+}()
+
+// ComplianceReport summarizes a department's records and degrees over a range of academic years
+// for accreditation
+type ComplianceReport struct {
+	Department string `json:"department"`
+	YearFrom   int    `json:"yearFrom"`
+	YearTo     int    `json:"yearTo"`
+
+	RecordCount     int            `json:"recordCount"`
+	RecordsByStatus map[string]int `json:"recordsByStatus"`
+
+	// Time from creation to the final approval, over records that went through the approval
+	// workflow; supersession replacements and imported records are left out
+	ApprovedCount        int     `json:"approvedCount"`
+	AverageApprovalHours float64 `json:"averageApprovalHours"`
+
+	// Students who graduated in the range, against certificates issued to the department's
+	// students in the range
+	Graduates              int            `json:"graduates"`
+	DegreesIssued          int            `json:"degreesIssued"` // ISSUED DEGREE certificates of those graduates
+	GraduatesWithoutDegree []string       `json:"graduatesWithoutDegree"`
+	CertificatesIssued     map[string]int `json:"certificatesIssued"` // by certification type
+
+	Partial     bool   `json:"partial"` // true when maxStatsRecords was reached
+	GeneratedAt string `json:"generatedAt"`
+}
+
+// GetComplianceReport reports a department's record statuses, approval latency and graduates
+// against certificates for yearRange, "YYYY" or "YYYY-YYYY" (NITWarangal, regulators and
+// identities of the department)
+func (s *SmartContract) GetComplianceReport(ctx contractapi.TransactionContextInterface, department string, yearRange string) (*ComplianceReport, error) {
+	callerOrg, err := requireOrgRole(ctx, "view compliance reports", orgRoleUniversity, orgRoleDepartment, orgRoleRegulator)
+	if err != nil {
+		return nil, err
+	}
+	seesAllDepartments, err := orgHasRole(ctx, callerOrg, orgRoleUniversity, orgRoleRegulator)
+	if err != nil {
+		return nil, err
+	}
+	if !seesAllDepartments {
+		if err := requireOwnDepartment(ctx, department); err != nil {
+			return nil, err
+		}
+	}
+	if department == "" {
+		return nil, fmt.Errorf("department is required")
+	}
+	yearFrom, yearTo, err := parseYearRange(yearRange)
+	if err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	report := &ComplianceReport{
+		Department:             department,
+		YearFrom:               yearFrom,
+		YearTo:                 yearTo,
+		RecordsByStatus:        map[string]int{},
+		GraduatesWithoutDegree: []string{},
+		CertificatesIssued:     map[string]int{},
+		GeneratedAt:            txTime.UTC().Format(time.RFC3339),
+	}
+
+	var approvalHours float64
+	read := 0
+	for year := yearFrom; year <= yearTo && !report.Partial; year++ {
+		recordIDs, err := indexedIDs(ctx, "record~department", []string{department, fmt.Sprintf("%04d", year)}, 3)
+		if err != nil {
+			return nil, err
+		}
+		for _, recordID := range recordIDs {
+			if read == maxStatsRecords {
+				report.Partial = true
+				break
+			}
+			read++
+
+			record, err := readAcademicRecord(ctx, recordID)
+			if err != nil {
+				continue
+			}
+			report.RecordCount++
+			report.RecordsByStatus[record.Status]++
+
+			if record.ApprovedAt == "" || record.SupersedesRecordID != "" || record.ImportedFrom != "" {
+				continue
+			}
+			createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+			if err != nil {
+				continue
+			}
+			approvedAt, err := time.Parse(time.RFC3339, record.ApprovedAt)
+			if err != nil || approvedAt.Before(createdAt) {
+				continue
+			}
+			report.ApprovedCount++
+			approvalHours += approvedAt.Sub(createdAt).Hours()
+		}
+	}
+	if report.ApprovedCount > 0 {
+		report.AverageApprovalHours = fromHundredths(toHundredths(approvalHours / float64(report.ApprovedCount)))
+	}
+
+	studentIDs, err := indexedIDs(ctx, "student~department", []string{department}, 1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(studentIDs)
+	for _, studentID := range studentIDs {
+		graduated, err := graduatedInRange(ctx, studentID, yearFrom, yearTo)
+		if err != nil {
+			return nil, err
+		}
+
+		certificateIDs, err := indexedCertificateIDs(ctx, studentID)
+		if err != nil {
+			return nil, err
+		}
+		hasDegree := false
+		for _, certificateID := range certificateIDs {
+			cert, err := readCertificate(ctx, certificateID)
+			if err != nil {
+				continue
+			}
+			issuedAt, err := time.Parse(time.RFC3339, cert.IssuedDate)
+			if err != nil || issuedAt.Year() < yearFrom || issuedAt.Year() > yearTo {
+				continue
+			}
+			report.CertificatesIssued[cert.CertificationType]++
+			if cert.CertificationType == "DEGREE" && cert.Status == certStatusIssued {
+				hasDegree = true
+			}
+		}
+
+		if !graduated {
+			continue
+		}
+		report.Graduates++
+		if hasDegree {
+			report.DegreesIssued++
+		} else {
+			report.GraduatesWithoutDegree = append(report.GraduatesWithoutDegree, studentID)
+		}
+	}
+
+	return report, nil
+}
+
+// rejectRegulatorWrites runs before every transaction and refuses regulator orgs any function
+// outside regulatorReadTransactions
+func rejectRegulatorWrites(ctx contractapi.TransactionContextInterface) error {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	regulator, err := orgHasRole(ctx, creatorOrg, orgRoleRegulator)
+	if err != nil || !regulator {
+		return err
+	}
+
+	// Functions may be qualified with the contract name, as in SmartContract:GetStudent
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	name := function
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		name = function[i+1:]
+	}
+	if strings.HasPrefix(function, "org.hyperledger.fabric:") || regulatorReadTransactions[name] {
+		return nil
+	}
+	return fmt.Errorf("FORBIDDEN: regulator org %s has read-only access and cannot call %s", creatorOrg, name)
+}
+
+// accessLogTag marks the ACCESS entries written for regulator reads
+func accessLogTag(ctx contractapi.TransactionContextInterface) (string, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	regulator, err := orgHasRole(ctx, creatorOrg, orgRoleRegulator)
+	if err != nil {
+		return "", err
+	}
+	if regulator {
+		return regulatorAccessTag, nil
+	}
+	return "", nil
+}
+
+// graduatedInRange reports whether a student's status changed to GRADUATED in [yearFrom, yearTo]
+func graduatedInRange(ctx contractapi.TransactionContextInterface, studentID string, yearFrom int, yearTo int) (bool, error) {
+	changes, err := getStatusChanges(ctx, studentID)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range changes {
+		if change.NewStatus != studentStatusGraduated {
+			continue
+		}
+		changedAt, err := time.Parse(time.RFC3339, change.ChangedAt)
+		if err == nil && changedAt.Year() >= yearFrom && changedAt.Year() <= yearTo {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseYearRange parses "YYYY" or "YYYY-YYYY" into an inclusive range of at most
+// maxComplianceYears years
+func parseYearRange(yearRange string) (int, int, error) {
+	parts := strings.Split(strings.TrimSpace(yearRange), "-")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("invalid year range %q: expected YYYY or YYYY-YYYY", yearRange)
+	}
+	years := make([]int, len(parts))
+	for i, part := range parts {
+		year, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || year < 1900 || year > 9999 {
+			return 0, 0, fmt.Errorf("invalid year range %q: expected YYYY or YYYY-YYYY", yearRange)
+		}
+		years[i] = year
+	}
+	yearFrom, yearTo := years[0], years[len(years)-1]
+	if yearFrom > yearTo {
+		return 0, 0, fmt.Errorf("year range %q ends before it starts", yearRange)
+	}
+	if yearTo-yearFrom >= maxComplianceYears {
+		return 0, 0, fmt.Errorf("year range %q spans more than %d years", yearRange, maxComplianceYears)
+	}
+	return yearFrom, yearTo, nil
+}
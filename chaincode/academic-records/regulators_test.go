@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// transactionNames returns the names of the contract's transaction functions: the exported
+// methods taking a transaction context
+func transactionNames() []string {
+	ctxType := reflect.TypeOf((*contractapi.TransactionContextInterface)(nil)).Elem()
+	contractType := reflect.TypeOf(&SmartContract{})
+	var names []string
+	for i := 0; i < contractType.NumMethod(); i++ {
+		method := contractType.Method(i)
+		if method.Type.NumIn() > 1 && method.Type.In(1) == ctxType {
+			names = append(names, method.Name)
+		}
+	}
+	return names
+}
+
+// callTransaction calls a transaction function by name with args filling each parameter;
+// a panic is returned as an error
+func callTransaction(ctx contractapi.TransactionContextInterface, contract *SmartContract, name string, arg func(reflect.Type) reflect.Value) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	method := reflect.ValueOf(contract).MethodByName(name)
+	in := []reflect.Value{reflect.ValueOf(ctx)}
+	for i := 1; i < method.Type().NumIn(); i++ {
+		in = append(in, arg(method.Type().In(i)))
+	}
+	out := method.Call(in)
+	if last := out[len(out)-1]; last.Type() == reflect.TypeOf((*error)(nil)).Elem() && !last.IsNil() {
+		return last.Interface().(error)
+	}
+	return nil
+}
+
+// regulatorTestLedger returns a ledger holding a verified record and a certificate
+func regulatorTestLedger(t *testing.T) *testLedger {
+	t.Helper()
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+	verifyTestRecord(t, l, "REC1")
+	NewTestCertificate(t, l, "CERT1", "CS21001", "TRANSCRIPT")
+	return l
+}
+
+func TestRegulatorReadTransactionsExist(t *testing.T) {
+	names := transactionNames()
+	for name := range regulatorReadTransactions {
+		if !ledgertest.ContainsString(names, name) {
+			t.Errorf("regulator read %s is not a transaction function", name)
+		}
+	}
+}
+
+func TestRegulatorIsRefusedEveryWrite(t *testing.T) {
+	l := regulatorTestLedger(t)
+	refused := 0
+	for _, name := range transactionNames() {
+		if regulatorReadTransactions[name] {
+			continue
+		}
+		refused++
+		for _, function := range []string{name, "SmartContract:" + name} {
+			ws := l.Submit(testRegulator, ledgertest.TxOptions{Function: function}, func(ctx contractapi.TransactionContextInterface) error {
+				t.Errorf("%s ran for a regulator", function)
+				return callTransaction(ctx, l.contract, name, reflect.Zero)
+			})
+			ledgertest.AssertTxError(t, ws, fmt.Sprintf("FORBIDDEN: regulator org %s has read-only access and cannot call %s", testRegulator.MSPID, name))
+			if writes := ws.LedgerWrites(); len(writes) != 0 {
+				t.Errorf("%s wrote %d entries for a regulator", function, len(writes))
+			}
+		}
+	}
+	if refused == 0 {
+		t.Fatalf("found no write transactions")
+	}
+
+	// The same functions stay open to the orgs that own them
+	ws := l.Submit(testRegistrar, ledgertest.TxOptions{Function: "UpdateStudentStatus"}, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := l.contract.UpdateStudentStatus(ctx, "CS21001", studentStatusSuspended, "fee default")
+		return err
+	})
+	if ws.Err != nil {
+		t.Errorf("UpdateStudentStatus for the university: %v", ws.Err)
+	}
+}
+
+func TestRegulatorReadsWriteNothing(t *testing.T) {
+	l := regulatorTestLedger(t)
+	reads := 0
+	for _, name := range transactionNames() {
+		if !regulatorReadTransactions[name] {
+			continue
+		}
+		reads++
+		logged := ledgertest.ContainsString(regulatorLoggedReads, name)
+
+		// Zero values, then every string argument set to an existing asset ID
+		for _, id := range []string{"", "CS21001", "REC1", "CERT1"} {
+			arg := func(argType reflect.Type) reflect.Value {
+				if argType.Kind() == reflect.String {
+					return reflect.ValueOf(id).Convert(argType)
+				}
+				return reflect.Zero(argType)
+			}
+			ws := l.Submit(testRegulator, ledgertest.TxOptions{Function: name}, func(ctx contractapi.TransactionContextInterface) error {
+				return callTransaction(ctx, l.contract, name, arg)
+			})
+			if ws.Err != nil && strings.HasPrefix(ws.Err.Error(), "panic:") {
+				t.Errorf("%s(%q) panicked: %v", name, id, ws.Err)
+			}
+
+			for _, op := range ws.LedgerWrites() {
+				if logged && isRegulatorAccessEntry(op) {
+					continue
+				}
+				t.Errorf("%s(%q) for a regulator: %s %s %q", name, id, op.Kind, op.Collection, op.Key)
+			}
+		}
+	}
+	if reads == 0 {
+		t.Fatalf("found no read transactions")
+	}
+}
+
+// isRegulatorAccessEntry reports whether op writes a tagged ACCESS audit entry, its index
+// entry or its audit statistics delta
+func isRegulatorAccessEntry(op ledgertest.WriteOp) bool {
+	if op.Kind != ledgertest.OpPutState {
+		return false
+	}
+	for _, prefix := range []string{"audit", "stats~delta" + ledgertest.CompositeKeyStart + "audit"} {
+		if strings.HasPrefix(op.Key, ledgertest.CompositeKeyStart+prefix+ledgertest.CompositeKeyStart) {
+			return true
+		}
+	}
+	var entry AuditLog
+	if err := json.Unmarshal(op.Value, &entry); err != nil {
+		return false
+	}
+	return entry.Action == "ACCESS" && strings.HasPrefix(entry.Details, regulatorAccessTag)
+}
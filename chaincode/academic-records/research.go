@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== ANONYMIZED RESEARCH EXPORT ==========
+//
+// ExportAnonymizedRecords hands institutional research record-level data without names,
+// emails or student IDs. Each student ID is replaced by HMAC-SHA256(salt, studentID), so a
+// student keeps the same pseudonym across pages, departments and years. The salt lives in a
+// private collection only NITWarangal peers hold (see collections_config.json); anyone who
+// wants to map a pseudonym back to a student needs the salt, which stays with the registrar.
+// Changing the salt would break every earlier export's pseudonyms, so it can only be set once.
+
+// collectionResearchSalt holds the pseudonym salt, see collections_config.json
+const collectionResearchSalt = "collectionResearchSalt"
+
+// researchSaltKey is the private data key of the salt
+const researchSaltKey = "RESEARCH_SALT"
+
+// transientResearchSalt is the transient map key SetResearchSalt reads the salt from
+const transientResearchSalt = "researchSalt"
+
+// minResearchSaltLength is the shortest salt accepted, in bytes
+const minResearchSaltLength = 32
+
+// researchAttribute is the client certificate attribute marking institutional research identities
+const researchAttribute = "research"
+
+// recordDepartmentYearSelector is the Mango selector used by ExportAnonymizedRecords
+type recordDepartmentYearSelector struct {
+	DocType    string          `json:"docType"`
+	Department string          `json:"department"`
+	Year       recordYearRange `json:"year"`
+}
+
+// AnonymizedCourse is a course result without the instructor
+type AnonymizedCourse struct {
+	CourseCode string  `json:"courseCode"`
+	CourseName string  `json:"courseName"`
+	Credits    float64 `json:"credits"`
+	Grade      string  `json:"grade"`
+	GradePoint float64 `json:"gradePoint"`
+}
+
+// AnonymizedRecord is an academic record with every identifying field removed
+type AnonymizedRecord struct {
+	Pseudonym  string              `json:"pseudonym"` // stable per student, see ExportAnonymizedRecords
+	Department string              `json:"department"`
+	Semester   int                 `json:"semester"`
+	Year       int                 `json:"year"`
+	Courses    []*AnonymizedCourse `json:"courses"`
+	SGPA       float64             `json:"sgpa"`
+	CGPA       float64             `json:"cgpa"`
+	Status     string              `json:"status"`
+	Version    int                 `json:"version"`
+}
+
+// PaginatedAnonymizedRecords is one page of ExportAnonymizedRecords
+type PaginatedAnonymizedRecords struct {
+	Records      []*AnonymizedRecord `json:"records"`
+	FetchedCount int32               `json:"fetchedCount"`
+	Bookmark     string              `json:"bookmark"`
+}
+
+// SetResearchSalt stores the pseudonym salt passed in the transient map under researchSalt
+// (NITWarangal admin identities only). The salt cannot be replaced once set.
+func (s *SmartContract) SetResearchSalt(ctx contractapi.TransactionContextInterface) error {
+	if _, err := requireOrgRole(ctx, "set the research salt", orgRoleUniversity); err != nil {
+		return err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return fmt.Errorf("setting the research salt requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	existing, err := ctx.GetStub().GetPrivateData(collectionResearchSalt, researchSaltKey)
+	if err != nil {
+		return fmt.Errorf("failed to read private data: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the research salt is already set; replacing it would change every pseudonym")
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to read transient data: %v", err)
+	}
+	salt, ok := transient[transientResearchSalt]
+	if !ok {
+		return fmt.Errorf("%s must be supplied in the transient map", transientResearchSalt)
+	}
+	if len(salt) < minResearchSaltLength {
+		return fmt.Errorf("%s must be at least %d bytes", transientResearchSalt, minResearchSaltLength)
+	}
+
+	if err := ctx.GetStub().PutPrivateData(collectionResearchSalt, researchSaltKey, salt); err != nil {
+		return fmt.Errorf("failed to put private data: %v", err)
+	}
+
+	// The audit entry is public, so it must never carry the salt itself
+	return logAudit(ctx, "SetResearchSalt", "CONFIG", researchSaltKey, "Research pseudonym salt set")
+}
+
+// ExportAnonymizedRecords returns a page of a department's records whose year lies in
+// [yearFrom, yearTo], with student IDs pseudonymized and names, emails, staff names, remarks
+// and record IDs removed (NITWarangal identities with research=true). Records created before
+// departments were stamped on records are not included.
+func (s *SmartContract) ExportAnonymizedRecords(ctx contractapi.TransactionContextInterface, department string, yearFrom int, yearTo int, pageSize int32, bookmark string) (*PaginatedAnonymizedRecords, error) {
+	if _, err := requireOrgRole(ctx, "export anonymized records", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(researchAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("exporting anonymized records requires the %s=true attribute: %v", researchAttribute, err)
+	}
+
+	if department == "" {
+		return nil, fmt.Errorf("department is required")
+	}
+	if yearFrom > yearTo {
+		return nil, fmt.Errorf("yearFrom %d is after yearTo %d", yearFrom, yearTo)
+	}
+
+	salt, err := ctx.GetStub().GetPrivateData(collectionResearchSalt, researchSaltKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data: %v", err)
+	}
+	if salt == nil {
+		return nil, fmt.Errorf("the research salt is not set; an admin must call SetResearchSalt first")
+	}
+
+	// Marshal the selector from a struct so caller input can never alter the query shape
+	query := struct {
+		Selector recordDepartmentYearSelector `json:"selector"`
+		Sort     []map[string]string          `json:"sort"`
+		UseIndex []string                     `json:"use_index"`
+	}{
+		Selector: recordDepartmentYearSelector{
+			DocType:    docTypeRecord,
+			Department: department,
+			Year:       recordYearRange{From: yearFrom, To: yearTo},
+		},
+		Sort:     []map[string]string{{"docType": "asc"}, {"department": "asc"}, {"year": "asc"}},
+		UseIndex: []string{"_design/indexRecordDepartmentYearDoc", "indexRecordDepartmentYear"},
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []*AnonymizedRecord{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var record AcademicRecord
+		if err := json.Unmarshal(response.Value, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal record: %v", err)
+		}
+		records = append(records, anonymizeRecord(&record, salt))
+	}
+
+	return &PaginatedAnonymizedRecords{
+		Records:      records,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}, nil
+}
+
+// anonymizeRecord copies the analytic fields of a record under the student's pseudonym
+func anonymizeRecord(record *AcademicRecord, salt []byte) *AnonymizedRecord {
+	courses := []*AnonymizedCourse{}
+	for _, course := range record.Courses {
+		courses = append(courses, &AnonymizedCourse{
+			CourseCode: course.CourseCode,
+			CourseName: course.CourseName,
+			Credits:    course.Credits,
+			Grade:      course.Grade,
+			GradePoint: course.GradePoint,
+		})
+	}
+
+	return &AnonymizedRecord{
+		Pseudonym:  studentPseudonym(salt, record.StudentID),
+		Department: record.Department,
+		Semester:   record.Semester,
+		Year:       record.Year,
+		Courses:    courses,
+		SGPA:       record.SGPA,
+		CGPA:       record.CGPA,
+		Status:     record.Status,
+		Version:    record.Version,
+	}
+}
+
+// studentPseudonym is the hex HMAC-SHA256 of a student ID under the research salt
+func studentPseudonym(salt []byte, studentID string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(studentID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== ROLE POLICY ==========
+
+// Operations gated by a role attribute on top of the MSP check
+const (
+	operationCreateRecord      = "createRecord"
+	operationApproveRecord     = "approveRecord"
+	operationIssueCertificate  = "issueCertificate"
+	operationRevokeCertificate = "revokeCertificate"
+	operationManageHold        = "manageHold"
+)
+
+// RolePolicy names the client certificate attribute holding the caller's role and the roles
+// accepted for each operation; stored on the ledger so it can change without a redeploy
+type RolePolicy struct {
+	DocType   string              `json:"docType"`
+	Attribute string              `json:"attribute"`
+	Roles     map[string][]string `json:"roles"` // operation -> accepted roles
+	UpdatedBy string              `json:"updatedBy"`
+	UpdatedAt string              `json:"updatedAt"`
+}
+
+// rolePolicyKey is the world state key of the role policy config asset
+const rolePolicyKey = "CONFIG_ROLES"
+
+// defaultRoleAttribute and defaultOperationRoles are used until NITWarangal stores its own policy
+const defaultRoleAttribute = "role"
+
+var defaultOperationRoles = map[string][]string{
+	operationCreateRecord:      {"exam_cell"},
+	operationApproveRecord:     {"registrar", "dean"},
+	operationIssueCertificate:  {"registrar", "dean"},
+	operationRevokeCertificate: {"registrar", "dean"},
+	operationManageHold:        {"dean", "dsa"},
+}
+
+// GetRolePolicy returns the role policy in force
+func (s *SmartContract) GetRolePolicy(ctx contractapi.TransactionContextInterface) (*RolePolicy, error) {
+	return getRolePolicy(ctx)
+}
+
+// UpdateRolePolicy replaces the role policy (NITWarangal only)
+func (s *SmartContract) UpdateRolePolicy(ctx contractapi.TransactionContextInterface, policyJSON string) (*RolePolicy, error) {
+	creatorOrg, err := requireOrgRole(ctx, "update the role policy", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy RolePolicy
+	if err := json.Unmarshal([]byte(policyJSON), &policy); err != nil {
+		return nil, fmt.Errorf("invalid role policy JSON: %v", err)
+	}
+	policy.Attribute = strings.TrimSpace(policy.Attribute)
+	if policy.Attribute == "" {
+		return nil, fmt.Errorf("role policy must name the role attribute")
+	}
+	for operation := range policy.Roles {
+		if _, known := defaultOperationRoles[operation]; !known {
+			return nil, fmt.Errorf("unknown operation %q in role policy", operation)
+		}
+	}
+	// Operations left out keep their default roles rather than becoming unusable
+	if policy.Roles == nil {
+		policy.Roles = map[string][]string{}
+	}
+	for operation, roles := range defaultOperationRoles {
+		if len(policy.Roles[operation]) == 0 {
+			policy.Roles[operation] = roles
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	policy.DocType = docTypeRolePolicy
+	policy.UpdatedBy = creatorOrg
+	policy.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	storedJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal role policy: %v", err)
+	}
+	if err := ctx.GetStub().PutState(rolePolicyKey, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	logAudit(ctx, "UpdateRolePolicy", "CONFIG", rolePolicyKey, fmt.Sprintf("Role policy updated: %s", string(storedJSON)))
+
+	return &policy, nil
+}
+
+// requireRole fails unless the caller's role attribute holds one of the roles the policy
+// accepts for operation
+func requireRole(ctx contractapi.TransactionContextInterface, operation string) error {
+	policy, err := getRolePolicy(ctx)
+	if err != nil {
+		return err
+	}
+	accepted := policy.Roles[operation]
+
+	role, found, err := ctx.GetClientIdentity().GetAttributeValue(policy.Attribute)
+	if err != nil {
+		return fmt.Errorf("failed to read client attribute %s: %v", policy.Attribute, err)
+	}
+	if !found {
+		return fmt.Errorf("%s requires the %s attribute with one of: %s", operation, policy.Attribute, strings.Join(accepted, ", "))
+	}
+
+	for _, candidate := range accepted {
+		if role == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s=%s may not perform %s; accepted roles: %s", policy.Attribute, role, operation, strings.Join(accepted, ", "))
+}
+
+// getRolePolicy reads the stored role policy, falling back to the default
+func getRolePolicy(ctx contractapi.TransactionContextInterface) (*RolePolicy, error) {
+	policyJSON, err := ctx.GetStub().GetState(rolePolicyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read role policy: %v", err)
+	}
+	if policyJSON == nil {
+		return &RolePolicy{DocType: docTypeRolePolicy, Attribute: defaultRoleAttribute, Roles: defaultOperationRoles}, nil
+	}
+
+	var policy RolePolicy
+	if err := json.Unmarshal(policyJSON, &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal role policy: %v", err)
+	}
+	// Operations added after the policy was stored keep their default roles
+	if policy.Roles == nil {
+		policy.Roles = map[string][]string{}
+	}
+	for operation, roles := range defaultOperationRoles {
+		if _, set := policy.Roles[operation]; !set {
+			policy.Roles[operation] = roles
+		}
+	}
+	return &policy, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== CERTIFICATE SHARE LINKS ==========
+//
+// A student hands an employer a share token instead of their certificate ID. Resolving the token
+// shows the public view of the one certificate and its verification status, and nothing else of
+// the student's profile, until the token expires or the student revokes it.
+
+// Share token statuses; expiry is checked against the transaction time, not stored
+const (
+	shareTokenActive  = "ACTIVE"
+	shareTokenRevoked = "REVOKED"
+)
+
+// maxShareTokenDays caps how far ahead a share token may expire
+const maxShareTokenDays = 365
+
+// shareLinkInvalid is the reason every unusable token resolves with
+const shareLinkInvalid = "link no longer valid"
+
+// ShareToken lets whoever holds TokenID see the public view of one certificate
+type ShareToken struct {
+	DocType       string `json:"docType"`
+	TokenID       string `json:"tokenId"`
+	CertificateID string `json:"certificateId"`
+	StudentID     string `json:"studentId"`
+	ExpiresAt     string `json:"expiresAt"`
+	Status        string `json:"status"` // ACTIVE, REVOKED
+	CreatedBy     string `json:"createdBy"`
+	CreatedAt     string `json:"createdAt"`
+	RevokedBy     string `json:"revokedBy,omitempty"`
+	RevokedAt     string `json:"revokedAt,omitempty"`
+}
+
+// PublicCertificateView is the part of a certificate a share link discloses
+type PublicCertificateView struct {
+	CertificateID     string `json:"certificateId"`
+	StudentID         string `json:"studentId"`
+	CertificationType string `json:"certificationType"`
+	IssuedDate        string `json:"issuedDate"`
+	ValidFrom         string `json:"validFrom,omitempty"`
+	ValidUntil        string `json:"validUntil,omitempty"`
+	GraduationDate    string `json:"graduationDate,omitempty"`
+	Classification    string `json:"classification,omitempty"`
+	CertificateHash   string `json:"certificateHash"`
+	Status            string `json:"status"`
+	InstitutionID     string `json:"institutionId"`
+	InstitutionName   string `json:"institutionName,omitempty"`
+}
+
+// ShareTokenResolution is what a share link shows; Certificate and Verdict are only set while
+// the token is valid
+type ShareTokenResolution struct {
+	TokenID     string                 `json:"tokenId"`
+	Valid       bool                   `json:"valid"`
+	Reason      string                 `json:"reason,omitempty"`
+	ExpiresAt   string                 `json:"expiresAt,omitempty"`
+	Certificate *PublicCertificateView `json:"certificate,omitempty"`
+	Verdict     *CertificateVerdict    `json:"verdict,omitempty"`
+	ResolvedAt  string                 `json:"resolvedAt"`
+}
+
+// CreateShareToken creates a share link to one of a student's issued certificates, valid until
+// expiresAt (the student's own identity, or NITWarangal on their behalf)
+func (s *SmartContract) CreateShareToken(ctx contractapi.TransactionContextInterface, certificateID string, expiresAt string) (*ShareToken, error) {
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	createdBy, err := assertActsForStudent(ctx, cert.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertInstitutionRead(ctx, cert.InstitutionID); err != nil {
+		return nil, err
+	}
+	if cert.Status != certStatusIssued {
+		return nil, fmt.Errorf("certificate %s is %s and cannot be shared", certificateID, cert.Status)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	expiry, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiresAt %q: must be RFC3339", expiresAt)
+	}
+	if !expiry.After(txTime) {
+		return nil, fmt.Errorf("expiresAt must be in the future")
+	}
+	if expiry.After(txTime.AddDate(0, 0, maxShareTokenDays)) {
+		return nil, fmt.Errorf("expiresAt must be within %d days", maxShareTokenDays)
+	}
+
+	// Deterministic on every endorser, without exposing the transaction it was created in
+	digest := sha256.Sum256([]byte("share:" + ctx.GetStub().GetTxID()))
+	token := ShareToken{
+		DocType:       docTypeShareToken,
+		TokenID:       "SHARE_" + hex.EncodeToString(digest[:16]),
+		CertificateID: certificateID,
+		StudentID:     cert.StudentID,
+		ExpiresAt:     expiry.UTC().Format(time.RFC3339),
+		Status:        shareTokenActive,
+		CreatedBy:     createdBy,
+		CreatedAt:     txTime.UTC().Format(time.RFC3339),
+	}
+
+	if err := putShareToken(ctx, &token); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "share~student", []string{cert.StudentID, token.TokenID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "CreateShareToken", "CERTIFICATE", certificateID, fmt.Sprintf("Share link %s created until %s", token.TokenID, token.ExpiresAt))
+
+	return &token, nil
+}
+
+// RevokeShareToken disables a share link before it expires (the student's own identity, or
+// NITWarangal on their behalf)
+func (s *SmartContract) RevokeShareToken(ctx contractapi.TransactionContextInterface, tokenID string) (*ShareToken, error) {
+	token, err := readShareToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, fmt.Errorf("share token %s not found", tokenID)
+	}
+	revokedBy, err := assertActsForStudent(ctx, token.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if token.Status != shareTokenActive {
+		return nil, fmt.Errorf("share token %s is already %s", tokenID, token.Status)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	token.Status = shareTokenRevoked
+	token.RevokedBy = revokedBy
+	token.RevokedAt = txTime.UTC().Format(time.RFC3339)
+
+	if err := putShareToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RevokeShareToken", "CERTIFICATE", token.CertificateID, fmt.Sprintf("Share link %s revoked", tokenID))
+
+	return token, nil
+}
+
+// GetShareTokens lists the share links a student has created (the student's own identity, or
+// NITWarangal on their behalf)
+func (s *SmartContract) GetShareTokens(ctx contractapi.TransactionContextInterface, studentID string) ([]*ShareToken, error) {
+	if _, err := assertActsForStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("share~student", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query share tokens: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	tokens := []*ShareToken{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		token, err := readShareToken(ctx, compositeKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		if token != nil {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// ResolveShareToken returns the public view and verification status of a share link's
+// certificate (any org). Unknown, expired and revoked tokens resolve as not valid rather than
+// failing, so the holder of the link sees why it stopped working.
+func (s *SmartContract) ResolveShareToken(ctx contractapi.TransactionContextInterface, tokenID string) (*ShareTokenResolution, error) {
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	resolution := &ShareTokenResolution{
+		TokenID:    tokenID,
+		ResolvedAt: txTime.UTC().Format(time.RFC3339),
+	}
+
+	token, err := readShareToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		resolution.Reason = shareLinkInvalid + ": no such link"
+		return resolution, nil
+	}
+	resolution.ExpiresAt = token.ExpiresAt
+	if token.Status == shareTokenRevoked {
+		resolution.Reason = shareLinkInvalid + ": revoked"
+		return resolution, nil
+	}
+	expiry, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	if err != nil || !txTime.Before(expiry) {
+		resolution.Reason = shareLinkInvalid + ": expired"
+		return resolution, nil
+	}
+
+	// Public verification reads certificates of any institution, so the view is unscoped too
+	cert, err := readCertificate(ctx, token.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+	verdict, err := s.IsCertificateValid(ctx, token.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution.Valid = true
+	resolution.Verdict = verdict
+	resolution.Certificate = &PublicCertificateView{
+		CertificateID:     cert.CertificateID,
+		StudentID:         cert.StudentID,
+		CertificationType: cert.CertificationType,
+		IssuedDate:        cert.IssuedDate,
+		ValidFrom:         cert.ValidFrom,
+		ValidUntil:        cert.ValidUntil,
+		GraduationDate:    cert.GraduationDate,
+		Classification:    cert.Classification,
+		CertificateHash:   cert.CertificateHash,
+		Status:            cert.Status,
+		InstitutionID:     verdict.InstitutionID,
+		InstitutionName:   verdict.InstitutionName,
+	}
+	return resolution, nil
+}
+
+// readShareToken loads a share token from world state, nil when there is none
+func readShareToken(ctx contractapi.TransactionContextInterface, tokenID string) (*ShareToken, error) {
+	tokenJSON, err := ctx.GetStub().GetState(tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if tokenJSON == nil {
+		return nil, nil
+	}
+
+	var token ShareToken
+	if err := json.Unmarshal(tokenJSON, &token); err != nil || token.DocType != docTypeShareToken {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// putShareToken saves a share token under its token ID
+func putShareToken(ctx contractapi.TransactionContextInterface, token *ShareToken) error {
+	tokenJSON, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal share token: %v", err)
+	}
+	if err := ctx.GetStub().PutState(token.TokenID, tokenJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
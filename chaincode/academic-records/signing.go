@@ -0,0 +1,413 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== ISSUER SIGNATURES ==========
+//
+// A signed certificate can be checked from the document alone. The registrar's signing service
+// signs the canonical JSON of the certificate's signed payload with a key whose X.509
+// certificate the university registered with RegisterSigningKey, and passes the signature to
+// SignCertificate as transient data. The payload covers the issue timestamp, which is only known
+// once the certificate is on the ledger, so the signing service signs right after issuance.
+// Fabric's own proposal signatures cover the whole proposal rather than the certificate, so they
+// cannot serve this purpose.
+
+// Transient map keys SignCertificate reads
+const (
+	transientCertificateSignature = "certificateSignature" // raw signature bytes
+	transientSignerFingerprint    = "signerFingerprint"    // hex SHA-256 of the signer certificate DER
+)
+
+// Signing key statuses; retired keys keep verifying old signatures but cannot sign new ones
+const (
+	signingKeyActive  = "ACTIVE"
+	signingKeyRetired = "RETIRED"
+)
+
+// Signed certificate outcomes, on top of the certificate verdict outcomes
+const (
+	certOutcomeUnsigned         = "UNSIGNED"
+	certOutcomeSignatureInvalid = "SIGNATURE_INVALID"
+)
+
+// SigningKey is an X.509 certificate whose key may sign certificates
+type SigningKey struct {
+	DocType        string `json:"docType"`
+	Fingerprint    string `json:"fingerprint"` // hex SHA-256 of the certificate DER
+	SerialNumber   string `json:"serialNumber"`
+	Subject        string `json:"subject"`
+	Algorithm      string `json:"algorithm"` // signature algorithm the key signs with
+	CertificatePEM string `json:"certificatePem"`
+	NotBefore      string `json:"notBefore"`
+	NotAfter       string `json:"notAfter"`
+	Status         string `json:"status"` // ACTIVE, RETIRED
+	RegisteredBy   string `json:"registeredBy"`
+	RegisteredAt   string `json:"registeredAt"`
+	RetiredAt      string `json:"retiredAt,omitempty"`
+}
+
+// certificateSignedPayload is the content an issuer signature covers
+type certificateSignedPayload struct {
+	CertificateID     string `json:"certificateId"`
+	StudentID         string `json:"studentId"`
+	CertificationType string `json:"certificationType"`
+	IssuedDate        string `json:"issuedDate"`
+	ValidUntil        string `json:"validUntil,omitempty"`
+	CertificateHash   string `json:"certificateHash"`
+	HashAlgorithm     string `json:"hashAlgorithm"`
+}
+
+// CertificateSigningInfo is what a relying party needs to check a signature offline
+type CertificateSigningInfo struct {
+	CertificateID      string      `json:"certificateId"`
+	SignedPayload      string      `json:"signedPayload"` // canonical JSON the signature covers
+	Signature          string      `json:"signature"`     // base64
+	SignatureAlgorithm string      `json:"signatureAlgorithm"`
+	SignedAt           string      `json:"signedAt"`
+	Signer             *SigningKey `json:"signer"`
+}
+
+// SignedCertificateVerdict combines the certificate verdict with the signature check
+type SignedCertificateVerdict struct {
+	CertificateVerdict
+	SignatureValid    bool   `json:"signatureValid"`
+	SignerFingerprint string `json:"signerFingerprint,omitempty"`
+	SignerStatus      string `json:"signerStatus,omitempty"`
+}
+
+// RegisterSigningKey registers the PEM X.509 certificate of a key allowed to sign certificates
+// (NITWarangal admin identities only)
+func (s *SmartContract) RegisterSigningKey(ctx contractapi.TransactionContextInterface, certificatePEM string) (*SigningKey, error) {
+	if _, err := requireOrgRole(ctx, "register signing keys", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("registering signing keys requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("certificatePem must hold one PEM CERTIFICATE block")
+	}
+	signer, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer certificate: %v", err)
+	}
+	algorithm, err := signingAlgorithm(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	if txTime.After(signer.NotAfter) {
+		return nil, fmt.Errorf("signer certificate expired at %s", signer.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	fingerprint := sha256.Sum256(block.Bytes)
+	key := &SigningKey{
+		DocType:        docTypeSigningKey,
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		SerialNumber:   signer.SerialNumber.String(),
+		Subject:        signer.Subject.String(),
+		Algorithm:      algorithm.String(),
+		CertificatePEM: string(pem.EncodeToMemory(block)),
+		NotBefore:      signer.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:       signer.NotAfter.UTC().Format(time.RFC3339),
+		Status:         signingKeyActive,
+		RegisteredBy:   getClientCommonName(ctx),
+		RegisteredAt:   txTime.UTC().Format(time.RFC3339),
+	}
+	if err := assertKeyUnused(ctx, signingKeyKey(key.Fingerprint), docTypeSigningKey); err != nil {
+		return nil, err
+	}
+	if err := putSigningKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RegisterSigningKey", "CONFIG", signingKeyKey(key.Fingerprint), fmt.Sprintf("Signing key %s registered: %s, serial %s", key.Fingerprint, key.Subject, key.SerialNumber))
+
+	return key, nil
+}
+
+// RetireSigningKey stops a key from signing further certificates; its signatures stay valid
+// (NITWarangal admin identities only)
+func (s *SmartContract) RetireSigningKey(ctx contractapi.TransactionContextInterface, fingerprint string, reason string) (*SigningKey, error) {
+	if _, err := requireOrgRole(ctx, "retire signing keys", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("retiring signing keys requires the %s=true attribute: %v", adminAttribute, err)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to retire a signing key")
+	}
+
+	key, err := readSigningKey(ctx, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if key.Status == signingKeyRetired {
+		return nil, fmt.Errorf("signing key %s is already retired", fingerprint)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	key.Status = signingKeyRetired
+	key.RetiredAt = txTime.UTC().Format(time.RFC3339)
+	if err := putSigningKey(ctx, key); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RetireSigningKey", "CONFIG", signingKeyKey(fingerprint), fmt.Sprintf("Signing key %s retired: %s", fingerprint, reason))
+
+	return key, nil
+}
+
+// SignCertificate attaches the issuer signature passed in the transient map to an issued
+// certificate (NITWarangal only). The signature must verify against the payload under the named
+// active signing key.
+func (s *SmartContract) SignCertificate(ctx contractapi.TransactionContextInterface, certificateID string) (*Certificate, error) {
+	if _, err := requireOrgRole(ctx, "sign certificates", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := requireRole(ctx, operationIssueCertificate); err != nil {
+		return nil, err
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient data: %v", err)
+	}
+	signature, ok := transient[transientCertificateSignature]
+	if !ok || len(signature) == 0 {
+		return nil, fmt.Errorf("%s must be supplied in the transient map", transientCertificateSignature)
+	}
+	fingerprint, ok := transient[transientSignerFingerprint]
+	if !ok {
+		return nil, fmt.Errorf("%s must be supplied in the transient map", transientSignerFingerprint)
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Status != certStatusIssued {
+		return nil, fmt.Errorf("certificate %s is %s and cannot be signed", certificateID, cert.Status)
+	}
+	if cert.Signature != "" {
+		return nil, fmt.Errorf("certificate %s is already signed", certificateID)
+	}
+
+	key, err := readSigningKey(ctx, string(fingerprint))
+	if err != nil {
+		return nil, err
+	}
+	if key.Status != signingKeyActive {
+		return nil, fmt.Errorf("signing key %s is %s", key.Fingerprint, key.Status)
+	}
+
+	payload, err := certificateSignedPayloadJSON(cert)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIssuerSignature(key, payload, signature); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	cert.Signature = base64.StdEncoding.EncodeToString(signature)
+	cert.SignatureAlgorithm = key.Algorithm
+	cert.SignerFingerprint = key.Fingerprint
+	cert.SignerSerial = key.SerialNumber
+	cert.SignedAt = txTime.UTC().Format(time.RFC3339)
+	if err := putCertificate(ctx, cert); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "SignCertificate", "CERTIFICATE", certificateID, fmt.Sprintf("Signed with key %s", key.Fingerprint))
+
+	return cert, nil
+}
+
+// GetCertificateSigningInfo returns a certificate's signature, the payload it covers and the
+// signer's X.509 certificate, for verification away from the network
+func (s *SmartContract) GetCertificateSigningInfo(ctx contractapi.TransactionContextInterface, certificateID string) (*CertificateSigningInfo, error) {
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Signature == "" {
+		return nil, fmt.Errorf("certificate %s is not signed", certificateID)
+	}
+
+	payload, err := certificateSignedPayloadJSON(cert)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := readSigningKey(ctx, cert.SignerFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificateSigningInfo{
+		CertificateID:      certificateID,
+		SignedPayload:      string(payload),
+		Signature:          cert.Signature,
+		SignatureAlgorithm: cert.SignatureAlgorithm,
+		SignedAt:           cert.SignedAt,
+		Signer:             signer,
+	}, nil
+}
+
+// VerifySignedCertificate checks a certificate's signature, hash, status and validity period in
+// one call; Valid is only true when all of them pass
+func (s *SmartContract) VerifySignedCertificate(ctx contractapi.TransactionContextInterface, certificateID string) (*SignedCertificateVerdict, error) {
+	verdict, err := s.IsCertificateValid(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	result := &SignedCertificateVerdict{CertificateVerdict: *verdict}
+	if !verdict.Exists {
+		return result, nil
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Signature == "" {
+		result.Valid = false
+		result.Outcome = certOutcomeUnsigned
+		result.Reason = "certificate carries no issuer signature"
+		return result, nil
+	}
+
+	result.SignerFingerprint = cert.SignerFingerprint
+	signer, err := readSigningKey(ctx, cert.SignerFingerprint)
+	if err == nil {
+		result.SignerStatus = signer.Status
+		signature, decodeErr := base64.StdEncoding.DecodeString(cert.Signature)
+		payload, payloadErr := certificateSignedPayloadJSON(cert)
+		result.SignatureValid = decodeErr == nil && payloadErr == nil && checkIssuerSignature(signer, payload, signature) == nil
+	}
+
+	if !result.SignatureValid {
+		result.Valid = false
+		result.Outcome = certOutcomeSignatureInvalid
+		result.Reason = "issuer signature does not verify against the registered signing key"
+	}
+	return result, nil
+}
+
+// certificateSignedPayloadJSON returns the canonical JSON an issuer signature covers
+func certificateSignedPayloadJSON(cert *Certificate) ([]byte, error) {
+	studentID := cert.StudentID
+	if cert.IssuedToStudentID != "" {
+		studentID = cert.IssuedToStudentID
+	}
+	hashAlgorithm := cert.HashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = hashAlgorithmSHA256
+	}
+	return canonicalJSON(certificateSignedPayload{
+		CertificateID:     cert.CertificateID,
+		StudentID:         studentID,
+		CertificationType: cert.CertificationType,
+		IssuedDate:        cert.IssuedDate,
+		ValidUntil:        cert.ValidUntil,
+		CertificateHash:   cert.CertificateHash,
+		HashAlgorithm:     hashAlgorithm,
+	})
+}
+
+// checkIssuerSignature verifies signature over payload with a registered signing key
+func checkIssuerSignature(key *SigningKey, payload []byte, signature []byte) error {
+	block, _ := pem.Decode([]byte(key.CertificatePEM))
+	if block == nil {
+		return fmt.Errorf("signing key %s has no PEM certificate", key.Fingerprint)
+	}
+	signer, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid signer certificate: %v", err)
+	}
+	algorithm, err := signingAlgorithm(signer)
+	if err != nil {
+		return err
+	}
+	if err := signer.CheckSignature(algorithm, payload, signature); err != nil {
+		return fmt.Errorf("signature does not verify with key %s: %v", key.Fingerprint, err)
+	}
+	return nil
+}
+
+// signingAlgorithm picks the signature algorithm for the signer's public key type
+func signingAlgorithm(signer *x509.Certificate) (x509.SignatureAlgorithm, error) {
+	switch signer.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return x509.ECDSAWithSHA256, nil
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSA, nil
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("signer certificate must hold an ECDSA, RSA or Ed25519 key")
+}
+
+// signingKeyKey is the world state key of a signing key
+func signingKeyKey(fingerprint string) string {
+	return "SIGNER_" + fingerprint
+}
+
+// readSigningKey loads a signing key by fingerprint
+func readSigningKey(ctx contractapi.TransactionContextInterface, fingerprint string) (*SigningKey, error) {
+	keyJSON, err := ctx.GetStub().GetState(signingKeyKey(fingerprint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if keyJSON == nil {
+		return nil, fmt.Errorf("signing key %s not found", fingerprint)
+	}
+
+	var key SigningKey
+	if err := json.Unmarshal(keyJSON, &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal signing key: %v", err)
+	}
+	return &key, nil
+}
+
+// putSigningKey saves a signing key under its fingerprint
+func putSigningKey(ctx contractapi.TransactionContextInterface, key *SigningKey) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal signing key: %v", err)
+	}
+	if err := ctx.GetStub().PutState(signingKeyKey(key.Fingerprint), keyJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== DEPARTMENT STATISTICS ==========
+
+// maxStatsRecords bounds the records GetDepartmentStats reads in one call; beyond it the result
+// is marked Partial
+const maxStatsRecords = 2000
+
+// SemesterStats aggregates one semester of a department's records. SGPA figures and the pass
+// percentage cover graded (APPROVED or VERIFIED) records only.
+type SemesterStats struct {
+	Semester       int            `json:"semester"`
+	RecordCount    int            `json:"recordCount"`
+	StatusCounts   map[string]int `json:"statusCounts"`
+	GradedCount    int            `json:"gradedCount"`
+	MeanSGPA       float64        `json:"meanSgpa"`
+	MedianSGPA     float64        `json:"medianSgpa"`
+	PassPercentage float64        `json:"passPercentage"` // graded records with no failed course
+}
+
+// DepartmentStats aggregates a department's records for a year
+type DepartmentStats struct {
+	Department string           `json:"department"`
+	Year       int              `json:"year"`
+	Semesters  []*SemesterStats `json:"semesters"`
+	Partial    bool             `json:"partial"` // true when more than maxStatsRecords records matched
+}
+
+// GetDepartmentStats computes SGPA, pass and status aggregates over a department's records for a
+// year, per semester; semester 0 covers every semester of the year (NITWarangal and identities
+// of the department, and regulators). At most maxStatsRecords records are read.
+func (s *SmartContract) GetDepartmentStats(ctx contractapi.TransactionContextInterface, department string, year int, semester int) (*DepartmentStats, error) {
+	callerOrg, err := requireOrgRole(ctx, "view department statistics", orgRoleUniversity, orgRoleDepartment, orgRoleRegulator)
+	if err != nil {
+		return nil, err
+	}
+	isUniversity, err := orgHasRole(ctx, callerOrg, orgRoleUniversity, orgRoleRegulator)
+	if err != nil {
+		return nil, err
+	}
+	if !isUniversity {
+		if err := requireOwnDepartment(ctx, department); err != nil {
+			return nil, err
+		}
+	}
+	if semester < 0 || semester > maxSemester {
+		return nil, fmt.Errorf("semester must be between 1 and %d, or 0 for any", maxSemester)
+	}
+
+	attributes := []string{department, fmt.Sprintf("%04d", year)}
+	if semester > 0 {
+		attributes = append(attributes, strconv.Itoa(semester))
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("record~department", attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	stats := &DepartmentStats{Department: department, Year: year, Semesters: []*SemesterStats{}}
+	bySemester := map[int]*SemesterStats{}
+	sgpas := map[int][]int64{}
+	passed := map[int]int{}
+	read := 0
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if read == maxStatsRecords {
+			stats.Partial = true
+			break
+		}
+		read++
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 4 {
+			continue
+		}
+		record, err := readAcademicRecord(ctx, compositeKeyParts[3])
+		if err != nil {
+			continue
+		}
+
+		entry := bySemester[record.Semester]
+		if entry == nil {
+			entry = &SemesterStats{Semester: record.Semester, StatusCounts: map[string]int{}}
+			bySemester[record.Semester] = entry
+			stats.Semesters = append(stats.Semesters, entry)
+		}
+		entry.RecordCount++
+		entry.StatusCounts[record.Status]++
+
+		if !countsTowardCGPA(record.Status) {
+			continue
+		}
+		entry.GradedCount++
+		sgpas[record.Semester] = append(sgpas[record.Semester], toHundredths(record.SGPA))
+		if recordPassed(record) {
+			passed[record.Semester]++
+		}
+	}
+
+	// The index orders semesters as strings, so 10 would come before 2
+	sort.Slice(stats.Semesters, func(i, j int) bool { return stats.Semesters[i].Semester < stats.Semesters[j].Semester })
+
+	for _, entry := range stats.Semesters {
+		values := sgpas[entry.Semester]
+		if len(values) == 0 {
+			continue
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+		var total int64
+		for _, value := range values {
+			total += value
+		}
+		count := int64(len(values))
+		entry.MeanSGPA = fromHundredths((total*2 + count) / (count * 2)) // rounded half up
+		if count%2 == 1 {
+			entry.MedianSGPA = fromHundredths(values[count/2])
+		} else {
+			entry.MedianSGPA = fromHundredths((values[count/2-1] + values[count/2] + 1) / 2)
+		}
+		entry.PassPercentage = fromHundredths((int64(passed[entry.Semester])*20000 + count) / (count * 2))
+	}
+
+	return stats, nil
+}
+
+// recordPassed reports whether every course of a graded record earned grade points
+func recordPassed(record *AcademicRecord) bool {
+	for _, course := range record.Courses {
+		if course.GradePoint == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ========== CGPA DISTRIBUTION ==========
+
+// maxCGPAHundredths is the top of the 10-point scale in hundredths
+const maxCGPAHundredths = 1000
+
+// CGPABucket counts the students whose CGPA lies in [From, To); the last bucket includes 10
+type CGPABucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int     `json:"count"`
+}
+
+// CGPADistribution is a histogram of a cohort's CGPAs
+type CGPADistribution struct {
+	Department     string        `json:"department"`
+	EnrollmentYear int           `json:"enrollmentYear"`
+	BucketSize     float64       `json:"bucketSize"`
+	Buckets        []*CGPABucket `json:"buckets"`
+	Population     int           `json:"population"` // students counted in the buckets
+	Excluded       int           `json:"excluded"`   // students with no verified record
+}
+
+// GetCGPADistribution buckets the CGPAs of a department's students who enrolled in enrollmentYear
+// (NITWarangal, Departments and regulators). CGPA is taken over verified records, and students without one
+// are only counted as excluded. Buckets are bucketSize wide from 0 to 10, compared in hundredths.
+func (s *SmartContract) GetCGPADistribution(ctx contractapi.TransactionContextInterface, department string, enrollmentYear int, bucketSize float64) (*CGPADistribution, error) {
+	if _, err := requireOrgRole(ctx, "view CGPA distributions", orgRoleUniversity, orgRoleDepartment, orgRoleRegulator); err != nil {
+		return nil, err
+	}
+	size := toHundredths(bucketSize)
+	if size < 10 || size > maxCGPAHundredths {
+		return nil, fmt.Errorf("bucket size must be between 0.1 and 10")
+	}
+
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cohort, err := cohortStudents(ctx, department, enrollmentYear)
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := &CGPADistribution{Department: department, EnrollmentYear: enrollmentYear, BucketSize: fromHundredths(size), Buckets: []*CGPABucket{}}
+	for from := int64(0); from < maxCGPAHundredths; from += size {
+		to := from + size
+		if to > maxCGPAHundredths {
+			to = maxCGPAHundredths
+		}
+		distribution.Buckets = append(distribution.Buckets, &CGPABucket{From: fromHundredths(from), To: fromHundredths(to)})
+	}
+
+	for _, student := range cohort {
+		records, err := getStudentRecordList(ctx, student.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		verified := []*AcademicRecord{}
+		for _, record := range records {
+			if record.Status == recordStatusVerified {
+				verified = append(verified, record)
+			}
+		}
+		if len(verified) == 0 {
+			distribution.Excluded++
+			continue
+		}
+		sort.SliceStable(verified, func(i, j int) bool {
+			return semesterAfter(verified[j], verified[i])
+		})
+
+		cgpa, _ := calculateCGPA(verified, config.RepeatPolicy)
+		index := int(toHundredths(cgpa) / size)
+		if index >= len(distribution.Buckets) {
+			index = len(distribution.Buckets) - 1
+		}
+		distribution.Buckets[index].Count++
+		distribution.Population++
+	}
+
+	return distribution, nil
+}
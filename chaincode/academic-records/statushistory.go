@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT STATUS HISTORY ==========
+//
+// Every status change writes a statuschange~studentID~txTime~txID entry. txTime is zero-padded,
+// so a student's entries iterate in chronological order.
+
+// StudentStatusChange is one change of a student's status
+type StudentStatusChange struct {
+	DocType     string `json:"docType"`
+	StudentID   string `json:"studentId"`
+	OldStatus   string `json:"oldStatus"`
+	NewStatus   string `json:"newStatus"`
+	Reason      string `json:"reason"`
+	ChangedBy   string `json:"changedBy"`   // MSP ID
+	ChangedByID string `json:"changedById"` // client identity
+	ChangedAt   string `json:"changedAt"`
+	TxID        string `json:"txId"`
+}
+
+// PaginatedStatusChanges holds one page of status changes and the bookmark for the next page
+type PaginatedStatusChanges struct {
+	Changes      []*StudentStatusChange `json:"changes"`
+	FetchedCount int32                  `json:"fetchedCount"`
+	Bookmark     string                 `json:"bookmark"`
+}
+
+// GetStudentStatusHistory returns a student's status changes, oldest first
+func (s *SmartContract) GetStudentStatusHistory(ctx contractapi.TransactionContextInterface, studentID string, pageSize int32, bookmark string) (*PaginatedStatusChanges, error) {
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("statuschange", []string{studentID}, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status changes: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	changes := []*StudentStatusChange{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var change StudentStatusChange
+		if err := json.Unmarshal(response.Value, &change); err != nil {
+			continue
+		}
+		changes = append(changes, &change)
+	}
+
+	return &PaginatedStatusChanges{
+		Changes:      changes,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}, nil
+}
+
+// getStatusChanges returns every status change of a student, oldest first
+func getStatusChanges(ctx contractapi.TransactionContextInterface, studentID string) ([]*StudentStatusChange, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("statuschange", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status changes: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	changes := []*StudentStatusChange{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var change StudentStatusChange
+		if err := json.Unmarshal(response.Value, &change); err != nil {
+			continue
+		}
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+// setStudentStatus saves the student with a new status, moves it in the student~status index
+// and records the change in the status history
+func setStudentStatus(ctx contractapi.TransactionContextInterface, student *Student, status string, reason string) error {
+	oldStatus := student.Status
+	student.Status = status
+
+	if err := putStudent(ctx, student); err != nil {
+		return err
+	}
+
+	if oldStatus != status {
+		if err := deleteIndex(ctx, "student~status", []string{oldStatus, student.StudentID}); err != nil {
+			return err
+		}
+		if err := putIndex(ctx, "student~status", []string{status, student.StudentID}); err != nil {
+			return err
+		}
+	}
+
+	return recordStatusChange(ctx, student.StudentID, oldStatus, status, reason)
+}
+
+// recordStatusChange stores a status change under its own key
+func recordStatusChange(ctx contractapi.TransactionContextInterface, studentID string, oldStatus string, newStatus string, reason string) error {
+	org, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	change := StudentStatusChange{
+		DocType:     docTypeStatusChange,
+		StudentID:   studentID,
+		OldStatus:   oldStatus,
+		NewStatus:   newStatus,
+		Reason:      reason,
+		ChangedBy:   org,
+		ChangedByID: clientID,
+		ChangedAt:   txTime.UTC().Format(time.RFC3339),
+		TxID:        txID,
+	}
+
+	changeKey, err := ctx.GetStub().CreateCompositeKey("statuschange", []string{studentID, fmt.Sprintf("%019d", txTime.UnixNano()), txID})
+	if err != nil {
+		return fmt.Errorf("failed to create status change key: %v", err)
+	}
+	changeJSON, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status change: %v", err)
+	}
+	if err := ctx.GetStub().PutState(changeKey, changeJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
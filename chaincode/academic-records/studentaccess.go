@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT READ ACCESS ==========
+//
+// Listing students discloses the PII of every one of them at once, so GetAllStudents,
+// QueryStudents and GetStudentsByStatus are open only to orgs of the studentListRoles in the org
+// config. A single student is read freely by orgs of the studentReadRoles; any other org, such as
+// a verifier, must name a certificate of the student it has an open verification request for, or
+// hold the student's consent grant.
+
+// Roles that may list students and read any single student until the org config sets others
+var (
+	defaultStudentListRoles = []string{orgRoleUniversity, orgRoleDepartment}
+	defaultStudentReadRoles = []string{orgRoleUniversity, orgRoleDepartment, orgRoleRegulator}
+)
+
+// assertCanListStudents refuses student listings to orgs outside the studentListRoles
+func assertCanListStudents(ctx contractapi.TransactionContextInterface) error {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	for _, role := range config.studentListRoles() {
+		if config.hasRole(creatorOrg, role) {
+			return nil
+		}
+	}
+	return fmt.Errorf("FORBIDDEN: org %s cannot list students", creatorOrg)
+}
+
+// assertStudentReadAllowed lets orgs of the studentReadRoles read any student and requires every
+// other org to present certificateID, a certificate of the student it is verifying, or to hold
+// an active consent grant from the student
+func assertStudentReadAllowed(ctx contractapi.TransactionContextInterface, studentID string, certificateID string) error {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return err
+	}
+	for _, role := range config.studentReadRoles() {
+		if config.hasRole(creatorOrg, role) {
+			return nil
+		}
+	}
+
+	if certificateID != "" {
+		verifying, err := verifyingCertificate(ctx, creatorOrg, studentID, certificateID)
+		if err != nil {
+			return err
+		}
+		if verifying {
+			return nil
+		}
+	}
+	// Either consent scope covers the student's profile
+	if assertRecordAccess(ctx, studentID, accessScopeCertificates) == nil {
+		return nil
+	}
+	return fmt.Errorf("FORBIDDEN: org %s may only read student %s with a certificate of theirs it is verifying or the student's consent", creatorOrg, studentID)
+}
+
+// verifyingCertificate reports whether certificateID belongs to the student and creatorOrg filed a
+// verification request for it that is pending or was answered VERIFIED, and has not expired
+func verifyingCertificate(ctx contractapi.TransactionContextInterface, creatorOrg string, studentID string, certificateID string) (bool, error) {
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil || cert.StudentID != studentID {
+		return false, nil
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("vreq~cert", []string{certificateID})
+	if err != nil {
+		return false, fmt.Errorf("failed to query verification requests: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return false, err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		request, err := readVerificationRequest(ctx, compositeKeyParts[1])
+		if err != nil || request.RequesterOrg != creatorOrg {
+			continue
+		}
+		if request.Status != verificationRequestPending && request.Status != verificationRequestVerified {
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, request.ExpiresAt)
+		if err == nil && txTime.Before(expiresAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// studentListRoles returns the roles that may list students, defaulting for configs stored before it existed
+func (c *OrgConfig) studentListRoles() []string {
+	if len(c.StudentListRoles) == 0 {
+		return defaultStudentListRoles
+	}
+	return c.StudentListRoles
+}
+
+// studentReadRoles returns the roles that may read any student, defaulting for configs stored before it existed
+func (c *OrgConfig) studentReadRoles() []string {
+	if len(c.StudentReadRoles) == 0 {
+		return defaultStudentReadRoles
+	}
+	return c.StudentReadRoles
+}
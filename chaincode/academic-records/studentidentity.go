@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== STUDENT IDENTITIES ==========
+//
+// Students hold Fabric identities issued by the portal CA. An identity belongs to a student when
+// its enrollment certificate carries the studentId attribute or, for certificates issued without
+// it, when NITWarangal has bound the identity's client ID to the student in the identity registry.
+// GetMyRecords and GetMyCertificates return only the caller's own student's assets.
+
+// identityBindingObjectType keys registry entries by client ID
+const identityBindingObjectType = "IDENTITY_BINDING"
+
+// IdentityBinding ties a client identity to the student it belongs to
+type IdentityBinding struct {
+	DocType   string `json:"docType"`
+	ClientID  string `json:"clientId"`
+	StudentID string `json:"studentId"`
+	BoundBy   string `json:"boundBy"`
+	BoundAt   string `json:"boundAt"`
+}
+
+// BindStudentIdentity binds a client identity to a student (NITWarangal only). An identity
+// belongs to one student at most; a student may hold several identities.
+func (s *SmartContract) BindStudentIdentity(ctx contractapi.TransactionContextInterface, studentID string, clientID string) (*IdentityBinding, error) {
+	creatorOrg, err := requireOrgRole(ctx, "bind student identities", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if clientID == "" {
+		return nil, fmt.Errorf("client ID is required")
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+
+	existing, err := readIdentityBinding(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("identity is already bound to student %s; unbind it first", existing.StudentID)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	binding := &IdentityBinding{
+		DocType:   docTypeIdentityBinding,
+		ClientID:  clientID,
+		StudentID: studentID,
+		BoundBy:   creatorOrg,
+		BoundAt:   txTime.UTC().Format(time.RFC3339),
+	}
+
+	bindingJSON, err := json.Marshal(binding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal identity binding: %v", err)
+	}
+	key, err := identityBindingKey(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, bindingJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+	if err := putIndex(ctx, "binding~student", []string{studentID, clientID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "BindStudentIdentity", "STUDENT", studentID, fmt.Sprintf("Bound identity %s", clientID))
+
+	return binding, nil
+}
+
+// UnbindStudentIdentity removes a client identity from the registry (NITWarangal only)
+func (s *SmartContract) UnbindStudentIdentity(ctx contractapi.TransactionContextInterface, clientID string) (*IdentityBinding, error) {
+	if _, err := requireOrgRole(ctx, "unbind student identities", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	binding, err := readIdentityBinding(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil {
+		return nil, fmt.Errorf("identity %s is not bound to a student", clientID)
+	}
+
+	key, err := identityBindingKey(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return nil, fmt.Errorf("failed to delete state: %v", err)
+	}
+	if err := deleteIndex(ctx, "binding~student", []string{binding.StudentID, clientID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "UnbindStudentIdentity", "STUDENT", binding.StudentID, fmt.Sprintf("Unbound identity %s", clientID))
+
+	return binding, nil
+}
+
+// GetIdentityBinding returns the student a client identity is bound to, for support cases
+// (NITWarangal only)
+func (s *SmartContract) GetIdentityBinding(ctx contractapi.TransactionContextInterface, clientID string) (*IdentityBinding, error) {
+	if _, err := requireOrgRole(ctx, "view identity bindings", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	binding, err := readIdentityBinding(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil {
+		return nil, fmt.Errorf("identity %s is not bound to a student", clientID)
+	}
+	return binding, nil
+}
+
+// GetStudentIdentities lists the client identities bound to a student (NITWarangal only)
+func (s *SmartContract) GetStudentIdentities(ctx contractapi.TransactionContextInterface, studentID string) ([]*IdentityBinding, error) {
+	if _, err := requireOrgRole(ctx, "view identity bindings", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("binding~student", []string{studentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query identity bindings: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	bindings := []*IdentityBinding{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 2 {
+			continue
+		}
+
+		binding, err := readIdentityBinding(ctx, compositeKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		if binding != nil && binding.StudentID == studentID {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings, nil
+}
+
+// GetMyRecords returns the academic records of the student bound to the caller's identity,
+// oldest semester first; superseded records are left out
+func (s *SmartContract) GetMyRecords(ctx contractapi.TransactionContextInterface) ([]*AcademicRecord, error) {
+	studentID, err := callerStudentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	all, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	records := []*AcademicRecord{}
+	for _, record := range all {
+		if record.Status != recordStatusSuperseded {
+			records = append(records, record)
+		}
+	}
+	sortRecordsBySemester(records, false)
+
+	if err := filterResponse(ctx, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetMyCertificates returns the certificates of the student bound to the caller's identity
+func (s *SmartContract) GetMyCertificates(ctx contractapi.TransactionContextInterface) ([]*Certificate, error) {
+	studentID, err := callerStudentID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readStudent(ctx, studentID); err != nil {
+		return nil, err
+	}
+
+	certificateIDs, err := indexedCertificateIDs(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	certificates := []*Certificate{}
+	for _, certificateID := range certificateIDs {
+		cert, err := readCertificateView(ctx, certificateID)
+		if err != nil || cert.StudentID != studentID {
+			continue // stale index entry
+		}
+		certificates = append(certificates, cert)
+	}
+	return certificates, nil
+}
+
+// callerStudentID resolves the caller's identity to its student, failing for unbound identities
+func callerStudentID(ctx contractapi.TransactionContextInterface) (string, error) {
+	studentID, err := boundStudentID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if studentID == "" {
+		return "", fmt.Errorf("no student bound to this identity")
+	}
+	return studentID, nil
+}
+
+// boundStudentID returns the student of the caller's studentId attribute or, failing that, of
+// its identity registry entry; empty when the identity belongs to no student
+func boundStudentID(ctx contractapi.TransactionContextInterface) (string, error) {
+	studentID, found, err := ctx.GetClientIdentity().GetAttributeValue(studentIDAttribute)
+	if err != nil {
+		return "", fmt.Errorf("failed to read client attributes: %v", err)
+	}
+	if found && studentID != "" {
+		return studentID, nil
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get client identity: %v", err)
+	}
+	binding, err := readIdentityBinding(ctx, clientID)
+	if err != nil || binding == nil {
+		return "", err
+	}
+	return binding.StudentID, nil
+}
+
+// readIdentityBinding loads the registry entry of a client identity, nil when it has none
+func readIdentityBinding(ctx contractapi.TransactionContextInterface, clientID string) (*IdentityBinding, error) {
+	key, err := identityBindingKey(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	bindingJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if bindingJSON == nil {
+		return nil, nil
+	}
+
+	var binding IdentityBinding
+	if err := json.Unmarshal(bindingJSON, &binding); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal identity binding: %v", err)
+	}
+	return &binding, nil
+}
+
+// identityBindingKey is the world state key of a client identity's registry entry
+func identityBindingKey(ctx contractapi.TransactionContextInterface, clientID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(identityBindingObjectType, []string{clientID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create identity binding key: %v", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== CERTIFICATE TEMPLATES ==========
+//
+// A template holds what the printed certificate says beyond its type: the formal degree title,
+// the program duration and the regulation year, plus any other printable fields. Templates are
+// keyed by certification type, department and program. IssueCertificate copies the matching
+// template onto the certificate, so editing a template never changes certificates already issued.
+
+// certTemplateObjectType prefixes the composite keys of certificate templates
+const certTemplateObjectType = "CERT_TEMPLATE"
+
+// CertificateTemplate holds the printable fields of one certification type, department and program
+type CertificateTemplate struct {
+	DocType           string            `json:"docType"`
+	CertificationType string            `json:"certificationType"`
+	Department        string            `json:"department"`
+	Program           string            `json:"program"`     // e.g. BTECH, MTECH, PHD
+	DegreeTitle       string            `json:"degreeTitle"` // e.g. Bachelor of Technology in Computer Science and Engineering
+	DurationYears     int               `json:"durationYears"`
+	RegulationYear    int               `json:"regulationYear"`
+	PrintFields       map[string]string `json:"printFields,omitempty"` // further labelled text for the printed document
+	UpdatedBy         string            `json:"updatedBy"`
+	UpdatedAt         string            `json:"updatedAt"`
+}
+
+// CertificateTemplateSnapshot is the copy of a template embedded in a certificate at issue time
+type CertificateTemplateSnapshot struct {
+	Department     string            `json:"department"`
+	Program        string            `json:"program"`
+	DegreeTitle    string            `json:"degreeTitle"`
+	DurationYears  int               `json:"durationYears"`
+	RegulationYear int               `json:"regulationYear"`
+	PrintFields    map[string]string `json:"printFields,omitempty"`
+	TemplateAt     string            `json:"templateAt"` // UpdatedAt of the template copied
+}
+
+// CreateCertificateTemplate stores a new template (NITWarangal only)
+func (s *SmartContract) CreateCertificateTemplate(ctx contractapi.TransactionContextInterface, templateJSON string) (*CertificateTemplate, error) {
+	return saveCertificateTemplate(ctx, templateJSON, false)
+}
+
+// UpdateCertificateTemplate replaces an existing template (NITWarangal only). Certificates
+// already issued keep the fields they were issued with.
+func (s *SmartContract) UpdateCertificateTemplate(ctx contractapi.TransactionContextInterface, templateJSON string) (*CertificateTemplate, error) {
+	return saveCertificateTemplate(ctx, templateJSON, true)
+}
+
+// DeleteCertificateTemplate removes a template (NITWarangal only). Certificates of its type
+// cannot be issued to the department and program until a new one is created.
+func (s *SmartContract) DeleteCertificateTemplate(ctx contractapi.TransactionContextInterface, certificationType string, department string, program string) error {
+	if _, err := requireOrgRole(ctx, "delete certificate templates", orgRoleUniversity); err != nil {
+		return err
+	}
+
+	key, err := certTemplateKey(ctx, certificationType, department, program)
+	if err != nil {
+		return err
+	}
+	existing, err := readCertificateTemplate(ctx, key)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("no %s certificate template for %s %s", certificationType, department, normalizeProgram(program))
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to delete state: %v", err)
+	}
+
+	existingJSON, err := json.Marshal(existing)
+	if err != nil {
+		return fmt.Errorf("failed to marshal certificate template: %v", err)
+	}
+	logAudit(ctx, "DeleteCertificateTemplate", "CONFIG", key, fmt.Sprintf("Certificate template deleted: %s", string(existingJSON)))
+	return nil
+}
+
+// GetCertificateTemplate returns one template
+func (s *SmartContract) GetCertificateTemplate(ctx contractapi.TransactionContextInterface, certificationType string, department string, program string) (*CertificateTemplate, error) {
+	key, err := certTemplateKey(ctx, certificationType, department, program)
+	if err != nil {
+		return nil, err
+	}
+	template, err := readCertificateTemplate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fmt.Errorf("no %s certificate template for %s %s", certificationType, department, normalizeProgram(program))
+	}
+	return template, nil
+}
+
+// ListCertificateTemplates returns every template of a certification type, optionally narrowed
+// to one department
+func (s *SmartContract) ListCertificateTemplates(ctx contractapi.TransactionContextInterface, certificationType string, department string) ([]*CertificateTemplate, error) {
+	attributes := []string{certificationType}
+	if department != "" {
+		attributes = append(attributes, department)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(certTemplateObjectType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificate templates: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	templates := []*CertificateTemplate{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var template CertificateTemplate
+		if err := json.Unmarshal(response.Value, &template); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal certificate template: %v", err)
+		}
+		templates = append(templates, &template)
+	}
+	return templates, nil
+}
+
+// saveCertificateTemplate validates and stores a template, auditing the old and new values
+func saveCertificateTemplate(ctx contractapi.TransactionContextInterface, templateJSON string, replace bool) (*CertificateTemplate, error) {
+	creatorOrg, err := requireOrgRole(ctx, "manage certificate templates", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	var template CertificateTemplate
+	if err := json.Unmarshal([]byte(templateJSON), &template); err != nil {
+		return nil, fmt.Errorf("invalid certificate template JSON: %v", err)
+	}
+	template.Program = normalizeProgram(template.Program)
+	template.DegreeTitle = strings.TrimSpace(template.DegreeTitle)
+	if template.DegreeTitle == "" {
+		return nil, fmt.Errorf("degree title is required")
+	}
+	if template.DurationYears < 1 || template.DurationYears > 10 {
+		return nil, fmt.Errorf("program duration must be between 1 and 10 years")
+	}
+	if template.RegulationYear < 1900 || template.RegulationYear > 9999 {
+		return nil, fmt.Errorf("invalid regulation year %d", template.RegulationYear)
+	}
+	for label := range template.PrintFields {
+		if strings.TrimSpace(label) == "" {
+			return nil, fmt.Errorf("print field labels cannot be empty")
+		}
+	}
+
+	key, err := certTemplateKey(ctx, template.CertificationType, template.Department, template.Program)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := readCertificateTemplate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if replace && existing == nil {
+		return nil, fmt.Errorf("no %s certificate template for %s %s", template.CertificationType, template.Department, template.Program)
+	}
+	if !replace && existing != nil {
+		return nil, fmt.Errorf("%s certificate template for %s %s already exists", template.CertificationType, template.Department, template.Program)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	template.DocType = docTypeCertTemplate
+	template.UpdatedBy = creatorOrg
+	template.UpdatedAt = txTime.UTC().Format(time.RFC3339)
+
+	storedJSON, err := json.Marshal(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal certificate template: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, storedJSON); err != nil {
+		return nil, fmt.Errorf("failed to put state: %v", err)
+	}
+
+	if existing == nil {
+		logAudit(ctx, "CreateCertificateTemplate", "CONFIG", key, fmt.Sprintf("Certificate template created: %s", string(storedJSON)))
+	} else {
+		oldJSON, err := json.Marshal(existing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal certificate template: %v", err)
+		}
+		logAudit(ctx, "UpdateCertificateTemplate", "CONFIG", key, fmt.Sprintf("Certificate template changed from %s to %s", string(oldJSON), string(storedJSON)))
+	}
+
+	return &template, nil
+}
+
+// certificateTemplateSnapshot copies the template for a student's department and program,
+// failing when there is none
+func certificateTemplateSnapshot(ctx contractapi.TransactionContextInterface, certificationType string, student *Student) (*CertificateTemplateSnapshot, error) {
+	key, err := certTemplateKey(ctx, certificationType, student.Department, student.Program)
+	if err != nil {
+		return nil, err
+	}
+	template, err := readCertificateTemplate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fmt.Errorf("no %s certificate template for %s %s; create one with CreateCertificateTemplate before issuing", certificationType, student.Department, normalizeProgram(student.Program))
+	}
+
+	return &CertificateTemplateSnapshot{
+		Department:     template.Department,
+		Program:        template.Program,
+		DegreeTitle:    template.DegreeTitle,
+		DurationYears:  template.DurationYears,
+		RegulationYear: template.RegulationYear,
+		PrintFields:    template.PrintFields,
+		TemplateAt:     template.UpdatedAt,
+	}, nil
+}
+
+// certTemplateKey builds the key of a template after validating its parts
+func certTemplateKey(ctx contractapi.TransactionContextInterface, certificationType string, department string, program string) (string, error) {
+	if strings.TrimSpace(certificationType) == "" {
+		return "", fmt.Errorf("certification type is required")
+	}
+	if strings.TrimSpace(department) == "" {
+		return "", fmt.Errorf("department is required")
+	}
+	return ctx.GetStub().CreateCompositeKey(certTemplateObjectType, []string{certificationType, department, normalizeProgram(program)})
+}
+
+// readCertificateTemplate reads a template by key, returning nil if there is none
+func readCertificateTemplate(ctx contractapi.TransactionContextInterface, key string) (*CertificateTemplate, error) {
+	templateJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if templateJSON == nil {
+		return nil, nil
+	}
+
+	var template CertificateTemplate
+	if err := json.Unmarshal(templateJSON, &template); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal certificate template: %v", err)
+	}
+	return &template, nil
+}
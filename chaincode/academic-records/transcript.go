@@ -0,0 +1,233 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== TRANSCRIPT SNAPSHOT ==========
+
+// snapshotCertificationTypes bind the student's verified records into the certificate at issuance
+var snapshotCertificationTypes = map[string]bool{
+	"TRANSCRIPT": true,
+	"DEGREE":     true,
+}
+
+// TranscriptIntegrityReport compares the records attested by a certificate with current ledger state
+type TranscriptIntegrityReport struct {
+	CertificateID    string   `json:"certificateId"`
+	Intact           bool     `json:"intact"`
+	AttestedHash     string   `json:"attestedHash"`
+	CurrentHash      string   `json:"currentHash"`
+	CoveredRecordIDs []string `json:"coveredRecordIds"`
+	MissingRecordIDs []string `json:"missingRecordIds"`
+}
+
+// VerifyTranscriptIntegrity recomputes the records hash of a TRANSCRIPT or DEGREE certificate
+// and reports whether the attested records are unchanged
+func (s *SmartContract) VerifyTranscriptIntegrity(ctx contractapi.TransactionContextInterface, certificateID string) (*TranscriptIntegrityReport, error) {
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.RecordsHash == "" {
+		return nil, fmt.Errorf("certificate %s does not attest any academic records", certificateID)
+	}
+
+	report := &TranscriptIntegrityReport{
+		CertificateID:    certificateID,
+		AttestedHash:     cert.RecordsHash,
+		CoveredRecordIDs: cert.CoveredRecordIDs,
+		MissingRecordIDs: []string{},
+	}
+	if report.CoveredRecordIDs == nil {
+		report.CoveredRecordIDs = []string{}
+	}
+
+	var records []*AcademicRecord
+	for _, recordID := range report.CoveredRecordIDs {
+		record, err := readAcademicRecord(ctx, recordID)
+		if err != nil {
+			report.MissingRecordIDs = append(report.MissingRecordIDs, recordID)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	report.CurrentHash, err = hashRecords(records, cert.HashFormat)
+	if err != nil {
+		return nil, err
+	}
+	report.Intact = len(report.MissingRecordIDs) == 0 && report.CurrentHash == report.AttestedHash
+
+	return report, nil
+}
+
+// snapshotVerifiedRecords returns the IDs and combined hash of a student's verified records
+func snapshotVerifiedRecords(ctx contractapi.TransactionContextInterface, studentID string) ([]string, string, error) {
+	all, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var records []*AcademicRecord
+	for _, record := range all {
+		if record.Status == recordStatusVerified {
+			records = append(records, record)
+		}
+	}
+
+	hash, err := hashRecords(records, hashFormatCanonical)
+	if err != nil {
+		return nil, "", err
+	}
+
+	recordIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		recordIDs = append(recordIDs, record.RecordID)
+	}
+	return recordIDs, hash, nil
+}
+
+// hashRecords sorts records by semester and hashes their canonical JSON, or for hashes made
+// before canonical JSON (an empty format) the JSON encoding/json emits in struct field order
+func hashRecords(records []*AcademicRecord, format string) (string, error) {
+	sorted := make([]*AcademicRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sameSemester(sorted[i], sorted[j]) {
+			return sorted[i].RecordID < sorted[j].RecordID
+		}
+		return semesterAfter(sorted[j], sorted[i])
+	})
+
+	switch format {
+	case hashFormatCanonical:
+		return canonicalHash(sorted)
+	case "":
+		recordsJSON, err := json.Marshal(sorted)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal records: %v", err)
+		}
+		hash := sha256.Sum256(recordsJSON)
+		return hex.EncodeToString(hash[:]), nil
+	}
+	return "", fmt.Errorf("unknown hash format %q", format)
+}
+
+// ========== TRANSCRIPT VIEW ==========
+
+// repeatMarker flags a later attempt of a course on the transcript
+const repeatMarker = "R"
+
+// TranscriptCourse is one course attempt as it appears on the transcript
+type TranscriptCourse struct {
+	CourseGrade
+	Attempt int    `json:"attempt"`
+	Marker  string `json:"marker,omitempty"` // "R" on a repeat attempt
+	Counted bool   `json:"counted"`          // whether this attempt counts toward CGPA
+}
+
+// TranscriptSemester is one approved or verified record on the transcript
+type TranscriptSemester struct {
+	RecordID   string              `json:"recordId"`
+	Department string              `json:"department"`
+	Semester   int                 `json:"semester"`
+	Year       int                 `json:"year"`
+	Status     string              `json:"status"`
+	SGPA       float64             `json:"sgpa"`
+	Courses    []*TranscriptCourse `json:"courses"`
+}
+
+// Transcript lists every attempt of every course, semester by semester, with the CGPA that
+// counts one attempt of each repeated course
+type Transcript struct {
+	StudentID    string                `json:"studentId"`
+	RepeatPolicy string                `json:"repeatPolicy"`
+	CGPA         float64               `json:"cgpa"`
+	TotalCredits float64               `json:"totalCredits"`
+	Semesters    []*TranscriptSemester `json:"semesters"`
+}
+
+// GetStudentTranscript returns the student's approved and verified records as a transcript.
+// Failed attempts stay visible; repeats carry the "R" marker and only the attempt chosen by
+// the repeat policy counts toward CGPA.
+func (s *SmartContract) GetStudentTranscript(ctx contractapi.TransactionContextInterface, studentID string) (*Transcript, error) {
+	if err := assertRecordAccess(ctx, studentID, accessScopeRecords); err != nil {
+		return nil, err
+	}
+	if err := assertUnloggedReadAllowed(ctx, "GetStudentRecordsLogged"); err != nil {
+		return nil, err
+	}
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := getDepartmentTransfers(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := cgpaRecords(ctx, studentID, nil)
+	if err != nil {
+		return nil, err
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := &Transcript{
+		StudentID:    studentID,
+		RepeatPolicy: config.RepeatPolicy,
+		Semesters:    []*TranscriptSemester{},
+	}
+	transcript.CGPA, transcript.TotalCredits = calculateCGPA(records, config.RepeatPolicy)
+
+	// countedAttempts keeps one attempt per course; find which (record, course) pair it was
+	counted := map[string]CourseGrade{}
+	for _, course := range countedAttempts(records, config.RepeatPolicy) {
+		counted[course.CourseCode] = course
+	}
+	countedIn := map[string]string{}
+	for _, record := range records {
+		for _, course := range record.Courses {
+			if course == counted[course.CourseCode] {
+				countedIn[course.CourseCode] = record.RecordID
+			}
+		}
+	}
+
+	attempts := map[string]int{}
+	for _, record := range records {
+		semester := &TranscriptSemester{
+			RecordID:   record.RecordID,
+			Department: recordDepartment(record, student, transfers),
+			Semester:   record.Semester,
+			Year:       record.Year,
+			Status:     record.Status,
+			SGPA:       record.SGPA,
+			Courses:    []*TranscriptCourse{},
+		}
+		for _, course := range record.Courses {
+			attempts[course.CourseCode]++
+			entry := &TranscriptCourse{
+				CourseGrade: course,
+				Attempt:     attempts[course.CourseCode],
+				Counted:     countedIn[course.CourseCode] == record.RecordID,
+			}
+			if entry.Attempt > 1 {
+				entry.Marker = repeatMarker
+			}
+			semester.Courses = append(semester.Courses, entry)
+		}
+		transcript.Semesters = append(transcript.Semesters, semester)
+	}
+
+	return transcript, nil
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== INTER-INSTITUTION TRANSFER ==========
+//
+// A student moving to a partner institute whose chaincode runs on the same channel takes their
+// verified history along. InitiateTransferOut serializes the student and their VERIFIED records
+// as canonical JSON, hashes it and calls AcceptTransferIn on the target chaincode in the same
+// transaction; only when that call succeeds is the student marked TRANSFERRED_OUT. An error from
+// the target fails the whole transaction, so neither ledger changes. AcceptTransferIn is the
+// receiving side of the same exchange: the caller must be a university admin whose MSP is
+// registered for the sending institution, and the received assets keep the payload hash as
+// their provenance. The same identity reaches both sides, so InitiateTransferOut requires an
+// admin too.
+
+// transferFormat identifies the layout of TransferPayload
+const transferFormat = "academic-records-transfer/v1"
+
+// acceptTransferInFunction is invoked on the target chaincode
+const acceptTransferInFunction = "AcceptTransferIn"
+
+// TransferPayload is the history handed to the target institution
+type TransferPayload struct {
+	Format              string            `json:"format"`
+	SourceInstitutionID string            `json:"sourceInstitutionId"`
+	TargetInstitutionID string            `json:"targetInstitutionId"`
+	SourceTxID          string            `json:"sourceTxId"`
+	TransferredAt       string            `json:"transferredAt"`
+	Student             *Student          `json:"student"` // public fields only; PII stays with the source
+	Records             []*AcademicRecord `json:"records"` // VERIFIED records, oldest semester first
+}
+
+// TransferEnvelope carries a payload with the hash it must match
+type TransferEnvelope struct {
+	Format      string `json:"format"`
+	PayloadHash string `json:"payloadHash"` // SHA-256 of Payload
+	Payload     string `json:"payload"`     // canonical JSON of a TransferPayload
+}
+
+// TransferResult reports one side of a transfer
+type TransferResult struct {
+	StudentID           string   `json:"studentId"`
+	SourceInstitutionID string   `json:"sourceInstitutionId"`
+	TargetInstitutionID string   `json:"targetInstitutionId"`
+	TargetChaincode     string   `json:"targetChaincode,omitempty"`
+	PayloadHash         string   `json:"payloadHash"`
+	RecordIDs           []string `json:"recordIds"`
+}
+
+// InitiateTransferOut hands a student's verified records to a partner institution's chaincode
+// and marks the student TRANSFERRED_OUT (NITWarangal admins only). It refuses while any record is still
+// in the workflow, and fails entirely if the target chaincode rejects the transfer.
+func (s *SmartContract) InitiateTransferOut(ctx contractapi.TransactionContextInterface, studentID string, targetChaincode string, targetInstitution string) (*TransferResult, error) {
+	if _, err := requireOrgRole(ctx, "transfer students out", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("transferring students out requires the %s=true attribute: %v", adminAttribute, err)
+	}
+	targetChaincode = strings.TrimSpace(targetChaincode)
+	if targetChaincode == "" {
+		return nil, fmt.Errorf("target chaincode is required")
+	}
+
+	student, err := readStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := closedStudentError(student); err != nil {
+		return nil, err
+	}
+	if studentFrozen(student) {
+		return nil, frozenStudentError(studentID)
+	}
+	sourceInstitutionID := assetInstitution(student.InstitutionID)
+
+	target, err := readInstitution(ctx, targetInstitution)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("institution %s is not registered", targetInstitution)
+	}
+	if target.InstitutionID == sourceInstitutionID {
+		return nil, fmt.Errorf("student %s already belongs to institution %s", studentID, target.InstitutionID)
+	}
+
+	records, err := getStudentRecordList(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	verified := []*AcademicRecord{}
+	pending := []string{}
+	for _, record := range records {
+		switch record.Status {
+		case recordStatusVerified:
+			verified = append(verified, record)
+		case recordStatusSuperseded, recordStatusRejected:
+		default:
+			pending = append(pending, record.RecordID)
+		}
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("student %s has records still in the workflow: %s; verify or reject them before transferring", studentID, strings.Join(pending, ", "))
+	}
+	sortRecordsBySemester(verified, false)
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	payload := &TransferPayload{
+		Format:              transferFormat,
+		SourceInstitutionID: sourceInstitutionID,
+		TargetInstitutionID: target.InstitutionID,
+		SourceTxID:          ctx.GetStub().GetTxID(),
+		TransferredAt:       now,
+		Student:             student,
+		Records:             verified,
+	}
+	payloadJSON, err := canonicalJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(payloadJSON)
+	envelopeJSON, err := json.Marshal(TransferEnvelope{Format: transferFormat, PayloadHash: hex.EncodeToString(hash[:]), Payload: string(payloadJSON)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transfer envelope: %v", err)
+	}
+
+	// Same channel, so the target's writes commit or fail with this transaction
+	response := ctx.GetStub().InvokeChaincode(targetChaincode, [][]byte{[]byte(acceptTransferInFunction), envelopeJSON}, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("target chaincode %s refused the transfer: %s", targetChaincode, response.Message)
+	}
+	var accepted TransferResult
+	if err := json.Unmarshal(response.Payload, &accepted); err != nil {
+		return nil, fmt.Errorf("target chaincode %s returned an invalid response: %v", targetChaincode, err)
+	}
+	if accepted.PayloadHash != hex.EncodeToString(hash[:]) {
+		return nil, fmt.Errorf("target chaincode %s acknowledged payload %s, not %s", targetChaincode, accepted.PayloadHash, hex.EncodeToString(hash[:]))
+	}
+
+	student.TransferredTo = target.InstitutionID
+	student.TransferChaincode = targetChaincode
+	student.TransferHash = accepted.PayloadHash
+	if err := setStudentStatus(ctx, student, studentStatusTransferredOut, fmt.Sprintf("Transferred to %s via %s", target.InstitutionID, targetChaincode)); err != nil {
+		return nil, err
+	}
+
+	result := &TransferResult{
+		StudentID:           studentID,
+		SourceInstitutionID: sourceInstitutionID,
+		TargetInstitutionID: target.InstitutionID,
+		TargetChaincode:     targetChaincode,
+		PayloadHash:         accepted.PayloadHash,
+		RecordIDs:           []string{},
+	}
+	for _, record := range verified {
+		result.RecordIDs = append(result.RecordIDs, record.RecordID)
+	}
+
+	logAudit(ctx, "InitiateTransferOut", "STUDENT", studentID, fmt.Sprintf("Transferred to %s via chaincode %s: %d verified records, payload sha256 %s", target.InstitutionID, targetChaincode, len(verified), accepted.PayloadHash))
+
+	if err := emitLifecycleEvent(ctx, eventStudentStatusChanged, "STUDENT", studentID, student.Status); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AcceptTransferIn receives a student transferred by a partner institution's chaincode. The
+// caller must be a university admin whose MSP is registered here for the sending institution,
+// the target institution must be registered too, and the payload must match its hash. Any failure fails the transaction,
+// including the sender's.
+func (s *SmartContract) AcceptTransferIn(ctx contractapi.TransactionContextInterface, envelopeJSON string) (*TransferResult, error) {
+	payload, payloadHash, err := parseTransfer(envelopeJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	creatorOrg, err := requireOrgRole(ctx, "accept transfers", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetClientIdentity().AssertAttributeValue(adminAttribute, "true"); err != nil {
+		return nil, fmt.Errorf("accepting transfers requires the %s=true attribute: %v", adminAttribute, err)
+	}
+	// Only an explicit registration counts; the founding institution's bootstrap does not apply
+	callerID, err := institutionOfMSP(ctx, creatorOrg)
+	if err != nil {
+		return nil, err
+	}
+	if callerID == "" {
+		return nil, fmt.Errorf("FORBIDDEN: org %s is not registered for any institution", creatorOrg)
+	}
+	if callerID != payload.SourceInstitutionID {
+		return nil, fmt.Errorf("FORBIDDEN: %s acts for institution %s, not the sending institution %s", creatorOrg, callerID, payload.SourceInstitutionID)
+	}
+	if payload.TargetInstitutionID == payload.SourceInstitutionID {
+		return nil, fmt.Errorf("a transfer must be between two institutions")
+	}
+	target, err := readInstitution(ctx, payload.TargetInstitutionID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("institution %s is not registered", payload.TargetInstitutionID)
+	}
+
+	student := payload.Student
+	studentID := student.StudentID
+	if err := assertKeyUnused(ctx, studentID, docTypeStudent); err != nil {
+		return nil, err
+	}
+	for _, record := range payload.Records {
+		if err := assertKeyUnused(ctx, record.RecordID, docTypeRecord); err != nil {
+			return nil, err
+		}
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	// The student continues at the target institution
+	stampImported(&student.CreatedAt, &student.OriginalCreatedAt, &student.ImportedFrom, now, payloadHash)
+	student.DocType = docTypeStudent
+	student.InstitutionID = target.InstitutionID
+	student.Status = studentStatusActive
+	student.TransferredFrom = payload.SourceInstitutionID
+	student.TransferredTo = ""
+	student.TransferChaincode = ""
+	student.TransferHash = payloadHash
+	student.Name, student.Email, student.Phone, student.Address = "", "", "", ""
+	if err := putTransferredAsset(ctx, studentID, student); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "student~status", []string{student.Status, studentID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "student~department", []string{student.Department, studentID}); err != nil {
+		return nil, err
+	}
+	if err := recordStatusChange(ctx, studentID, "", student.Status, fmt.Sprintf("Transferred in from %s, payload sha256 %s", payload.SourceInstitutionID, payloadHash)); err != nil {
+		return nil, err
+	}
+
+	result := &TransferResult{
+		StudentID:           studentID,
+		SourceInstitutionID: payload.SourceInstitutionID,
+		TargetInstitutionID: target.InstitutionID,
+		PayloadHash:         payloadHash,
+		RecordIDs:           []string{},
+	}
+	for _, record := range payload.Records {
+		stampImported(&record.CreatedAt, &record.OriginalCreatedAt, &record.ImportedFrom, now, payloadHash)
+		record.InstitutionID = target.InstitutionID
+		// Graded on the sender's scale, which this ledger does not hold
+		record.GradeScaleYear = 0
+		if err := putTransferredAsset(ctx, record.RecordID, record); err != nil {
+			return nil, err
+		}
+		if err := putInstructorIndexes(ctx, record, record.Courses); err != nil {
+			return nil, err
+		}
+		if err := putRecordIndexes(ctx, record); err != nil {
+			return nil, err
+		}
+		if err := setRecordEndorsementPolicy(ctx, record); err != nil {
+			return nil, err
+		}
+		result.RecordIDs = append(result.RecordIDs, record.RecordID)
+	}
+
+	logAudit(ctx, "AcceptTransferIn", "STUDENT", studentID, fmt.Sprintf("Transferred in from %s by %s (source tx %s): %d verified records, payload sha256 %s", payload.SourceInstitutionID, creatorOrg, payload.SourceTxID, len(result.RecordIDs), payloadHash))
+
+	return result, nil
+}
+
+// parseTransfer checks an envelope against its hash and the payload against itself
+func parseTransfer(envelopeJSON string) (*TransferPayload, string, error) {
+	var envelope TransferEnvelope
+	if err := json.Unmarshal([]byte(envelopeJSON), &envelope); err != nil {
+		return nil, "", fmt.Errorf("invalid transfer JSON: %v", err)
+	}
+	if envelope.Format != transferFormat {
+		return nil, "", fmt.Errorf("unsupported transfer format %q: expected %s", envelope.Format, transferFormat)
+	}
+	hash := sha256.Sum256([]byte(envelope.Payload))
+	if hex.EncodeToString(hash[:]) != envelope.PayloadHash {
+		return nil, "", fmt.Errorf("transfer payload does not match its hash %s", envelope.PayloadHash)
+	}
+
+	var payload TransferPayload
+	if err := json.Unmarshal([]byte(envelope.Payload), &payload); err != nil {
+		return nil, "", fmt.Errorf("invalid transfer payload: %v", err)
+	}
+	if payload.Format != transferFormat {
+		return nil, "", fmt.Errorf("unsupported transfer payload format %q", payload.Format)
+	}
+	if payload.Student == nil || payload.Student.StudentID == "" {
+		return nil, "", fmt.Errorf("transfer payload names no student")
+	}
+	for _, record := range payload.Records {
+		if record.StudentID != payload.Student.StudentID {
+			return nil, "", fmt.Errorf("record %s belongs to student %s, not %s", record.RecordID, record.StudentID, payload.Student.StudentID)
+		}
+		if record.Status != recordStatusVerified {
+			return nil, "", fmt.Errorf("record %s is %s; only VERIFIED records can be transferred", record.RecordID, record.Status)
+		}
+	}
+	return &payload, envelope.PayloadHash, nil
+}
+
+// putTransferredAsset writes a received asset. The caller acts for the sending institution, so
+// the institution check of the put helpers, which would refuse it, does not apply.
+func putTransferredAsset(ctx contractapi.TransactionContextInterface, key string, asset interface{}) error {
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", key, err)
+	}
+	if err := ctx.GetStub().PutState(key, assetJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
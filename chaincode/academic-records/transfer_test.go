@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// transferEnvelopeJSON returns a correctly hashed envelope moving student CS21001 with one
+// verified record from sourceID to targetID
+func transferEnvelopeJSON(t *testing.T, sourceID string, targetID string) string {
+	t.Helper()
+	payloadJSON, err := canonicalJSON(&TransferPayload{
+		Format:              transferFormat,
+		SourceInstitutionID: sourceID,
+		TargetInstitutionID: targetID,
+		SourceTxID:          "source-tx",
+		TransferredAt:       ledgertest.Epoch.Format(time.RFC3339),
+		Student:             &Student{StudentID: "CS21001", Department: "CSE", Program: "B.Tech", Status: studentStatusActive},
+		Records:             []*AcademicRecord{{RecordID: "REC1", StudentID: "CS21001", Department: "CSE", Semester: 1, Year: 2023, Status: recordStatusVerified}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build transfer payload: %v", err)
+	}
+	hash := sha256.Sum256(payloadJSON)
+	envelopeJSON, err := json.Marshal(TransferEnvelope{Format: transferFormat, PayloadHash: hex.EncodeToString(hash[:]), Payload: string(payloadJSON)})
+	if err != nil {
+		t.Fatalf("failed to marshal transfer envelope: %v", err)
+	}
+	return string(envelopeJSON)
+}
+
+func TestAcceptTransferInRequiresASendingUniversityAdmin(t *testing.T) {
+	l := newTestLedger(t)
+	iithAdmin := testAdmin.InMSP("IITHUniversityMSP")
+	iithExamCell := testExamCell.InMSP("IITHDepartmentsMSP")
+	iithVerifier := testVerifier.InMSP("IITHVerifiersMSP")
+	unregisteredAdmin := testAdmin.InMSP("OtherUniversityMSP")
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.UniversityOrgs = append(config.UniversityOrgs, iithAdmin.MSPID, unregisteredAdmin.MSPID)
+		config.DepartmentOrgs = append(config.DepartmentOrgs, iithExamCell.MSPID)
+		config.VerifierOrgs = append(config.VerifierOrgs, iithVerifier.MSPID)
+	})
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, saveInstitutionCall(t, l, false, testInstitution("IITH", iithAdmin.MSPID, iithExamCell.MSPID, iithVerifier.MSPID)))
+
+	accept := func(ctx contractapi.TransactionContextInterface) (*TransferResult, error) {
+		return l.contract.AcceptTransferIn(ctx, transferEnvelopeJSON(t, "IITH", defaultInstitutionID))
+	}
+	tests := []struct {
+		name    string
+		as      *ledgertest.Identity
+		wantErr string
+	}{
+		{"department of the sender", iithExamCell, "only the university can accept transfers"},
+		{"verifier of the sender", iithVerifier, "only the university can accept transfers"},
+		{"sender without the admin attribute", iithAdmin.With(map[string]string{"admin": ""}), "accepting transfers requires the admin=true attribute"},
+		{"university org no institution lists", unregisteredAdmin, "FORBIDDEN: org OtherUniversityMSP is not registered for any institution"},
+		{"admin of another institution", testAdmin, "acts for institution NITW, not the sending institution IITH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := ledgertest.InvokeError(t, l, tt.as, ledgertest.TxOptions{}, tt.wantErr, accept)
+			if ws.Put("CS21001") != nil || ws.Put("REC1") != nil {
+				t.Errorf("refused transfer wrote the student or record")
+			}
+		})
+	}
+
+	result := ledgertest.MustInvoke(t, l, iithAdmin, ledgertest.TxOptions{}, accept)
+	if result.TargetInstitutionID != defaultInstitutionID || len(result.RecordIDs) != 1 {
+		t.Errorf("accepted transfer = %+v", result)
+	}
+	if student := l.storedStudent(t, "CS21001"); student.InstitutionID != defaultInstitutionID || student.TransferredFrom != "IITH" {
+		t.Errorf("transferred student belongs to %q from %q", student.InstitutionID, student.TransferredFrom)
+	}
+}
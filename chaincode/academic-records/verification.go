@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== VERIFICATION EVENTS ==========
+//
+// Each RecordVerification writes its own verify~certID~epoch~txTime~txID key instead of
+// incrementing a counter on the certificate, so concurrent verifications of the same
+// certificate never conflict. The count is rolled up per epoch: RollupVerifications
+// folds the current epoch's events into the roll-up total and opens the next epoch,
+// so counting only has to walk events recorded since the last roll-up. Epochs only grow
+// and txTime is zero-padded, so the keys of a certificate are in chronological order.
+
+// VerificationEvent is one registered verification of a certificate
+type VerificationEvent struct {
+	CertificateID string `json:"certificateId"`
+	TxID          string `json:"txId"`
+	RequestedBy   string `json:"requestedBy"`
+	RequesterOrg  string `json:"requesterOrg"`
+	RequesterID   string `json:"requesterId"`
+	Outcome       string `json:"outcome"`
+	Verified      bool   `json:"verified"`
+	Timestamp     string `json:"timestamp"`
+}
+
+// VerificationRollup holds the number of successful verifications in closed epochs
+type VerificationRollup struct {
+	CertificateID string `json:"certificateId"`
+	Count         int    `json:"count"`
+	Epoch         int    `json:"epoch"` // epoch new events are written to
+	RolledUpAt    string `json:"rolledUpAt,omitempty"`
+}
+
+// PaginatedVerificationHistory holds one page of verification events and the bookmark for the next page
+type PaginatedVerificationHistory struct {
+	Events       []*VerificationEvent `json:"events"`
+	FetchedCount int32                `json:"fetchedCount"`
+	Bookmark     string               `json:"bookmark"`
+}
+
+// GetVerificationHistory returns who verified a certificate and when, oldest first (NITWarangal only).
+// Verifications before and after a revocation are both included.
+func (s *SmartContract) GetVerificationHistory(ctx contractapi.TransactionContextInterface, certificateID string, pageSize int32, bookmark string) (*PaginatedVerificationHistory, error) {
+	if _, err := requireOrgRole(ctx, "view verification history", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	if _, err := readCertificate(ctx, certificateID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination("verify", []string{certificateID}, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verifications: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	events := []*VerificationEvent{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var event VerificationEvent
+		if err := json.Unmarshal(response.Value, &event); err != nil {
+			continue
+		}
+		events = append(events, &event)
+	}
+
+	return &PaginatedVerificationHistory{
+		Events:       events,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}, nil
+}
+
+// GetVerificationCount returns the number of successful verifications of a certificate
+func (s *SmartContract) GetVerificationCount(ctx contractapi.TransactionContextInterface, certificateID string) (int, error) {
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return 0, err
+	}
+	return countVerifications(ctx, cert)
+}
+
+// RollupVerifications folds the current epoch's verification events into the stored total
+// (NITWarangal only); run it periodically for frequently verified certificates
+func (s *SmartContract) RollupVerifications(ctx contractapi.TransactionContextInterface, certificateID string) (*VerificationRollup, error) {
+	if _, err := requireOrgRole(ctx, "roll up verifications", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	cert, err := readCertificate(ctx, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	rollup, err := getVerificationRollup(ctx, cert)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := countEpochVerifications(ctx, certificateID, rollup.Epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	rollup.Count += pending
+	rollup.Epoch++
+	rollup.RolledUpAt = txTime.UTC().Format(time.RFC3339)
+
+	if err := putVerificationRollup(ctx, rollup); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RollupVerifications", "CERTIFICATE", certificateID, fmt.Sprintf("Rolled up %d verifications, total %d", pending, rollup.Count))
+
+	return rollup, nil
+}
+
+// recordVerificationEvent stores a verification under its own key
+func recordVerificationEvent(ctx contractapi.TransactionContextInterface, cert *Certificate, requestedBy string, result *CertificateCheckResult) error {
+	org, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	requesterID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	// Reading the roll-up only conflicts with a concurrent roll-up, not with other verifications
+	rollup, err := getVerificationRollup(ctx, cert)
+	if err != nil {
+		return err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	event := VerificationEvent{
+		CertificateID: cert.CertificateID,
+		TxID:          txID,
+		RequestedBy:   requestedBy,
+		RequesterOrg:  org,
+		RequesterID:   requesterID,
+		Outcome:       result.Outcome,
+		Verified:      result.Verified,
+		Timestamp:     result.CheckedAt,
+	}
+
+	eventKey, err := ctx.GetStub().CreateCompositeKey("verify", []string{cert.CertificateID, epochKey(rollup.Epoch), fmt.Sprintf("%019d", txTime.UnixNano()), txID})
+	if err != nil {
+		return fmt.Errorf("failed to create verification key: %v", err)
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification event: %v", err)
+	}
+	if err := ctx.GetStub().PutState(eventKey, eventJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
+
+// countVerifications adds the verifications recorded since the last roll-up to the roll-up total
+func countVerifications(ctx contractapi.TransactionContextInterface, cert *Certificate) (int, error) {
+	rollup, err := getVerificationRollup(ctx, cert)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := countEpochVerifications(ctx, cert.CertificateID, rollup.Epoch)
+	if err != nil {
+		return 0, err
+	}
+	return rollup.Count + pending, nil
+}
+
+// countEpochVerifications counts the successful verifications recorded in one epoch
+func countEpochVerifications(ctx contractapi.TransactionContextInterface, certificateID string, epoch int) (int, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("verify", []string{certificateID, epochKey(epoch)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to query verifications: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	count := 0
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return 0, err
+		}
+
+		var event VerificationEvent
+		if err := json.Unmarshal(response.Value, &event); err != nil {
+			continue
+		}
+		if event.Verified {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// getVerificationRollup reads a certificate's roll-up. Certificates verified before per-event
+// keys existed start from their stored VerificationCount.
+func getVerificationRollup(ctx contractapi.TransactionContextInterface, cert *Certificate) (*VerificationRollup, error) {
+	rollupKey, err := ctx.GetStub().CreateCompositeKey("verifyrollup", []string{cert.CertificateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create roll-up key: %v", err)
+	}
+	rollupJSON, err := ctx.GetStub().GetState(rollupKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if rollupJSON == nil {
+		return &VerificationRollup{CertificateID: cert.CertificateID, Count: cert.VerificationCount}, nil
+	}
+
+	var rollup VerificationRollup
+	if err := json.Unmarshal(rollupJSON, &rollup); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verification roll-up: %v", err)
+	}
+	return &rollup, nil
+}
+
+// putVerificationRollup saves a certificate's roll-up
+func putVerificationRollup(ctx contractapi.TransactionContextInterface, rollup *VerificationRollup) error {
+	rollupKey, err := ctx.GetStub().CreateCompositeKey("verifyrollup", []string{rollup.CertificateID})
+	if err != nil {
+		return fmt.Errorf("failed to create roll-up key: %v", err)
+	}
+	rollupJSON, err := json.Marshal(rollup)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification roll-up: %v", err)
+	}
+	if err := ctx.GetStub().PutState(rollupKey, rollupJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
+
+// epochKey zero-pads an epoch so epochs sort numerically within the verify index
+func epochKey(epoch int) string {
+	return fmt.Sprintf("%06d", epoch)
+}
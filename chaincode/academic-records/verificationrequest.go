@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== VERIFICATION REQUESTS ==========
+
+// Verification request statuses
+const (
+	verificationRequestPending  = "PENDING"
+	verificationRequestVerified = "VERIFIED"
+	verificationRequestInvalid  = "INVALID"
+	verificationRequestExpired  = "EXPIRED"
+)
+
+// gatewayAttribute marks an external-facing gateway identity allowed to file verification requests
+const gatewayAttribute = "verificationGateway"
+
+// PaginatedVerificationRequests holds one page of verification requests and the bookmark for the next page
+type PaginatedVerificationRequests struct {
+	Requests     []*VerificationRequest `json:"requests"`
+	FetchedCount int32                  `json:"fetchedCount"`
+	Bookmark     string                 `json:"bookmark"`
+}
+
+// ExpiryResult reports the requests expired by one ExpireStaleRequests call
+type ExpiryResult struct {
+	Expired []string `json:"expired"`
+	More    bool     `json:"more"` // true when the batch limit was hit; call again to continue
+}
+
+// CreateVerificationRequest records an external query about a certificate (Verifiers or a gateway identity)
+func (s *SmartContract) CreateVerificationRequest(ctx contractapi.TransactionContextInterface, certificateID string, certHash string, requestedBy string) (*VerificationRequest, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+
+	verifier, err := orgHasRole(ctx, creatorOrg, orgRoleVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if !verifier {
+		if err := ctx.GetClientIdentity().AssertAttributeValue(gatewayAttribute, "true"); err != nil {
+			return nil, fmt.Errorf("only Verifiers or a verification gateway can create verification requests")
+		}
+	}
+
+	if certificateID == "" {
+		return nil, fmt.Errorf("certificate ID is required")
+	}
+	if requestedBy == "" {
+		return nil, fmt.Errorf("requestedBy is required")
+	}
+
+	requesterID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	policy, err := getCertificatePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deterministic on every endorser
+	requestID := "VREQ_" + ctx.GetStub().GetTxID()
+
+	request := VerificationRequest{
+		DocType:         docTypeVerificationReq,
+		RequestID:       requestID,
+		CertificateID:   certificateID,
+		CertificateHash: certHash,
+		RequestedBy:     requestedBy,
+		RequesterOrg:    creatorOrg,
+		RequesterID:     requesterID,
+		RequestedAt:     txTime.UTC().Format(time.RFC3339),
+		ExpiresAt:       txTime.AddDate(0, 0, policy.verificationRequestTTL()).UTC().Format(time.RFC3339),
+		Status:          verificationRequestPending,
+	}
+
+	if err := putVerificationRequest(ctx, &request); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "vreq~status~cert", []string{request.Status, certificateID, requestID}); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "vreq~cert", []string{certificateID, requestID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "CreateVerificationRequest", "VERIFICATION_REQUEST", requestID, fmt.Sprintf("%s asked about certificate %s", requestedBy, certificateID))
+
+	return &request, nil
+}
+
+// RespondToVerificationRequest resolves a pending request as VERIFIED or INVALID (NITWarangal only)
+func (s *SmartContract) RespondToVerificationRequest(ctx contractapi.TransactionContextInterface, requestID string, outcome string, reason string) (*VerificationRequest, error) {
+	creatorOrg, err := requireOrgRole(ctx, "respond to verification requests", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome != verificationRequestVerified && outcome != verificationRequestInvalid {
+		return nil, fmt.Errorf("invalid outcome %q: must be VERIFIED or INVALID", outcome)
+	}
+	if outcome == verificationRequestInvalid && reason == "" {
+		return nil, fmt.Errorf("a reason is required when marking a request INVALID")
+	}
+
+	request, err := readVerificationRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != verificationRequestPending {
+		return nil, fmt.Errorf("cannot respond to verification request in %s status", request.Status)
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	if verificationRequestExpiredAt(request, txTime) {
+		return nil, fmt.Errorf("verification request %s expired at %s", requestID, request.ExpiresAt)
+	}
+
+	if err := deleteIndex(ctx, "vreq~status~cert", []string{request.Status, request.CertificateID, requestID}); err != nil {
+		return nil, err
+	}
+
+	request.Status = outcome
+	request.RespondedBy = creatorOrg
+	request.RespondedAt = txTime.UTC().Format(time.RFC3339)
+	request.ResponseReason = reason
+
+	if err := putVerificationRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	if err := putIndex(ctx, "vreq~status~cert", []string{request.Status, request.CertificateID, requestID}); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RespondToVerificationRequest", "VERIFICATION_REQUEST", requestID, fmt.Sprintf("Marked %s: %s", outcome, reason))
+
+	return request, nil
+}
+
+// ExpireStaleRequests marks PENDING requests made before the RFC3339 cutoff as EXPIRED
+// (NITWarangal only). At most maxPageSize requests are expired per call.
+func (s *SmartContract) ExpireStaleRequests(ctx contractapi.TransactionContextInterface, before string) (*ExpiryResult, error) {
+	if _, err := requireOrgRole(ctx, "expire verification requests", orgRoleUniversity); err != nil {
+		return nil, err
+	}
+
+	cutoff, err := time.Parse(time.RFC3339, before)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cutoff %q: must be RFC3339", before)
+	}
+
+	// Pagination is not available in submit transactions, so walk the index and stop at the batch limit
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey("vreq~status~cert", []string{verificationRequestPending})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verification requests: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var stale []*VerificationRequest
+	result := &ExpiryResult{Expired: []string{}}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) < 3 {
+			continue
+		}
+
+		request, err := readVerificationRequest(ctx, compositeKeyParts[2])
+		if err != nil {
+			continue
+		}
+		requestedAt, err := time.Parse(time.RFC3339, request.RequestedAt)
+		if err != nil || !requestedAt.Before(cutoff) {
+			continue
+		}
+
+		if int32(len(stale)) == maxPageSize {
+			result.More = true
+			break
+		}
+		stale = append(stale, request)
+	}
+
+	for _, request := range stale {
+		if err := deleteIndex(ctx, "vreq~status~cert", []string{request.Status, request.CertificateID, request.RequestID}); err != nil {
+			return nil, err
+		}
+
+		request.Status = verificationRequestExpired
+		if err := putVerificationRequest(ctx, request); err != nil {
+			return nil, err
+		}
+		if err := putIndex(ctx, "vreq~status~cert", []string{request.Status, request.CertificateID, request.RequestID}); err != nil {
+			return nil, err
+		}
+
+		logAudit(ctx, "ExpireStaleRequests", "VERIFICATION_REQUEST", request.RequestID, fmt.Sprintf("Expired: pending since %s, cutoff %s", request.RequestedAt, before))
+		result.Expired = append(result.Expired, request.RequestID)
+	}
+
+	return result, nil
+}
+
+// GetVerificationRequest retrieves a single verification request
+func (s *SmartContract) GetVerificationRequest(ctx contractapi.TransactionContextInterface, requestID string) (*VerificationRequest, error) {
+	if err := assertCanViewVerificationRequests(ctx); err != nil {
+		return nil, err
+	}
+	return readVerificationRequest(ctx, requestID)
+}
+
+// GetVerificationRequests lists verification requests filtered by status and/or certificate;
+// empty filters match everything. Unless includeExpired is set, EXPIRED requests and PENDING
+// requests past their expiry are left out.
+func (s *SmartContract) GetVerificationRequests(ctx contractapi.TransactionContextInterface, status string, certificateID string, includeExpired bool, pageSize int32, bookmark string) (*PaginatedVerificationRequests, error) {
+	if err := assertCanViewVerificationRequests(ctx); err != nil {
+		return nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	// vreq~status~cert serves status and status+certificate filters, vreq~cert the certificate-only filter
+	index := "vreq~status~cert"
+	attributes := []string{}
+	switch {
+	case status != "" && certificateID != "":
+		attributes = []string{status, certificateID}
+	case status != "":
+		attributes = []string{status}
+	case certificateID != "":
+		index = "vreq~cert"
+		attributes = []string{certificateID}
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(index, attributes, normalizePageSize(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verification requests: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	requests := []*VerificationRequest{}
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(response.Key)
+		if err != nil || len(compositeKeyParts) == 0 {
+			continue
+		}
+
+		request, err := readVerificationRequest(ctx, compositeKeyParts[len(compositeKeyParts)-1])
+		if err != nil {
+			continue
+		}
+		if !includeExpired && (request.Status == verificationRequestExpired || verificationRequestExpiredAt(request, txTime)) {
+			continue
+		}
+		requests = append(requests, request)
+	}
+
+	return &PaginatedVerificationRequests{
+		Requests:     requests,
+		FetchedCount: metadata.FetchedRecordsCount,
+		Bookmark:     metadata.Bookmark,
+	}, nil
+}
+
+// verificationRequestExpiredAt reports whether a pending request has passed its ExpiresAt
+func verificationRequestExpiredAt(request *VerificationRequest, at time.Time) bool {
+	if request.Status != verificationRequestPending || request.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, request.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return at.After(expiresAt)
+}
+
+// assertCanViewVerificationRequests limits the request queue to NITWarangal and Verifiers
+func assertCanViewVerificationRequests(ctx contractapi.TransactionContextInterface) error {
+	_, err := requireOrgRole(ctx, "view verification requests", orgRoleUniversity, orgRoleVerifier)
+	return err
+}
+
+// readVerificationRequest loads a verification request from world state
+func readVerificationRequest(ctx contractapi.TransactionContextInterface, requestID string) (*VerificationRequest, error) {
+	requestJSON, err := ctx.GetStub().GetState(requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+	if requestJSON == nil {
+		return nil, fmt.Errorf("verification request %s not found", requestID)
+	}
+
+	var request VerificationRequest
+	if err := json.Unmarshal(requestJSON, &request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal verification request: %v", err)
+	}
+	if request.DocType != docTypeVerificationReq {
+		return nil, fmt.Errorf("verification request %s not found", requestID)
+	}
+	return &request, nil
+}
+
+// putVerificationRequest saves a verification request under its request ID
+func putVerificationRequest(ctx contractapi.TransactionContextInterface, request *VerificationRequest) error {
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verification request: %v", err)
+	}
+	if err := ctx.GetStub().PutState(request.RequestID, requestJSON); err != nil {
+		return fmt.Errorf("failed to put state: %v", err)
+	}
+	return nil
+}
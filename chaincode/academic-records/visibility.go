@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== RESPONSE VISIBILITY ==========
+//
+// Getters returning students and academic records pass their response through filterResponse,
+// which shapes it by the visibility profile of the caller's org. Profiles are set per MSP ID in
+// the visibilityProfiles entry of the org config; an org not listed there gets the default of
+// its role: FULL for university orgs, DEPARTMENT for department orgs and PUBLIC for everyone
+// else. Writes return their asset unfiltered, as only the orgs allowed to write can call them.
+
+// Visibility profiles
+const (
+	visibilityFull       = "FULL"       // every field
+	visibilityDepartment = "DEPARTMENT" // remarks and approval notes withheld on other departments' records
+	visibilityPublic     = "PUBLIC"     // minimal public projection: no PII, remarks or identities of officers
+)
+
+// validVisibilityProfiles are the profiles an org config may assign
+var validVisibilityProfiles = map[string]bool{
+	visibilityFull:       true,
+	visibilityDepartment: true,
+	visibilityPublic:     true,
+}
+
+// responseVisibility is the profile a caller's responses are shaped by
+type responseVisibility struct {
+	profile    string
+	department string // department attribute of the caller, for the DEPARTMENT profile
+}
+
+// filterResponse shapes a getter's response in place for the caller's visibility profile. It
+// accepts the student and record types returned by getters and fails on any other type so a new
+// getter cannot silently skip filtering.
+func filterResponse(ctx contractapi.TransactionContextInterface, response interface{}) error {
+	visibility, err := callerVisibility(ctx)
+	if err != nil {
+		return err
+	}
+	if visibility.profile == visibilityFull {
+		return nil
+	}
+
+	switch value := response.(type) {
+	case *Student:
+		if value != nil {
+			visibility.student(value)
+		}
+	case []*Student:
+		for _, student := range value {
+			visibility.student(student)
+		}
+	case *PaginatedStudents:
+		for _, student := range value.Students {
+			visibility.student(student)
+		}
+	case *AcademicRecord:
+		if value != nil {
+			visibility.record(value)
+		}
+	case []*AcademicRecord:
+		for _, record := range value {
+			visibility.record(record)
+		}
+	case *PaginatedRecords:
+		for _, record := range value.Records {
+			visibility.record(record)
+		}
+	case *PaginatedVerificationQueue:
+		for _, entry := range value.Entries {
+			if entry.Record != nil {
+				visibility.record(entry.Record)
+			}
+			if visibility.profile == visibilityPublic {
+				entry.ApprovedBy = ""
+			}
+		}
+	case []*RecordHistoryEntry:
+		for _, entry := range value {
+			if entry.Value != nil {
+				visibility.record(entry.Value)
+			}
+			// Versions that no longer parse cannot be shaped, so only FULL callers see them
+			entry.RawValue = ""
+		}
+	default:
+		return fmt.Errorf("no visibility filter for response type %T", response)
+	}
+	return nil
+}
+
+// callerVisibility resolves the visibility profile of the caller's org
+func callerVisibility(ctx contractapi.TransactionContextInterface) (*responseVisibility, error) {
+	creatorOrg, err := getCreatorOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator organization: %v", err)
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := &responseVisibility{profile: config.visibilityProfile(creatorOrg)}
+	if visibility.profile == visibilityDepartment {
+		department, _, err := ctx.GetClientIdentity().GetAttributeValue(departmentAttribute)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client attribute %s: %v", departmentAttribute, err)
+		}
+		visibility.department = department
+	}
+	return visibility, nil
+}
+
+// visibilityProfile returns the profile configured for mspID, or the default of its role
+func (c *OrgConfig) visibilityProfile(mspID string) string {
+	if profile, ok := c.VisibilityProfiles[mspID]; ok {
+		return profile
+	}
+	switch {
+	case c.hasRole(mspID, orgRoleUniversity):
+		return visibilityFull
+	case c.hasRole(mspID, orgRoleDepartment):
+		return visibilityDepartment
+	}
+	return visibilityPublic
+}
+
+// student shapes one student; students carry no remarks, so only PUBLIC changes them
+func (v *responseVisibility) student(student *Student) {
+	if v.profile != visibilityPublic {
+		return
+	}
+	*student = Student{
+		DocType:        student.DocType,
+		StudentID:      student.StudentID,
+		Department:     student.Department,
+		Program:        student.Program,
+		EnrollmentDate: student.EnrollmentDate,
+		Status:         student.Status,
+		InstitutionID:  student.InstitutionID,
+		MergedInto:     student.MergedInto,
+	}
+}
+
+// record shapes one academic record
+func (v *responseVisibility) record(record *AcademicRecord) {
+	switch v.profile {
+	case visibilityDepartment:
+		// Records created before departments were stamped on them count as another department's
+		if v.department != "" && record.Department == v.department {
+			return
+		}
+		record.Remarks = ""
+		for _, rejection := range record.Rejections {
+			rejection.Remarks = ""
+		}
+		for _, approval := range record.Approvals {
+			approval.Notes = ""
+		}
+	case visibilityPublic:
+		*record = AcademicRecord{
+			DocType:            record.DocType,
+			RecordID:           record.RecordID,
+			StudentID:          record.StudentID,
+			Department:         record.Department,
+			InstitutionID:      record.InstitutionID,
+			Semester:           record.Semester,
+			Year:               record.Year,
+			Courses:            record.Courses,
+			SGPA:               record.SGPA,
+			CGPA:               record.CGPA,
+			Status:             record.Status,
+			VerifiedAt:         record.VerifiedAt,
+			Version:            record.Version,
+			SupersedesRecordID: record.SupersedesRecordID,
+			SupersededBy:       record.SupersededBy,
+			RegulationYear:     record.RegulationYear,
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// presentFields returns the sorted JSON fields of v that hold a non-zero value
+func presentFields(t *testing.T, v interface{}) []string {
+	t.Helper()
+	valueJSON, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal %T: %v", v, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(valueJSON, &fields); err != nil {
+		t.Fatalf("failed to decode %T: %v", v, err)
+	}
+	present := []string{}
+	for name, raw := range fields {
+		switch string(raw) {
+		case `""`, `0`, `null`, `false`, `[]`, `{}`:
+			continue
+		}
+		present = append(present, name)
+	}
+	sort.Strings(present)
+	return present
+}
+
+// visibilityTestLedger returns a ledger holding student CS21001 with PII and a verified record
+// REC1 carrying rejection remarks, approval notes and verification remarks
+func visibilityTestLedger(t *testing.T) *testLedger {
+	t.Helper()
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 3)
+	_, _, reject := recordActionCall(l, recordActionReject, "REC1")
+	_, _, submit := recordActionCall(l, recordActionSubmit, "REC1")
+	ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, reject)
+	ledgertest.MustInvoke(t, l, testExamCell, ledgertest.TxOptions{}, submit)
+	checklist := strings.Replace(testChecklist, `"notes":""`, `"notes":"grade sheet sighted"`, 1)
+	for _, approver := range []*ledgertest.Identity{testRegistrar, testDean} {
+		ledgertest.MustInvoke(t, l, approver, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.ApproveAcademicRecord(ctx, "REC1", checklist, "")
+		})
+	}
+	ledgertest.MustInvoke(t, l, testVerifier, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.VerifyAcademicRecord(ctx, "REC1", "matches the original grade sheet", false)
+	})
+	return l
+}
+
+// filteredAs returns a copy of value shaped by filterResponse for the identity
+func filteredAs[T any](t *testing.T, l *testLedger, as *ledgertest.Identity, value *T) *T {
+	t.Helper()
+	var copied T
+	valueJSON, _ := json.Marshal(value)
+	if err := json.Unmarshal(valueJSON, &copied); err != nil {
+		t.Fatal(err)
+	}
+	ws := l.Submit(as, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) error {
+		return filterResponse(ctx, &copied)
+	})
+	if ws.Err != nil {
+		t.Fatalf("filterResponse as %s: %v", as.MSPID, ws.Err)
+	}
+	return &copied
+}
+
+func TestVisibilityProfileRecordFields(t *testing.T) {
+	l := visibilityTestLedger(t)
+	record := l.storedRecord(t, "REC1")
+
+	full := []string{"approvals", "approvedAt", "approvedBy", "cgpa", "courses", "coursesHash", "createdAt", "createdBy", "department", "docType", "institutionId", "recordId", "regulationYear", "rejections", "remarks", "semester", "sgpa", "status", "studentId", "verifiedAt", "verifiedBy", "version", "year"}
+	withoutRemarks := []string{}
+	for _, field := range full {
+		if field != "remarks" {
+			withoutRemarks = append(withoutRemarks, field)
+		}
+	}
+	public := []string{"cgpa", "courses", "department", "docType", "institutionId", "recordId", "regulationYear", "semester", "sgpa", "status", "studentId", "verifiedAt", "version", "year"}
+
+	if got := presentFields(t, record); !reflect.DeepEqual(got, full) {
+		t.Fatalf("stored record fields = %v, want %v", got, full)
+	}
+
+	tests := []struct {
+		name       string
+		as         *ledgertest.Identity
+		wantFields []string
+		wantNotes  bool // approval notes and rejection remarks survive
+	}{
+		{"FULL for the university", testRegistrar, full, true},
+		{"DEPARTMENT for the owning department", testExamCell, full, true},
+		{"DEPARTMENT for another department", testExamCell.With(map[string]string{"department": "ECE"}), withoutRemarks, false},
+		{"DEPARTMENT without a department attribute", testExamCell.With(map[string]string{"department": ""}), withoutRemarks, false},
+		{"PUBLIC for verifiers", testVerifier, public, false},
+		{"PUBLIC for regulators", testRegulator, public, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := filteredAs(t, l, tt.as, record)
+			if got := presentFields(t, filtered); !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", got, tt.wantFields)
+			}
+			for _, approval := range filtered.Approvals {
+				if (approval.Notes != "") != tt.wantNotes {
+					t.Errorf("approval by %s has notes %q", approval.ApproverID, approval.Notes)
+				}
+			}
+			for _, rejection := range filtered.Rejections {
+				if (rejection.Remarks != "") != tt.wantNotes {
+					t.Errorf("rejection has remarks %q", rejection.Remarks)
+				}
+			}
+			if !reflect.DeepEqual(filtered.Courses, record.Courses) {
+				t.Errorf("courses changed by filtering")
+			}
+		})
+	}
+}
+
+func TestVisibilityProfileStudentFields(t *testing.T) {
+	l := visibilityTestLedger(t)
+	student := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*Student, error) {
+		return l.contract.GetStudent(ctx, "CS21001", false, "")
+	})
+
+	full := []string{"createdAt", "createdBy", "department", "docType", "email", "enrollmentDate", "institutionId", "name", "program", "status", "studentId"}
+	public := []string{"department", "docType", "enrollmentDate", "institutionId", "program", "status", "studentId"}
+	if got := presentFields(t, student); !reflect.DeepEqual(got, full) {
+		t.Fatalf("student fields = %v, want %v", got, full)
+	}
+
+	tests := []struct {
+		name       string
+		as         *ledgertest.Identity
+		wantFields []string
+	}{
+		{"FULL", testRegistrar, full},
+		{"DEPARTMENT", testExamCell.With(map[string]string{"department": "ECE"}), full},
+		{"PUBLIC", testVerifier, public},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := presentFields(t, filteredAs(t, l, tt.as, student)); !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", got, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestVisibilityProfilesFollowConfig(t *testing.T) {
+	l := visibilityTestLedger(t)
+	getRecord := func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.GetAcademicRecord(ctx, "REC1")
+	}
+	if got := ledgertest.MustInvoke(t, l, testRegulator, ledgertest.TxOptions{}, getRecord); got.Remarks != "" || got.CreatedBy != "" || len(got.Approvals) != 0 {
+		t.Errorf("regulator read of REC1 = %+v, want the PUBLIC projection", got)
+	}
+
+	l.updateConfig(t, func(config *OrgConfig) {
+		config.VisibilityProfiles = map[string]string{testRegulator.MSPID: visibilityFull, testRegistrar.MSPID: visibilityPublic}
+	})
+	if got := ledgertest.MustInvoke(t, l, testRegulator, ledgertest.TxOptions{}, getRecord); got.Remarks == "" || got.CreatedBy == "" || len(got.Approvals) != 2 {
+		t.Errorf("regulator read of REC1 with a FULL profile = %+v", got)
+	}
+	if got := ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, getRecord); got.Remarks != "" || got.ApprovedBy != "" {
+		t.Errorf("university read of REC1 with a PUBLIC profile = %+v", got)
+	}
+
+	ledgertest.InvokeError(t, l, testRegistrar, ledgertest.TxOptions{}, "no visibility filter for response type *main.Certificate", func(ctx contractapi.TransactionContextInterface) (*Certificate, error) {
+		return nil, filterResponse(ctx, &Certificate{})
+	})
+}
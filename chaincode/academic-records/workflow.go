@@ -0,0 +1,441 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ========== RECORD WORKFLOW ==========
+
+// Actions that move an academic record between statuses
+const (
+	recordActionSubmit    = "SUBMIT"
+	recordActionApprove   = "APPROVE"
+	recordActionVerify    = "VERIFY"
+	recordActionReject    = "REJECT"
+	recordActionSupersede = "SUPERSEDE"
+)
+
+// recordTransitions maps current status -> action -> resulting status.
+// Anything not listed here is an invalid transition.
+var recordTransitions = map[string]map[string]string{
+	recordStatusDraft: {
+		recordActionSubmit: recordStatusSubmitted,
+	},
+	recordStatusSubmitted: {
+		recordActionApprove: recordStatusApproved,
+		recordActionReject:  recordStatusRejected,
+	},
+	recordStatusApproved: {
+		recordActionVerify:    recordStatusVerified,
+		recordActionSupersede: recordStatusSuperseded,
+	},
+	recordStatusVerified: {
+		recordActionSupersede: recordStatusSuperseded,
+	},
+	recordStatusRejected: {
+		recordActionSubmit: recordStatusSubmitted,
+	},
+}
+
+// validateTransition returns the status a record in currentStatus moves to under action
+func validateTransition(currentStatus string, action string) (string, error) {
+	next, ok := recordTransitions[currentStatus][action]
+	if !ok {
+		return "", fmt.Errorf("cannot %s record in %s status", action, currentStatus)
+	}
+	return next, nil
+}
+
+// transitionRecord applies action to the record, moving it in the record~status index
+// within the same transaction. It returns the previous status; the caller still has to
+// save the record.
+func transitionRecord(ctx contractapi.TransactionContextInterface, record *AcademicRecord, action string) (string, error) {
+	from := record.Status
+	to, err := validateTransition(from, action)
+	if err != nil {
+		return "", err
+	}
+
+	if err := deleteIndex(ctx, "record~status", []string{from, record.RecordID}); err != nil {
+		return "", err
+	}
+	if err := putIndex(ctx, "record~status", []string{to, record.RecordID}); err != nil {
+		return "", err
+	}
+	record.Status = to
+	return from, nil
+}
+
+// transitionDetails formats a status change for the audit log
+func transitionDetails(from string, to string, details string) string {
+	return fmt.Sprintf("%s -> %s: %s", from, to, details)
+}
+
+// CreateDraftRecord starts a semester record in DRAFT so grades can be added over time;
+// the optional initial course list is read from the "courses" transient key. regulationYear
+// selects the grade scale as in CreateAcademicRecord.
+func (s *SmartContract) CreateDraftRecord(ctx contractapi.TransactionContextInterface, recordID string, studentID string, semester int, year int, regulationYear int) (*AcademicRecord, error) {
+	return s.createRecord(ctx, recordID, studentID, semester, year, regulationYear, recordStatusDraft)
+}
+
+// UpdateDraftRecord replaces the course list of a DRAFT or REJECTED record (Departments only)
+// with the one in the "courses" transient key
+func (s *SmartContract) UpdateDraftRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
+	if _, err := requireOrgRole(ctx, "update draft records", orgRoleDepartment); err != nil {
+		return nil, err
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertStudentNotFrozen(ctx, record.StudentID); err != nil {
+		return nil, err
+	}
+	if record.Status != recordStatusDraft && record.Status != recordStatusRejected {
+		return nil, fmt.Errorf("record %s is %s; only DRAFT or REJECTED records can be edited", recordID, record.Status)
+	}
+
+	if err := replaceRecordCourses(ctx, record, "UpdateDraftRecord"); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// UpdateAcademicRecord corrects the course list of a record that has not been approved yet
+// (Departments only) from the "courses" transient key. Every change bumps the record version.
+func (s *SmartContract) UpdateAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
+	if _, err := requireOrgRole(ctx, "update academic records", orgRoleDepartment); err != nil {
+		return nil, err
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertStudentNotFrozen(ctx, record.StudentID); err != nil {
+		return nil, err
+	}
+	if !editableRecordStatuses[record.Status] {
+		return nil, fmt.Errorf("record %s is %s; approved or verified records cannot be changed", recordID, record.Status)
+	}
+
+	if err := replaceRecordCourses(ctx, record, "UpdateAcademicRecord"); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// editableRecordStatuses are the statuses in which a department may still change courses
+var editableRecordStatuses = map[string]bool{
+	recordStatusDraft:     true,
+	recordStatusSubmitted: true,
+	recordStatusRejected:  true,
+}
+
+// replaceRecordCourses validates the transient course list, stores it in the draft grades
+// collection, bumps the version and audits the change
+func replaceRecordCourses(ctx contractapi.TransactionContextInterface, record *AcademicRecord, action string) error {
+	allowEmpty := record.Status == recordStatusDraft
+	coursesJSON, err := readTransientCourses(ctx, allowEmpty)
+	if err != nil {
+		return err
+	}
+	scale, err := gradeScaleForRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+	courses, err := prepareCourses(ctx, coursesJSON, scale, allowEmpty)
+	if err != nil {
+		return err
+	}
+	if err := stageDraftCourses(ctx, record, courses); err != nil {
+		return err
+	}
+
+	// Records written before versioning count as version 1
+	oldVersion := record.Version
+	if oldVersion == 0 {
+		oldVersion = 1
+	}
+	record.Version = oldVersion + 1
+
+	if err := putAcademicRecord(ctx, record); err != nil {
+		return err
+	}
+
+	logAudit(ctx, action, "RECORD", record.RecordID, fmt.Sprintf("Version %d -> %d: course list replaced (%d courses)", oldVersion, record.Version, len(courses)))
+	return nil
+}
+
+// SubmitAcademicRecord freezes a DRAFT (or REJECTED) record's courses and moves it to
+// SUBMITTED for approval
+func (s *SmartContract) SubmitAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string) (*AcademicRecord, error) {
+	if _, err := requireOrgRole(ctx, "submit academic records", orgRoleDepartment); err != nil {
+		return nil, err
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertStudentNotFrozen(ctx, record.StudentID); err != nil {
+		return nil, err
+	}
+	// Refuse the transition before the courses are re-staged
+	if _, err := validateTransition(record.Status, recordActionSubmit); err != nil {
+		return nil, err
+	}
+
+	// A submitted record needs a complete, valid course list graded on the record's scale
+	courses, err := readDraftCourses(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCourses(courses); err != nil {
+		return nil, err
+	}
+	scale, err := gradeScaleForRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyGradeScale(scale, courses, nil); err != nil {
+		return nil, err
+	}
+	if err := stageDraftCourses(ctx, record, courses); err != nil {
+		return nil, err
+	}
+
+	// A corrected record may only come back if no other record has taken its semester
+	if record.Status == recordStatusRejected {
+		if err := checkSemesterUnique(ctx, record.StudentID, record.Semester, record.RecordID); err != nil {
+			return nil, err
+		}
+	}
+
+	fromStatus, err := transitionRecord(ctx, record, recordActionSubmit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := putAcademicRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "SubmitAcademicRecord", "RECORD", recordID, transitionDetails(fromStatus, record.Status, "Record submitted for approval"))
+
+	if err := emitLifecycleEvent(ctx, eventRecordSubmitted, "RECORD", recordID, record.Status); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// RejectAcademicRecord sends a SUBMITTED record back to its department (NITWarangal only).
+// Remarks are mandatory and every rejection is kept on the record.
+func (s *SmartContract) RejectAcademicRecord(ctx contractapi.TransactionContextInterface, recordID string, remarks string) (*AcademicRecord, error) {
+	creatorOrg, err := requireOrgRole(ctx, "reject records", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	remarks = strings.TrimSpace(remarks)
+	if remarks == "" {
+		return nil, fmt.Errorf("remarks are required when rejecting a record")
+	}
+
+	record, err := readAcademicRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertStudentNotFrozen(ctx, record.StudentID); err != nil {
+		return nil, err
+	}
+
+	fromStatus, err := transitionRecord(ctx, record, recordActionReject)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+
+	record.Rejections = append(record.Rejections, &Rejection{
+		RejectedBy:   creatorOrg,
+		RejectedByID: clientID,
+		RejectedAt:   txTime.UTC().Format(time.RFC3339),
+		Remarks:      remarks,
+	})
+
+	if err := putAcademicRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	logAudit(ctx, "RejectAcademicRecord", "RECORD", recordID, transitionDetails(fromStatus, record.Status, remarks))
+
+	if err := emitLifecycleEvent(ctx, eventRecordRejected, "RECORD", recordID, record.Status); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// SupersedeAcademicRecord corrects an approved or verified record without mutating it
+// (NITWarangal only). A new record carrying the corrected courses is created as APPROVED,
+// so it has to be verified again, and the original is marked SUPERSEDED with a pointer to it.
+// As on approval, a disclosureSeed in the transient map commits the replacement's courses.
+func (s *SmartContract) SupersedeAcademicRecord(ctx contractapi.TransactionContextInterface, originalRecordID string, newRecordID string, coursesJSON string, reason string) (*AcademicRecord, error) {
+	creatorOrg, err := requireOrgRole(ctx, "supersede records", orgRoleUniversity)
+	if err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to supersede a record")
+	}
+
+	original, err := readAcademicRecord(ctx, originalRecordID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The correction is graded on the same scale as the record it replaces
+	scale, err := gradeScaleForRecord(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+	courses, err := prepareCourses(ctx, coursesJSON, scale, false)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement, events, err := supersedeRecord(ctx, creatorOrg, original, newRecordID, courses, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// One transaction, two asset changes: a single composite event
+	if err := emitEvents(ctx, eventRecordSuperseded, events...); err != nil {
+		return nil, err
+	}
+
+	return replacement, nil
+}
+
+// supersedeRecord marks original SUPERSEDED and stores an APPROVED replacement carrying the
+// given courses, returning the replacement and the lifecycle events for both records
+func supersedeRecord(ctx contractapi.TransactionContextInterface, creatorOrg string, original *AcademicRecord, newRecordID string, courses []CourseGrade, reason string) (*AcademicRecord, []*LifecycleEvent, error) {
+	originalRecordID := original.RecordID
+	if err := assertKeyUnused(ctx, newRecordID, docTypeRecord); err != nil {
+		return nil, nil, err
+	}
+
+	wasApproved := original.Status == recordStatusApproved
+	fromStatus, err := transitionRecord(ctx, original, recordActionSupersede)
+	if err != nil {
+		return nil, nil, err
+	}
+	original.SupersededBy = newRecordID
+	original.DeansListed = false
+
+	// An approved original was still waiting in the verifier queue
+	if wasApproved {
+		if err := deleteIndex(ctx, "record~awaitingverification", []string{original.ApprovedAt, original.RecordID}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := putAcademicRecord(ctx, original); err != nil {
+		return nil, nil, err
+	}
+	if err := setRecordEndorsementPolicy(ctx, original); err != nil {
+		return nil, nil, err
+	}
+
+	txTime, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	now := txTime.UTC().Format(time.RFC3339)
+
+	replacement := AcademicRecord{
+		DocType:            docTypeRecord,
+		RecordID:           newRecordID,
+		StudentID:          original.StudentID,
+		Department:         original.Department,
+		InstitutionID:      original.InstitutionID,
+		Semester:           original.Semester,
+		Year:               original.Year,
+		Courses:            courses,
+		Status:             recordStatusApproved,
+		CreatedBy:          creatorOrg,
+		ApprovedBy:         creatorOrg,
+		CreatedAt:          now,
+		ApprovedAt:         now,
+		Version:            1,
+		SupersedesRecordID: originalRecordID,
+		SupersessionReason: reason,
+		RegulationYear:     original.RegulationYear,
+		GradeScaleYear:     original.GradeScaleYear,
+	}
+
+	// The replacement takes the original's semester slot in the GPA calculation
+	if err := computeRecordGPA(ctx, &replacement); err != nil {
+		return nil, nil, err
+	}
+	config, err := getOrgConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	replacement.DeansListed = deansListed(&replacement, config)
+	if err := commitRecordCourses(ctx, &replacement); err != nil {
+		return nil, nil, err
+	}
+
+	if err := putAcademicRecord(ctx, &replacement); err != nil {
+		return nil, nil, err
+	}
+	if err := putRecordIndexes(ctx, &replacement); err != nil {
+		return nil, nil, err
+	}
+	if err := putInstructorIndexes(ctx, &replacement, courses); err != nil {
+		return nil, nil, err
+	}
+	if err := setRecordEndorsementPolicy(ctx, &replacement); err != nil {
+		return nil, nil, err
+	}
+	if err := putIndex(ctx, "record~awaitingverification", []string{replacement.ApprovedAt, newRecordID}); err != nil {
+		return nil, nil, err
+	}
+
+	logAudit(ctx, "SupersedeAcademicRecord", "RECORD", originalRecordID, transitionDetails(fromStatus, original.Status, fmt.Sprintf("Superseded by %s: %s", newRecordID, reason)))
+	logAudit(ctx, "SupersedeAcademicRecord", "RECORD", newRecordID, fmt.Sprintf("Created as APPROVED replacement for %s: %s", originalRecordID, reason))
+
+	supersededEvent, err := newLifecycleEvent(ctx, eventRecordSuperseded, "RECORD", originalRecordID, original.Status)
+	if err != nil {
+		return nil, nil, err
+	}
+	replacementEvent, err := newLifecycleEvent(ctx, eventRecordApproved, "RECORD", newRecordID, replacement.Status)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Certificates attesting the original no longer match the student's records
+	flaggedEvents, err := flagCoveringCertificates(ctx, original, newRecordID, reason)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &replacement, append([]*LifecycleEvent{supersededEvent, replacementEvent}, flaggedEvents...), nil
+}
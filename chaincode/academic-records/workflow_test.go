@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/nit-warangal/academic-records/internal/ledgertest"
+)
+
+// allRecordStatuses lists every record status, including one no record can have
+var allRecordStatuses = []string{recordStatusDraft, recordStatusSubmitted, recordStatusApproved, recordStatusVerified, recordStatusRejected, recordStatusSuperseded, "ARCHIVED"}
+
+// allRecordActions lists every record workflow action
+var allRecordActions = []string{recordActionSubmit, recordActionApprove, recordActionVerify, recordActionReject, recordActionSupersede}
+
+// wantRecordTransitions is the workflow as documented, kept apart from recordTransitions so
+// a change to the table has to be made in both places
+var wantRecordTransitions = map[string]string{
+	recordStatusDraft + "/" + recordActionSubmit:       recordStatusSubmitted,
+	recordStatusSubmitted + "/" + recordActionApprove:  recordStatusApproved,
+	recordStatusSubmitted + "/" + recordActionReject:   recordStatusRejected,
+	recordStatusApproved + "/" + recordActionVerify:    recordStatusVerified,
+	recordStatusApproved + "/" + recordActionSupersede: recordStatusSuperseded,
+	recordStatusVerified + "/" + recordActionSupersede: recordStatusSuperseded,
+	recordStatusRejected + "/" + recordActionSubmit:    recordStatusSubmitted,
+}
+
+func TestValidateTransition(t *testing.T) {
+	for _, status := range allRecordStatuses {
+		for _, action := range allRecordActions {
+			next, err := validateTransition(status, action)
+			want, valid := wantRecordTransitions[status+"/"+action]
+			switch {
+			case valid && (err != nil || next != want):
+				t.Errorf("%s from %s = %q, %v; want %s", action, status, next, err, want)
+			case !valid && err == nil:
+				t.Errorf("%s from %s is allowed and leads to %s", action, status, next)
+			case !valid && err.Error() != fmt.Sprintf("cannot %s record in %s status", action, status):
+				t.Errorf("%s from %s fails with %q", action, status, err)
+			}
+		}
+	}
+}
+
+// recordInStatus creates a record of a new student and moves it to status through the contract
+func recordInStatus(t *testing.T, l *testLedger, status string) string {
+	t.Helper()
+	studentID := "CS21_" + status
+	recordID := "REC_" + status
+	NewTestStudent(t, l, studentID, "CSE")
+
+	if status == recordStatusDraft {
+		ledgertest.MustInvoke(t, l, testExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2))}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.CreateDraftRecord(ctx, recordID, studentID, 1, 2023, 0)
+		})
+		return recordID
+	}
+
+	NewTestRecord(t, l, recordID, studentID, 1, 2)
+	switch status {
+	case recordStatusApproved:
+		approveTestRecord(t, l, recordID)
+	case recordStatusVerified:
+		verifyTestRecord(t, l, recordID)
+	case recordStatusRejected:
+		ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.RejectAcademicRecord(ctx, recordID, "grade sheet unsigned")
+		})
+	case recordStatusSuperseded:
+		verifyTestRecord(t, l, recordID)
+		supersedeTestRecord(t, l, recordID, recordID+"_V2")
+	}
+	if got := l.storedRecord(t, recordID).Status; got != status {
+		t.Fatalf("record %s is %s, want %s", recordID, got, status)
+	}
+	return recordID
+}
+
+// supersedeTestRecord replaces a record with a copy whose first course is regraded B
+func supersedeTestRecord(t *testing.T, l *testLedger, recordID string, newRecordID string) *AcademicRecord {
+	t.Helper()
+	courses := l.storedRecord(t, recordID).Courses
+	courses[0].Grade, courses[0].GradePoint = "B", 8
+	coursesJSON := string(coursesTransient(courses)[transientCourses])
+	return ledgertest.MustInvoke(t, l, testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		return l.contract.SupersedeAcademicRecord(ctx, recordID, newRecordID, coursesJSON, "revaluation")
+	})
+}
+
+// recordActionCall invokes the contract function performing action on a record, with the
+// identity allowed to perform it
+func recordActionCall(l *testLedger, action string, recordID string) (*ledgertest.Identity, ledgertest.TxOptions, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error)) {
+	switch action {
+	case recordActionSubmit:
+		return testExamCell, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.SubmitAcademicRecord(ctx, recordID)
+		}
+	case recordActionApprove:
+		return testAdmin, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.ApproveAcademicRecord(ctx, recordID, testChecklist, "")
+		}
+	case recordActionVerify:
+		return testVerifier, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.VerifyAcademicRecord(ctx, recordID, "", false)
+		}
+	case recordActionReject:
+		return testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.RejectAcademicRecord(ctx, recordID, "rejected again")
+		}
+	}
+	return testRegistrar, ledgertest.TxOptions{}, func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+		coursesJSON := string(coursesTransient(testCourses(2))[transientCourses])
+		return l.contract.SupersedeAcademicRecord(ctx, recordID, recordID+"_FIX", coursesJSON, "correction")
+	}
+}
+
+func TestInvalidRecordTransitionsAreRefused(t *testing.T) {
+	l := newTestLedger(t)
+	for _, status := range allRecordStatuses[:6] {
+		recordID := recordInStatus(t, l, status)
+		for _, action := range allRecordActions {
+			if _, valid := wantRecordTransitions[status+"/"+action]; valid {
+				continue
+			}
+			t.Run(action+"_from_"+status, func(t *testing.T) {
+				as, opts, call := recordActionCall(l, action, recordID)
+				before := string(l.Stub.State[recordID])
+
+				ws := ledgertest.InvokeError(t, l, as, opts, fmt.Sprintf("cannot %s record in %s status", action, status), call)
+				if writes := ws.LedgerWrites(); len(writes) != 0 {
+					t.Errorf("refused transition wrote %d entries", len(writes))
+				}
+				if string(l.Stub.State[recordID]) != before {
+					t.Errorf("refused transition changed the stored record")
+				}
+			})
+		}
+	}
+}
+
+func TestTransitionsAreAudited(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+
+	details := func(want string) func(t *testing.T, ws *ledgertest.WriteSet) {
+		return func(t *testing.T, ws *ledgertest.WriteSet) {
+			t.Helper()
+			for _, entry := range writtenAuditEntries(t, ws) {
+				if strings.HasPrefix(entry.Details, want) {
+					return
+				}
+			}
+			t.Errorf("no audit entry records %q", want)
+		}
+	}
+	record := func(fn func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error)) func(ctx contractapi.TransactionContextInterface) error {
+		return func(ctx contractapi.TransactionContextInterface) error {
+			_, err := fn(ctx)
+			return err
+		}
+	}
+	_, _, submit := recordActionCall(l, recordActionSubmit, "REC1")
+	_, _, reject := recordActionCall(l, recordActionReject, "REC1")
+	_, _, approve := recordActionCall(l, recordActionApprove, "REC1")
+	_, _, verify := recordActionCall(l, recordActionVerify, "REC1")
+
+	NewTestRecord(t, l, "REC1", "CS21001", 1, 2)
+	ledgertest.RunScenario(t, l,
+		ledgertest.Step{Name: "reject", As: testRegistrar, Run: record(reject), Check: details("SUBMITTED -> REJECTED")},
+		ledgertest.Step{Name: "resubmit", As: testExamCell, Run: record(submit), Check: details("REJECTED -> SUBMITTED")},
+		ledgertest.Step{Name: "approve", As: testRegistrar, Run: record(approve)},
+		ledgertest.Step{Name: "co-sign", As: testDean, Run: record(approve), Check: details("SUBMITTED -> APPROVED")},
+		ledgertest.Step{Name: "verify", As: testVerifier, Run: record(verify), Check: details("APPROVED -> VERIFIED")},
+	)
+	supersedeTestRecord(t, l, "REC1", "REC1_V2")
+	if got := l.storedRecord(t, "REC1"); got.Status != recordStatusSuperseded || got.VerifiedBy != testVerifier.MSPID {
+		t.Errorf("superseded record = %s verified by %q", got.Status, got.VerifiedBy)
+	}
+}
+
+func TestRecordCreatedAtIsTheTxTimestamp(t *testing.T) {
+	l := newTestLedger(t)
+	NewTestStudent(t, l, "CS21001", "CSE")
+	at := ledgertest.Epoch.Add(72 * time.Hour)
+
+	creates := map[string]func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error){
+		"REC1": func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.CreateAcademicRecord(ctx, "REC1", "CS21001", 1, 2023, 0)
+		},
+		"DRAFT2": func(ctx contractapi.TransactionContextInterface) (*AcademicRecord, error) {
+			return l.contract.CreateDraftRecord(ctx, "DRAFT2", "CS21001", 2, 2024, 0)
+		},
+	}
+	for recordID, create := range creates {
+		at = at.Add(time.Hour)
+		ledgertest.MustInvoke(t, l, testExamCell, ledgertest.TxOptions{Transient: coursesTransient(testCourses(2)), At: at}, create)
+		if got, want := l.storedRecord(t, recordID).CreatedAt, at.Format(time.RFC3339); got != want {
+			t.Errorf("%s createdAt = %s, want the tx timestamp %s", recordID, got, want)
+		}
+	}
+}